@@ -0,0 +1,46 @@
+// Command grpc-plugin is a minimal long-lived argocd-lint plugin. Build it and
+// pass the binary with --grpc-plugin; the process is started once per run and
+// receives every manifest over a gRPC stream.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	grpcplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/grpc"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// ownerRule stands in for a rule backed by an inventory API: the owner list
+// is loaded once when the plugin starts, not once per file.
+type ownerRule struct {
+	owners map[string]bool
+}
+
+func (r *ownerRule) Metadata() types.RuleMetadata {
+	return types.RuleMetadata{
+		ID:              "GP100",
+		Description:     "Application projects must be registered in the service inventory",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+		Category:        "inventory",
+		Enabled:         true,
+	}
+}
+
+func (r *ownerRule) Check(_ context.Context, resource grpcplugin.Resource) ([]types.Finding, error) {
+	spec, _ := resource.Object["spec"].(map[string]interface{})
+	project, _ := spec["project"].(string)
+	if r.owners[strings.TrimSpace(project)] {
+		return nil, nil
+	}
+	return []types.Finding{{
+		Message: fmt.Sprintf("project %q is not registered in the service inventory", project),
+		Line:    resource.MetadataLine,
+	}}, nil
+}
+
+func main() {
+	grpcplugin.Serve(&ownerRule{owners: map[string]bool{"payments": true, "platform": true}})
+}
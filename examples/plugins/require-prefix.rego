@@ -9,7 +9,7 @@ metadata := {
   "help_url": "https://example.com/argocd-lint/plugins#prefix",
 }
 
-required_prefix := "team-"
+required_prefix := object.get(data.params, "prefix", "team-")
 
 applies {
   input.kind == "Application"
@@ -0,0 +1,19 @@
+package argocd_lint.require_prefix
+
+test_prefixed_application_passes {
+  count(deny) == 0 with input as {"kind": "Application", "name": "team-payments"}
+}
+
+test_unprefixed_application_is_denied {
+  denied := deny with input as {"kind": "Application", "name": "payments"}
+  denied[_].message == "payments is missing required prefix team-"
+}
+
+test_applies_only_to_applications {
+  not applies with input as {"kind": "AppProject", "name": "payments"}
+}
+
+test_prefix_comes_from_params {
+  denied := deny with input as {"kind": "Application", "name": "team-payments"} with data.params as {"prefix": "squad-"}
+  count(denied) == 1
+}
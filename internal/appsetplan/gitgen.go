@@ -0,0 +1,364 @@
+package appsetplan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"gopkg.in/yaml.v3"
+)
+
+// planner expands generators. Git generators read repoRoot when it is a
+// checkout of their repoURL and otherwise a shallow clone, when allowed.
+// Pull request and SCM provider generators read data, or the provider APIs
+// when online. With skipRemote, git, pull request, and SCM provider
+// generators are skipped.
+type planner struct {
+	ctx        context.Context
+	repoRoot   string
+	clone      bool
+	skipRemote bool
+	cloneDir   string
+	clones     map[string]string
+	data       *GeneratorData
+	online     bool
+	tokens     map[string]string
+	// tokenHosts are API hosts besides the public ones that may receive
+	// tokens.
+	tokenHosts []string
+	client     *http.Client
+}
+
+// close removes the repositories cloned for git generators.
+func (p *planner) close() {
+	if p.cloneDir != "" {
+		_ = os.RemoveAll(p.cloneDir)
+	}
+}
+
+// checkout returns a directory holding revision of repo.
+func (p *planner) checkout(repo, revision string) (string, error) {
+	if p.repoRoot != "" {
+		origin, err := gitutil.RemoteURL(p.ctx, p.repoRoot)
+		if err != nil || origin == "" || gitutil.SameRepo(origin, repo) {
+			return p.repoRoot, nil
+		}
+	}
+	if !p.clone {
+		return "", fmt.Errorf("git generator for %s needs a local checkout (--repo-root) or --clone", repo)
+	}
+	key := repo + "@" + revision
+	if dir, ok := p.clones[key]; ok {
+		return dir, nil
+	}
+	if p.cloneDir == "" {
+		dir, err := os.MkdirTemp("", "argocd-lint-appset-")
+		if err != nil {
+			return "", err
+		}
+		p.cloneDir = dir
+		p.clones = map[string]string{}
+	}
+	dir := filepath.Join(p.cloneDir, strconv.Itoa(len(p.clones)))
+	if err := gitutil.ShallowClone(p.ctx, repo, revision, dir); err != nil {
+		return "", fmt.Errorf("clone %s: %w", repo, err)
+	}
+	p.clones[key] = dir
+	return dir, nil
+}
+
+// gitParams returns one parameter set per directory or file the git
+// generator matches. Parameters are flattened ("path.basename") unless
+// goTemplate is set, as Argo CD does.
+func (p *planner) gitParams(gen map[string]interface{}, goTemplate bool) ([]map[string]interface{}, error) {
+	repo := stringGet(gen, "repoURL")
+	if repo == "" {
+		return nil, fmt.Errorf("git generator has no repoURL")
+	}
+	root, err := p.checkout(repo, stringGet(gen, "revision"))
+	if err != nil {
+		return nil, err
+	}
+	prefix := stringGet(gen, "pathParamPrefix")
+	var sets []map[string]interface{}
+	if directories := sliceGet(gen, "directories"); len(directories) > 0 {
+		include, exclude := pathPatterns(directories)
+		dirs, err := walkRepo(root, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			if !matchAny(include, dir) || matchAny(exclude, dir) {
+				continue
+			}
+			params := map[string]interface{}{}
+			addPathParams(params, dir, false, prefix, goTemplate)
+			sets = append(sets, params)
+		}
+	} else if files := sliceGet(gen, "files"); len(files) > 0 {
+		include, exclude := pathPatterns(files)
+		paths, err := walkRepo(root, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range paths {
+			if !matchAny(include, file) || matchAny(exclude, file) {
+				continue
+			}
+			entries, err := readGeneratorFile(filepath.Join(root, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, fmt.Errorf("git generator file %s: %w", file, err)
+			}
+			for _, entry := range entries {
+				params := map[string]interface{}{}
+				if goTemplate {
+					for k, v := range entry {
+						params[k] = v
+					}
+				} else {
+					flatten(params, "", entry)
+				}
+				addPathParams(params, file, true, prefix, goTemplate)
+				sets = append(sets, params)
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("git generator for %s has neither directories nor files", repo)
+	}
+	addGeneratorValues(sets, gen, goTemplate)
+	return sets, nil
+}
+
+func pathPatterns(items []interface{}) (include, exclude []string) {
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pattern := strings.TrimPrefix(stringGet(item, "path"), "/")
+		if pattern == "" {
+			continue
+		}
+		if excluded, _ := item["exclude"].(bool); excluded {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+	return include, exclude
+}
+
+// walkRepo lists the directories, or the files, under root as slash paths
+// relative to it, skipping .git.
+func walkRepo(root string, dirs bool) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if p == root || d.IsDir() != dirs {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(out)
+	return out, err
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if globPattern(pattern).MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// globPattern compiles a path glob in which * and ? stay within a path
+// segment and ** spans segments.
+func globPattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// readGeneratorFile parses a JSON or YAML file into parameter sets; a list
+// yields one set per element.
+func readGeneratorFile(file string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, nil
+	case []interface{}:
+		var entries []map[string]interface{}
+		for _, item := range v {
+			if entry, ok := item.(map[string]interface{}); ok {
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("expected an object or a list of objects")
+	}
+}
+
+// addPathParams adds the path parameters of a matched directory or file.
+func addPathParams(params map[string]interface{}, rel string, isFile bool, prefix string, goTemplate bool) {
+	dir := rel
+	if isFile {
+		dir = path.Dir(rel)
+	}
+	segments := strings.Split(dir, "/")
+	values := map[string]interface{}{
+		"path":               dir,
+		"basename":           path.Base(dir),
+		"basenameNormalized": normalizeName(path.Base(dir)),
+	}
+	if isFile {
+		values["filename"] = path.Base(rel)
+		values["filenameNormalized"] = normalizeName(path.Base(rel))
+	}
+	if goTemplate {
+		values["segments"] = segments
+		if prefix != "" {
+			params[prefix] = map[string]interface{}{"path": values}
+			return
+		}
+		params["path"] = values
+		return
+	}
+	key := "path"
+	if prefix != "" {
+		key = prefix + ".path"
+	}
+	params[key] = dir
+	for name, value := range values {
+		if name != "path" {
+			params[key+"."+name] = value
+		}
+	}
+	for i, segment := range segments {
+		params[fmt.Sprintf("%s[%d]", key, i)] = segment
+	}
+}
+
+// addValues renders the generator's values against params and adds them
+// under values.
+func addValues(params map[string]interface{}, values map[string]interface{}, goTemplate bool) {
+	rendered := map[string]interface{}{}
+	for key, raw := range values {
+		value, ok := raw.(string)
+		if !ok {
+			value = fmt.Sprint(raw)
+		}
+		if !goTemplate {
+			value = substituteParams(value, params)
+		}
+		rendered[key] = value
+	}
+	if goTemplate {
+		params["values"] = rendered
+		return
+	}
+	for key, value := range rendered {
+		params["values."+key] = value
+	}
+}
+
+// flatten adds the leaves of value to params under dot-joined keys.
+func flatten(params map[string]interface{}, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flatten(params, name, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(params, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	default:
+		if prefix != "" {
+			params[prefix] = fmt.Sprint(v)
+		}
+	}
+}
+
+var unsupportedNameChars = regexp.MustCompile(`[^a-z0-9.-]`)
+
+func normalizeName(name string) string {
+	return unsupportedNameChars.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// substituteParams replaces {{ key }} references to flattened parameters,
+// whose dotted or indexed names Go templates cannot express.
+func substituteParams(body string, params map[string]interface{}) string {
+	return paramReference.ReplaceAllStringFunc(body, func(ref string) string {
+		key := strings.TrimSpace(ref[2 : len(ref)-2])
+		if value, ok := params[key]; ok && !isIdentifier(key) {
+			return fmt.Sprint(value)
+		}
+		return ref
+	})
+}
+
+var (
+	paramReference = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	identifier     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+func isIdentifier(key string) bool {
+	return identifier.MatchString(key)
+}
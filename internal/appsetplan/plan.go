@@ -2,11 +2,14 @@ package appsetplan
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/argocd-lint/argocd-lint/internal/loader"
@@ -65,6 +68,24 @@ type Summary struct {
 type Options struct {
 	AppSetPath string
 	CurrentDir string
+	// RepoRoot is a local checkout read by git generators whose repoURL it
+	// matches; other repositories are shallow-cloned when Clone is set.
+	RepoRoot string
+	// Clone lets git generators shallow-clone repositories that RepoRoot
+	// does not check out. Without it they fail with an error.
+	Clone bool
+	// GeneratorData is a JSON or YAML file listing the pull requests and
+	// repositories that pullRequest and scmProvider generators expand to.
+	GeneratorData string
+	// Online lets those generators query the GitHub and GitLab APIs when
+	// no GeneratorData is given, authenticating with Tokens keyed by
+	// provider ("github", "gitlab"). Tokens are only sent over https to
+	// api.github.com, gitlab.com, and the TokenHosts, since a manifest's
+	// api field may name any host.
+	Online     bool
+	Tokens     map[string]string
+	TokenHosts []string
+	HTTPClient *http.Client
 }
 
 // Generate produces the ApplicationSet plan.
@@ -88,7 +109,17 @@ func Generate(opts Options) (Result, error) {
 		return Result{}, fmt.Errorf("no ApplicationSet found in %s", opts.AppSetPath)
 	}
 
-	desired, err := renderDesiredApplications(appset)
+	p := &planner{ctx: context.Background(), repoRoot: opts.RepoRoot, clone: opts.Clone, online: opts.Online, tokens: opts.Tokens, tokenHosts: opts.TokenHosts, client: opts.HTTPClient}
+	if opts.GeneratorData != "" {
+		if p.data, err = LoadGeneratorData(opts.GeneratorData); err != nil {
+			return Result{}, err
+		}
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	defer p.close()
+	desired, err := p.renderDesiredApplications(appset)
 	if err != nil {
 		return Result{}, err
 	}
@@ -137,7 +168,24 @@ func Generate(opts Options) (Result, error) {
 	}, nil
 }
 
-func renderDesiredApplications(appset *manifest.Manifest) ([]PlanRow, error) {
+// GeneratedNames returns the Application names the ApplicationSet generates.
+// It supports the same generators as Generate, except that git, pull
+// request, and SCM provider generators are skipped rather than cloned or
+// queried, so the names cover the remaining generators only.
+func GeneratedNames(appset *manifest.Manifest) ([]string, error) {
+	p := &planner{ctx: context.Background(), skipRemote: true}
+	rows, err := p.renderDesiredApplications(appset)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		names = append(names, row.Name)
+	}
+	return names, nil
+}
+
+func (p *planner) renderDesiredApplications(appset *manifest.Manifest) ([]PlanRow, error) {
 	spec := mapGet(appset.Object, "spec")
 	generators := sliceGet(spec, "generators")
 	if len(generators) == 0 {
@@ -148,34 +196,60 @@ func renderDesiredApplications(appset *manifest.Manifest) ([]PlanRow, error) {
 	if len(template) == 0 {
 		return nil, fmt.Errorf("ApplicationSet %s missing template", appset.Name)
 	}
+	goTemplate, _ := spec["goTemplate"].(bool)
 
 	var desired []PlanRow
+	skipped := 0
 	for _, raw := range generators {
 		genMap, ok := raw.(map[string]interface{})
 		if !ok {
 			continue
 		}
+		var items []map[string]interface{}
+		if p.skipRemote && (len(mapGet(genMap, "git")) > 0 || len(mapGet(genMap, "pullRequest")) > 0 || len(mapGet(genMap, "scmProvider")) > 0) {
+			skipped++
+			continue
+		}
 		if list := mapGet(genMap, "list"); len(list) > 0 {
-			elements := sliceGet(list, "elements")
-			for _, element := range elements {
-				ctx, ok := element.(map[string]interface{})
-				if !ok {
-					continue
+			for _, element := range sliceGet(list, "elements") {
+				if ctx, ok := element.(map[string]interface{}); ok {
+					items = append(items, ctx)
 				}
-				rendered, err := renderTemplate(template, ctx)
-				if err != nil {
-					return nil, fmt.Errorf("render template: %w", err)
-				}
-				row := extractPreview(rendered)
-				if row.Name == "" {
-					row.Name = fmt.Sprintf("<unnamed:%d>", len(desired))
-				}
-				desired = append(desired, row)
 			}
+		} else if git := mapGet(genMap, "git"); len(git) > 0 {
+			params, err := p.gitParams(git, goTemplate)
+			if err != nil {
+				return nil, err
+			}
+			items = params
+		} else if pr := mapGet(genMap, "pullRequest"); len(pr) > 0 {
+			params, err := p.pullRequestParams(pr, goTemplate)
+			if err != nil {
+				return nil, err
+			}
+			items = params
+		} else if scm := mapGet(genMap, "scmProvider"); len(scm) > 0 {
+			params, err := p.scmProviderParams(scm, goTemplate)
+			if err != nil {
+				return nil, err
+			}
+			items = params
+		} else {
 			continue
 		}
+		for _, ctx := range items {
+			rendered, err := renderTemplate(template, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("render template: %w", err)
+			}
+			row := extractPreview(rendered)
+			if row.Name == "" {
+				row.Name = fmt.Sprintf("<unnamed:%d>", len(desired))
+			}
+			desired = append(desired, row)
+		}
 	}
-	if len(desired) == 0 {
+	if len(desired) == 0 && skipped == 0 {
 		return nil, fmt.Errorf("unsupported generators in ApplicationSet %s", appset.Name)
 	}
 	return desired, nil
@@ -186,7 +260,7 @@ func renderTemplate(tpl map[string]interface{}, item map[string]interface{}) (ma
 	if err != nil {
 		return nil, err
 	}
-	tmpl, err := templateWithSprig(string(raw), item)
+	tmpl, err := templateWithSprig(substituteParams(string(raw), item), item)
 	if err != nil {
 		return nil, err
 	}
@@ -211,9 +285,11 @@ func renderTemplate(tpl map[string]interface{}, item map[string]interface{}) (ma
 func templateWithSprig(body string, item map[string]interface{}) (*template.Template, error) {
 	funcMap := sprig.TxtFuncMap()
 	for k, v := range item {
-		key := k
+		if !isIdentifier(k) {
+			continue
+		}
 		val := v
-		funcMap[key] = func() interface{} { return val }
+		funcMap[k] = func() interface{} { return val }
 	}
 	tmpl := template.New("appset").Funcs(funcMap)
 	tmpl.Option("missingkey=zero")
@@ -1,9 +1,17 @@
 package appsetplan
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
 )
 
 func writeFile(t *testing.T, dir, name, content string) string {
@@ -95,3 +103,279 @@ spec:
 		t.Fatalf("expected both create and unchanged actions")
 	}
 }
+
+func TestGenerateGitDirectoriesPlan(t *testing.T) {
+	repo := t.TempDir()
+	for _, dir := range []string{"apps/api", "apps/web", "apps/legacy", "docs"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	appset := `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: apps
+spec:
+  generators:
+    - git:
+        repoURL: https://example.com/repo.git
+        revision: v1.0.0
+        directories:
+          - path: apps/*
+          - path: apps/legacy
+            exclude: true
+  template:
+    metadata:
+      name: '{{path.basename}}-{{path[0]}}'
+    spec:
+      project: default
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: '{{path.basename}}'
+      source:
+        repoURL: https://example.com/repo.git
+        path: '{{path}}'
+`
+	appsetPath := writeFile(t, t.TempDir(), "appset.yaml", appset)
+
+	result, err := Generate(Options{AppSetPath: appsetPath, RepoRoot: repo})
+	if err != nil {
+		t.Fatalf("generate plan: %v", err)
+	}
+	if len(result.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", result.Rows)
+	}
+	if row := result.Rows[0]; row.Name != "api-apps" || row.Source.Path != "apps/api" || row.Destination.Namespace != "api" {
+		t.Fatalf("unexpected row: %+v", row)
+	}
+	if row := result.Rows[1]; row.Name != "web-apps" {
+		t.Fatalf("unexpected row: %+v", row)
+	}
+
+	doc, err := (&manifest.Parser{}).ParseFile(appsetPath)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if names, err := GeneratedNames(doc[0]); err != nil || len(names) != 0 {
+		t.Fatalf("expected GeneratedNames to skip git generators, got %v, %v", names, err)
+	}
+}
+
+func TestGenerateGitFilesPlanClonesRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "clusters", "prod"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repo, "clusters/prod/config.json", `{"cluster": {"name": "prod-eu", "address": "https://prod.example.com"}}`)
+	writeFile(t, repo, "clusters/staging.yaml", "- cluster:\n    name: stage-a\n    address: https://a.example.com\n- cluster:\n    name: stage-b\n    address: https://b.example.com\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	appset := `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: clusters
+spec:
+  goTemplate: true
+  generators:
+    - git:
+        repoURL: file://` + filepath.ToSlash(repo) + `
+        revision: v1
+        files:
+          - path: clusters/**
+        values:
+          env: '{{ .path.basename }}'
+  template:
+    metadata:
+      name: '{{ .cluster.name }}'
+    spec:
+      project: default
+      destination:
+        server: '{{ .cluster.address }}'
+        namespace: '{{ .values.env }}'
+      source:
+        repoURL: https://example.com/repo.git
+        path: '{{ .path.path }}'
+`
+	appsetPath := writeFile(t, t.TempDir(), "appset.yaml", appset)
+	previous := gitutil.Protocols
+	gitutil.Protocols = []string{"file"}
+	defer func() { gitutil.Protocols = previous }()
+
+	if _, err := Generate(Options{AppSetPath: appsetPath}); err == nil || !strings.Contains(err.Error(), "--clone") {
+		t.Fatalf("expected git generator to need --clone, got %v", err)
+	}
+	result, err := Generate(Options{AppSetPath: appsetPath, Clone: true})
+	if err != nil {
+		t.Fatalf("generate plan: %v", err)
+	}
+	if len(result.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", result.Rows)
+	}
+	byName := map[string]PlanRow{}
+	for _, row := range result.Rows {
+		byName[row.Name] = row
+	}
+	if row := byName["prod-eu"]; row.Destination.Server != "https://prod.example.com" || row.Source.Path != "clusters/prod" {
+		t.Fatalf("unexpected prod row: %+v", row)
+	}
+	if row := byName["stage-b"]; row.Destination.Server != "https://b.example.com" || row.Source.Path != "clusters" {
+		t.Fatalf("unexpected stage-b row: %+v", row)
+	}
+}
+
+func TestGeneratePullRequestPlanFromGeneratorData(t *testing.T) {
+	dir := t.TempDir()
+	data := writeFile(t, dir, "prs.json", `{
+  "pullRequests": [
+    {"repo": "acme/shop", "number": 12, "branch": "Feature/Cart", "targetBranch": "main", "headSha": "0123456789abcdef", "labels": ["preview"]},
+    {"repo": "acme/shop", "number": 13, "branch": "docs/typo", "targetBranch": "main", "headSha": "fedcba9876543210"},
+    {"repo": "acme/other", "number": 14, "branch": "feature/x", "targetBranch": "main", "labels": ["preview"]}
+  ],
+  "repositories": [
+    {"organization": "acme", "repository": "shop", "url": "https://github.com/acme/shop.git", "branch": "main", "sha": "aaaaaaaaaaaa", "labels": ["argocd"]},
+    {"organization": "acme", "repository": "wiki", "url": "https://github.com/acme/wiki.git", "branch": "main", "sha": "bbbbbbbbbbbb"}
+  ]
+}`)
+	appset := `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: previews
+spec:
+  generators:
+    - pullRequest:
+        github:
+          owner: acme
+          repo: shop
+          labels: [preview]
+        filters:
+          - branchMatch: '^feature/'
+          - branchMatch: '^Feature/'
+  template:
+    metadata:
+      name: 'preview-{{branch_slug}}-{{number}}'
+    spec:
+      project: default
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: 'preview-{{number}}'
+      source:
+        repoURL: https://github.com/acme/shop.git
+        path: deploy
+`
+	appsetPath := writeFile(t, dir, "appset.yaml", appset)
+
+	result, err := Generate(Options{AppSetPath: appsetPath, GeneratorData: data})
+	if err != nil {
+		t.Fatalf("generate plan: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].Name != "preview-feature-cart-12" || result.Rows[0].Destination.Namespace != "preview-12" {
+		t.Fatalf("unexpected rows %+v", result.Rows)
+	}
+
+	scm := `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: repos
+spec:
+  goTemplate: true
+  generators:
+    - scmProvider:
+        github:
+          organization: acme
+        filters:
+          - labelMatch: argocd
+  template:
+    metadata:
+      name: '{{ .repository }}-{{ .short_sha_7 }}'
+    spec:
+      project: default
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: '{{ .repository }}'
+      source:
+        repoURL: '{{ .url }}'
+        path: deploy
+`
+	result, err = Generate(Options{AppSetPath: writeFile(t, dir, "scm.yaml", scm), GeneratorData: data})
+	if err != nil {
+		t.Fatalf("generate scm plan: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].Name != "shop-aaaaaaa" || result.Rows[0].Source.RepoURL != "https://github.com/acme/shop.git" {
+		t.Fatalf("unexpected rows %+v", result.Rows)
+	}
+
+	if _, err := Generate(Options{AppSetPath: appsetPath}); err == nil || !strings.Contains(err.Error(), "--generator-data") {
+		t.Fatalf("expected generator data error, got %v", err)
+	}
+}
+
+func TestGeneratePullRequestPlanOnline(t *testing.T) {
+	var auth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/shop/pulls" {
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `[{"number": 7, "title": "Add cart", "user": {"login": "dev"}, "head": {"ref": "cart", "sha": "0123456789abcdef"}, "base": {"ref": "main"}, "labels": [{"name": "preview"}]}]`)
+	}))
+	defer server.Close()
+	appset := `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: previews
+spec:
+  goTemplate: true
+  generators:
+    - pullRequest:
+        github:
+          api: ` + server.URL + `
+          owner: acme
+          repo: shop
+  template:
+    metadata:
+      name: 'shop-{{ .number }}-{{ .head_short_sha_7 }}'
+    spec:
+      project: default
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: '{{ .branch_slug }}'
+      source:
+        repoURL: https://github.com/acme/shop.git
+        path: deploy
+`
+	appsetPath := writeFile(t, t.TempDir(), "appset.yaml", appset)
+
+	opts := Options{AppSetPath: appsetPath, Online: true, Tokens: map[string]string{"github": "secret"}, HTTPClient: server.Client()}
+	result, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("generate plan: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].Name != "shop-7-0123456" || result.Rows[0].Destination.Namespace != "cart" {
+		t.Fatalf("unexpected rows %+v", result.Rows)
+	}
+	if auth != "" {
+		t.Fatalf("expected no token for an api host taken from the manifest, got %q", auth)
+	}
+
+	opts.TokenHosts = []string{strings.TrimPrefix(server.URL, "https://")}
+	if _, err := Generate(opts); err != nil {
+		t.Fatalf("generate plan: %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Fatalf("expected the token for an allowed host, got %q", auth)
+	}
+}
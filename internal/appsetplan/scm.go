@@ -0,0 +1,540 @@
+package appsetplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GeneratorData describes the pull requests and repositories that
+// pullRequest and scmProvider generators would discover, so plans can run
+// offline.
+type GeneratorData struct {
+	PullRequests []PullRequest `yaml:"pullRequests"`
+	Repositories []Repository  `yaml:"repositories"`
+}
+
+// PullRequest is an open pull (or merge) request. Repo, as owner/name or
+// the GitLab project path, limits it to the generators for that repository;
+// when empty it applies to every pullRequest generator.
+type PullRequest struct {
+	Repo         string   `yaml:"repo"`
+	Number       int      `yaml:"number"`
+	Title        string   `yaml:"title"`
+	Branch       string   `yaml:"branch"`
+	TargetBranch string   `yaml:"targetBranch"`
+	HeadSHA      string   `yaml:"headSha"`
+	Author       string   `yaml:"author"`
+	Labels       []string `yaml:"labels"`
+}
+
+// Repository is a repository branch found by an SCM provider. Organization,
+// when set, limits it to the generators for that organization or group.
+type Repository struct {
+	Organization string   `yaml:"organization"`
+	Repository   string   `yaml:"repository"`
+	URL          string   `yaml:"url"`
+	Branch       string   `yaml:"branch"`
+	SHA          string   `yaml:"sha"`
+	Labels       []string `yaml:"labels"`
+}
+
+// LoadGeneratorData reads a JSON or YAML generator data file.
+func LoadGeneratorData(path string) (*GeneratorData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read generator data: %w", err)
+	}
+	var out GeneratorData
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("parse generator data %s: %w", path, err)
+	}
+	return &out, nil
+}
+
+// scmProviders are the provider keys of pullRequest and scmProvider
+// generators; online mode supports github and gitlab.
+var scmProviders = []string{"github", "gitlab", "gitea", "bitbucket", "bitbucketServer", "azureDevOps", "awsCodeCommit"}
+
+// provider returns the provider key and settings of a pullRequest or
+// scmProvider generator.
+func provider(gen map[string]interface{}) (string, map[string]interface{}) {
+	for _, name := range scmProviders {
+		if settings := mapGet(gen, name); len(settings) > 0 {
+			return name, settings
+		}
+	}
+	return "", nil
+}
+
+// pullRequestParams returns one parameter set per open pull request the
+// generator selects, read from the generator data or, online, from the
+// provider API.
+func (p *planner) pullRequestParams(gen map[string]interface{}, goTemplate bool) ([]map[string]interface{}, error) {
+	name, settings := provider(gen)
+	if name == "" {
+		return nil, fmt.Errorf("pullRequest generator has no supported provider")
+	}
+	repo := stringGet(settings, "project")
+	if repo == "" {
+		repo = strings.Trim(stringGet(settings, "owner")+"/"+stringGet(settings, "repo"), "/")
+	}
+	var prs []PullRequest
+	switch {
+	case p.data != nil:
+		for _, pr := range p.data.PullRequests {
+			if pr.Repo == "" || strings.EqualFold(pr.Repo, repo) {
+				prs = append(prs, pr)
+			}
+		}
+	case p.online:
+		var err error
+		if prs, err = p.fetchPullRequests(name, settings, repo); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("pullRequest generator for %s needs generator data (--generator-data) or --online", repo)
+	}
+	labels := stringList(sliceGet(settings, "labels"))
+	filters := sliceGet(gen, "filters")
+	var sets []map[string]interface{}
+	for _, pr := range prs {
+		if !hasLabels(pr.Labels, labels) || !matchFilters(filters, map[string]string{
+			"branchMatch":       pr.Branch,
+			"targetBranchMatch": pr.TargetBranch,
+			"titleMatch":        pr.Title,
+		}) {
+			continue
+		}
+		params := map[string]interface{}{
+			"number":             strconv.Itoa(pr.Number),
+			"title":              pr.Title,
+			"branch":             pr.Branch,
+			"branch_slug":        slug(pr.Branch),
+			"target_branch":      pr.TargetBranch,
+			"target_branch_slug": slug(pr.TargetBranch),
+			"head_sha":           pr.HeadSHA,
+			"head_short_sha":     shortSHA(pr.HeadSHA, 8),
+			"head_short_sha_7":   shortSHA(pr.HeadSHA, 7),
+			"author":             pr.Author,
+		}
+		if goTemplate {
+			params["labels"] = pr.Labels
+		} else {
+			params["labels"] = strings.Join(pr.Labels, ",")
+		}
+		sets = append(sets, params)
+	}
+	addGeneratorValues(sets, gen, goTemplate)
+	return sets, nil
+}
+
+// scmProviderParams returns one parameter set per repository the generator
+// selects.
+func (p *planner) scmProviderParams(gen map[string]interface{}, goTemplate bool) ([]map[string]interface{}, error) {
+	name, settings := provider(gen)
+	if name == "" {
+		return nil, fmt.Errorf("scmProvider generator has no supported provider")
+	}
+	org := stringGet(settings, "organization")
+	for _, key := range []string{"group", "owner", "project"} {
+		if org == "" {
+			org = stringGet(settings, key)
+		}
+	}
+	var repos []Repository
+	switch {
+	case p.data != nil:
+		for _, repo := range p.data.Repositories {
+			if repo.Organization == "" || strings.EqualFold(repo.Organization, org) {
+				repos = append(repos, repo)
+			}
+		}
+	case p.online:
+		var err error
+		if repos, err = p.fetchRepositories(name, settings, org, stringGet(gen, "cloneProtocol")); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("scmProvider generator for %s needs generator data (--generator-data) or --online", org)
+	}
+	filters := sliceGet(gen, "filters")
+	var sets []map[string]interface{}
+	for _, repo := range repos {
+		if !matchFilters(filters, map[string]string{
+			"repositoryMatch": repo.Repository,
+			"branchMatch":     repo.Branch,
+		}) || !matchLabelFilters(filters, repo.Labels) {
+			continue
+		}
+		sets = append(sets, map[string]interface{}{
+			"organization":     repo.Organization,
+			"repository":       repo.Repository,
+			"url":              repo.URL,
+			"branch":           repo.Branch,
+			"branchNormalized": slug(repo.Branch),
+			"sha":              repo.SHA,
+			"short_sha":        shortSHA(repo.SHA, 8),
+			"short_sha_7":      shortSHA(repo.SHA, 7),
+			"labels":           strings.Join(repo.Labels, ","),
+		})
+	}
+	addGeneratorValues(sets, gen, goTemplate)
+	return sets, nil
+}
+
+func addGeneratorValues(sets []map[string]interface{}, gen map[string]interface{}, goTemplate bool) {
+	if values := mapGet(gen, "values"); len(values) > 0 {
+		for _, params := range sets {
+			addValues(params, values, goTemplate)
+		}
+	}
+}
+
+// matchFilters reports whether values pass the generator filters: any
+// filter may match, and every regexp in a filter must.
+func matchFilters(filters []interface{}, values map[string]string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, raw := range filters {
+		filter, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matched := true
+		for key, value := range values {
+			pattern := stringGet(filter, key)
+			if pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil || !re.MatchString(value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLabelFilters reports whether some label matches every labelMatch
+// filter.
+func matchLabelFilters(filters []interface{}, labels []string) bool {
+	for _, raw := range filters {
+		filter, ok := raw.(map[string]interface{})
+		if !ok || stringGet(filter, "labelMatch") == "" {
+			continue
+		}
+		re, err := regexp.Compile(stringGet(filter, "labelMatch"))
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, label := range labels {
+			if re.MatchString(label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func hasLabels(have, want []string) bool {
+	for _, label := range want {
+		found := false
+		for _, candidate := range have {
+			if candidate == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func stringList(items []interface{}) []string {
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug lowercases value and joins its alphanumeric runs with dashes,
+// keeping at most 50 characters, like Argo CD's branch_slug.
+func slug(value string) string {
+	out := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+	if len(out) > 50 {
+		out = strings.TrimRight(out[:50], "-")
+	}
+	return out
+}
+
+func shortSHA(sha string, n int) string {
+	if len(sha) > n {
+		return sha[:n]
+	}
+	return sha
+}
+
+// defaultAPIs are the public API endpoints of the online providers.
+var defaultAPIs = map[string]string{
+	"github": "https://api.github.com",
+	"gitlab": "https://gitlab.com",
+}
+
+// trustedHost reports whether the provider token may be sent to u: the
+// provider's public API host or a host allowed with Options.TokenHosts,
+// never a host taken only from a manifest's api field.
+func (p *planner) trustedHost(name string, u *url.URL) bool {
+	if u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	if public, err := url.Parse(defaultAPIs[name]); err == nil && strings.EqualFold(public.Host, host) {
+		return true
+	}
+	for _, allowed := range p.tokenHosts {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *planner) api(name string, settings map[string]interface{}) (string, error) {
+	base := stringGet(settings, "api")
+	if base == "" {
+		base = defaultAPIs[name]
+	}
+	if base == "" {
+		return "", fmt.Errorf("online mode does not support the %s provider; use --generator-data", name)
+	}
+	return strings.TrimRight(base, "/"), nil
+}
+
+func (p *planner) fetchPullRequests(name string, settings map[string]interface{}, repo string) ([]PullRequest, error) {
+	base, err := p.api(name, settings)
+	if err != nil {
+		return nil, err
+	}
+	var prs []PullRequest
+	switch name {
+	case "github":
+		var page []struct {
+			Number int    `json:"number"`
+			Title  string `json:"title"`
+			User   struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			Head struct {
+				Ref string `json:"ref"`
+				SHA string `json:"sha"`
+			} `json:"head"`
+			Base struct {
+				Ref string `json:"ref"`
+			} `json:"base"`
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		}
+		err = p.getPages(name, base+"/repos/"+repo+"/pulls?state=open", &page, func() int {
+			for _, pr := range page {
+				var labels []string
+				for _, label := range pr.Labels {
+					labels = append(labels, label.Name)
+				}
+				prs = append(prs, PullRequest{Repo: repo, Number: pr.Number, Title: pr.Title, Branch: pr.Head.Ref,
+					TargetBranch: pr.Base.Ref, HeadSHA: pr.Head.SHA, Author: pr.User.Login, Labels: labels})
+			}
+			return len(page)
+		})
+	case "gitlab":
+		state := stringGet(settings, "pullRequestState")
+		if state == "" {
+			state = "opened"
+		}
+		var page []struct {
+			IID          int      `json:"iid"`
+			Title        string   `json:"title"`
+			SourceBranch string   `json:"source_branch"`
+			TargetBranch string   `json:"target_branch"`
+			SHA          string   `json:"sha"`
+			Labels       []string `json:"labels"`
+			Author       struct {
+				Username string `json:"username"`
+			} `json:"author"`
+		}
+		endpoint := base + "/api/v4/projects/" + url.PathEscape(repo) + "/merge_requests?state=" + url.QueryEscape(state)
+		err = p.getPages(name, endpoint, &page, func() int {
+			for _, mr := range page {
+				prs = append(prs, PullRequest{Repo: repo, Number: mr.IID, Title: mr.Title, Branch: mr.SourceBranch,
+					TargetBranch: mr.TargetBranch, HeadSHA: mr.SHA, Author: mr.Author.Username, Labels: mr.Labels})
+			}
+			return len(page)
+		})
+	default:
+		return nil, fmt.Errorf("online mode does not support the %s provider; use --generator-data", name)
+	}
+	return prs, err
+}
+
+func (p *planner) fetchRepositories(name string, settings map[string]interface{}, org, protocol string) ([]Repository, error) {
+	base, err := p.api(name, settings)
+	if err != nil {
+		return nil, err
+	}
+	var repos []Repository
+	switch name {
+	case "github":
+		var page []struct {
+			Name          string   `json:"name"`
+			CloneURL      string   `json:"clone_url"`
+			SSHURL        string   `json:"ssh_url"`
+			DefaultBranch string   `json:"default_branch"`
+			Topics        []string `json:"topics"`
+			Archived      bool     `json:"archived"`
+		}
+		err = p.getPages(name, base+"/orgs/"+url.PathEscape(org)+"/repos", &page, func() int {
+			for _, repo := range page {
+				if repo.Archived {
+					continue
+				}
+				cloneURL := repo.SSHURL
+				if protocol == "https" {
+					cloneURL = repo.CloneURL
+				}
+				repos = append(repos, Repository{Organization: org, Repository: repo.Name, URL: cloneURL, Branch: repo.DefaultBranch, Labels: repo.Topics})
+			}
+			return len(page)
+		})
+		for i := range repos {
+			if err != nil {
+				break
+			}
+			var branch struct {
+				Commit struct {
+					SHA string `json:"sha"`
+				} `json:"commit"`
+			}
+			err = p.getJSON(name, base+"/repos/"+url.PathEscape(org)+"/"+url.PathEscape(repos[i].Repository)+"/branches/"+url.PathEscape(repos[i].Branch), &branch)
+			repos[i].SHA = branch.Commit.SHA
+		}
+	case "gitlab":
+		var page []struct {
+			ID            int      `json:"id"`
+			Path          string   `json:"path"`
+			HTTPURL       string   `json:"http_url_to_repo"`
+			SSHURL        string   `json:"ssh_url_to_repo"`
+			DefaultBranch string   `json:"default_branch"`
+			Topics        []string `json:"topics"`
+			Namespace     struct {
+				FullPath string `json:"full_path"`
+			} `json:"namespace"`
+		}
+		var ids []int
+		subgroups, _ := settings["includeSubgroups"].(bool)
+		endpoint := base + "/api/v4/groups/" + url.PathEscape(org) + "/projects?archived=false&include_subgroups=" + strconv.FormatBool(subgroups)
+		err = p.getPages(name, endpoint, &page, func() int {
+			for _, repo := range page {
+				cloneURL := repo.SSHURL
+				if protocol == "https" {
+					cloneURL = repo.HTTPURL
+				}
+				ids = append(ids, repo.ID)
+				repos = append(repos, Repository{Organization: repo.Namespace.FullPath, Repository: repo.Path, URL: cloneURL, Branch: repo.DefaultBranch, Labels: repo.Topics})
+			}
+			return len(page)
+		})
+		for i := range repos {
+			if err != nil {
+				break
+			}
+			var branch struct {
+				Commit struct {
+					ID string `json:"id"`
+				} `json:"commit"`
+			}
+			err = p.getJSON(name, fmt.Sprintf("%s/api/v4/projects/%d/repository/branches/%s", base, ids[i], url.PathEscape(repos[i].Branch)), &branch)
+			repos[i].SHA = branch.Commit.ID
+		}
+	default:
+		return nil, fmt.Errorf("online mode does not support the %s provider; use --generator-data", name)
+	}
+	return repos, err
+}
+
+// perPage is the page size requested from provider list endpoints.
+const perPage = 100
+
+// getPages decodes successive pages of endpoint into page, calling collect
+// after each, until a page is shorter than perPage.
+func (p *planner) getPages(name, endpoint string, page interface{}, collect func() int) error {
+	sep := "&"
+	if !strings.Contains(endpoint, "?") {
+		sep = "?"
+	}
+	for n := 1; ; n++ {
+		if err := p.getJSON(name, fmt.Sprintf("%s%sper_page=%d&page=%d", endpoint, sep, perPage, n), page); err != nil {
+			return err
+		}
+		if collect() < perPage {
+			return nil
+		}
+	}
+}
+
+// getJSON fetches endpoint with the provider token and decodes the body.
+func (p *planner) getJSON(name, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := p.tokens[name]; token != "" && p.trustedHost(name, req.URL) {
+		if name == "gitlab" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch %s: %s", endpoint, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", endpoint, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parse %s: %w", endpoint, err)
+	}
+	return nil
+}
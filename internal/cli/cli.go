@@ -3,8 +3,11 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -12,17 +15,35 @@ import (
 	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/appsetplan"
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/drift"
 	"github.com/argocd-lint/argocd-lint/internal/dryrun"
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
 	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/internal/outdated"
 	"github.com/argocd-lint/argocd-lint/internal/output"
 	"github.com/argocd-lint/argocd-lint/internal/render"
+	"github.com/argocd-lint/argocd-lint/internal/schema"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+	customplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/custom"
+	grpcplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/grpc"
 	regoplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 	"github.com/argocd-lint/argocd-lint/pkg/version"
 	"github.com/spf13/pflag"
 )
 
+// stdin feeds the "-" target; tests replace it.
+var stdin io.Reader = os.Stdin
+
+// stdinPath names manifests read from stdin in findings.
+const stdinPath = "<stdin>"
+
 // Execute is the entrypoint for the CLI. Returns process exit code.
 func Execute(args []string, stdout, stderr io.Writer) int {
 	if len(args) > 0 {
@@ -31,37 +52,112 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 			return runPluginsCommand(args[1:], stdout, stderr)
 		case "applicationset":
 			return runApplicationSetCommand(args[1:], stdout, stderr)
+		case "cluster":
+			return runClusterCommand(args[1:], stdout, stderr)
+		case "controller":
+			return runControllerCommand(args[1:], stdout, stderr)
+		case "rules":
+			return runRulesCommand(args[1:], stdout, stderr)
+		case "diff":
+			return runDiffCommand(args[1:], stdout, stderr)
+		case "diff-report":
+			return runDiffReportCommand(args[1:], stdout, stderr)
+		case "init":
+			return runInitCommand(args[1:], stdout, stderr)
+		case "config":
+			return runConfigCommand(args[1:], stdout, stderr)
+		case "render":
+			return runRenderCommand(args[1:], stdout, stderr)
+		case "schema":
+			return runSchemaCommand(args[1:], stdout, stderr)
+		case "waivers":
+			return runWaiversCommand(args[1:], stdout, stderr)
 		}
 	}
 	flags := pflag.NewFlagSet("argocd-lint", pflag.ContinueOnError)
 	flags.SetOutput(stderr)
 
-	rulesPath := flags.String("rules", "", "Path to rules configuration file")
-	format := flags.String("format", "table", "Output format: table|json|sarif")
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	formats := flags.StringArray("format", []string{output.FormatTable}, "Output format: table|json|sarif|github|teamcity|html|auto, optionally as format=path to write a file (repeatable; auto picks github inside GitHub Actions and table elsewhere)")
+	outputPath := flags.String("output", "", "Write the stdout format to this file instead; the table is still printed to stdout")
 	includeApps := flags.Bool("apps", true, "Include Application manifests")
 	includeAppSets := flags.Bool("appsets", true, "Include ApplicationSet manifests")
 	includeProjects := flags.Bool("projects", true, "Include AppProject manifests")
+	failOn := flags.String("fail-on", "", "Exit policy: threshold|new|none (new requires --baseline; none is report-only); overrides config")
 	severityThreshold := flags.String("severity-threshold", "", "Exit with non-zero status at or above this severity (info|warn|error); overrides config")
-	argocdVersion := flags.String("argocd-version", "", "Pin schema validation to a specific Argo CD version (e.g. v2.8)")
+	argocdVersion := flags.String("argocd-version", "", "Pin schema validation to a specific Argo CD version ("+strings.Join(schema.EmbeddedVersions(), ", ")+"; other releases via schema fetch and --crd-schemas), or from-cluster to validate against the Argo CD CRDs installed in the cluster (--kubeconfig, --kube-context)")
+	crdSchemas := flags.StringSlice("crd-schemas", nil, "Directory of CustomResourceDefinitions or JSON schemas that validate matching resources, replacing the embedded Argo CD schemas for the kinds they cover and, with --render, checking rendered resources (repeatable; adds to config crdSchemas)")
 	renderEnabled := flags.Bool("render", false, "Render Helm/Kustomize sources before linting")
-	helmBinary := flags.String("helm-binary", "helm", "Helm binary to use for rendering")
-	kustomizeBinary := flags.String("kustomize-binary", "kustomize", "Kustomize binary to use for rendering")
-	repoRoot := flags.String("repo-root", "", "Override repository root for resolving source paths when rendering")
+	flags.String("helm-binary", "", "Ignored: Helm charts are rendered in-process")
+	_ = flags.MarkDeprecated("helm-binary", "Helm charts are rendered in-process; the flag has no effect")
+	kustomizeBinary := flags.String("kustomize-binary", "", "Run this kustomize binary instead of the embedded kustomize API")
+	kustomizeRemote := flags.Bool("kustomize-allow-remote", true, "Let kustomizations fetch remote bases, resources, and components (false rejects them)")
+	kustomizeRestrictor := flags.String("kustomize-load-restrictor", "LoadRestrictionsRootOnly", "Kustomize file load restrictions: LoadRestrictionsRootOnly|LoadRestrictionsNone")
+	repoRoot := flags.String("repo-root", "", "Repository root for resolving source paths (default: the git checkout holding the targets); the rules that check source paths on disk run whenever a root resolves")
 	renderCache := flags.Bool("render-cache", false, "Cache render results for identical sources during a run")
 	showVersion := flags.Bool("version", false, "Print argocd-lint version and exit")
 	dryRunMode := flags.String("dry-run", "", "Perform extended validation: kubeconform|server")
-	kubeconfig := flags.String("kubeconfig", "", "Path to kubeconfig for server-side dry-run")
-	kubeContext := flags.String("kube-context", "", "Kubernetes context for server-side dry-run")
-	kubectlBinary := flags.String("kubectl-binary", "kubectl", "kubectl binary to use for server dry-run")
-	kubeconformBinary := flags.String("kubeconform-binary", "kubeconform", "kubeconform binary for schema validation")
-	pluginFiles := flags.StringSlice("plugin", nil, "Path to a Rego plugin module (repeatable)")
-	pluginDirs := flags.StringSlice("plugin-dir", nil, "Directory of Rego plugin modules (repeatable, recursive)")
+	dryRunRendered := flags.Bool("dry-run-rendered", false, "With --dry-run, also dry-run the resources each Application renders to, reported against the Application (implies --render)")
+	kubeconfig := flags.String("kubeconfig", "", "Path to kubeconfig for server-side dry-run and --against-cluster")
+	kubeContext := flags.String("kube-context", "", "Kubernetes context for server-side dry-run and --against-cluster")
+	kubeQPS := flags.Float32("kube-qps", 0, "Maximum API requests per second for server-side dry-run (0=client-go default of 5)")
+	kubeBurst := flags.Int("kube-burst", 0, "Maximum burst of API requests for server-side dry-run (0=client-go default of 10)")
+	kubectlBinary := flags.String("kubectl-binary", "kubectl", "kubectl binary to use for --against-cluster")
+	flags.String("kubeconform-binary", "", "Ignored: kubeconform validation runs in-process")
+	_ = flags.MarkDeprecated("kubeconform-binary", "kubeconform validation runs in-process; the flag has no effect")
+	kubeconformSchemas := flags.StringArray("kubeconform-schema-location", nil, "Schema location for --dry-run kubeconform: default, a URL, or a local path, templated like kubeconform -schema-location (repeatable, tried in order; default default)")
+	kubeconformIgnoreMissing := flags.Bool("kubeconform-ignore-missing-schemas", false, "Skip resources without a schema, such as custom resources, in --dry-run kubeconform instead of failing them")
+	pluginFiles := flags.StringSlice("plugin", nil, "Path to a Rego plugin module, or an oci:// or https:// bundle reference (repeatable)")
+	pluginDirs := flags.StringSlice("plugin-dir", nil, "Directory of Rego plugin modules, or an oci:// or https:// bundle reference (repeatable, recursive)")
+	pluginVerify := flags.Bool("plugin-verify", false, "Refuse Rego plugins without a valid cosign signature (--plugin-key, or keyless with the certificate flags)")
+	pluginKey := flags.String("plugin-key", "", "Public key (cosign.pub) that Rego plugin signatures must verify against")
+	pluginIdentity := flags.String("plugin-certificate-identity", "", "Signer identity required for keyless plugin verification")
+	pluginIssuer := flags.String("plugin-certificate-oidc-issuer", "", "OIDC issuer required for keyless plugin verification")
+	pluginCacheDir := flags.String("plugin-cache-dir", "", "Cache fetched bundles and Rego rule metadata here, so warm runs compile only the plugins they evaluate")
+	grpcPlugins := flags.StringSlice("grpc-plugin", nil, "Path to a long-lived gRPC plugin binary started once per run (repeatable)")
+	conftestPolicies := flags.StringSlice("conftest-policy", nil, "conftest policy module or directory (deny/warn/violation rules over the raw manifest), or an oci:// or https:// bundle reference (repeatable)")
+	gatekeeperPolicies := flags.StringSlice("gatekeeper-policy", nil, "File or directory of Gatekeeper ConstraintTemplates and constraints (repeatable)")
 	maxParallel := flags.Int("max-parallel", 0, "Maximum number of lint workers to run concurrently (0=CPU count)")
-	profiles := flags.StringSlice("profile", nil, "Apply built-in rule profiles (dev, prod, security, hardening)")
+	profiles := flags.StringSlice("profile", nil, "Apply rule profiles: built-in (dev, prod, security, hardening) or definedProfiles from the config")
 	metricsFormat := flags.String("metrics", "", "Emit summary telemetry (table|json)")
+	metricsPush := flags.String("metrics-push", "", "Push summary metrics to a Prometheus Pushgateway URL")
+	metricsJob := flags.String("metrics-job", "argocd-lint", "Pushgateway job name used with --metrics-push")
+	metricsLabels := flags.StringSlice("metrics-label", nil, "Extra Pushgateway grouping label key=value (repeatable)")
 	baselinePath := flags.String("baseline", "", "Path to baseline JSON that suppresses known findings")
 	writeBaseline := flags.String("write-baseline", "", "Write current findings to baseline JSON")
 	baselineAging := flags.Int("baseline-aging", 0, "Report baseline entries older than N days")
+	checkOutdated := flags.Bool("check-outdated", false, "Query git ls-remote / Helm repo indexes and report pinned revisions behind the latest release")
+	outdatedReport := flags.String("outdated-report", "", "Write the outdated revision report as JSON to this path (implies --check-outdated)")
+	excludes := flags.StringSlice("exclude", nil, "Skip files and directories matching this gitignore-style pattern (repeatable; adds to the .argocdlintignore of the repository root)")
+	changedSince := flags.String("changed-since", "", "Only report findings for manifests changed since the merge base with this git ref (AppProjects are still loaded)")
+	groupBy := flags.String("group-by", "", "Group table output by rule|file|resource")
+	minSeverity := flags.String("min-severity", "", "Only report findings at or above this severity (info|warn|error), in every sink; does not affect the exit code")
+	maxFindings := flags.Int("max-findings", 0, "Print at most N findings in the table, followed by an \"and N more\" footer (0=all)")
+	noColor := flags.Bool("no-color", false, "Disable colored table output (also honoured via NO_COLOR)")
+	enableRules := flags.StringSlice("enable-rule", nil, "Enable these rule IDs regardless of config (repeatable or comma-separated)")
+	disableRules := flags.StringSlice("disable-rule", nil, "Disable these rule IDs regardless of config (repeatable or comma-separated)")
+	strictConfig := flags.Bool("strict-config", false, "Reject the config, and any config it extends, on unknown keys or rule IDs and invalid severities instead of ignoring them")
+	onlyRules := flags.StringSlice("only-rule", nil, "Run only these rule IDs, disabling every other rule (repeatable or comma-separated)")
+	timeout := flags.Duration("timeout", 0, "Abort the run after this long, e.g. 5m (0=no limit); exits 2")
+	renderTimeout := flags.Duration("render-timeout", 0, "Bound the Helm/Kustomize render stage, e.g. 2m (0=only --timeout applies)")
+	renderParallel := flags.Int("render-parallel", 0, "Maximum number of sources to render concurrently (0=one per lint worker)")
+	renderSourceTimeout := flags.Duration("render-source-timeout", 0, "Report a source whose render runs longer than this as a render failure, e.g. 30s (0=no limit)")
+	kubeVersion := flags.String("kube-version", "", "Kubernetes version Helm charts render against, e.g. 1.29 (overrides config render.kubeVersion)")
+	apiVersions := flags.StringSlice("api-versions", nil, "Extra API versions Helm charts see in .Capabilities.APIVersions, e.g. monitoring.coreos.com/v1 (repeatable; overrides config render.apiVersions)")
+	cmpCommands := flags.StringArray("cmp-command", nil, "Emulate a Config Management Plugin when rendering as name=shell command, run in the source directory; name * handles plugins without a command (repeatable; overrides config render.cmpCommands)")
+	allowConfigCMP := flags.Bool("allow-config-cmp-commands", false, "Run the shell commands of config render.cmpCommands when rendering (off by default since config may extend a remote file)")
+	renderMaxOutput := flags.Int64("render-max-output-bytes", 0, "Report a source whose rendered output is larger than this many bytes as a render failure (0=no limit)")
+	renderSnapshots := flags.String("render-snapshots", "", "Compare rendered output with the snapshots in this directory and report drift as RENDER_SNAPSHOT (implies --render; record them with argocd-lint render --write-snapshots)")
+	dryRunTimeout := flags.Duration("dryrun-timeout", 0, "Bound the dry-run stage, e.g. 1m (0=only --timeout applies)")
+	logLevel := flags.String("log-level", "", "Log level for diagnostics on stderr: debug|info|warn|error (default warn)")
+	logFormat := flags.String("log-format", logging.FormatText, "Log format: text|json")
+	againstCluster := flags.Bool("against-cluster", false, "Compare Applications with their live objects and report out-of-band changes to project, destination, targetRevision, and syncPolicy")
+
+	// completion is dispatched here, once the lint flags exist, so the
+	// generated scripts always match the flags Execute accepts.
+	if len(args) > 0 && args[0] == "completion" {
+		return runCompletionCommand(args[1:], flags, stdout, stderr)
+	}
 
 	if err := flags.Parse(args); err != nil {
 		printError(stderr, "argument", err)
@@ -72,25 +168,73 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 		fmt.Fprintln(stdout, version.String())
 		return 0
 	}
+	if *dryRunRendered && *dryRunMode == "" {
+		printError(stderr, "argument", errors.New("--dry-run-rendered requires --dry-run kubeconform or --dry-run server"))
+		return 2
+	}
+	logger, err := logging.New(stderr, *logLevel, *logFormat)
+	if err != nil {
+		printError(stderr, "log", err)
+		return 2
+	}
+	sinks, err := output.ParseSinks(resolveAutoFormats(*formats), strings.TrimSpace(*outputPath))
+	if err != nil {
+		printError(stderr, "format", err)
+		return 2
+	}
+	var minimumSeverity types.Severity
+	if strings.TrimSpace(*minSeverity) != "" {
+		minimumSeverity, err = config.ParseSeverity(*minSeverity)
+		if err != nil {
+			printError(stderr, "min-severity", err)
+			return 2
+		}
+	}
 
 	remaining := flags.Args()
 	if len(remaining) == 0 {
-		fmt.Fprintln(stderr, "Usage: argocd-lint <path> [flags]")
+		fmt.Fprintln(stderr, "Usage: argocd-lint <path|glob|->... [flags]")
 		return 2
 	}
-	target := remaining[0]
-	absTarget, err := ResolvePath(target)
-	if err != nil {
-		printError(stderr, "target", err)
-		return 2
+	var stdinManifests []*manifest.Manifest
+	paths := make([]string, 0, len(remaining))
+	for _, arg := range remaining {
+		if arg != "-" {
+			paths = append(paths, arg)
+			continue
+		}
+		if stdinManifests != nil {
+			continue
+		}
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			printError(stderr, "stdin", err)
+			return 2
+		}
+		stdinManifests, err = manifest.Parser{}.Parse(stdinPath, data)
+		if err != nil {
+			printError(stderr, "stdin", err)
+			return 2
+		}
+		if stdinManifests == nil {
+			stdinManifests = []*manifest.Manifest{}
+		}
+	}
+	var targets []string
+	if len(paths) > 0 {
+		targets, err = loader.ExpandTargets(paths)
+		if err != nil {
+			printError(stderr, "target", err)
+			return 2
+		}
 	}
-	info, err := os.Stat(absTarget)
+	targetDirs, err := targetDirectories(targets)
 	if err != nil {
 		printError(stderr, "target", err)
 		return 2
 	}
 
-	cfg, err := config.Load(*rulesPath)
+	cfg, err := config.LoadWithOptions(*rulesPath, config.LoadOptions{Strict: *strictConfig})
 	if err != nil {
 		printError(stderr, "config", err)
 		return 2
@@ -99,6 +243,7 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 		printError(stderr, "profile", err)
 		return 2
 	}
+	cfg.Selection = config.RuleSelection{Enable: *enableRules, Disable: *disableRules, Only: *onlyRules}
 	var baseline *lint.Baseline
 	if *baselinePath != "" {
 		baseline, err = lint.LoadBaseline(*baselinePath)
@@ -119,69 +264,150 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 		printError(stderr, "runner", err)
 		return 2
 	}
-
-	if len(*pluginFiles) > 0 || len(*pluginDirs) > 0 {
-		var resolved []string
-		for _, p := range append(*pluginFiles, *pluginDirs...) {
-			path, err := ResolvePath(p)
-			if err != nil {
-				printError(stderr, "plugin path", err)
-				return 2
-			}
-			if _, err := os.Stat(path); err != nil {
-				printError(stderr, "plugin path", err)
-				return 2
-			}
-			resolved = append(resolved, path)
+	if strings.EqualFold(*argocdVersion, schema.VersionFromCluster) {
+		ctx := context.Background()
+		if *timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *timeout)
+			defer cancel()
 		}
-		loader := regoplugin.NewLoader(resolved...)
-		plugins, err := loader.Load(context.Background())
-		if err != nil {
-			printError(stderr, "plugin load", err)
+		if err := clusterSchemas(ctx, runner, cluster.NewClient(cluster.Options{KubectlBinary: *kubectlBinary, Kubeconfig: *kubeconfig, KubeContext: *kubeContext})); err != nil {
+			printError(stderr, "schema", err)
 			return 2
 		}
-		runner.RegisterPlugins(plugins...)
+	}
+	if err := runner.AddSchemaDirs(append(cfg.CRDSchemas, *crdSchemas...)...); err != nil {
+		printError(stderr, "schema", err)
+		return 2
 	}
 
-	root := *repoRoot
-	if root != "" {
-		root, err = ResolvePath(root)
-		if err != nil {
-			printError(stderr, "repo root", err)
+	verifier, err := pluginVerifier(*pluginVerify, *pluginKey, *pluginIdentity, *pluginIssuer, len(*pluginFiles)+len(*pluginDirs)+len(*conftestPolicies)+len(*gatekeeperPolicies) > 0)
+	if err != nil {
+		printError(stderr, "plugin verify", err)
+		return 2
+	}
+	if err := registerPlugins(runner, *pluginFiles, *pluginDirs, *pluginCacheDir, verifier, logger); err != nil {
+		printError(stderr, "plugin load", err)
+		return 2
+	}
+	if err := registerCompatPolicies(runner, *conftestPolicies, *gatekeeperPolicies, verifier, logger); err != nil {
+		printError(stderr, "plugin load", err)
+		return 2
+	}
+	closeGRPCPlugins, err := registerGRPCPlugins(runner, *grpcPlugins, logger)
+	if err != nil {
+		printError(stderr, "plugin load", err)
+		return 2
+	}
+	defer closeGRPCPlugins()
+	if err := registerCustomRules(runner, cfg); err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	if err := checkRuleSelection(runner, cfg.Selection); err != nil {
+		printError(stderr, "rule selection", err)
+		return 2
+	}
+	if *strictConfig {
+		// Checked once plugins are registered, so their rule IDs are known.
+		if err := checkConfigRuleIDs(runner, cfg); err != nil {
+			printError(stderr, "config", err)
 			return 2
 		}
-	} else {
-		if info.IsDir() {
-			root = absTarget
-		} else {
-			root = filepath.Dir(absTarget)
-		}
+	}
+
+	root, exactRoot, err := renderRoot(*repoRoot, targetDirs, wd)
+	if err != nil {
+		printError(stderr, "repo root", err)
+		return 2
 	}
 
 	renderOpts := render.Options{
-		Enabled:         *renderEnabled,
-		HelmBinary:      *helmBinary,
-		KustomizeBinary: *kustomizeBinary,
-		RepoRoot:        root,
-		CacheEnabled:    *renderCache,
+		Enabled:                 *renderEnabled || *renderSnapshots != "" || *dryRunRendered,
+		KustomizeBinary:         *kustomizeBinary,
+		KustomizeDenyRemote:     !*kustomizeRemote,
+		KustomizeLoadRestrictor: *kustomizeRestrictor,
+		RepoRoot:                root,
+		CacheEnabled:            *renderCache,
+		Timeout:                 *renderTimeout,
+		Parallel:                *renderParallel,
+		SourceTimeout:           *renderSourceTimeout,
+		MaxOutputBytes:          *renderMaxOutput,
+		KubeVersion:             cfg.Render.KubeVersion,
+		APIVersions:             cfg.Render.APIVersions,
+		SnapshotDir:             *renderSnapshots,
+		KeepResources:           *dryRunRendered,
+	}
+	if *kubeVersion != "" {
+		renderOpts.KubeVersion = *kubeVersion
+	}
+	if len(*apiVersions) > 0 {
+		renderOpts.APIVersions = *apiVersions
+	}
+	renderOpts.CMPCommands, err = pluginCommands(cfg.Render.CMPCommands, *cmpCommands, *allowConfigCMP)
+	if err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	kubeconformOpts := kubeconform.Options{
+		SchemaLocations:      *kubeconformSchemas,
+		KubernetesVersion:    renderOpts.KubeVersion,
+		IgnoreMissingSchemas: *kubeconformIgnoreMissing,
+	}
+	// With --dry-run-rendered the dry-run stage validates rendered output
+	// itself, so the render stage does not repeat it.
+	if strings.EqualFold(*dryRunMode, "kubeconform") && !*dryRunRendered {
+		renderOpts.Kubeconform = &kubeconformOpts
 	}
 
 	dryRunOpts := dryrun.Options{
-		Enabled:           *dryRunMode != "",
-		Mode:              *dryRunMode,
-		KubectlBinary:     *kubectlBinary,
-		KubeconformBinary: *kubeconformBinary,
-		Kubeconfig:        *kubeconfig,
-		KubeContext:       *kubeContext,
+		Enabled:     *dryRunMode != "",
+		Mode:        *dryRunMode,
+		Kubeconfig:  *kubeconfig,
+		KubeContext: *kubeContext,
+		QPS:         *kubeQPS,
+		Burst:       *kubeBurst,
+		Kubeconform: kubeconformOpts,
+		Timeout:     *dryRunTimeout,
 	}
 
-	threshold := cfg.Threshold
 	if *severityThreshold != "" {
-		threshold = *severityThreshold
+		cfg.OverrideThreshold(*severityThreshold)
+	}
+	threshold := cfg.Threshold
+	thresholdValue := threshold
+	if thresholdValue == "" {
+		thresholdValue = string(types.SeverityError)
+	}
+	thresholdSeverity, err := config.ParseSeverity(thresholdValue)
+	if err != nil {
+		printError(stderr, "threshold", err)
+		return 2
+	}
+	exitPolicy := cfg.ExitPolicy
+	if *failOn != "" {
+		exitPolicy.FailOn = *failOn
+	}
+	if exitPolicy.FailOn, err = config.ParseFailOn(exitPolicy.FailOn); err != nil {
+		printError(stderr, "fail-on", err)
+		return 2
+	}
+	if exitPolicy.FailOn == config.FailOnNew && baseline == nil {
+		printError(stderr, "fail-on", fmt.Errorf("--fail-on new requires --baseline"))
+		return 2
+	}
+
+	ignore, err := loader.LoadIgnore(root, *excludes)
+	if err != nil {
+		printError(stderr, "ignore", err)
+		return 2
 	}
 
 	opts := lint.Options{
-		Target:                 target,
+		Targets:                targets,
+		Manifests:              stdinManifests,
+		Ignore:                 ignore,
+		Logger:                 logger,
 		IncludeApplications:    *includeApps,
 		IncludeApplicationSets: *includeAppSets,
 		IncludeProjects:        *includeProjects,
@@ -193,17 +419,48 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 		MaxParallel:            *maxParallel,
 		Baseline:               baseline,
 		BaselineAgingDays:      *baselineAging,
+		Outdated:               outdated.Options{Enabled: *checkOutdated || *outdatedReport != ""},
+		Drift: drift.Options{
+			Enabled: *againstCluster,
+			Cluster: cluster.Options{KubectlBinary: *kubectlBinary, Kubeconfig: *kubeconfig, KubeContext: *kubeContext},
+		},
+	}
+	if exactRoot {
+		opts.RepoRoot = root
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if *changedSince != "" {
+		changed, err := gitutil.ChangedFiles(ctx, root, *changedSince)
+		if err != nil {
+			printError(stderr, "changed", err)
+			return 2
+		}
+		opts.ChangedFiles = changed
 	}
 
 	start := time.Now()
-	report, err := runner.Run(opts)
+	report, err := runner.RunContext(ctx, opts)
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			err = fmt.Errorf("run timed out after %s: %w", *timeout, err)
+		}
 		printError(stderr, "lint", err)
 		return 2
 	}
 	duration := time.Since(start)
 
-	if err := output.Write(report, *format, stdout); err != nil {
+	writeOpts := output.TerminalOptions(stdout, *noColor)
+	writeOpts.GroupBy = strings.ToLower(strings.TrimSpace(*groupBy))
+	writeOpts.MinSeverity = minimumSeverity
+	writeOpts.MaxFindings = *maxFindings
+	if err := output.WriteSinks(report, sinks, stdout, writeOpts); err != nil {
 		printError(stderr, "output", err)
 		return 2
 	}
@@ -213,6 +470,24 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 			return 2
 		}
 	}
+	if strings.TrimSpace(*metricsPush) != "" {
+		labels, err := pushLabels(ctx, root, append(append([]string(nil), cfg.Profiles...), *profiles...), *metricsLabels)
+		if err != nil {
+			printError(stderr, "metrics", err)
+			return 2
+		}
+		pushOpts := output.PushOptions{URL: *metricsPush, Job: *metricsJob, Labels: labels}
+		if err := output.PushMetrics(ctx, report, duration, pushOpts); err != nil {
+			printError(stderr, "metrics", err)
+			return 2
+		}
+	}
+	if *outdatedReport != "" {
+		if err := output.WriteOutdatedReport(*outdatedReport, report.Outdated); err != nil {
+			printError(stderr, "outdated", err)
+			return 2
+		}
+	}
 	if *writeBaseline != "" {
 		if err := lint.WriteBaseline(*writeBaseline, report.Suppressed); err != nil {
 			printError(stderr, "baseline", err)
@@ -220,22 +495,286 @@ func Execute(args []string, stdout, stderr io.Writer) int {
 		}
 	}
 
-	thresholdValue := opts.SeverityThreshold
-	if thresholdValue == "" {
-		thresholdValue = string(types.SeverityError)
+	cfg.ExitPolicy = exitPolicy
+	if len(lint.FailingFindings(report, thresholdSeverity, cfg)) > 0 {
+		return 1
 	}
-	thresholdSeverity, err := config.ParseSeverity(thresholdValue)
+
+	return 0
+}
+
+// registerGRPCPlugins starts the given gRPC plugin binaries and registers
+// their rules with the runner. The returned func stops the plugin processes.
+func registerGRPCPlugins(runner *lint.Runner, paths []string, logger *slog.Logger) (func(), error) {
+	if len(paths) == 0 {
+		return func() {}, nil
+	}
+	resolved := make([]string, 0, len(paths))
+	for _, p := range paths {
+		path, err := ResolvePath(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, path)
+	}
+	started := time.Now()
+	loader := grpcplugin.NewLoader(resolved...)
+	plugins, err := loader.Load(context.Background())
 	if err != nil {
-		printError(stderr, "threshold", err)
-		return 2
+		loader.Close()
+		return nil, err
+	}
+	for _, plug := range plugins {
+		logger.Debug("loaded plugin", "rule", plug.Metadata().ID, "protocol", "grpc")
 	}
+	logger.Debug("stage finished", "stage", "grpc-plugins", "paths", resolved, "count", len(plugins), "duration", time.Since(started))
+	runner.RegisterPlugins(plugins...)
+	return loader.Close, nil
+}
 
-	highest := output.HighestSeverity(report.Findings)
-	if types.SeverityOrder[highest] >= types.SeverityOrder[thresholdSeverity] && len(report.Findings) > 0 {
-		return 1
+// registerCustomRules registers the CEL customRules from the config file,
+// rejecting IDs that clash with built-in or plugin rules.
+func registerCustomRules(runner *lint.Runner, cfg config.Config) error {
+	if len(cfg.CustomRules) == 0 {
+		return nil
+	}
+	rules, err := customplugin.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	catalog, err := runner.Catalog()
+	if err != nil {
+		return err
+	}
+	for _, meta := range catalog {
+		for _, custom := range rules {
+			if custom.Metadata().ID == meta.ID {
+				return fmt.Errorf("custom rule %s clashes with an existing rule ID", meta.ID)
+			}
+		}
 	}
+	runner.RegisterPlugins(rules...)
+	return nil
+}
 
-	return 0
+// checkRuleSelection rejects --enable-rule, --disable-rule, and --only-rule
+// IDs that match no rule in the runner's catalog, including plugins.
+func checkRuleSelection(runner *lint.Runner, selection config.RuleSelection) error {
+	ids := selection.IDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	catalog, err := runner.Catalog()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, meta := range catalog {
+		known[strings.ToUpper(meta.ID)] = true
+	}
+	for _, id := range ids {
+		if !known[strings.ToUpper(strings.TrimSpace(id))] {
+			return fmt.Errorf("unknown rule %q (see `argocd-lint rules list`)", id)
+		}
+	}
+	return nil
+}
+
+// pluginVerifier checks the plugin signature flags and, when verification is
+// enabled and needed, builds the verifier: key-based with --plugin-key,
+// keyless otherwise.
+func pluginVerifier(enabled bool, key, identity, issuer string, needed bool) (*regoplugin.Verifier, error) {
+	keyless := identity != "" || issuer != ""
+	switch {
+	case !enabled && (key != "" || keyless):
+		return nil, fmt.Errorf("--plugin-key and the --plugin-certificate flags require --plugin-verify")
+	case !enabled:
+		return nil, nil
+	case key != "" && keyless:
+		return nil, fmt.Errorf("use either --plugin-key or the keyless --plugin-certificate flags, not both")
+	case key == "" && (identity == "" || issuer == ""):
+		return nil, fmt.Errorf("--plugin-verify needs --plugin-key, or --plugin-certificate-identity and --plugin-certificate-oidc-issuer")
+	case !needed:
+		return nil, nil
+	case key != "":
+		path, err := ResolvePath(key)
+		if err != nil {
+			return nil, err
+		}
+		return regoplugin.NewKeyVerifier(path)
+	default:
+		return regoplugin.NewKeylessVerifier(identity, issuer)
+	}
+}
+
+// registerPlugins loads Rego modules from the given files, directories, and
+// oci:// or https:// bundle references into the runner, logging each loaded
+// rule at debug level. A non-empty cacheDir holds fetched bundles and the
+// module cache. With a verifier, unsigned or modified modules are refused.
+func registerPlugins(runner *lint.Runner, files, dirs []string, cacheDir string, verifier *regoplugin.Verifier, logger *slog.Logger) error {
+	if len(files) == 0 && len(dirs) == 0 {
+		return nil
+	}
+	resolved, err := resolvePluginPaths(append(append([]string(nil), files...), dirs...))
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	loader := regoplugin.NewLoader(resolved...).WithRemoteOptions(regoplugin.RemoteOptions{CacheDir: cacheDir, Verifier: verifier})
+	if cacheDir != "" {
+		loader.WithModuleCache(cacheDir)
+	}
+	plugins, err := loader.Load(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, plug := range plugins {
+		logger.Debug("loaded plugin", "rule", plug.Metadata().ID)
+	}
+	logger.Debug("stage finished", "stage", "plugins", "paths", resolved, "count", len(plugins), "duration", time.Since(started))
+	runner.RegisterPlugins(plugins...)
+	return nil
+}
+
+// registerCompatPolicies loads conftest policies and Gatekeeper
+// ConstraintTemplates with their constraints into the runner. With a
+// verifier, unsigned or modified policy files are refused.
+func registerCompatPolicies(runner *lint.Runner, conftest, gatekeeper []string, verifier *regoplugin.Verifier, logger *slog.Logger) error {
+	started := time.Now()
+	var plugins []plugin.RulePlugin
+	if len(conftest) > 0 {
+		resolved, err := resolvePluginPaths(conftest)
+		if err != nil {
+			return err
+		}
+		loaded, err := regoplugin.NewLoader(resolved...).WithRemoteOptions(regoplugin.RemoteOptions{Verifier: verifier}).LoadConftest(context.Background())
+		if err != nil {
+			return err
+		}
+		plugins = append(plugins, loaded...)
+	}
+	if len(gatekeeper) > 0 {
+		resolved, err := resolvePluginPaths(gatekeeper)
+		if err != nil {
+			return err
+		}
+		loaded, err := regoplugin.LoadGatekeeper(context.Background(), verifier, resolved...)
+		if err != nil {
+			return err
+		}
+		plugins = append(plugins, loaded...)
+	}
+	if len(plugins) == 0 {
+		return nil
+	}
+	for _, plug := range plugins {
+		logger.Debug("loaded plugin", "rule", plug.Metadata().ID)
+	}
+	logger.Debug("stage finished", "stage", "compat-policies", "count", len(plugins), "duration", time.Since(started))
+	runner.RegisterPlugins(plugins...)
+	return nil
+}
+
+// resolvePluginPaths makes local plugin paths absolute and checks that they
+// exist; oci:// and https:// references are kept for the loader to fetch.
+func resolvePluginPaths(paths []string) ([]string, error) {
+	var resolved []string
+	for _, p := range paths {
+		if regoplugin.IsOCIReference(p) || regoplugin.IsHTTPSReference(p) {
+			resolved = append(resolved, p)
+			continue
+		}
+		path, err := ResolvePath(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved, nil
+}
+
+// pluginCommands merges --cmp-command name=command entries over the
+// render.cmpCommands of the config. Config commands are shell commands from a
+// file that may extend a remote config, so they are only used when
+// allowConfig is set.
+func pluginCommands(configured map[string]string, entries []string, allowConfig bool) (map[string]string, error) {
+	commands := make(map[string]string, len(configured)+len(entries))
+	if allowConfig {
+		for name, command := range configured {
+			commands[name] = command
+		}
+	}
+	for _, entry := range entries {
+		name, command, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(command) == "" {
+			return nil, fmt.Errorf("--cmp-command %q: expected name=command", entry)
+		}
+		commands[strings.TrimSpace(name)] = command
+	}
+	return commands, nil
+}
+
+// targetDirectories returns the absolute directory of each target, or the
+// target itself when it is a directory.
+func targetDirectories(targets []string) ([]string, error) {
+	dirs := make([]string, 0, len(targets))
+	for _, target := range targets {
+		absTarget, err := ResolvePath(target)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(absTarget)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, absTarget)
+		} else {
+			dirs = append(dirs, filepath.Dir(absTarget))
+		}
+	}
+	return dirs, nil
+}
+
+// renderRoot resolves the repository root that rendered source paths are
+// relative to: repoRoot when set, else the git checkout holding the targets,
+// else their common directory. exact reports whether the result is a known
+// repository root rather than a guess.
+func renderRoot(repoRoot string, targetDirs []string, wd string) (root string, exact bool, err error) {
+	if repoRoot != "" {
+		root, err = ResolvePath(repoRoot)
+		return root, err == nil, err
+	}
+	dir := wd
+	if len(targetDirs) > 0 {
+		dir = commonDir(targetDirs)
+	}
+	if top, err := gitutil.TopLevel(context.Background(), dir); err == nil && top != "" {
+		return filepath.Clean(top), true, nil
+	}
+	return dir, false, nil
+}
+
+// commonDir returns the deepest directory containing every absolute dir.
+func commonDir(dirs []string) string {
+	common := filepath.Clean(dirs[0])
+	for _, dir := range dirs[1:] {
+		dir = filepath.Clean(dir)
+		for common != dir && !strings.HasPrefix(dir, common+string(filepath.Separator)) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
 }
 
 // ResolvePath ensures the target is absolute relative to working dir.
@@ -250,6 +789,60 @@ func ResolvePath(target string) (string, error) {
 	return filepath.Join(wd, target), nil
 }
 
+// pushLabels builds the Pushgateway grouping labels. repo and branch are taken
+// from the git checkout at root (falling back to common CI variables), profile
+// lists the active rule profiles once each, and explicit key=value pairs win.
+func pushLabels(ctx context.Context, root string, profiles, extra []string) (map[string]string, error) {
+	labels := map[string]string{}
+	if repo, err := gitutil.RemoteURL(ctx, root); err == nil && repo != "" {
+		labels["repo"] = stripUserinfo(repo)
+	} else {
+		labels["repo"] = firstEnv("GITHUB_REPOSITORY", "CI_PROJECT_PATH", "BUILD_REPOSITORY_NAME")
+	}
+	if branch, err := gitutil.CurrentBranch(ctx, root); err == nil && branch != "" && branch != "HEAD" {
+		labels["branch"] = branch
+	} else {
+		labels["branch"] = firstEnv("GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "BUILD_SOURCEBRANCHNAME")
+	}
+	var active []string
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" && !seen[p] {
+			seen[p] = true
+			active = append(active, p)
+		}
+	}
+	labels["profile"] = strings.Join(active, ",")
+	for _, pair := range extra {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid metrics label %q (expected key=value)", pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// stripUserinfo drops credentials, such as a CI token, embedded in a remote
+// URL before it is sent anywhere.
+func stripUserinfo(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.User == nil {
+		return repo
+	}
+	u.User = nil
+	return u.String()
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 type pluginRow struct {
 	Bundle      string   `json:"bundle"`
 	Rule        string   `json:"rule"`
@@ -265,7 +858,13 @@ func runPluginsCommand(args []string, stdout, stderr io.Writer) int {
 	if len(args) == 0 || args[0] == "list" {
 		return runPluginsList(args, stdout, stderr)
 	}
-	fmt.Fprintln(stderr, "Usage: argocd-lint plugins list [flags]")
+	if args[0] == "test" {
+		return runPluginsTest(args[1:], stdout, stderr)
+	}
+	if args[0] == "lint" {
+		return runPluginsLint(args[1:], stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "Usage: argocd-lint plugins list|test|lint [flags]")
 	return 2
 }
 
@@ -451,6 +1050,13 @@ func runApplicationSetPlan(args []string, stdout, stderr io.Writer) int {
 	flags.SetOutput(stderr)
 	file := flags.String("file", "", "Path to ApplicationSet manifest")
 	current := flags.String("current", "", "Directory or file with existing Application manifests")
+	repoRoot := flags.String("repo-root", "", "Local checkout read by git generators whose repoURL it matches")
+	clone := flags.Bool("clone", false, "Shallow-clone the repoURL of git generators that --repo-root does not check out")
+	generatorData := flags.String("generator-data", "", "JSON or YAML file with the pull requests and repositories for pullRequest and scmProvider generators")
+	online := flags.Bool("online", false, "Query the GitHub or GitLab API for pullRequest and scmProvider generators without --generator-data")
+	githubToken := flags.String("github-token", "", "GitHub token for --online (default: $GITHUB_TOKEN)")
+	gitlabToken := flags.String("gitlab-token", "", "GitLab token for --online (default: $GITLAB_TOKEN)")
+	tokenHosts := flags.StringSlice("token-host", nil, "Self-hosted API host (e.g. github.example.com) that may receive the tokens; by default they are only sent to api.github.com and gitlab.com")
 	format := flags.String("format", "table", "Output format: table|json")
 	if err := flags.Parse(args); err != nil {
 		printError(stderr, "argument", err)
@@ -460,7 +1066,22 @@ func runApplicationSetPlan(args []string, stdout, stderr io.Writer) int {
 		fmt.Fprintln(stderr, "--file is required")
 		return 2
 	}
-	plan, err := appsetplan.Generate(appsetplan.Options{AppSetPath: *file, CurrentDir: *current})
+	tokens := map[string]string{"github": *githubToken, "gitlab": *gitlabToken}
+	for provider, env := range map[string]string{"github": "GITHUB_TOKEN", "gitlab": "GITLAB_TOKEN"} {
+		if tokens[provider] == "" {
+			tokens[provider] = os.Getenv(env)
+		}
+	}
+	plan, err := appsetplan.Generate(appsetplan.Options{
+		AppSetPath:    *file,
+		CurrentDir:    *current,
+		RepoRoot:      *repoRoot,
+		Clone:         *clone,
+		GeneratorData: *generatorData,
+		Online:        *online,
+		Tokens:        tokens,
+		TokenHosts:    *tokenHosts,
+	})
 	if err != nil {
 		printError(stderr, "plan", err)
 		return 2
@@ -576,6 +1197,46 @@ func formatSource(src appsetplan.SourcePreview) string {
 	return strings.Join(parts, " | ")
 }
 
+// resolveAutoFormats replaces the auto format, alone or as auto=path, with
+// github inside GitHub Actions and table elsewhere.
+func resolveAutoFormats(formats []string) []string {
+	auto := output.FormatTable
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		auto = output.FormatGitHub
+	}
+	resolved := make([]string, 0, len(formats))
+	for _, format := range formats {
+		name, path, hasPath := strings.Cut(format, "=")
+		if strings.EqualFold(strings.TrimSpace(name), "auto") {
+			format = auto
+			if hasPath {
+				format += "=" + path
+			}
+		}
+		resolved = append(resolved, format)
+	}
+	return resolved
+}
+
 func printError(w io.Writer, stage string, err error) {
 	fmt.Fprintf(w, "[ERROR] %-12s %v\n", strings.ToUpper(stage), err)
 }
+
+// crdSource reads CRDs from a cluster; cluster.Client and cluster.KubeClient
+// implement it.
+type crdSource interface {
+	CustomResourceDefinitions(ctx context.Context, names ...string) ([]map[string]interface{}, error)
+}
+
+// clusterSchemas loads the schemas of the Argo CD CRDs installed in the
+// cluster into runner, for --argocd-version from-cluster.
+func clusterSchemas(ctx context.Context, runner *lint.Runner, source crdSource) error {
+	crds, err := source.CustomResourceDefinitions(ctx, cluster.Resources...)
+	if err != nil {
+		return fmt.Errorf("read Argo CD CRDs: %w", err)
+	}
+	if len(crds) == 0 {
+		return errors.New("no Argo CD CRDs are installed in the cluster")
+	}
+	return runner.AddCRDs(crds...)
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/controller"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/argocd-lint/argocd-lint/internal/schema"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"github.com/spf13/pflag"
+)
+
+// runClusterCommand lints the live Applications, ApplicationSets, and
+// AppProjects read through kubectl or the Argo CD API server, exiting 1
+// when findings reach the severity threshold.
+func runClusterCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("cluster", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	profiles := flags.StringSlice("profile", nil, "Apply rule profiles: built-in (dev, prod, security, hardening) or definedProfiles from the config")
+	argocdVersion := flags.String("argocd-version", "", "Pin schema validation to a specific Argo CD version ("+strings.Join(schema.EmbeddedVersions(), ", ")+"; other releases via schema fetch and --crd-schemas), or from-cluster to validate against the installed Argo CD CRDs")
+	severityThreshold := flags.String("severity-threshold", "", "Exit with non-zero status at or above this severity (info|warn|error); overrides config")
+	format := flags.String("format", output.FormatTable, "Output format: table|json|sarif|github|teamcity|html")
+	namespace := flags.String("namespace", "", "Namespace to audit (default: all namespaces)")
+	kubeconfig := flags.String("kubeconfig", "", "Path to kubeconfig (default: $KUBECONFIG or ~/.kube/config)")
+	kubeContext := flags.String("kube-context", "", "Kubernetes context to use")
+	kubectlBinary := flags.String("kubectl-binary", "kubectl", "kubectl binary used to reach the API server")
+	argocdServer := flags.String("argocd-server", "", "Read resources from this Argo CD API server instead of the Kubernetes API")
+	authToken := flags.String("auth-token", "", "Argo CD API token for --argocd-server (default: $ARGOCD_AUTH_TOKEN)")
+	insecure := flags.Bool("insecure", false, "Skip TLS certificate verification of --argocd-server")
+	pluginFiles := flags.StringSlice("plugin", nil, "Load Rego plugin module (repeatable)")
+	pluginDirs := flags.StringSlice("plugin-dir", nil, "Load all Rego plugin modules from directory (repeatable)")
+	strictConfig := flags.Bool("strict-config", false, "Reject the config, and any config it extends, on unknown keys or rule IDs and invalid severities instead of ignoring them")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(stderr, "Usage: argocd-lint cluster [--kubeconfig <path>] [--namespace <ns>] | [--argocd-server <host> --auth-token <token>] [flags]")
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(*rulesPath, config.LoadOptions{Strict: *strictConfig})
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	if err := cfg.ApplyProfiles(*profiles...); err != nil {
+		printError(stderr, "profile", err)
+		return 2
+	}
+	if *severityThreshold != "" {
+		cfg.OverrideThreshold(*severityThreshold)
+	}
+	threshold := cfg.Threshold
+	if threshold == "" {
+		threshold = string(types.SeverityError)
+	}
+	thresholdSeverity, err := config.ParseSeverity(threshold)
+	if err != nil {
+		printError(stderr, "threshold", err)
+		return 2
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	runner, err := lint.NewRunner(cfg, wd, *argocdVersion)
+	if err != nil {
+		printError(stderr, "runner", err)
+		return 2
+	}
+	if err := registerPlugins(runner, *pluginFiles, *pluginDirs, "", nil, logging.Discard()); err != nil {
+		printError(stderr, "plugin load", err)
+		return 2
+	}
+	if *strictConfig {
+		if err := checkConfigRuleIDs(runner, cfg); err != nil {
+			printError(stderr, "config", err)
+			return 2
+		}
+	}
+
+	ctx := context.Background()
+	clusterOpts := cluster.Options{KubectlBinary: *kubectlBinary, Kubeconfig: *kubeconfig, KubeContext: *kubeContext}
+	if strings.EqualFold(*argocdVersion, schema.VersionFromCluster) {
+		if *argocdServer != "" {
+			printError(stderr, "schema", fmt.Errorf("--argocd-version %s reads CRDs through kubectl and cannot be used with --argocd-server", schema.VersionFromCluster))
+			return 2
+		}
+		if err := clusterSchemas(ctx, runner, cluster.NewClient(clusterOpts)); err != nil {
+			printError(stderr, "schema", err)
+			return 2
+		}
+	}
+	if err := runner.AddSchemaDirs(cfg.CRDSchemas...); err != nil {
+		printError(stderr, "schema", err)
+		return 2
+	}
+
+	var source controller.Source = cluster.NewClient(clusterOpts)
+	if *argocdServer != "" {
+		if *authToken == "" {
+			*authToken = os.Getenv("ARGOCD_AUTH_TOKEN")
+		}
+		api, err := cluster.NewAPIClient(cluster.APIOptions{Server: *argocdServer, AuthToken: *authToken, Insecure: *insecure})
+		if err != nil {
+			printError(stderr, "argument", err)
+			return 2
+		}
+		source = api
+	}
+	live, err := source.List(ctx, *namespace)
+	if err != nil {
+		printError(stderr, "cluster", err)
+		return 2
+	}
+	report, err := runner.RunContext(ctx, lint.Options{
+		Manifests:              live,
+		IncludeApplications:    true,
+		IncludeApplicationSets: true,
+		IncludeProjects:        true,
+		Config:                 cfg,
+		WorkingDir:             wd,
+		SeverityThreshold:      threshold,
+	})
+	if err != nil {
+		printError(stderr, "lint", err)
+		return 2
+	}
+	if err := output.Write(report, *format, stdout); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	if len(lint.FailingFindings(report, thresholdSeverity, cfg)) > 0 {
+		return 1
+	}
+	return 0
+}
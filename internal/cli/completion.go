@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/spf13/pflag"
+)
+
+// subcommandWords lists the subcommands and the words each one accepts next.
+var subcommandWords = map[string][]string{
+	"applicationset": {"plan"},
+	"cluster":        nil,
+	"completion":     {"bash", "zsh", "fish"},
+	"config":         {"validate", "schema"},
+	"controller":     nil,
+	"diff":           nil,
+	"diff-report":    nil,
+	"init":           nil,
+	"plugins":        {"list", "test", "lint"},
+	"render":         nil,
+	"rules":          {"list", "explain"},
+	"schema":         {"fetch"},
+	"waivers":        {"report"},
+}
+
+// completionValues returns the fixed values offered for enumerated flags.
+func completionValues() map[string][]string {
+	severities := []string{"info", "warn", "error"}
+	return map[string][]string{
+		"format":                    {output.FormatTable, output.FormatJSON, output.FormatSARIF, output.FormatGitHub, output.FormatTeamCity, output.FormatHTML, "auto"},
+		"profile":                   config.AvailableProfiles(),
+		"group-by":                  {output.GroupByRule, output.GroupByFile, output.GroupByResource},
+		"fail-on":                   {config.FailOnThreshold, config.FailOnNew, config.FailOnNone},
+		"min-severity":              severities,
+		"severity-threshold":        severities,
+		"dry-run":                   {"kubeconform", "server"},
+		"kustomize-load-restrictor": {"LoadRestrictionsRootOnly", "LoadRestrictionsNone"},
+		"metrics":                   {output.FormatTable, output.FormatJSON},
+		"log-level":                 {"debug", "info", "warn", "error"},
+		"log-format":                {logging.FormatText, logging.FormatJSON},
+	}
+}
+
+// runCompletionCommand prints a completion script for the lint flags, the
+// subcommands, enumerated flag values, and rule IDs for `rules explain`.
+func runCompletionCommand(args []string, flags *pflag.FlagSet, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: argocd-lint completion bash|zsh|fish")
+		return 2
+	}
+	ruleIDs, err := completionRuleIDs()
+	if err != nil {
+		printError(stderr, "rules", err)
+		return 2
+	}
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion(flags, ruleIDs)
+	case "zsh":
+		script = "#compdef argocd-lint\nautoload -U +X bashcompinit && bashcompinit\n" + bashCompletion(flags, ruleIDs)
+	case "fish":
+		script = fishCompletion(flags, ruleIDs)
+	default:
+		printError(stderr, "completion", fmt.Errorf("unsupported shell %q (expected bash, zsh, or fish)", args[0]))
+		return 2
+	}
+	if _, err := io.WriteString(stdout, script); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	return 0
+}
+
+func completionRuleIDs() ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	runner, err := lint.NewRunner(config.Config{}, wd, "")
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := runner.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(catalog))
+	for _, meta := range catalog {
+		ids = append(ids, meta.ID)
+	}
+	return ids, nil
+}
+
+func sortedSubcommands() []string {
+	names := make([]string, 0, len(subcommandWords))
+	for name := range subcommandWords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bashCompletion(flags *pflag.FlagSet, ruleIDs []string) string {
+	var flagNames []string
+	flags.VisitAll(func(f *pflag.Flag) {
+		flagNames = append(flagNames, "--"+f.Name)
+	})
+	var b strings.Builder
+	b.WriteString("# bash completion for argocd-lint\n")
+	b.WriteString("_argocd_lint() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	values := completionValues()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "        --%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, strings.Join(values[name], " "))
+	}
+	fmt.Fprintf(&b, "        explain) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", strings.Join(ruleIDs, " "))
+	for _, name := range sortedSubcommands() {
+		if words := subcommandWords[name]; len(words) > 0 {
+			fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, strings.Join(words, " "))
+		}
+	}
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    if [[ \"$cur\" == -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n", strings.Join(flagNames, " "))
+	fmt.Fprintf(&b, "    if [[ $COMP_CWORD -eq 1 ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n    fi\n", strings.Join(sortedSubcommands(), " "))
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _argocd_lint argocd-lint\n")
+	return b.String()
+}
+
+func fishCompletion(flags *pflag.FlagSet, ruleIDs []string) string {
+	values := completionValues()
+	var b strings.Builder
+	b.WriteString("# fish completion for argocd-lint\n")
+	for _, name := range sortedSubcommands() {
+		fmt.Fprintf(&b, "complete -c argocd-lint -n __fish_use_subcommand -a %s\n", name)
+		if words := subcommandWords[name]; len(words) > 0 {
+			fmt.Fprintf(&b, "complete -c argocd-lint -n '__fish_seen_subcommand_from %s' -f -a %s\n", name, fishQuote(strings.Join(words, " ")))
+		}
+	}
+	fmt.Fprintf(&b, "complete -c argocd-lint -n '__fish_seen_subcommand_from explain' -f -a %s\n", fishQuote(strings.Join(ruleIDs, " ")))
+	flags.VisitAll(func(f *pflag.Flag) {
+		line := fmt.Sprintf("complete -c argocd-lint -l %s -d %s", f.Name, fishQuote(f.Usage))
+		if options, ok := values[f.Name]; ok {
+			line += " -x -a " + fishQuote(strings.Join(options, " "))
+		} else if f.Value.Type() != "bool" {
+			line += " -r"
+		}
+		b.WriteString(line + "\n")
+	})
+	return b.String()
+}
+
+func fishQuote(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", `\'`) + "'"
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return runConfigValidate(args[1:], stdout, stderr)
+		case "schema":
+			return runConfigSchema(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintln(stderr, "Usage: argocd-lint config validate [path] [flags] | config schema")
+	return 2
+}
+
+// runConfigSchema prints the JSON Schema of the config file.
+func runConfigSchema(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintln(stderr, "Usage: argocd-lint config schema")
+		return 2
+	}
+	data, err := config.JSONSchema()
+	if err != nil {
+		printError(stderr, "schema", err)
+		return 2
+	}
+	if _, err := stdout.Write(data); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	return 0
+}
+
+// runConfigValidate strictly checks a config file and exits 1 when it has
+// errors; expired waivers are reported as warnings only.
+func runConfigValidate(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("config validate", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pluginFiles := flags.StringSlice("plugin", nil, "Rego plugin module whose rule IDs are known (repeatable)")
+	pluginDirs := flags.StringSlice("plugin-dir", nil, "Directory of Rego plugin modules whose rule IDs are known (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	path := flags.Arg(0)
+	if path == "" {
+		path = config.DefaultFileName
+	}
+	data, err := config.ReadFile(path)
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	runner, err := lint.NewRunner(config.Config{}, wd, "")
+	if err != nil {
+		printError(stderr, "runner", err)
+		return 2
+	}
+	if err := registerPlugins(runner, *pluginFiles, *pluginDirs, "", nil, logging.Discard()); err != nil {
+		printError(stderr, "plugin load", err)
+		return 2
+	}
+	known, err := knownRuleIDs(runner)
+	if err != nil {
+		printError(stderr, "rules", err)
+		return 2
+	}
+
+	errorsFound := 0
+	for _, problem := range config.Validate(data, known) {
+		level := "error"
+		if problem.Warning {
+			level = "warning"
+		} else {
+			errorsFound++
+		}
+		fmt.Fprintf(stdout, "%s:%d: %s: %s\n", path, problem.Line, level, problem.Message)
+	}
+	if errorsFound > 0 {
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s: OK\n", path)
+	return 0
+}
+
+// checkConfigRuleIDs rejects rule IDs in a config loaded with
+// --strict-config that neither built-in nor registered plugin rules use.
+func checkConfigRuleIDs(runner *lint.Runner, cfg config.Config) error {
+	known, err := knownRuleIDs(runner)
+	if err != nil {
+		return err
+	}
+	return cfg.CheckRuleIDs(known)
+}
+
+// knownRuleIDs returns the IDs of the built-in and registered plugin rules.
+func knownRuleIDs(runner *lint.Runner) (map[string]bool, error) {
+	catalog, err := runner.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, meta := range catalog {
+		known[meta.ID] = true
+	}
+	return known, nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/controller"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/argocd-lint/argocd-lint/internal/schema"
+	"github.com/spf13/pflag"
+)
+
+func runControllerCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("controller", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	profiles := flags.StringSlice("profile", nil, "Apply rule profiles: built-in (dev, prod, security, hardening) or definedProfiles from the config")
+	argocdVersion := flags.String("argocd-version", "", "Pin schema validation to a specific Argo CD version ("+strings.Join(schema.EmbeddedVersions(), ", ")+"; other releases via schema fetch and --crd-schemas), or from-cluster to validate against the installed Argo CD CRDs")
+	namespace := flags.String("namespace", "", "Namespace to scan (default: all namespaces)")
+	interval := flags.Duration("interval", 5*time.Minute, "Time between scans")
+	listen := flags.String("listen", ":9090", "Address serving /metrics and /healthz (empty disables)")
+	events := flags.Bool("events", true, "Record Kubernetes Events when a resource's findings change")
+	once := flags.Bool("once", false, "Run a single scan, print the report, and exit")
+	format := flags.String("format", "table", "Output format for --once: table|json|sarif|github|teamcity")
+	kubeconfig := flags.String("kubeconfig", "", "Path to kubeconfig (default: in-cluster or $KUBECONFIG)")
+	kubeContext := flags.String("kube-context", "", "Kubernetes context to use")
+	strictConfig := flags.Bool("strict-config", false, "Reject the config, and any config it extends, on unknown keys or rule IDs and invalid severities instead of ignoring them")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(*rulesPath, config.LoadOptions{Strict: *strictConfig})
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	if err := cfg.ApplyProfiles(*profiles...); err != nil {
+		printError(stderr, "profile", err)
+		return 2
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	runner, err := lint.NewRunner(cfg, wd, *argocdVersion)
+	if err != nil {
+		printError(stderr, "runner", err)
+		return 2
+	}
+	if *strictConfig {
+		if err := checkConfigRuleIDs(runner, cfg); err != nil {
+			printError(stderr, "config", err)
+			return 2
+		}
+	}
+	client, err := cluster.NewKubeClient(cluster.Options{Kubeconfig: *kubeconfig, KubeContext: *kubeContext})
+	if err != nil {
+		printError(stderr, "cluster", err)
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if strings.EqualFold(*argocdVersion, schema.VersionFromCluster) {
+		if err := clusterSchemas(ctx, runner, client); err != nil {
+			printError(stderr, "schema", err)
+			return 2
+		}
+	}
+	if err := runner.AddSchemaDirs(cfg.CRDSchemas...); err != nil {
+		printError(stderr, "schema", err)
+		return 2
+	}
+
+	opts := controller.Options{
+		Namespace: *namespace,
+		Interval:  *interval,
+		Lint: lint.Options{
+			IncludeApplications:    true,
+			IncludeApplicationSets: true,
+			IncludeProjects:        true,
+			Config:                 cfg,
+			WorkingDir:             wd,
+			SeverityThreshold:      cfg.Threshold,
+		},
+		Log: stderr,
+	}
+	if *events && !*once {
+		opts.Events = client
+	}
+	ctrl := controller.New(runner, client, opts)
+
+	if *once {
+		report, err := ctrl.RunOnce(ctx)
+		if err != nil {
+			printError(stderr, "scan", err)
+			return 2
+		}
+		if err := output.Write(report, *format, stdout); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		return 0
+	}
+
+	if *listen != "" {
+		go func() {
+			if err := ctrl.Serve(ctx, *listen); err != nil {
+				printError(stderr, "listen", err)
+				stop()
+			}
+		}()
+		fmt.Fprintf(stderr, "serving metrics on %s\n", *listen)
+	}
+	if err := ctrl.Run(ctx); err != nil {
+		printError(stderr, "controller", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/drift"
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/spf13/pflag"
+)
+
+// runDiffCommand compares the Applications and AppProjects under the
+// targets with their live objects and exits 1 when any differ or are
+// missing from the cluster.
+func runDiffCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("diff", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	kubeconfig := flags.String("kubeconfig", "", "Path to kubeconfig")
+	kubeContext := flags.String("kube-context", "", "Kubernetes context")
+	kubectlBinary := flags.String("kubectl-binary", "kubectl", "kubectl binary used to read live objects")
+	namespace := flags.String("namespace", drift.DefaultNamespace, "Namespace of resources that do not declare one")
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	manifests, err := loadTargetManifests(paths)
+	if err != nil {
+		printError(stderr, "target", err)
+		return 2
+	}
+	client := cluster.NewClient(cluster.Options{KubectlBinary: *kubectlBinary, Kubeconfig: *kubeconfig, KubeContext: *kubeContext})
+	diffs, err := drift.Live(context.Background(), client, manifests, *namespace)
+	if err != nil {
+		printError(stderr, "cluster", err)
+		return 2
+	}
+	switch strings.ToLower(*format) {
+	case "", "table":
+		writeLiveDiffTable(diffs, stdout)
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if diffs == nil {
+			diffs = []drift.ResourceDiff{}
+		}
+		if err := enc.Encode(diffs); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+	for _, diff := range diffs {
+		if diff.Changed() {
+			return 1
+		}
+	}
+	return 0
+}
+
+func writeLiveDiffTable(diffs []drift.ResourceDiff, w io.Writer) {
+	changed := 0
+	for _, diff := range diffs {
+		if !diff.Changed() {
+			continue
+		}
+		changed++
+		header := fmt.Sprintf("%s %s/%s (%s:%d)", diff.Kind, diff.Namespace, diff.Name, diff.FilePath, diff.Line)
+		if diff.Missing {
+			fmt.Fprintf(w, "%s: not found in the cluster\n", header)
+			continue
+		}
+		fmt.Fprintln(w, header)
+		for _, field := range diff.Differences {
+			fmt.Fprintf(w, "  %s\n", field)
+		}
+	}
+	if changed == 0 {
+		fmt.Fprintf(w, "No differences in %d resources\n", len(diffs))
+		return
+	}
+	fmt.Fprintf(w, "%d of %d resources differ from the cluster\n", changed, len(diffs))
+}
+
+// loadTargetManifests parses the Argo CD resources under paths, honouring
+// the .argocdlintignore of the repository holding them.
+func loadTargetManifests(paths []string) ([]*manifest.Manifest, error) {
+	targets, err := loader.ExpandTargets(paths)
+	if err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	targetDirs, err := targetDirectories(targets)
+	if err != nil {
+		return nil, err
+	}
+	root, _, err := renderRoot("", targetDirs, wd)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := loader.LoadIgnore(root, nil)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []*manifest.Manifest
+	for _, target := range targets {
+		files, err := loader.DiscoverFilesIgnoring(target, ignore)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			docs, err := manifest.Parser{}.ParseFile(file)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, docs...)
+		}
+	}
+	return manifests, nil
+}
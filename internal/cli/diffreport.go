@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/spf13/pflag"
+)
+
+// runDiffReportCommand compares two JSON reports and exits 1 only when the
+// newer one introduces findings.
+func runDiffReportCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("diff-report", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "Usage: argocd-lint diff-report <old.json> <new.json> [flags]")
+		return 2
+	}
+	previous, err := output.LoadJSONReport(flags.Arg(0))
+	if err != nil {
+		printError(stderr, "report", err)
+		return 2
+	}
+	current, err := output.LoadJSONReport(flags.Arg(1))
+	if err != nil {
+		printError(stderr, "report", err)
+		return 2
+	}
+	diff := output.DiffReports(previous, current)
+	if err := output.WriteReportDiff(diff, *format, stdout); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	if len(diff.New) > 0 {
+		return 1
+	}
+	return 0
+}
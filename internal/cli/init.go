@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/spf13/pflag"
+)
+
+func runInitCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	path := flags.String("output", config.DefaultFileName, "Path of the config file to create")
+	profiles := flags.StringSlice("profile", nil, "Built-in profiles to enable ("+strings.Join(config.AvailableProfiles(), ", ")+")")
+	threshold := flags.String("severity-threshold", "", "Severity that fails the run (info|warn|error); defaults to the profile's")
+	interactive := flags.Bool("interactive", false, "Prompt for profiles and threshold on stdin")
+	force := flags.Bool("force", false, "Overwrite an existing config file")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if *interactive {
+		reader := bufio.NewReader(stdin)
+		answer, err := prompt(reader, stdout, fmt.Sprintf("Profiles (%s)", strings.Join(config.AvailableProfiles(), ", ")), strings.Join(*profiles, ","))
+		if err != nil {
+			printError(stderr, "init", err)
+			return 2
+		}
+		*profiles = splitList(answer)
+		if *threshold, err = prompt(reader, stdout, "Severity threshold (info|warn|error)", *threshold); err != nil {
+			printError(stderr, "init", err)
+			return 2
+		}
+	}
+	if *threshold != "" {
+		if _, err := config.ParseSeverity(*threshold); err != nil {
+			printError(stderr, "init", err)
+			return 2
+		}
+	}
+	if !*force {
+		if _, err := os.Stat(*path); err == nil {
+			printError(stderr, "init", fmt.Errorf("%s already exists (use --force to overwrite)", *path))
+			return 2
+		}
+	}
+	content := scaffoldConfig(*profiles, strings.ToLower(strings.TrimSpace(*threshold)))
+	if _, err := config.Parse([]byte(content)); err != nil {
+		printError(stderr, "init", fmt.Errorf("generated config is invalid: %w", err))
+		return 2
+	}
+	if err := os.WriteFile(*path, []byte(content), 0o644); err != nil {
+		printError(stderr, "init", err)
+		return 2
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *path)
+	return 0
+}
+
+// prompt asks one question and returns the trimmed answer, or fallback when
+// the answer is empty.
+func prompt(reader *bufio.Reader, w io.Writer, question, fallback string) (string, error) {
+	if fallback != "" {
+		fmt.Fprintf(w, "%s [%s]: ", question, fallback)
+	} else {
+		fmt.Fprintf(w, "%s: ", question)
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return fallback, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// scaffoldConfig renders a starter config with the chosen profiles and
+// threshold; every other section is commented out as a template.
+func scaffoldConfig(profiles []string, threshold string) string {
+	var b strings.Builder
+	b.WriteString("# argocd-lint configuration. See `argocd-lint rules list` for rule IDs.\n")
+	if len(profiles) > 0 {
+		b.WriteString("profiles:\n")
+		for _, name := range profiles {
+			fmt.Fprintf(&b, "  - %s\n", strings.ToLower(name))
+		}
+	} else {
+		b.WriteString("# profiles: [prod]\n")
+	}
+	switch {
+	case threshold != "" && len(profiles) > 0:
+		// Profiles set severityThreshold, so the chosen threshold is the
+		// fallback exit threshold instead.
+		fmt.Fprintf(&b, "exitPolicy:\n  categoryThresholds:\n    \"*\": %s\n", threshold)
+	case threshold != "":
+		fmt.Fprintf(&b, "severityThreshold: %s\n", threshold)
+	default:
+		b.WriteString("# severityThreshold: error\n")
+	}
+	b.WriteString(`
+# rules:
+#   AR001:
+#     severity: error
+#     params:
+#       floatingRevisions: [main, develop, "release/*"]
+#   AR006:
+#     enabled: false
+
+# overrides:
+#   - pattern: "environments/prod/*"
+#     rules:
+#       AR007:
+#         severity: error
+
+# waivers:
+#   - rule: AR005
+#     file: "apps/legacy/*.yaml"
+#     reason: manual sync until cutover
+#     expires: "2030-01-01"
+
+# policies:
+#   allowedRepoURLDomains: [github.com]
+#   blockedNamespaces: [kube-system, kube-public]
+#   requiredAnnotations: [backstage.io/owner]
+#   namingConventions:
+#     application: {pattern: "^[a-z0-9-]+$"}
+
+# customRules:
+#   - id: CORP001
+#     severity: error
+#     appliesTo: [Application]
+#     expr: "object.spec.source.repoURL.startsWith('https://git.corp/')"
+#     message: "{{ .name }} must use the corporate Git server"
+#   - id: CORP002
+#     path: spec.destination.namespace
+#     required: true
+#     pattern: "^team-"
+
+# plugins:
+#   RG001:
+#     params:
+#       maxReplicas: 5
+
+# exitPolicy:
+#   failOn: threshold
+#   categoryThresholds:
+#     security: warn
+`)
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	regoplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
+	"github.com/spf13/pflag"
+)
+
+type pluginLintProblem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// runPluginsLint statically checks Rego plugin modules and exits 1 when any
+// of them has an error. Warnings alone do not fail the command.
+func runPluginsLint(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("plugins lint", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	switch strings.ToLower(*format) {
+	case "", "table", "json":
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	paths, err := resolvePluginPaths(roots)
+	if err != nil {
+		printError(stderr, "plugin dir", err)
+		return 2
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	runner, err := lint.NewRunner(config.Config{}, wd, "")
+	if err != nil {
+		printError(stderr, "runner", err)
+		return 2
+	}
+	catalog, err := runner.Catalog()
+	if err != nil {
+		printError(stderr, "rules", err)
+		return 2
+	}
+	builtin := make(map[string]bool, len(catalog))
+	for _, meta := range catalog {
+		builtin[meta.ID] = true
+	}
+	found, err := regoplugin.LintModules(context.Background(), builtin, paths...)
+	if err != nil {
+		printError(stderr, "plugin lint", err)
+		return 2
+	}
+	problems := make([]pluginLintProblem, 0, len(found))
+	errorsFound := 0
+	for _, p := range found {
+		problem := pluginLintProblem{File: relativeTo(wd, p.File), Line: p.Line, Level: "error", Message: p.Message}
+		if p.Warning {
+			problem.Level = "warning"
+		} else {
+			errorsFound++
+		}
+		problems = append(problems, problem)
+	}
+	if strings.ToLower(*format) == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+	} else if len(problems) == 0 {
+		fmt.Fprintln(stdout, "No problems found.")
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "%s:%d: %s: %s\n", p.File, p.Line, p.Level, p.Message)
+		}
+		fmt.Fprintf(stdout, "\n%d errors, %d warnings\n", errorsFound, len(problems)-errorsFound)
+	}
+	if errorsFound > 0 {
+		return 1
+	}
+	return 0
+}
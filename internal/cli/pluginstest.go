@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	regoplugin "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"github.com/spf13/pflag"
+)
+
+// fixtureDir names the directories that hold fixture manifests. Each
+// fixtures/<name>.yaml is linted with the plugins and its findings are
+// compared with fixtures/<name>.json.
+const fixtureDir = "fixtures"
+
+type pluginTestResult struct {
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Location string   `json:"location,omitempty"`
+	Status   string   `json:"status"`
+	Details  []string `json:"details,omitempty"`
+}
+
+// runPluginsTest runs the *_test.rego unit tests and fixture tests of Rego
+// plugin directories and exits 1 when any of them fails.
+func runPluginsTest(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("plugins test", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "table", "Output format: table|json")
+	run := flags.String("run", "", "Only run Rego tests and fixtures whose name matches this regular expression")
+	rulesPath := flags.String("rules", "", "Path or https:// URL of a config file whose rule settings and plugin params apply to fixture tests")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	cfg, err := config.Load(*rulesPath)
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	switch strings.ToLower(*format) {
+	case "", "table", "json":
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+	var filter *regexp.Regexp
+	if *run != "" {
+		var err error
+		if filter, err = regexp.Compile(*run); err != nil {
+			printError(stderr, "argument", fmt.Errorf("--run: %w", err))
+			return 2
+		}
+	}
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	ctx := context.Background()
+	results := []pluginTestResult{}
+	for _, root := range roots {
+		dir, err := ResolvePath(root)
+		if err != nil {
+			printError(stderr, "plugin dir", err)
+			return 2
+		}
+		if _, err := os.Stat(dir); err != nil {
+			printError(stderr, "plugin dir", err)
+			return 2
+		}
+		regoResults, err := regoplugin.RunTests(ctx, *run, dir)
+		if err != nil {
+			printError(stderr, "plugin test", err)
+			return 2
+		}
+		for _, r := range regoResults {
+			result := pluginTestResult{Kind: "rego", Name: r.Package + "." + r.Name, Location: relativeTo(wd, r.Location), Status: "pass"}
+			switch {
+			case r.Error != "":
+				result.Status = "error"
+				result.Details = []string{r.Error}
+			case r.Fail:
+				result.Status = "fail"
+			case r.Skip:
+				result.Status = "skip"
+			}
+			results = append(results, result)
+		}
+		fixtureResults, err := runPluginFixtures(ctx, cfg, dir, wd, filter)
+		if err != nil {
+			printError(stderr, "plugin test", err)
+			return 2
+		}
+		results = append(results, fixtureResults...)
+	}
+	if strings.ToLower(*format) == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+	} else if len(results) == 0 {
+		fmt.Fprintln(stdout, "No tests found.")
+	} else {
+		renderPluginTestResults(results, stdout)
+	}
+	for _, r := range results {
+		if r.Status == "fail" || r.Status == "error" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// runPluginFixtures lints every fixture manifest under dir with the plugins
+// in dir and compares the plugin findings with the expected ones.
+func runPluginFixtures(ctx context.Context, cfg config.Config, dir, wd string, filter *regexp.Regexp) ([]pluginTestResult, error) {
+	var fixtures []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || filepath.Base(filepath.Dir(path)) != fixtureDir || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		if filter == nil || filter.MatchString(relativeTo(wd, path)) {
+			fixtures = append(fixtures, path)
+		}
+		return nil
+	})
+	if err != nil || len(fixtures) == 0 {
+		return nil, err
+	}
+	plugins, err := regoplugin.NewLoader(dir).Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(plugins))
+	for _, plug := range plugins {
+		ids[plug.Metadata().ID] = true
+	}
+	runner, err := lint.NewRunner(cfg, wd, "")
+	if err != nil {
+		return nil, err
+	}
+	runner.RegisterPlugins(plugins...)
+	results := make([]pluginTestResult, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		result := pluginTestResult{Kind: "fixture", Name: relativeTo(wd, fixture), Status: "pass"}
+		expected, err := readExpectedFindings(strings.TrimSuffix(fixture, filepath.Ext(fixture)) + ".json")
+		if err != nil {
+			result.Status = "error"
+			result.Details = []string{err.Error()}
+			results = append(results, result)
+			continue
+		}
+		report, err := runner.RunContext(ctx, lint.Options{Target: fixture})
+		if err != nil {
+			result.Status = "error"
+			result.Details = []string{err.Error()}
+			results = append(results, result)
+			continue
+		}
+		var actual []types.Finding
+		for _, f := range report.Findings {
+			if ids[f.RuleID] {
+				actual = append(actual, f)
+			}
+		}
+		if result.Details = compareFindings(expected, actual); len(result.Details) > 0 {
+			result.Status = "fail"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func readExpectedFindings(path string) ([]types.Finding, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("missing expected findings %s", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var expected []types.Finding
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	for i, f := range expected {
+		if f.RuleID == "" {
+			return nil, fmt.Errorf("%s: finding %d has no ruleId", filepath.Base(path), i)
+		}
+	}
+	return expected, nil
+}
+
+// compareFindings pairs each expected finding with an actual one. Expected
+// findings only constrain the fields they set, so a fixture can pin just the
+// rule and resource, or the exact message and line too. It returns one line
+// per expected finding that was not reported and per unexpected finding.
+func compareFindings(expected, actual []types.Finding) []string {
+	matched := make([]bool, len(actual))
+	var details []string
+	for _, want := range expected {
+		found := false
+		for i, got := range actual {
+			if !matched[i] && findingMatches(want, got) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			details = append(details, "missing "+describeFinding(want))
+		}
+	}
+	for i, got := range actual {
+		if !matched[i] {
+			details = append(details, "unexpected "+describeFinding(got))
+		}
+	}
+	return details
+}
+
+func findingMatches(want, got types.Finding) bool {
+	return want.RuleID == got.RuleID &&
+		(want.Message == "" || want.Message == got.Message) &&
+		(want.Severity == "" || want.Severity == got.Severity) &&
+		(want.ResourceName == "" || want.ResourceName == got.ResourceName) &&
+		(want.ResourceKind == "" || want.ResourceKind == got.ResourceKind) &&
+		(want.Line == 0 || want.Line == got.Line)
+}
+
+func describeFinding(f types.Finding) string {
+	var b strings.Builder
+	b.WriteString(f.RuleID)
+	if f.ResourceName != "" {
+		fmt.Fprintf(&b, " on %s", f.ResourceName)
+	}
+	if f.Line > 0 {
+		fmt.Fprintf(&b, " (line %d)", f.Line)
+	}
+	if f.Message != "" {
+		fmt.Fprintf(&b, ": %s", f.Message)
+	}
+	return b.String()
+}
+
+func renderPluginTestResults(results []pluginTestResult, w io.Writer) {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+		line := fmt.Sprintf("%-5s %s", strings.ToUpper(r.Status), r.Name)
+		if r.Location != "" {
+			line += " (" + r.Location + ")"
+		}
+		fmt.Fprintln(w, line)
+		for _, detail := range r.Details {
+			fmt.Fprintf(w, "      %s\n", detail)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d errors, %d skipped\n", counts["pass"], counts["fail"], counts["error"], counts["skip"])
+}
+
+func relativeTo(wd, path string) string {
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/argocd-lint/argocd-lint/internal/render"
+	"github.com/spf13/pflag"
+)
+
+// renderRuleIDs are the checks the render command reports: render failures
+// and snapshot drift.
+var renderRuleIDs = []string{"RENDER_HELM", "RENDER_KUSTOMIZE", "RENDER_JSONNET", "RENDER_PLUGIN", "RENDER_SNAPSHOT"}
+
+// runRenderCommand records or checks render snapshots for the Applications
+// and ApplicationSets under the targets. It exits 1 when a source fails to
+// render or, with --check-snapshots, when rendered output drifted.
+func runRenderCommand(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("render", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	writeDir := flags.String("write-snapshots", "", "Write the rendered output of each Application to this directory")
+	checkDir := flags.String("check-snapshots", "", "Compare the rendered output of each Application with the snapshots in this directory")
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	repoRoot := flags.String("repo-root", "", "Override repository root for resolving source paths")
+	format := flags.String("format", output.FormatTable, "Output format: table|json|sarif|github|teamcity")
+	kubeVersion := flags.String("kube-version", "", "Kubernetes version Helm charts render against (overrides config render.kubeVersion)")
+	apiVersions := flags.StringSlice("api-versions", nil, "Extra API versions Helm charts see in .Capabilities.APIVersions (overrides config render.apiVersions)")
+	cmpCommands := flags.StringArray("cmp-command", nil, "Emulate a Config Management Plugin as name=shell command (repeatable; overrides config render.cmpCommands)")
+	allowConfigCMP := flags.Bool("allow-config-cmp-commands", false, "Run the shell commands of config render.cmpCommands (off by default)")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if (*writeDir == "") == (*checkDir == "") {
+		fmt.Fprintln(stderr, "Usage: argocd-lint render <path>... --write-snapshots <dir> | --check-snapshots <dir> [flags]")
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	targets, err := loader.ExpandTargets(paths)
+	if err != nil {
+		printError(stderr, "target", err)
+		return 2
+	}
+	targetDirs, err := targetDirectories(targets)
+	if err != nil {
+		printError(stderr, "target", err)
+		return 2
+	}
+
+	cfg, err := config.Load(*rulesPath)
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	cfg.Selection = config.RuleSelection{Only: renderRuleIDs}
+	wd, err := os.Getwd()
+	if err != nil {
+		printError(stderr, "workdir", err)
+		return 2
+	}
+	root, _, err := renderRoot(*repoRoot, targetDirs, wd)
+	if err != nil {
+		printError(stderr, "repo root", err)
+		return 2
+	}
+	renderOpts := render.Options{
+		Enabled:        true,
+		RepoRoot:       root,
+		KubeVersion:    cfg.Render.KubeVersion,
+		APIVersions:    cfg.Render.APIVersions,
+		SnapshotDir:    *checkDir,
+		WriteSnapshots: *writeDir != "",
+	}
+	if *writeDir != "" {
+		renderOpts.SnapshotDir = *writeDir
+	}
+	if *kubeVersion != "" {
+		renderOpts.KubeVersion = *kubeVersion
+	}
+	if len(*apiVersions) > 0 {
+		renderOpts.APIVersions = *apiVersions
+	}
+	renderOpts.CMPCommands, err = pluginCommands(cfg.Render.CMPCommands, *cmpCommands, *allowConfigCMP)
+	if err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	ignore, err := loader.LoadIgnore(root, nil)
+	if err != nil {
+		printError(stderr, "ignore", err)
+		return 2
+	}
+
+	runner, err := lint.NewRunner(cfg, wd, "")
+	if err != nil {
+		printError(stderr, "runner", err)
+		return 2
+	}
+	report, err := runner.Run(lint.Options{
+		Targets:                targets,
+		Ignore:                 ignore,
+		IncludeApplications:    true,
+		IncludeApplicationSets: true,
+		Config:                 cfg,
+		WorkingDir:             wd,
+		Render:                 renderOpts,
+	})
+	if err != nil {
+		printError(stderr, "render", err)
+		return 2
+	}
+	if err := output.Write(report, *format, stdout); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	if len(report.Findings) > 0 {
+		return 1
+	}
+	if *writeDir != "" {
+		fmt.Fprintf(stderr, "wrote render snapshots to %s\n", *writeDir)
+	}
+	return 0
+}
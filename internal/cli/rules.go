@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+type ruleRow struct {
+	Rule            string                 `json:"id"`
+	Severity        string                 `json:"severity"`
+	DefaultSeverity string                 `json:"defaultSeverity"`
+	Enabled         bool                   `json:"enabled"`
+	AppliesTo       []string               `json:"appliesTo,omitempty"`
+	Category        string                 `json:"category,omitempty"`
+	Description     string                 `json:"description"`
+	HelpURL         string                 `json:"helpUrl,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+	Rationale       string                 `json:"rationale,omitempty"`
+	Example         string                 `json:"example,omitempty"`
+	Remediation     string                 `json:"remediation,omitempty"`
+}
+
+func runRulesCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return runRulesList(args[1:], stdout, stderr)
+		case "explain":
+			return runRulesExplain(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintln(stderr, "Usage: argocd-lint rules list|explain <rule-id> [flags]")
+	return 2
+}
+
+// rulesFlags registers the flags shared by the rules subcommands and returns
+// a loader for the catalog resolved against the active config and profiles.
+func rulesFlags(flags *pflag.FlagSet) func() ([]ruleRow, error) {
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	profiles := flags.StringSlice("profile", nil, "Apply rule profiles: built-in (dev, prod, security, hardening) or definedProfiles from the config")
+	pluginFiles := flags.StringSlice("plugin", nil, "Path to a Rego plugin module, or an oci:// or https:// bundle reference (repeatable)")
+	pluginDirs := flags.StringSlice("plugin-dir", nil, "Directory of Rego plugin modules, or an oci:// or https:// bundle reference (repeatable, recursive)")
+	pluginCacheDir := flags.String("plugin-cache-dir", "", "Cache fetched bundles and Rego rule metadata here")
+	grpcPlugins := flags.StringSlice("grpc-plugin", nil, "Path to a gRPC plugin binary (repeatable)")
+	conftestPolicies := flags.StringSlice("conftest-policy", nil, "conftest policy module or directory, or an oci:// or https:// bundle reference (repeatable)")
+	gatekeeperPolicies := flags.StringSlice("gatekeeper-policy", nil, "File or directory of Gatekeeper ConstraintTemplates and constraints (repeatable)")
+	strictConfig := flags.Bool("strict-config", false, "Reject the config, and any config it extends, on unknown keys or rule IDs and invalid severities instead of ignoring them")
+	return func() ([]ruleRow, error) {
+		cfg, err := config.LoadWithOptions(*rulesPath, config.LoadOptions{Strict: *strictConfig})
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.ApplyProfiles(*profiles...); err != nil {
+			return nil, err
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		runner, err := lint.NewRunner(cfg, wd, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := registerPlugins(runner, *pluginFiles, *pluginDirs, *pluginCacheDir, nil, logging.Discard()); err != nil {
+			return nil, err
+		}
+		if err := registerCompatPolicies(runner, *conftestPolicies, *gatekeeperPolicies, nil, logging.Discard()); err != nil {
+			return nil, err
+		}
+		closeGRPCPlugins, err := registerGRPCPlugins(runner, *grpcPlugins, logging.Discard())
+		if err != nil {
+			return nil, err
+		}
+		defer closeGRPCPlugins()
+		if err := registerCustomRules(runner, cfg); err != nil {
+			return nil, err
+		}
+		if *strictConfig {
+			if err := checkConfigRuleIDs(runner, cfg); err != nil {
+				return nil, err
+			}
+		}
+		catalog, err := runner.Catalog()
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]ruleRow, 0, len(catalog))
+		for _, meta := range catalog {
+			resolved, err := cfg.Resolve(meta, "")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", meta.ID, err)
+			}
+			applies := make([]string, 0, len(meta.AppliesTo))
+			for _, kind := range meta.AppliesTo {
+				applies = append(applies, string(kind))
+			}
+			rows = append(rows, ruleRow{
+				Rule:            meta.ID,
+				Severity:        string(resolved.Severity),
+				DefaultSeverity: string(meta.DefaultSeverity),
+				Enabled:         resolved.Enabled,
+				AppliesTo:       applies,
+				Category:        meta.Category,
+				Description:     meta.Description,
+				HelpURL:         meta.HelpURL,
+				Params:          resolved.Params,
+				Rationale:       meta.Rationale,
+				Example:         meta.Example,
+				Remediation:     meta.Remediation,
+			})
+		}
+		return rows, nil
+	}
+}
+
+func runRulesList(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("rules list", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	load := rulesFlags(flags)
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	rows, err := load()
+	if err != nil {
+		printError(stderr, "rules", err)
+		return 2
+	}
+	switch strings.ToLower(*format) {
+	case "", "table":
+		if err := renderRulesTable(rows, stdout); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		return 0
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		return 0
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+}
+
+func runRulesExplain(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("rules explain", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	load := rulesFlags(flags)
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: argocd-lint rules explain <rule-id> [flags]")
+		return 2
+	}
+	id := strings.ToUpper(strings.TrimSpace(flags.Arg(0)))
+	rows, err := load()
+	if err != nil {
+		printError(stderr, "rules", err)
+		return 2
+	}
+	var row *ruleRow
+	for i := range rows {
+		if strings.ToUpper(rows[i].Rule) == id {
+			row = &rows[i]
+			break
+		}
+	}
+	if row == nil {
+		printError(stderr, "rules", fmt.Errorf("unknown rule %q", flags.Arg(0)))
+		return 2
+	}
+	switch strings.ToLower(*format) {
+	case "", "table":
+		if err := renderRuleExplanation(*row, stdout); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		return 0
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(row); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		return 0
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+}
+
+func renderRulesTable(rows []ruleRow, w io.Writer) error {
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		applies := "-"
+		if len(row.AppliesTo) > 0 {
+			applies = strings.Join(row.AppliesTo, ",")
+		}
+		enabled := "yes"
+		if !row.Enabled {
+			enabled = "no"
+		}
+		data = append(data, []string{row.Rule, strings.ToUpper(row.Severity), enabled, applies, row.Category, row.Description})
+	}
+	if err := writeBoxTable(w, []string{"Rule", "Severity", "Enabled", "Applies", "Category", "Description"}, data); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nTotal: %d rules\n", len(rows))
+	return err
+}
+
+// writeBoxTable writes rows under headers in a bordered table.
+func writeBoxTable(w io.Writer, headers []string, data [][]string) error {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, entry := range data {
+		for i, cell := range entry {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	separator := make([]string, len(widths))
+	for i, width := range widths {
+		separator[i] = strings.Repeat("-", width+2)
+	}
+	line := "+" + strings.Join(separator, "+") + "+"
+	lineFmt := func(values []string) string {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, width := range widths {
+			fmt.Fprintf(&b, " %-*s ", width, values[i])
+			b.WriteString("|")
+		}
+		b.WriteString("\n")
+		return b.String()
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, lineFmt(headers)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+	for _, row := range data {
+		if _, err := io.WriteString(w, lineFmt(row)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+func renderRuleExplanation(row ruleRow, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s – %s\n\n", row.Rule, row.Description)
+	if row.Category != "" {
+		fmt.Fprintf(&b, "Category:   %s\n", row.Category)
+	}
+	if len(row.AppliesTo) > 0 {
+		fmt.Fprintf(&b, "Applies to: %s\n", strings.Join(row.AppliesTo, ", "))
+	}
+	severity := row.Severity
+	if row.Severity != row.DefaultSeverity {
+		severity = fmt.Sprintf("%s (default %s)", row.Severity, row.DefaultSeverity)
+	}
+	fmt.Fprintf(&b, "Severity:   %s\n", severity)
+	fmt.Fprintf(&b, "Enabled:    %t\n", row.Enabled)
+	if len(row.Params) > 0 {
+		keys := make([]string, 0, len(row.Params))
+		for key := range row.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("Params:\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "  %s: %v\n", key, row.Params[key])
+		}
+	}
+	if row.HelpURL != "" {
+		fmt.Fprintf(&b, "Docs:       %s\n", row.HelpURL)
+	}
+	if row.Rationale != "" {
+		fmt.Fprintf(&b, "\nRationale:\n%s\n", indentText(row.Rationale))
+	}
+	if row.Example != "" {
+		fmt.Fprintf(&b, "\nExample:\n%s\n", indentText(row.Example))
+	}
+	if row.Remediation != "" {
+		fmt.Fprintf(&b, "\nRemediation:\n%s\n", indentText(row.Remediation))
+	}
+	fmt.Fprintf(&b, "\nConfigure it under rules.%s in the rules file, or waive it per resource with the\nannotation %s: \"%s:<YYYY-MM-DD>:<reason>\".\n", row.Rule, config.WaiverAnnotation, row.Rule)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// indentText indents each line of text by two spaces.
+func indentText(text string) string {
+	return "  " + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n  ")
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/argocd-lint/argocd-lint/internal/schema"
+	"github.com/spf13/pflag"
+)
+
+func runSchemaCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "fetch" {
+		return runSchemaFetch(args[1:], stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "Usage: argocd-lint schema fetch <version> --out <dir> [flags]")
+	return 2
+}
+
+// runSchemaFetch downloads the Application and ApplicationSet CRDs of an
+// Argo CD release and writes them in the embedded schema format, ready for
+// --crd-schemas.
+func runSchemaFetch(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("schema fetch", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	outDir := flags.String("out", "", "Directory to write application.json and applicationset.json to")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	if flags.NArg() != 1 || *outDir == "" {
+		fmt.Fprintln(stderr, "Usage: argocd-lint schema fetch <version> --out <dir> [flags]")
+		return 2
+	}
+	files, err := schema.FetchUpstream(context.Background(), flags.Arg(0))
+	if err != nil {
+		printError(stderr, "schema", err)
+		return 2
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		printError(stderr, "output", err)
+		return 2
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(*outDir, name)
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		fmt.Fprintf(stdout, "wrote %s\n", path)
+	}
+	return 0
+}
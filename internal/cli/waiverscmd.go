@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type waiverRow struct {
+	Rule       string `json:"rule"`
+	File       string `json:"file"`
+	Resource   string `json:"resource,omitempty"`
+	Expires    string `json:"expires"`
+	DaysLeft   int    `json:"daysLeft"`
+	Expired    bool   `json:"expired"`
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	Ticket     string `json:"ticket,omitempty"`
+	Reason     string `json:"reason"`
+	Source     string `json:"source"`
+}
+
+func runWaiversCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "report" {
+		return runWaiversReport(args[1:], stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "Usage: argocd-lint waivers report [paths...] [flags]")
+	return 2
+}
+
+// runWaiversReport lists the waivers of the config and, for the given
+// paths, those declared with the waiver annotation, soonest expiry first.
+func runWaiversReport(args []string, stdout, stderr io.Writer) int {
+	flags := pflag.NewFlagSet("waivers report", pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rulesPath := flags.String("rules", "", "Path or https:// URL of the rules configuration file")
+	profiles := flags.StringSlice("profile", nil, "Apply rule profiles, whose waiver policies the config waivers must meet")
+	includeExpired := flags.Bool("include-expired", false, "Also list waivers that have expired")
+	format := flags.String("format", "table", "Output format: table|json")
+	if err := flags.Parse(args); err != nil {
+		printError(stderr, "argument", err)
+		return 2
+	}
+	cfg, err := config.Load(*rulesPath)
+	if err != nil {
+		printError(stderr, "config", err)
+		return 2
+	}
+	if err := cfg.ApplyProfiles(*profiles...); err != nil {
+		printError(stderr, "profile", err)
+		return 2
+	}
+	now := time.Now()
+	var rows []waiverRow
+	add := func(w config.Waiver, source string) {
+		expires, err := w.ExpiryTime()
+		if err != nil {
+			return
+		}
+		expired := now.After(expires)
+		if expired && !*includeExpired {
+			return
+		}
+		rows = append(rows, waiverRow{
+			Rule:       w.Rule,
+			File:       w.File,
+			Resource:   w.Resource,
+			Expires:    w.Expires,
+			DaysLeft:   int(expires.Sub(now).Hours() / 24),
+			Expired:    expired,
+			ApprovedBy: w.ApprovedBy,
+			Ticket:     w.Ticket,
+			Reason:     w.Reason,
+			Source:     source,
+		})
+	}
+	for _, w := range cfg.Waivers {
+		add(w, "config")
+	}
+	if flags.NArg() > 0 {
+		manifests, err := loadTargetManifests(flags.Args())
+		if err != nil {
+			printError(stderr, "target", err)
+			return 2
+		}
+		for _, m := range manifests {
+			metadata, _ := m.Object["metadata"].(map[string]interface{})
+			annotations, _ := metadata["annotations"].(map[string]interface{})
+			value, _ := annotations[config.WaiverAnnotation].(string)
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			parsed, err := config.ParseWaiverAnnotation(value)
+			if err != nil {
+				printError(stderr, "waiver", fmt.Errorf("%s: %s/%s: %w", m.FilePath, m.Kind, m.Name, err))
+				return 2
+			}
+			for _, w := range parsed {
+				w.File = m.FilePath
+				w.Resource = m.Kind + "/" + m.Name
+				add(w, "annotation")
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].DaysLeft < rows[j].DaysLeft })
+
+	switch strings.ToLower(*format) {
+	case "", "table":
+		if len(rows) == 0 {
+			fmt.Fprintln(stdout, "No active waivers")
+			return 0
+		}
+		data := make([][]string, 0, len(rows))
+		for _, row := range rows {
+			scope := row.File
+			if row.Resource != "" {
+				scope += " " + row.Resource
+			}
+			left := strconv.Itoa(row.DaysLeft)
+			if row.Expired {
+				left = "expired"
+			}
+			data = append(data, []string{row.Rule, scope, row.Expires, left, dash(row.ApprovedBy), dash(row.Ticket), row.Source, row.Reason})
+		}
+		if err := writeBoxTable(stdout, []string{"Rule", "Scope", "Expires", "Days left", "Approved by", "Ticket", "Source", "Reason"}, data); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+		fmt.Fprintf(stdout, "\nTotal: %d waivers\n", len(rows))
+	case "json":
+		if rows == nil {
+			rows = []waiverRow{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			printError(stderr, "output", err)
+			return 2
+		}
+	default:
+		printError(stderr, "format", fmt.Errorf("unsupported format %q", *format))
+		return 2
+	}
+	return 0
+}
+
+func dash(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,244 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+// Options configures access to the Kubernetes API through kubectl.
+type Options struct {
+	KubectlBinary string
+	Kubeconfig    string
+	KubeContext   string
+}
+
+// Client reads and annotates Argo CD resources using kubectl.
+type Client struct {
+	options Options
+}
+
+// Resources lists the Argo CD custom resources queried by List.
+var Resources = []string{
+	"applications.argoproj.io",
+	"applicationsets.argoproj.io",
+	"appprojects.argoproj.io",
+}
+
+// NewClient creates a kubectl-backed client.
+func NewClient(opts Options) *Client {
+	if strings.TrimSpace(opts.KubectlBinary) == "" {
+		opts.KubectlBinary = "kubectl"
+	}
+	return &Client{options: opts}
+}
+
+// SourcePath returns the pseudo file path used for live resources so findings
+// can be attributed without a file on disk.
+func SourcePath(kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("cluster://%s/%s", kind, name)
+	}
+	return fmt.Sprintf("cluster://%s/%s/%s", namespace, kind, name)
+}
+
+// List fetches live Applications, ApplicationSets, and AppProjects. An empty
+// namespace lists across all namespaces.
+func (c *Client) List(ctx context.Context, namespace string) ([]*manifest.Manifest, error) {
+	args := []string{"get", strings.Join(Resources, ","), "-o", "json"}
+	if namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "--namespace", namespace)
+	}
+	out, err := c.run(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("decode kubectl output: %w", err)
+	}
+	manifests := make([]*manifest.Manifest, 0, len(list.Items))
+	for _, item := range list.Items {
+		m, err := parseLive(item)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests, nil
+}
+
+// Get fetches a single live object. A nil manifest with nil error means the
+// object does not exist.
+func (c *Client) Get(ctx context.Context, resource, namespace, name string) (*manifest.Manifest, error) {
+	args := []string{"get", resource, name, "-o", "json", "--ignore-not-found"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	out, err := c.run(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	return parseLive(out)
+}
+
+// CustomResourceDefinitions fetches the named CRDs, such as Resources, as
+// decoded objects. CRDs that are not installed are left out.
+func (c *Client) CustomResourceDefinitions(ctx context.Context, names ...string) ([]map[string]interface{}, error) {
+	args := append([]string{"get", "customresourcedefinitions.apiextensions.k8s.io"}, names...)
+	out, err := c.run(ctx, nil, append(args, "-o", "json", "--ignore-not-found")...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var list struct {
+		Kind  string                   `json:"kind"`
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("decode kubectl output: %w", err)
+	}
+	if list.Kind == "CustomResourceDefinition" {
+		// A single name prints the object rather than a List.
+		var crd map[string]interface{}
+		if err := json.Unmarshal(out, &crd); err != nil {
+			return nil, fmt.Errorf("decode kubectl output: %w", err)
+		}
+		return []map[string]interface{}{crd}, nil
+	}
+	return list.Items, nil
+}
+
+// Event describes a Kubernetes Event attached to a live Argo CD resource.
+type Event struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       string
+	Type      string
+	Reason    string
+	Message   string
+}
+
+// CreateEvent records a core/v1 Event against the involved object.
+func (c *Client) CreateEvent(ctx context.Context, ev Event) error {
+	data, err := json.Marshal(eventObject(ev))
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	_, err = c.run(ctx, data, "create", "-f", "-")
+	return err
+}
+
+// eventObject builds the core/v1 Event for ev.
+func eventObject(ev Event) map[string]interface{} {
+	now := time.Now().UTC().Format(time.RFC3339)
+	namespace := ev.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"generateName": strings.ToLower(ev.Name) + ".",
+			"namespace":    namespace,
+		},
+		"involvedObject": map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       ev.Kind,
+			"namespace":  ev.Namespace,
+			"name":       ev.Name,
+			"uid":        ev.UID,
+		},
+		"type":           ev.Type,
+		"reason":         ev.Reason,
+		"message":        ev.Message,
+		"firstTimestamp": now,
+		"lastTimestamp":  now,
+		"count":          1,
+		"source":         map[string]interface{}{"component": "argocd-lint"},
+	}
+}
+
+func (c *Client) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	if c.options.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", c.options.Kubeconfig)
+	}
+	if c.options.KubeContext != "" {
+		args = append(args, "--context", c.options.KubeContext)
+	}
+	cmd := exec.CommandContext(ctx, c.options.KubectlBinary, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("kubectl %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseLive converts a live object into a manifest, dropping server-populated
+// fields that are not part of the desired state.
+func parseLive(raw []byte) (*manifest.Manifest, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("decode live object: %w", err)
+	}
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, key := range []string{"managedFields", "resourceVersion", "generation", "creationTimestamp"} {
+			delete(metadata, key)
+		}
+	}
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	cleaned, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encode live object: %w", err)
+	}
+	docs, err := manifest.Parser{}.Parse(SourcePath(kind, namespace, name), cleaned)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return docs[0], nil
+}
+
+// UID returns the metadata.uid of a live manifest, if present.
+func UID(m *manifest.Manifest) string {
+	if m == nil {
+		return ""
+	}
+	metadata, _ := m.Object["metadata"].(map[string]interface{})
+	uid, _ := metadata["uid"].(string)
+	return uid
+}
@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeClient reads Argo CD resources and records Events through the
+// Kubernetes API with client-go, so it needs no kubectl binary.
+type KubeClient struct {
+	dynamic dynamic.Interface
+}
+
+// kubeResources are the Argo CD resources listed by KubeClient.List, in
+// the order of Resources.
+var kubeResources = []schema.GroupVersionResource{
+	{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"},
+	{Group: "argoproj.io", Version: "v1alpha1", Resource: "applicationsets"},
+	{Group: "argoproj.io", Version: "v1alpha1", Resource: "appprojects"},
+}
+
+var (
+	crdResource   = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	eventResource = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+)
+
+// NewKubeClient creates a client-go client. Without a kubeconfig or context
+// in opts it uses the in-cluster service account when running in a pod and
+// $KUBECONFIG or ~/.kube/config otherwise. KubectlBinary is ignored.
+func NewKubeClient(opts Options) (*KubeClient, error) {
+	config, err := restConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	config.UserAgent = "argocd-lint"
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	return &KubeClient{dynamic: client}, nil
+}
+
+func restConfig(opts Options) (*rest.Config, error) {
+	if opts.Kubeconfig == "" && opts.KubeContext == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, fmt.Errorf("load in-cluster config: %w", err)
+		}
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.KubeContext}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// List fetches live Applications, ApplicationSets, and AppProjects. An empty
+// namespace lists across all namespaces.
+func (c *KubeClient) List(ctx context.Context, namespace string) ([]*manifest.Manifest, error) {
+	var manifests []*manifest.Manifest
+	for _, resource := range kubeResources {
+		list, err := c.dynamic.Resource(resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", resource.GroupResource(), err)
+		}
+		for i := range list.Items {
+			m, err := parseUnstructured(&list.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+// CustomResourceDefinitions fetches the named CRDs, such as Resources, as
+// decoded objects. CRDs that are not installed are left out.
+func (c *KubeClient) CustomResourceDefinitions(ctx context.Context, names ...string) ([]map[string]interface{}, error) {
+	var crds []map[string]interface{}
+	for _, name := range names {
+		crd, err := c.dynamic.Resource(crdResource).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", name, err)
+		}
+		crds = append(crds, crd.Object)
+	}
+	return crds, nil
+}
+
+// CreateEvent records a core/v1 Event against the involved object.
+func (c *KubeClient) CreateEvent(ctx context.Context, ev Event) error {
+	event := &unstructured.Unstructured{Object: eventObject(ev)}
+	_, err := c.dynamic.Resource(eventResource).Namespace(event.GetNamespace()).Create(ctx, event, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create event: %w", err)
+	}
+	return nil
+}
+
+func parseUnstructured(obj *unstructured.Unstructured) (*manifest.Manifest, error) {
+	raw, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("encode live object: %w", err)
+	}
+	return parseLive(raw)
+}
@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const liveApplications = `{"apiVersion":"argoproj.io/v1alpha1","kind":"ApplicationList","metadata":{},"items":[{"apiVersion":"argoproj.io/v1alpha1","kind":"Application","metadata":{"name":"guestbook","namespace":"argocd","uid":"1234","resourceVersion":"99"},"spec":{"project":"default"},"status":{"sync":{"status":"Synced"}}}]}`
+
+// fakeKubeAPI serves the Argo CD lists and the application CRD, and records
+// the Events created. It returns a kubeconfig for the server.
+func fakeKubeAPI(t *testing.T) (string, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/argoproj.io/v1alpha1/applications":
+			_, _ = io.WriteString(w, liveApplications)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/apis/argoproj.io/v1alpha1/"):
+			_, _ = io.WriteString(w, `{"apiVersion":"argoproj.io/v1alpha1","kind":"List","metadata":{},"items":[]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/apiextensions.k8s.io/v1/customresourcedefinitions/applications.argoproj.io":
+			_, _ = io.WriteString(w, `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"applications.argoproj.io"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/namespaces/argocd/events":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			events = append(events, string(body))
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+  - name: fake
+    cluster: {server: %s}
+contexts:
+  - name: fake
+    context: {cluster: fake, user: fake}
+users:
+  - name: fake
+    user: {token: test}
+current-context: fake
+`, server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(content), 0o600); err != nil {
+		t.Fatalf("write kubeconfig: %v", err)
+	}
+	return kubeconfig, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), events...)
+	}
+}
+
+func TestKubeClient(t *testing.T) {
+	kubeconfig, events := fakeKubeAPI(t)
+	client, err := NewKubeClient(Options{Kubeconfig: kubeconfig})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	ctx := context.Background()
+
+	live, err := client.List(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(live) != 1 || live[0].FilePath != "cluster://argocd/Application/guestbook" {
+		t.Fatalf("expected the live application, got %+v", live)
+	}
+	if _, ok := live[0].Object["status"]; ok {
+		t.Fatalf("expected status to be dropped from live objects")
+	}
+
+	crds, err := client.CustomResourceDefinitions(ctx, Resources...)
+	if err != nil {
+		t.Fatalf("crds: %v", err)
+	}
+	if len(crds) != 1 {
+		t.Fatalf("expected only the installed CRD, got %d", len(crds))
+	}
+
+	ev := Event{Kind: "Application", Namespace: "argocd", Name: "guestbook", UID: "1234", Type: "Warning", Reason: "LintFindings", Message: "findings"}
+	if err := client.CreateEvent(ctx, ev); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	created := events()
+	if len(created) != 1 {
+		t.Fatalf("expected one event, got %d", len(created))
+	}
+	var event struct {
+		InvolvedObject struct {
+			UID string `json:"uid"`
+		} `json:"involvedObject"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(created[0]), &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if event.InvolvedObject.UID != "1234" || event.Reason != "LintFindings" {
+		t.Fatalf("unexpected event %s", created[0])
+	}
+}
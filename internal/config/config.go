@@ -1,20 +1,31 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
 // RuleConfig describes rule overrides.
 type RuleConfig struct {
-	Enabled  *bool  `yaml:"enabled"`
-	Severity string `yaml:"severity"`
+	Enabled  *bool                  `yaml:"enabled"`
+	Severity string                 `yaml:"severity"`
+	Params   map[string]interface{} `yaml:"params"`
+}
+
+// PluginConfig configures a plugin rule.
+type PluginConfig struct {
+	Params map[string]interface{} `yaml:"params"`
 }
 
 // Override applies overrides based on file path pattern.
@@ -25,49 +36,324 @@ type Override struct {
 
 // Config is the runtime rule configuration.
 type Config struct {
-	Rules     map[string]RuleConfig `yaml:"rules"`
-	Overrides []Override            `yaml:"overrides"`
-	Threshold string                `yaml:"severityThreshold"`
-	Policies  PolicyConfig          `yaml:"policies"`
-	Profiles  []string              `yaml:"profiles"`
-	Waivers   []Waiver              `yaml:"waivers"`
+	// Extends lists config files or https:// URLs this file builds on, in
+	// order; see Merge. Load resolves them, Parse keeps them as written.
+	Extends []string              `yaml:"extends"`
+	Rules   map[string]RuleConfig `yaml:"rules"`
+	// Categories and Tags tune every rule of a category, or carrying a tag,
+	// at once; settings for a rule ID win over both.
+	Categories map[string]RuleConfig `yaml:"categories"`
+	Tags       map[string]RuleConfig `yaml:"tags"`
+	Overrides  []Override            `yaml:"overrides"`
+	Threshold  string                `yaml:"severityThreshold"`
+	ExitPolicy ExitPolicy            `yaml:"exitPolicy"`
+	Policies   PolicyConfig          `yaml:"policies"`
+	Profiles   []string              `yaml:"profiles"`
+	// DefinedProfiles declares named profiles selectable like built-ins
+	// with profiles or --profile.
+	DefinedProfiles map[string]ProfileConfig `yaml:"definedProfiles"`
+	Waivers         []Waiver                 `yaml:"waivers"`
+	WaiverPolicy    WaiverPolicy             `yaml:"waiverPolicy"`
+	// CustomRules are declarative CEL rules evaluated like plugin rules.
+	CustomRules []CustomRule `yaml:"customRules"`
+	// Plugins holds parameters for plugin rules, keyed by rule ID. Rego
+	// plugins read them as data.params.
+	Plugins map[string]PluginConfig `yaml:"plugins"`
+	// Render holds --render defaults; the matching CLI flags win.
+	Render RenderConfig `yaml:"render"`
+	// CRDSchemas lists directories of custom resource schemas, added to
+	// those given with --crd-schemas.
+	CRDSchemas []string `yaml:"crdSchemas"`
+	// Selection holds invocation-time rule toggles from the CLI.
+	Selection RuleSelection `yaml:"-"`
+
+	// strictFiles are the files of a strict load whose rule IDs
+	// CheckRuleIDs checks.
+	strictFiles []strictFile
+}
+
+// RuleSelection enables or disables rules at invocation time. It wins over
+// the rules file, profiles, and overrides: when Only is set every other rule
+// is disabled, then Enable and Disable apply in that order.
+type RuleSelection struct {
+	Enable  []string
+	Disable []string
+	Only    []string
+}
+
+// IDs returns every rule ID named by the selection.
+func (s RuleSelection) IDs() []string {
+	ids := make([]string, 0, len(s.Enable)+len(s.Disable)+len(s.Only))
+	ids = append(ids, s.Only...)
+	ids = append(ids, s.Enable...)
+	return append(ids, s.Disable...)
+}
+
+func (s RuleSelection) apply(id string, enabled bool) bool {
+	if len(s.Only) > 0 {
+		enabled = containsRuleID(s.Only, id)
+	}
+	if containsRuleID(s.Enable, id) {
+		enabled = true
+	}
+	if containsRuleID(s.Disable, id) {
+		enabled = false
+	}
+	return enabled
 }
 
-// PolicyConfig captures additional governance settings.
+func containsRuleID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if strings.EqualFold(strings.TrimSpace(candidate), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyConfig captures additional governance settings. Destination server,
+// namespace, and project lists accept globs; AllowedDestinationServers
+// matches destination.server or destination.name.
 type PolicyConfig struct {
-	AllowedRepoURLProtocols []string `yaml:"allowedRepoURLProtocols"`
-	AllowedRepoURLDomains   []string `yaml:"allowedRepoURLDomains"`
+	AllowedRepoURLProtocols      []string          `yaml:"allowedRepoURLProtocols"`
+	AllowedRepoURLDomains        []string          `yaml:"allowedRepoURLDomains"`
+	BlockedRepoURLDomains        []string          `yaml:"blockedRepoURLDomains"`
+	AllowedDestinationServers    []string          `yaml:"allowedDestinationServers"`
+	AllowedDestinationNamespaces []string          `yaml:"allowedDestinationNamespaces"`
+	BlockedNamespaces            []string          `yaml:"blockedNamespaces"`
+	AllowedProjects              []string          `yaml:"allowedProjects"`
+	NamingConventions            NamingConventions `yaml:"namingConventions"`
+	RequiredAnnotations          []string          `yaml:"requiredAnnotations"`
 }
 
-// Load reads configuration from file. Empty path returns defaults.
+// NamingConventions holds name rules per resource kind and for destination
+// namespaces. Empty entries are not enforced.
+type NamingConventions struct {
+	Application    NameRule `yaml:"application"`
+	ApplicationSet NameRule `yaml:"applicationSet"`
+	AppProject     NameRule `yaml:"appProject"`
+	Namespace      NameRule `yaml:"namespace"`
+}
+
+// NameRule constrains a name with a regular expression and a maximum length.
+type NameRule struct {
+	Pattern   string `yaml:"pattern"`
+	MaxLength int    `yaml:"maxLength"`
+}
+
+// Validate reports invalid patterns or lengths.
+func (n NamingConventions) Validate() error {
+	for key, rule := range map[string]NameRule{
+		"application":    n.Application,
+		"applicationSet": n.ApplicationSet,
+		"appProject":     n.AppProject,
+		"namespace":      n.Namespace,
+	} {
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("namingConventions.%s.pattern: %w", key, err)
+			}
+		}
+		if rule.MaxLength < 0 {
+			return fmt.Errorf("namingConventions.%s.maxLength must not be negative", key)
+		}
+	}
+	return nil
+}
+
+// RenderConfig configures rendering.
+type RenderConfig struct {
+	// KubeVersion is the Kubernetes version Helm charts see as
+	// .Capabilities.KubeVersion, e.g. 1.29 or v1.29.3.
+	KubeVersion string `yaml:"kubeVersion"`
+	// APIVersions are added to the API versions Helm charts see in
+	// .Capabilities.APIVersions, as group/version or group/version/Kind.
+	APIVersions []string `yaml:"apiVersions"`
+	// CMPCommands maps Config Management Plugin names to shell commands that
+	// emulate them when rendering; "*" handles plugins without a command.
+	CMPCommands map[string]string `yaml:"cmpCommands"`
+}
+
+var kubeVersionPattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?([-+].*)?$`)
+
+// Validate reports a malformed kubeVersion or empty apiVersions and
+// cmpCommands entries.
+func (r RenderConfig) Validate() error {
+	if r.KubeVersion != "" && !kubeVersionPattern.MatchString(r.KubeVersion) {
+		return fmt.Errorf("kubeVersion: invalid Kubernetes version %q (use e.g. 1.29 or v1.29.3)", r.KubeVersion)
+	}
+	for i, version := range r.APIVersions {
+		if strings.TrimSpace(version) == "" {
+			return fmt.Errorf("apiVersions[%d] must not be empty", i)
+		}
+	}
+	for name, command := range r.CMPCommands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("cmpCommands.%s must not be empty", name)
+		}
+	}
+	return nil
+}
+
+// DefaultFileName is the config file argocd-lint init writes and config
+// validate checks by default.
+const DefaultFileName = ".argocd-lint.yaml"
+
+// Load reads configuration from a file or https:// URL, layered over the
+// configs it extends: each extends entry is merged over the previous ones
+// and the file itself over all of them. Relative entries resolve against
+// the extending file. Empty path returns defaults.
 func Load(path string) (Config, error) {
+	return LoadWithOptions(path, LoadOptions{})
+}
+
+// LoadOptions controls how LoadWithOptions reads a config.
+type LoadOptions struct {
+	// Strict rejects every file in the extends chain that Validate reports
+	// errors for, such as unknown keys or invalid severities, instead of
+	// ignoring them.
+	Strict bool
+	// KnownRules lists the rule IDs strict loading accepts; nil defers the
+	// rule ID check to Config.CheckRuleIDs. Custom rules declared in the
+	// chain are always known.
+	KnownRules map[string]bool
+}
+
+// LoadWithOptions is Load with options; strict problems are returned as a
+// *StrictError.
+func LoadWithOptions(path string, opts LoadOptions) (Config, error) {
 	if path == "" {
 		return Config{}, nil
 	}
-	data, err := os.ReadFile(path)
+	return loadExtending(path, nil, opts)
+}
+
+// StrictError lists the problems that failed a strict load.
+type StrictError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *StrictError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, fmt.Sprintf("%s:%d: %s", e.Path, problem.Line, problem.Message))
+	}
+	return "strict config check failed:\n" + strings.Join(lines, "\n")
+}
+
+// ReadFile reads a config file from disk or, for https:// URLs, from the
+// fetch cache; a #sha256=<hex> fragment pins the downloaded content.
+func ReadFile(path string) ([]byte, error) {
+	if fetch.IsURL(path) {
+		local, err := fetch.File(context.Background(), path, "")
+		if err != nil {
+			return nil, err
+		}
+		path = local
+	}
+	return os.ReadFile(path)
+}
+
+// Parse decodes and validates configuration YAML, expanding ${NAME} and
+// ${NAME:-default} environment references in values (except extends) first.
+func Parse(data []byte) (Config, error) {
+	cfg, err := decode(data, true)
 	if err != nil {
-		return Config{}, fmt.Errorf("read config: %w", err)
+		return Config{}, err
 	}
+	return cfg.applyFileProfiles(nil)
+}
+
+// decode parses and validates configuration YAML without applying its
+// profiles, which may be defined in the configs it extends. Environment
+// references are expanded when expand is set; configs downloaded from URLs
+// are decoded as written, so they cannot read the local environment.
+func decode(data []byte, expand bool) (Config, error) {
 	if len(data) == 0 {
 		return Config{}, nil
 	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Config{}, fmt.Errorf("parse config: %w", err)
+	}
+	var expansion envExpansion
+	if expand {
+		var err error
+		if expansion, err = expandEnv(&root); err != nil {
+			return Config{}, fmt.Errorf("parse config: %w", err)
+		}
+	}
+	cfg, err := decodeNode(&root)
+	if err != nil && len(expansion.values) > 0 {
+		return Config{}, errors.New(expansion.redact(err.Error()))
+	}
+	return cfg, err
+}
+
+func decodeNode(root *yaml.Node) (Config, error) {
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := root.Decode(&cfg); err != nil {
 		return Config{}, fmt.Errorf("parse config: %w", err)
 	}
-	if err := cfg.ApplyProfiles(cfg.Profiles...); err != nil {
+	if err := cfg.ExitPolicy.Validate(); err != nil {
+		return Config{}, fmt.Errorf("exitPolicy: %w", err)
+	}
+	if err := cfg.Policies.NamingConventions.Validate(); err != nil {
+		return Config{}, fmt.Errorf("policies: %w", err)
+	}
+	if err := cfg.Render.Validate(); err != nil {
+		return Config{}, fmt.Errorf("render: %w", err)
+	}
+	if err := validateCustomRules(cfg.CustomRules); err != nil {
 		return Config{}, err
 	}
-	cfg.Profiles = append([]string(nil), cfg.Profiles...)
 	for i := range cfg.Waivers {
 		if err := cfg.Waivers[i].Validate(); err != nil {
 			return Config{}, fmt.Errorf("waiver %d: %w", i, err)
 		}
 	}
+	if err := cfg.WaiverPolicy.Validate(); err != nil {
+		return Config{}, fmt.Errorf("waiverPolicy: %w", err)
+	}
 	return cfg, nil
 }
 
-// Resolve merges default rule metadata with configuration overrides.
+// applyFileProfiles applies the profiles a config file selects, which may
+// also name profiles inherited from the configs it extends.
+func (cfg Config) applyFileProfiles(inherited map[string]ProfileConfig) (Config, error) {
+	if len(inherited) > 0 {
+		defined := make(map[string]ProfileConfig, len(inherited)+len(cfg.DefinedProfiles))
+		for name, profile := range inherited {
+			defined[name] = profile
+		}
+		for name, profile := range cfg.DefinedProfiles {
+			defined[name] = profile
+		}
+		cfg.DefinedProfiles = defined
+	}
+	if err := cfg.validateDefinedProfiles(); err != nil {
+		return Config{}, err
+	}
+	// An explicit waiverPolicy.maxDuration in the file wins over profile
+	// defaults.
+	explicitMaxDuration := cfg.WaiverPolicy.MaxDuration
+	if err := cfg.applyProfiles(cfg.Profiles); err != nil {
+		return Config{}, err
+	}
+	if explicitMaxDuration != "" {
+		cfg.WaiverPolicy.MaxDuration = explicitMaxDuration
+	}
+	if err := cfg.checkWaiverPolicy(time.Now()); err != nil {
+		return Config{}, err
+	}
+	cfg.Profiles = append([]string(nil), cfg.Profiles...)
+	return cfg, nil
+}
+
+// Resolve merges default rule metadata with configuration overrides. Params
+// from plugins, the rule's category, its tags (in name order), rules, and
+// matching overrides are merged in that order.
 func (c Config) Resolve(rule types.RuleMetadata, filePath string) (types.ConfiguredRule, error) {
 	result := types.ConfiguredRule{
 		Metadata: rule,
@@ -85,9 +371,38 @@ func (c Config) Resolve(rule types.RuleMetadata, filePath string) (types.Configu
 			}
 			result.Severity = sev
 		}
+		if len(rc.Params) > 0 {
+			merged := make(map[string]interface{}, len(result.Params)+len(rc.Params))
+			for key, value := range result.Params {
+				merged[key] = value
+			}
+			for key, value := range rc.Params {
+				merged[key] = value
+			}
+			result.Params = merged
+		}
 		return nil
 	}
 
+	if pluginConfig, ok := c.Plugins[rule.ID]; ok {
+		if err := apply(RuleConfig{Params: pluginConfig.Params}); err != nil {
+			return result, err
+		}
+	}
+	for name, categoryConfig := range c.Categories {
+		if rule.Category != "" && strings.EqualFold(name, rule.Category) {
+			if err := apply(categoryConfig); err != nil {
+				return result, err
+			}
+		}
+	}
+	for _, tag := range ruleConfigKeys(c.Tags) {
+		if hasTag(rule.Tags, tag) {
+			if err := apply(c.Tags[tag]); err != nil {
+				return result, err
+			}
+		}
+	}
 	if ruleConfig, ok := c.Rules[rule.ID]; ok {
 		if err := apply(ruleConfig); err != nil {
 			return result, err
@@ -109,6 +424,7 @@ func (c Config) Resolve(rule types.RuleMetadata, filePath string) (types.Configu
 			}
 		}
 	}
+	result.Enabled = c.Selection.apply(rule.ID, result.Enabled)
 	return result, nil
 }
 
@@ -128,3 +444,21 @@ func ParseSeverity(value string) (types.Severity, error) {
 		return "", errors.New("unknown severity: " + value)
 	}
 }
+
+func ruleConfigKeys(m map[string]RuleConfig) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if strings.EqualFold(candidate, tag) {
+			return true
+		}
+	}
+	return false
+}
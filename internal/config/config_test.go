@@ -1,10 +1,18 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
@@ -122,6 +130,51 @@ func TestWaiverValidation(t *testing.T) {
 	}
 }
 
+func TestResolveAppliesRuleSelection(t *testing.T) {
+	disabled := false
+	cfg := Config{
+		Rules:     map[string]RuleConfig{"AR002": {Enabled: &disabled}},
+		Selection: RuleSelection{Only: []string{"ar001"}, Enable: []string{"AR002"}, Disable: []string{"AR003"}},
+	}
+	for id, want := range map[string]bool{"AR001": true, "AR002": true, "AR003": false, "AR004": false} {
+		rule, err := cfg.Resolve(types.RuleMetadata{ID: id, DefaultSeverity: types.SeverityWarn, Enabled: true}, "apps/app.yaml")
+		if err != nil {
+			t.Fatalf("resolve %s: %v", id, err)
+		}
+		if rule.Enabled != want {
+			t.Fatalf("expected %s enabled=%t, got %t", id, want, rule.Enabled)
+		}
+	}
+}
+
+func TestResolveMergesPluginParams(t *testing.T) {
+	cfg, err := Parse([]byte(`plugins:
+  RG001:
+    params:
+      maxReplicas: 5
+      team: payments
+overrides:
+  - pattern: 'apps/batch/*.yaml'
+    rules:
+      RG001:
+        params:
+          maxReplicas: 20
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	meta := types.RuleMetadata{ID: "RG001", DefaultSeverity: types.SeverityWarn, Enabled: true}
+	for file, want := range map[string]int{"apps/web/app.yaml": 5, "apps/batch/job.yaml": 20} {
+		rule, err := cfg.Resolve(meta, file)
+		if err != nil {
+			t.Fatalf("resolve %s: %v", file, err)
+		}
+		if rule.Params["maxReplicas"] != want || rule.Params["team"] != "payments" {
+			t.Fatalf("%s: unexpected params %v", file, rule.Params)
+		}
+	}
+}
+
 func TestParseSeverityErrors(t *testing.T) {
 	if sev, err := ParseSeverity("critical"); err == nil {
 		t.Fatalf("expected error on unknown severity")
@@ -134,3 +187,606 @@ func TestParseSeverityErrors(t *testing.T) {
 		t.Fatalf("expected empty severity on error, got %q", sev)
 	}
 }
+
+func TestParseWaiverAnnotation(t *testing.T) {
+	waivers, err := ParseWaiverAnnotation("AR005:2025-12-31:legacy cluster; AR001:2026-01-31T12:00:00Z:pinned by: release team")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(waivers) != 2 {
+		t.Fatalf("expected two waivers, got %d", len(waivers))
+	}
+	if waivers[0].Rule != "AR005" || waivers[0].Expires != "2025-12-31" || waivers[0].Reason != "legacy cluster" {
+		t.Fatalf("unexpected first waiver: %+v", waivers[0])
+	}
+	if waivers[1].Expires != "2026-01-31T12:00:00Z" || waivers[1].Reason != "pinned by: release team" {
+		t.Fatalf("expected RFC3339 expiry to be split correctly, got %+v", waivers[1])
+	}
+	if _, err := ParseWaiverAnnotation("AR005:2025-12-31:"); err == nil {
+		t.Fatalf("expected missing reason to fail")
+	}
+	waivers, err = ParseWaiverAnnotation("AR005:2025-12-31:legacy cluster | ticket=OPS-12 | approvedBy=platform")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if waivers[0].Reason != "legacy cluster" || waivers[0].Ticket != "OPS-12" || waivers[0].ApprovedBy != "platform" {
+		t.Fatalf("expected ticket and approvedBy attributes, got %+v", waivers[0])
+	}
+	if _, err := ParseWaiverAnnotation("AR005:2025-12-31:legacy|owner=me"); err == nil || !strings.Contains(err.Error(), "unknown attribute") {
+		t.Fatalf("expected unknown attribute to fail, got %v", err)
+	}
+}
+
+func TestLoadRejectsInvalidNamingPattern(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := []byte("policies:\n  namingConventions:\n    application:\n      pattern: '^[a-z'\n")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "namingConventions.application.pattern") {
+		t.Fatalf("expected naming pattern error, got %v", err)
+	}
+}
+
+func TestParseRenderConfig(t *testing.T) {
+	cfg, err := Parse([]byte("render:\n  kubeVersion: v1.29.3\n  apiVersions: [monitoring.coreos.com/v1]\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Render.KubeVersion != "v1.29.3" || len(cfg.Render.APIVersions) != 1 {
+		t.Fatalf("unexpected render config: %+v", cfg.Render)
+	}
+	if _, err := Parse([]byte("render:\n  kubeVersion: latest\n")); err == nil || !strings.Contains(err.Error(), "render: kubeVersion") {
+		t.Fatalf("expected kubeVersion error, got %v", err)
+	}
+	if _, err := Parse([]byte("render:\n  cmpCommands: {sops: ''}\n")); err == nil || !strings.Contains(err.Error(), "cmpCommands.sops") {
+		t.Fatalf("expected empty cmpCommands error, got %v", err)
+	}
+}
+
+func TestLoadExitPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := []byte("exitPolicy:\n  failOn: new\n  categoryThresholds:\n    security: warn\n    '*': none\n")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.ExitPolicy.FailOn != FailOnNew {
+		t.Fatalf("expected failOn new, got %q", cfg.ExitPolicy.FailOn)
+	}
+	if sev, ok := cfg.ExitPolicy.Threshold("AR013", "security", types.SeverityError); !ok || sev != types.SeverityWarn {
+		t.Fatalf("expected security threshold warn, got %q (%t)", sev, ok)
+	}
+	if _, ok := cfg.ExitPolicy.Threshold("AR005", "operations", types.SeverityError); ok {
+		t.Fatalf("expected wildcard none to exempt other categories")
+	}
+
+	content = []byte("exitPolicy:\n  categoryThresholds:\n    security: fatal\n")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "categoryThresholds.security") {
+		t.Fatalf("expected category threshold error, got %v", err)
+	}
+}
+
+func TestValidateReportsProblemsWithLines(t *testing.T) {
+	data := []byte(`rules:
+  AR001:
+    severity: fatal
+  AR999:
+    enabled: false
+profiles: [staging]
+overrides:
+  - pattern: "apps/[prod"
+waivers:
+  - rule: AR005
+    file: "apps/*.yaml"
+    reason: legacy
+    expires: "2020-01-01"
+unknownKey: true
+plugins:
+  RG404:
+    params: {maxReplicas: 1}
+`)
+	problems := Validate(data, map[string]bool{"AR001": true, "AR005": true})
+	want := []struct {
+		line     int
+		fragment string
+		warning  bool
+	}{
+		{3, "unknown severity", false},
+		{4, "AR999: unknown rule ID", false},
+		{6, "unknown profile \"staging\"", false},
+		{8, "invalid glob", false},
+		{13, "expired on 2020-01-01", true},
+		{14, "unknown key \"unknownKey\"", false},
+		{16, "plugins.RG404: unknown rule ID", false},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), problems)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.Line != w.line || !strings.Contains(p.Message, w.fragment) || p.Warning != w.warning {
+			t.Fatalf("problem %d: expected line %d %q (warning=%t), got %+v", i, w.line, w.fragment, w.warning, p)
+		}
+	}
+	if problems := Validate([]byte("severityThreshold: warn\n"), nil); len(problems) != 0 {
+		t.Fatalf("expected a valid config to pass, got %+v", problems)
+	}
+}
+
+func TestParseRejectsInvalidCustomRules(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax":    "customRules:\n  - id: C1\n    expr: \"object.spec.(\"\n",
+		"not bool":  "customRules:\n  - id: C1\n    expr: \"name + 'x'\"\n",
+		"missing":   "customRules:\n  - id: C1\n",
+		"severity":  "customRules:\n  - id: C1\n    expr: \"true\"\n    severity: fatal\n",
+		"template":  "customRules:\n  - id: C1\n    expr: \"true\"\n    message: \"{{ .name \"\n",
+		"duplicate": "customRules:\n  - id: C1\n    expr: \"true\"\n  - id: C1\n    expr: \"false\"\n",
+		"both":      "customRules:\n  - id: C1\n    expr: \"true\"\n    path: spec.project\n",
+		"no check":  "customRules:\n  - id: C1\n    path: spec.project\n",
+		"bad path":  "customRules:\n  - id: C1\n    path: spec.sources[x]\n    required: true\n",
+		"pattern":   "customRules:\n  - id: C1\n    path: spec.project\n    pattern: \"(\"\n",
+		"forbidden": "customRules:\n  - id: C1\n    path: spec.project\n    forbidden: true\n    oneOf: [default]\n",
+	} {
+		if _, err := Parse([]byte(content)); err == nil || !strings.Contains(err.Error(), "customRules[") {
+			t.Fatalf("%s: expected a customRules error, got %v", name, err)
+		}
+	}
+	problems := Validate([]byte("customRules:\n  - id: AR001\n    expr: \"true\"\n  - id: C2\n    expr: \"kind ==\"\nrules:\n  C2:\n    severity: info\n"), map[string]bool{"AR001": true})
+	if len(problems) != 2 || problems[0].Line != 2 || problems[1].Line != 4 {
+		t.Fatalf("expected a clash on line 2 and a compile error on line 4, got %+v", problems)
+	}
+}
+
+func TestFieldPathLookup(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "web"}},
+		"spec": map[string]interface{}{"sources": []interface{}{
+			map[string]interface{}{"repoURL": "https://git.corp/a.git"},
+			map[string]interface{}{"chart": "web"},
+		}},
+	}
+	for path, want := range map[string]struct {
+		matches []string
+		missing []string
+	}{
+		"$.metadata.labels['app.kubernetes.io/name']": {matches: []string{"metadata.labels['app.kubernetes.io/name']=web"}},
+		"spec.sources[*].repoURL":                     {matches: []string{"spec.sources[0].repoURL=https://git.corp/a.git"}, missing: []string{"spec.sources[1].repoURL"}},
+		"spec.sources[1].chart":                       {matches: []string{"spec.sources[1].chart=web"}},
+		"spec.sources[2].chart":                       {missing: []string{"spec.sources[2]"}},
+		"spec.destination.namespace":                  {missing: []string{"spec.destination"}},
+	} {
+		parsed, err := ParseFieldPath(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		matches, missing := parsed.Lookup(object)
+		var got []string
+		for _, match := range matches {
+			got = append(got, fmt.Sprintf("%s=%v", match.Path, match.Value))
+		}
+		if !reflect.DeepEqual(got, want.matches) || !reflect.DeepEqual(missing, want.missing) {
+			t.Fatalf("%s: got matches %v and missing %v", path, got, missing)
+		}
+	}
+	for _, path := range []string{"", "spec..project", "spec.sources[", "spec.sources[-1]"} {
+		if _, err := ParseFieldPath(path); err == nil {
+			t.Fatalf("expected %q to be rejected", path)
+		}
+	}
+}
+
+func TestLoadFetchesHTTPSConfig(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("severityThreshold: error\n"))
+	}))
+	defer server.Close()
+	previous := fetch.Client
+	fetch.Client = server.Client()
+	defer func() { fetch.Client = previous }()
+
+	cfg, err := Load(server.URL + "/argocd-lint.yaml")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Threshold != "error" {
+		t.Fatalf("expected the remote threshold, got %q", cfg.Threshold)
+	}
+	if _, err := Load(server.URL + "/argocd-lint.yaml#sha256=" + strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestLoadMergesExtends(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/policies/base.yaml":
+			_, _ = w.Write([]byte("extends: [common.yaml]\nseverityThreshold: error\nrules:\n  AR001:\n    severity: error\n    params: {floatingRevisions: [main]}\n"))
+		case "/policies/common.yaml":
+			_, _ = w.Write([]byte("rules:\n  AR010:\n    enabled: false\npolicies:\n  allowedRepoURLDomains: [github.com]\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	previous := fetch.Client
+	fetch.Client = server.Client()
+	defer func() { fetch.Client = previous }()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"team.yaml":         "rules:\n  AR001:\n    params: {floatingRevisions: [develop]}\n",
+		".argocd-lint.yaml": "extends: [" + server.URL + "/policies/base.yaml, ./team.yaml]\nrules:\n  AR001:\n    severity: warn\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	cfg, err := Load(filepath.Join(dir, ".argocd-lint.yaml"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ar001 := cfg.Rules["AR001"]
+	if ar001.Severity != "warn" || !reflect.DeepEqual(ar001.Params["floatingRevisions"], []interface{}{"develop"}) {
+		t.Fatalf("expected the repo severity and team params to win, got %+v", ar001)
+	}
+	if enabled := cfg.Rules["AR010"].Enabled; enabled == nil || *enabled {
+		t.Fatalf("expected AR010 disabled by the nested base, got %+v", cfg.Rules["AR010"])
+	}
+	if cfg.Threshold != "error" || !reflect.DeepEqual(cfg.Policies.AllowedRepoURLDomains, []string{"github.com"}) {
+		t.Fatalf("expected base threshold and policies, got %q %v", cfg.Threshold, cfg.Policies.AllowedRepoURLDomains)
+	}
+
+	cycle := filepath.Join(dir, "cycle.yaml")
+	if err := os.WriteFile(cycle, []byte("extends: [cycle.yaml]\n"), 0o600); err != nil {
+		t.Fatalf("write cycle: %v", err)
+	}
+	if _, err := Load(cycle); err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Fatalf("expected an extends cycle error, got %v", err)
+	}
+}
+
+func TestParseExpandsEnvironmentVariables(t *testing.T) {
+	t.Setenv("ARGOCD_LINT_DOMAIN", "git.corp.example")
+	t.Setenv("ARGOCD_LINT_EMPTY", "")
+	data := []byte(`severityThreshold: ${ARGOCD_LINT_THRESHOLD:-warn}
+policies:
+  allowedRepoURLDomains: [github.com, "${ARGOCD_LINT_DOMAIN}"]
+  namingConventions:
+    application:
+      pattern: "^$${literal}$"
+      maxLength: ${ARGOCD_LINT_MAX:-53}
+waivers:
+  - rule: AR001
+    file: apps/*.yaml
+    reason: migration
+    expires: ${ARGOCD_LINT_EMPTY:-2099-01-01}
+`)
+	cfg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Threshold != "warn" || !reflect.DeepEqual(cfg.Policies.AllowedRepoURLDomains, []string{"github.com", "git.corp.example"}) {
+		t.Fatalf("expected expanded threshold and domains, got %q %v", cfg.Threshold, cfg.Policies.AllowedRepoURLDomains)
+	}
+	if naming := cfg.Policies.NamingConventions.Application; naming.MaxLength != 53 || naming.Pattern != "^${literal}$" {
+		t.Fatalf("expected a numeric default and an escaped literal, got %+v", naming)
+	}
+	if cfg.Waivers[0].Expires != "2099-01-01" {
+		t.Fatalf("expected the default for an empty variable, got %q", cfg.Waivers[0].Expires)
+	}
+	if problems := Validate(data, nil); len(problems) != 0 {
+		t.Fatalf("expected the expanded config to validate, got %+v", problems)
+	}
+
+	unset := []byte("policies:\n  allowedRepoURLDomains: [\"${ARGOCD_LINT_UNSET_DOMAIN}\"]\n")
+	if _, err := Parse(unset); err == nil || !strings.Contains(err.Error(), "ARGOCD_LINT_UNSET_DOMAIN is not set") {
+		t.Fatalf("expected an unset variable error, got %v", err)
+	}
+	if problems := Validate(unset, nil); len(problems) != 1 || problems[0].Line != 2 {
+		t.Fatalf("expected one problem on line 2, got %+v", problems)
+	}
+}
+
+func TestEnvironmentIsNotExpandedInExtendsOrRemoteConfigs(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("ARGOCD_LINT_SECRET", "s3cr3t-token")
+	var requested []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte("policies:\n  allowedRepoURLDomains: [\"${ARGOCD_LINT_SECRET}\"]\n"))
+	}))
+	defer server.Close()
+	previous := fetch.Client
+	fetch.Client = server.Client()
+	defer func() { fetch.Client = previous }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "extends: [\"" + server.URL + "/${ARGOCD_LINT_SECRET}.yaml\"]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, err := Load(path)
+	if len(requested) != 1 || strings.Contains(requested[0], "s3cr3t") || (err != nil && strings.Contains(err.Error(), "s3cr3t")) {
+		t.Fatalf("expected extends to be fetched unexpanded, requested %v (%v)", requested, err)
+	}
+
+	if err := os.WriteFile(path, []byte("extends: [\""+server.URL+"/base.yaml\"]\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Policies.AllowedRepoURLDomains; len(got) != 1 || got[0] != "${ARGOCD_LINT_SECRET}" {
+		t.Fatalf("expected the remote config to keep its reference, got %v", got)
+	}
+
+	_, err = Parse([]byte("exitPolicy:\n  failOn: ${ARGOCD_LINT_SECRET}\n"))
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("expected an error without the expanded value, got %v", err)
+	}
+	if problems := Validate([]byte("exitPolicy:\n  failOn: ${ARGOCD_LINT_SECRET}\n"), nil); len(problems) == 0 || strings.Contains(problems[0].Message, "s3cr3t") {
+		t.Fatalf("expected a problem without the expanded value, got %+v", problems)
+	}
+}
+
+func TestDefinedProfiles(t *testing.T) {
+	cfg, err := Parse([]byte(`definedProfiles:
+  staging:
+    profiles: [prod]
+    threshold: warn
+    rules:
+      AR001: {severity: warn}
+      AR010: {enabled: false}
+  nightly:
+    profiles: [staging]
+    rules:
+      AR005: {severity: error}
+profiles: [nightly]
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Threshold != "warn" || cfg.Rules["AR001"].Severity != "warn" || cfg.Rules["AR007"].Severity != "error" || cfg.Rules["AR005"].Severity != "error" {
+		t.Fatalf("expected nightly to compose staging over prod, got threshold %q rules %+v", cfg.Threshold, cfg.Rules)
+	}
+	if enabled := cfg.Rules["AR010"].Enabled; enabled == nil || *enabled {
+		t.Fatalf("expected staging to disable AR010, got %+v", cfg.Rules["AR010"])
+	}
+
+	selected, err := Parse([]byte("definedProfiles:\n  Staging: {threshold: info}\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := selected.ApplyProfiles("staging"); err != nil || selected.Threshold != "info" {
+		t.Fatalf("expected --profile staging to select the defined profile, got %q (%v)", selected.Threshold, err)
+	}
+	if names := selected.ProfileNames(); !reflect.DeepEqual(names, []string{"Staging", "dev", "hardening", "prod", "security"}) {
+		t.Fatalf("unexpected profile names %v", names)
+	}
+
+	for name, content := range map[string]string{
+		"cycle":     "definedProfiles:\n  a: {profiles: [b]}\n  b: {profiles: [a]}\n",
+		"unknown":   "definedProfiles:\n  a: {profiles: [qa]}\n",
+		"severity":  "definedProfiles:\n  a: {threshold: loud}\n",
+		"selection": "profiles: [qa]\n",
+	} {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+	problems := Validate([]byte("definedProfiles:\n  a:\n    profiles: [qa]\nprofiles: [a, b]\n"), nil)
+	if len(problems) != 2 || problems[0].Line != 2 || problems[1].Line != 4 || !strings.Contains(problems[1].Message, `unknown profile "b"`) {
+		t.Fatalf("expected the unknown composed and selected profiles, got %+v", problems)
+	}
+}
+
+func TestLoadAppliesProfilesDefinedInExtendedConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml":         "definedProfiles:\n  staging: {profiles: [dev], threshold: info}\n",
+		".argocd-lint.yaml": "extends: [base.yaml]\nprofiles: [staging]\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	cfg, err := Load(filepath.Join(dir, ".argocd-lint.yaml"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Threshold != "info" || cfg.Rules["AR005"].Severity != "info" {
+		t.Fatalf("expected the inherited staging profile, got threshold %q rules %+v", cfg.Threshold, cfg.Rules)
+	}
+	if err := cfg.ApplyProfiles("staging"); err != nil {
+		t.Fatalf("expected --profile to see inherited profiles: %v", err)
+	}
+}
+
+func TestResolveAppliesCategoriesAndTags(t *testing.T) {
+	cfg, err := Parse([]byte(`categories:
+  Security: {severity: error}
+  advisory: {enabled: false}
+tags:
+  pci:
+    severity: info
+    params: {strict: true}
+rules:
+  AR013: {severity: warn}
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	cases := []struct {
+		meta     types.RuleMetadata
+		severity types.Severity
+		enabled  bool
+	}{
+		{types.RuleMetadata{ID: "AR014", Category: "security", DefaultSeverity: types.SeverityWarn, Enabled: true}, types.SeverityError, true},
+		{types.RuleMetadata{ID: "AR013", Category: "security", DefaultSeverity: types.SeverityWarn, Enabled: true}, types.SeverityWarn, true},
+		{types.RuleMetadata{ID: "AR044", Category: "advisory", DefaultSeverity: types.SeverityInfo, Enabled: true}, types.SeverityInfo, false},
+		{types.RuleMetadata{ID: "RG001", Category: "security", Tags: []string{"PCI"}, DefaultSeverity: types.SeverityWarn, Enabled: true}, types.SeverityInfo, true},
+	}
+	for _, tc := range cases {
+		rule, err := cfg.Resolve(tc.meta, "apps/app.yaml")
+		if err != nil {
+			t.Fatalf("resolve %s: %v", tc.meta.ID, err)
+		}
+		if rule.Severity != tc.severity || rule.Enabled != tc.enabled {
+			t.Fatalf("%s: expected %s enabled=%t, got %s enabled=%t", tc.meta.ID, tc.severity, tc.enabled, rule.Severity, rule.Enabled)
+		}
+	}
+	if rule, _ := cfg.Resolve(cases[3].meta, "apps/app.yaml"); rule.Params["strict"] != true {
+		t.Fatalf("expected tag params, got %v", rule.Params)
+	}
+	if problems := Validate([]byte("tags:\n  pci: {severity: loud}\n"), nil); len(problems) != 1 || !strings.Contains(problems[0].Message, "tags.pci.severity") {
+		t.Fatalf("expected an invalid tag severity, got %+v", problems)
+	}
+}
+
+func TestLoadWithOptionsStrict(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml":         "customRules:\n  - id: CORP001\n    expr: \"true\"\n    message: ok\n",
+		".argocd-lint.yaml": "extends: [base.yaml]\nrules:\n  CORP001: {severity: warn}\n  AR01: {enabled: false}\n  AR002: {severity: fatal}\nsevrityThreshold: warn\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	path := filepath.Join(dir, ".argocd-lint.yaml")
+	if _, err := Load(path); err != nil {
+		t.Fatalf("expected lenient load to succeed, got %v", err)
+	}
+	_, err := LoadWithOptions(path, LoadOptions{Strict: true, KnownRules: map[string]bool{"AR001": true, "AR002": true}})
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected a strict error, got %v", err)
+	}
+	want := []string{
+		path + ":4: rules.AR01: unknown rule ID",
+		path + ":5: rules.AR002.severity: unknown severity: fatal",
+		path + ":6: unknown key \"sevrityThreshold\"",
+	}
+	for _, line := range want {
+		if !strings.Contains(err.Error(), line) {
+			t.Fatalf("expected %q in:\n%v", line, err)
+		}
+	}
+	if len(strictErr.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), strictErr.Problems)
+	}
+}
+
+func TestCheckRuleIDsAfterStrictLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml":         "rules:\n  AR01: {enabled: false}\n",
+		".argocd-lint.yaml": "extends: [base.yaml]\nrules:\n  AR002: {severity: warn}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	path := filepath.Join(dir, ".argocd-lint.yaml")
+	known := map[string]bool{"AR001": true, "AR002": true}
+	cfg, err := LoadWithOptions(path, LoadOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("expected the rule ID check to be deferred, got %v", err)
+	}
+	err = cfg.CheckRuleIDs(known)
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) || strictErr.Path != filepath.Join(dir, "base.yaml") || !strings.Contains(err.Error(), ":2: rules.AR01: unknown rule ID") {
+		t.Fatalf("expected the unknown rule ID in base.yaml, got %v", err)
+	}
+	if lenient, err := Load(path); err != nil || lenient.CheckRuleIDs(known) != nil {
+		t.Fatalf("expected a lenient load to skip the rule ID check, got %v", err)
+	}
+}
+
+func TestWaiverPolicy(t *testing.T) {
+	soon := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	late := time.Now().AddDate(0, 0, 200).Format("2006-01-02")
+	waiver := func(expires, extra string) string {
+		return "waivers:\n  - rule: AR001\n    file: apps/*.yaml\n    reason: migration\n    expires: \"" + expires + "\"\n" + extra
+	}
+
+	if _, err := Parse([]byte(waiver(late, "waiverPolicy: {maxDuration: 90d}\n"))); err == nil || !strings.Contains(err.Error(), "more than waiverPolicy.maxDuration 90d") {
+		t.Fatalf("expected a waiver beyond maxDuration to be rejected, got %v", err)
+	}
+	if _, err := Parse([]byte(waiver(soon, "waiverPolicy: {maxDuration: 2 weeks}\n"))); err == nil || !strings.Contains(err.Error(), "waiverPolicy: maxDuration") {
+		t.Fatalf("expected an invalid maxDuration, got %v", err)
+	}
+	if _, err := Parse([]byte(waiver(soon, "profiles: [prod]\n"))); err == nil || !strings.Contains(err.Error(), "ticket is required") {
+		t.Fatalf("expected the prod profile to require a ticket, got %v", err)
+	}
+	cfg, err := Parse([]byte(waiver(late, "    ticket: SEC-1\n    approvedBy: alice\nprofiles: [prod]\nwaiverPolicy: {maxDuration: 365d}\n")))
+	if err != nil {
+		t.Fatalf("expected an explicit maxDuration to win over the profile, got %v", err)
+	}
+	if cfg.Waivers[0].Ticket != "SEC-1" || cfg.Waivers[0].ApprovedBy != "alice" || !cfg.WaiverPolicy.RequireTicket {
+		t.Fatalf("unexpected waiver config: %+v %+v", cfg.Waivers[0], cfg.WaiverPolicy)
+	}
+
+	lenient, err := Parse([]byte(waiver(soon, "")))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := lenient.ApplyProfiles("hardening"); err == nil || !strings.Contains(err.Error(), "waiver AR001 on apps/*.yaml: ticket is required") {
+		t.Fatalf("expected --profile hardening to enforce tickets, got %v", err)
+	}
+
+	problems := Validate([]byte(waiver(late, "profiles: [prod]\n")), nil)
+	if len(problems) != 1 || problems[0].Line != 2 || !strings.Contains(problems[0].Message, "waivers[0]: ticket is required") {
+		t.Fatalf("expected a waiver policy problem, got %+v", problems)
+	}
+}
+
+func TestRuleThresholds(t *testing.T) {
+	cfg, err := Parse([]byte("severityThreshold: error\nexitPolicy:\n  categoryThresholds:\n    security: warn\n    AR044: none\n    \"*\": error\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		rule, category string
+		want           types.Severity
+		fails          bool
+	}{
+		{"AR013", "security", types.SeverityWarn, true},
+		{"ar044", "security", "", false},
+		{"AR044", "advisory", "", false},
+		{"AR020", "Advisory", types.SeverityError, true},
+	}
+	for _, tc := range cases {
+		got, fails := cfg.ExitPolicy.Threshold(tc.rule, tc.category, types.SeverityInfo)
+		if got != tc.want || fails != tc.fails {
+			t.Fatalf("%s/%s: expected %s %t, got %s %t", tc.rule, tc.category, tc.want, tc.fails, got, fails)
+		}
+	}
+	cfg.OverrideThreshold("warn")
+	if got, _ := cfg.ExitPolicy.Threshold("AR021", "operations", types.SeverityInfo); got != types.SeverityInfo || cfg.Threshold != "warn" {
+		t.Fatalf("expected --severity-threshold to replace the * entry, got %s", got)
+	}
+	if got, _ := cfg.ExitPolicy.Threshold("AR013", "security", types.SeverityInfo); got != types.SeverityWarn {
+		t.Fatalf("expected --severity-threshold to keep rule and category entries, got %s", got)
+	}
+	if _, err := Parse([]byte("exitPolicy:\n  categoryThresholds: {AR013: loud}\n")); err == nil || !strings.Contains(err.Error(), "categoryThresholds.AR013") {
+		t.Fatalf("expected an invalid threshold, got %v", err)
+	}
+}
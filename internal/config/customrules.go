@@ -0,0 +1,288 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/google/cel-go/cel"
+)
+
+// CustomRule is a declarative rule defined in the config file. It is either
+// a CEL rule, where Expr must evaluate to true for a manifest to pass, or a
+// path rule, where the field selected by Path must satisfy Required,
+// Forbidden, Pattern, and OneOf. Message is a Go template rendered for each
+// violation.
+type CustomRule struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Severity    string   `yaml:"severity"`
+	AppliesTo   []string `yaml:"appliesTo"`
+	Category    string   `yaml:"category"`
+	Tags        []string `yaml:"tags"`
+	HelpURL     string   `yaml:"helpUrl"`
+	Expr        string   `yaml:"expr"`
+	Path        string   `yaml:"path"`
+	Required    bool     `yaml:"required"`
+	Forbidden   bool     `yaml:"forbidden"`
+	Pattern     string   `yaml:"pattern"`
+	OneOf       []string `yaml:"oneOf"`
+	Message     string   `yaml:"message"`
+}
+
+// CompiledRule is a checked CustomRule ready for evaluation. Program is set
+// for CEL rules and Path for path rules.
+type CompiledRule struct {
+	Program cel.Program
+	Path    FieldPath
+	Pattern *regexp.Regexp
+	Message *template.Template
+}
+
+var customRuleIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+var customRuleKinds = map[string]bool{"Application": true, "ApplicationSet": true, "AppProject": true}
+
+// CELEnv returns the environment custom rule expressions are compiled in:
+// object is the whole manifest, and file, kind, name, and namespace are
+// shortcuts for its location and identity.
+func CELEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("file", cel.StringType),
+		cel.Variable("kind", cel.StringType),
+		cel.Variable("name", cel.StringType),
+		cel.Variable("namespace", cel.StringType),
+	)
+}
+
+// Compile checks the rule definition and prepares it for evaluation.
+func (r CustomRule) Compile() (*CompiledRule, error) {
+	if !customRuleIDPattern.MatchString(r.ID) {
+		return nil, fmt.Errorf("id %q must start with a letter and contain only letters, digits, '-' or '_'", r.ID)
+	}
+	if r.Severity != "" {
+		if _, err := ParseSeverity(r.Severity); err != nil {
+			return nil, fmt.Errorf("severity: %w", err)
+		}
+	}
+	for _, kind := range r.AppliesTo {
+		if !customRuleKinds[kind] {
+			return nil, fmt.Errorf("appliesTo: unknown kind %q (expected Application, ApplicationSet, or AppProject)", kind)
+		}
+	}
+	compiled := &CompiledRule{}
+	expr, path := strings.TrimSpace(r.Expr), strings.TrimSpace(r.Path)
+	switch {
+	case expr != "" && path != "":
+		return nil, fmt.Errorf("set either expr or path, not both")
+	case expr != "":
+		if r.Required || r.Forbidden || r.Pattern != "" || len(r.OneOf) > 0 {
+			return nil, fmt.Errorf("required, forbidden, pattern, and oneOf only apply to path rules")
+		}
+		env, err := CELEnv()
+		if err != nil {
+			return nil, err
+		}
+		ast, issues := env.Compile(r.Expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("expr: %w", issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, fmt.Errorf("expr must return a bool, got %s", ast.OutputType())
+		}
+		if compiled.Program, err = env.Program(ast); err != nil {
+			return nil, fmt.Errorf("expr: %w", err)
+		}
+	case path != "":
+		var err error
+		if compiled.Path, err = ParseFieldPath(path); err != nil {
+			return nil, fmt.Errorf("path: %w", err)
+		}
+		if !r.Required && !r.Forbidden && r.Pattern == "" && len(r.OneOf) == 0 {
+			return nil, fmt.Errorf("path rules need at least one of required, forbidden, pattern, or oneOf")
+		}
+		if r.Forbidden && (r.Required || r.Pattern != "" || len(r.OneOf) > 0) {
+			return nil, fmt.Errorf("forbidden cannot be combined with required, pattern, or oneOf")
+		}
+		if r.Pattern != "" {
+			if compiled.Pattern, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("pattern: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expr or path is required")
+	}
+	message := r.Message
+	if message == "" {
+		message = r.Description
+	}
+	if message == "" && compiled.Program != nil {
+		message = fmt.Sprintf("%s: expression %s is false", r.ID, r.Expr)
+	}
+	if message != "" {
+		tmpl, err := template.New(r.ID).Option("missingkey=zero").Parse(message)
+		if err != nil {
+			return nil, fmt.Errorf("message: %w", err)
+		}
+		compiled.Message = tmpl
+	}
+	return compiled, nil
+}
+
+func validateCustomRules(rules []CustomRule) error {
+	seen := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if _, err := rule.Compile(); err != nil {
+			return fmt.Errorf("customRules[%d]: %w", i, err)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("customRules[%d]: duplicate id %q", i, rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+	return nil
+}
+
+// FieldPath selects fields in a manifest. Segments are map keys, list
+// indexes, or "*" for every list item or map value.
+type FieldPath []pathSegment
+
+type pathSegment struct {
+	key   string
+	index int
+	// kind is one of "key", "index", or "*".
+	kind string
+}
+
+// FieldMatch is a field selected by a FieldPath.
+type FieldMatch struct {
+	Path  string
+	Value interface{}
+}
+
+// ParseFieldPath parses a JSONPath-style field selector such as
+// spec.sources[*].repoURL or metadata.labels['app.kubernetes.io/name']. A
+// leading "$." is optional.
+func ParseFieldPath(path string) (FieldPath, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	var segments FieldPath
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				segments = append(segments, pathSegment{kind: "*"})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, pathSegment{kind: "key", key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index [%s] in %q", inner, path)
+				}
+				segments = append(segments, pathSegment{kind: "index", index: index})
+			}
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' {
+				return nil, fmt.Errorf("empty segment in %q", path)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "*" {
+				segments = append(segments, pathSegment{kind: "*"})
+			} else {
+				segments = append(segments, pathSegment{kind: "key", key: key})
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return segments, nil
+}
+
+// Lookup returns the fields the path selects in object, and the concrete
+// paths of fields that are missing. Wildcards expand over the items present,
+// so spec.sources[*].repoURL reports each source without a repoURL.
+func (p FieldPath) Lookup(object map[string]interface{}) (matches []FieldMatch, missing []string) {
+	var walk func(value interface{}, segments FieldPath, prefix string)
+	walk = func(value interface{}, segments FieldPath, prefix string) {
+		if len(segments) == 0 {
+			matches = append(matches, FieldMatch{Path: prefix, Value: value})
+			return
+		}
+		segment := segments[0]
+		switch segment.kind {
+		case "key":
+			next := joinFieldPath(prefix, segment.key)
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				missing = append(missing, next)
+				return
+			}
+			child, ok := m[segment.key]
+			if !ok || child == nil {
+				missing = append(missing, next)
+				return
+			}
+			walk(child, segments[1:], next)
+		case "index":
+			next := fmt.Sprintf("%s[%d]", prefix, segment.index)
+			list, ok := value.([]interface{})
+			if !ok || segment.index >= len(list) {
+				missing = append(missing, next)
+				return
+			}
+			walk(list[segment.index], segments[1:], next)
+		default:
+			switch typed := value.(type) {
+			case []interface{}:
+				for i, item := range typed {
+					walk(item, segments[1:], fmt.Sprintf("%s[%d]", prefix, i))
+				}
+			case map[string]interface{}:
+				for _, key := range sortedKeys(typed) {
+					walk(typed[key], segments[1:], joinFieldPath(prefix, key))
+				}
+			default:
+				missing = append(missing, prefix+"[*]")
+			}
+		}
+	}
+	walk(object, p, "")
+	return matches, missing
+}
+
+func joinFieldPath(prefix, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s['%s']", prefix, key)
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
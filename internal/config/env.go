@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern matches $${...} escapes and ${NAME} or ${NAME:-default}
+// references.
+var envPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// lookupEnv resolves environment variables; tests may replace it.
+var lookupEnv = os.LookupEnv
+
+// envExpansion records what expandEnv changed.
+type envExpansion struct {
+	// lines are the lines of the expanded values.
+	lines []int
+	// values are the values read from the environment, which errors must
+	// not echo.
+	values []string
+}
+
+// expandEnv replaces environment references in the scalar values of node,
+// not in mapping keys or the top-level extends list, whose entries may be
+// URLs. ${NAME:-default} uses default when NAME is unset or empty, $${
+// yields a literal ${, and ${NAME} without a default must be set. Plain
+// scalars are re-typed after expansion, so ${RETRIES:-3} decodes as a
+// number where one is expected.
+func expandEnv(node *yaml.Node) (envExpansion, error) {
+	var out envExpansion
+	var walk func(n *yaml.Node, isKey, top bool) error
+	walk = func(n *yaml.Node, isKey, top bool) error {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, child := range n.Content {
+				if err := walk(child, false, true); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for _, child := range n.Content {
+				if err := walk(child, false, false); err != nil {
+					return err
+				}
+			}
+		case yaml.MappingNode:
+			for i, child := range n.Content {
+				if top && i%2 == 1 && n.Content[i-1].Value == "extends" {
+					continue
+				}
+				if err := walk(child, i%2 == 0, false); err != nil {
+					return err
+				}
+			}
+		case yaml.ScalarNode:
+			if isKey || !strings.Contains(n.Value, "${") {
+				return nil
+			}
+			value, used, err := expandString(n.Value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			n.Value = value
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+			out.lines = append(out.lines, n.Line)
+			out.values = append(out.values, used...)
+		}
+		return nil
+	}
+	return out, walk(node, false, false)
+}
+
+// redact replaces the environment values in msg, so errors about expanded
+// values do not print secrets. Values shorter than four characters are kept
+// to leave line numbers and short settings readable.
+func (e envExpansion) redact(msg string) string {
+	for _, value := range e.values {
+		if len(value) >= 4 {
+			msg = strings.ReplaceAll(msg, value, "<redacted>")
+		}
+	}
+	return msg
+}
+
+func expandString(value string) (string, []string, error) {
+	var missing string
+	var used []string
+	expanded := envPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := envPattern.FindStringSubmatch(ref)
+		if v, ok := lookupEnv(m[1]); ok && (v != "" || m[2] == "") {
+			used = append(used, v)
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		if missing == "" {
+			missing = m[1]
+		}
+		return ""
+	})
+	if missing != "" {
+		return "", nil, fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a fallback)", missing, missing)
+	}
+	return expanded, used, nil
+}
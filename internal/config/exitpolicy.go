@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// Exit policy modes accepted by ExitPolicy.FailOn and --fail-on.
+const (
+	FailOnThreshold = "threshold"
+	FailOnNew       = "new"
+	FailOnNone      = "none"
+)
+
+// ExitPolicy decides which findings make a run exit non-zero.
+type ExitPolicy struct {
+	// FailOn is "threshold" (default), "new" (only findings not covered by
+	// the baseline), or "none" (report-only).
+	FailOn string `yaml:"failOn"`
+	// CategoryThresholds overrides severityThreshold per rule ID or rule
+	// category. The value "none" never fails and the "*" key matches
+	// findings no other key covers.
+	CategoryThresholds map[string]string `yaml:"categoryThresholds"`
+}
+
+// Validate reports unknown modes and severities.
+func (p ExitPolicy) Validate() error {
+	if _, err := ParseFailOn(p.FailOn); err != nil {
+		return err
+	}
+	for category, value := range p.CategoryThresholds {
+		if strings.EqualFold(strings.TrimSpace(value), FailOnNone) {
+			continue
+		}
+		if _, err := ParseSeverity(value); err != nil {
+			return fmt.Errorf("categoryThresholds.%s: %w", category, err)
+		}
+	}
+	return nil
+}
+
+// ParseFailOn normalizes a fail-on mode; empty means threshold.
+func ParseFailOn(value string) (string, error) {
+	switch norm := strings.ToLower(strings.TrimSpace(value)); norm {
+	case "", FailOnThreshold:
+		return FailOnThreshold, nil
+	case FailOnNew, FailOnNone:
+		return norm, nil
+	default:
+		return "", fmt.Errorf("unknown fail-on mode %q (expected threshold, new, or none)", value)
+	}
+}
+
+// Threshold returns the severity at which a finding of ruleID in category
+// fails the run: the CategoryThresholds entry for the rule ID, then for the
+// category (both case-insensitive), then the "*" entry, and finally
+// fallback. ok is false when such findings never fail the run.
+func (p ExitPolicy) Threshold(ruleID, category string, fallback types.Severity) (types.Severity, bool) {
+	for _, key := range []string{ruleID, category, "*"} {
+		if key == "" {
+			continue
+		}
+		if value, found := lookupThreshold(p.CategoryThresholds, key); found {
+			return parseThreshold(value, fallback)
+		}
+	}
+	return fallback, true
+}
+
+// OverrideThreshold applies a --severity-threshold flag: it replaces
+// severityThreshold and the categoryThresholds "*" entry, which stands in
+// for it in the config.
+func (cfg *Config) OverrideThreshold(value string) {
+	cfg.Threshold = value
+	if _, found := cfg.ExitPolicy.CategoryThresholds["*"]; !found {
+		return
+	}
+	thresholds := make(map[string]string, len(cfg.ExitPolicy.CategoryThresholds))
+	for key, v := range cfg.ExitPolicy.CategoryThresholds {
+		if key != "*" {
+			thresholds[key] = v
+		}
+	}
+	cfg.ExitPolicy.CategoryThresholds = thresholds
+}
+
+// parseThreshold parses a threshold value; ok is false for "none".
+func parseThreshold(value string, fallback types.Severity) (types.Severity, bool) {
+	if strings.EqualFold(strings.TrimSpace(value), FailOnNone) {
+		return "", false
+	}
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return fallback, true
+	}
+	return severity, true
+}
+
+func lookupThreshold(thresholds map[string]string, key string) (string, bool) {
+	if value, ok := thresholds[key]; ok {
+		return value, true
+	}
+	for name, value := range thresholds {
+		if strings.EqualFold(name, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
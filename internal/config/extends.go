@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+)
+
+// loadExtending reads the config at path and the configs it extends. chain
+// holds the files being loaded, to reject cycles.
+func loadExtending(path string, chain []string, opts LoadOptions) (Config, error) {
+	for _, loading := range chain {
+		if loading == path {
+			return Config{}, fmt.Errorf("extends cycle: %s", path)
+		}
+	}
+	data, err := ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	cfg, err := decode(data, !fetch.IsURL(path))
+	if err != nil {
+		if opts.Strict {
+			if strictErr := checkStrict(path, data, opts.KnownRules, nil); strictErr != nil {
+				return Config{}, strictErr
+			}
+		}
+		return Config{}, err
+	}
+	chain = append(chain, path)
+	var merged Config
+	var files []strictFile
+	for _, ref := range cfg.Extends {
+		base, err := loadExtending(resolveExtends(path, ref), chain, opts)
+		if err != nil {
+			return Config{}, fmt.Errorf("extends %s: %w", ref, err)
+		}
+		merged = Merge(merged, base)
+		files = append(files, base.strictFiles...)
+	}
+	if opts.Strict {
+		if err := checkStrict(path, data, opts.KnownRules, merged.CustomRules); err != nil {
+			return Config{}, err
+		}
+		if opts.KnownRules == nil {
+			files = append(files, strictFile{path: path, data: data, inherited: merged.CustomRules})
+		}
+	}
+	if cfg, err = cfg.applyFileProfiles(merged.DefinedProfiles); err != nil {
+		return Config{}, err
+	}
+	if len(cfg.Extends) == 0 {
+		cfg.strictFiles = files
+		return cfg, nil
+	}
+	out := Merge(merged, cfg)
+	if err := out.checkWaiverPolicy(time.Now()); err != nil {
+		return Config{}, err
+	}
+	out.strictFiles = files
+	return out, nil
+}
+
+// strictFile is a file of the extends chain kept by a strict load for the
+// deferred rule ID check.
+type strictFile struct {
+	path      string
+	data      []byte
+	inherited []CustomRule
+}
+
+// CheckRuleIDs finishes a strict load made without KnownRules: it returns a
+// *StrictError when a file of the extends chain configures a rule ID that
+// is not in knownRules. Callers register plugin rules first, so that their
+// IDs are known, and load the config only once.
+func (cfg Config) CheckRuleIDs(knownRules map[string]bool) error {
+	for _, file := range cfg.strictFiles {
+		if err := checkStrict(file.path, file.data, knownRules, file.inherited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStrict returns a *StrictError when Validate reports errors for data.
+// Custom rules from the configs it extends count as known rule IDs.
+func checkStrict(path string, data []byte, knownRules map[string]bool, inherited []CustomRule) error {
+	if knownRules != nil && len(inherited) > 0 {
+		extended := make(map[string]bool, len(knownRules)+len(inherited))
+		for id := range knownRules {
+			extended[id] = true
+		}
+		for _, rule := range inherited {
+			extended[rule.ID] = true
+		}
+		knownRules = extended
+	}
+	var errs []Problem
+	for _, problem := range validate(data, knownRules, !fetch.IsURL(path)) {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &StrictError{Path: path, Problems: errs}
+}
+
+// resolveExtends resolves an extends entry against the file that declares
+// it: relative paths are relative to its directory, or to its URL when it
+// was downloaded.
+func resolveExtends(parent, ref string) string {
+	if fetch.IsURL(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+	if fetch.IsURL(parent) {
+		base, err := url.Parse(parent)
+		if err != nil {
+			return ref
+		}
+		rel, err := url.Parse(filepath.ToSlash(ref))
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(rel).String()
+	}
+	return filepath.Join(filepath.Dir(parent), ref)
+}
+
+// Merge layers over on top of base. Rule and plugin settings merge per rule
+// ID, and category and tag settings per name, with params merged per key;
+// scalar settings and defined profiles from over win when set, and waiver
+// policy requirements add up; lists of overrides, waivers, and schema
+// directories are appended, and a custom rule replaces the base rule with
+// the same ID.
+func Merge(base, over Config) Config {
+	out := base
+	out.Extends = over.Extends
+	out.Rules = mergeRules(base.Rules, over.Rules)
+	out.Categories = mergeRules(base.Categories, over.Categories)
+	out.Tags = mergeRules(base.Tags, over.Tags)
+	out.Overrides = append(append([]Override(nil), base.Overrides...), over.Overrides...)
+	if over.Threshold != "" {
+		out.Threshold = over.Threshold
+	}
+	if over.ExitPolicy.FailOn != "" {
+		out.ExitPolicy.FailOn = over.ExitPolicy.FailOn
+	}
+	out.ExitPolicy.CategoryThresholds = mergeStrings(base.ExitPolicy.CategoryThresholds, over.ExitPolicy.CategoryThresholds)
+	out.Policies = mergePolicies(base.Policies, over.Policies)
+	out.Profiles = appendUnique(base.Profiles, over.Profiles)
+	if len(base.DefinedProfiles)+len(over.DefinedProfiles) > 0 {
+		out.DefinedProfiles = make(map[string]ProfileConfig, len(base.DefinedProfiles)+len(over.DefinedProfiles))
+		for name, profile := range base.DefinedProfiles {
+			out.DefinedProfiles[name] = profile
+		}
+		for name, profile := range over.DefinedProfiles {
+			out.DefinedProfiles[name] = profile
+		}
+	}
+	out.Waivers = append(append([]Waiver(nil), base.Waivers...), over.Waivers...)
+	out.WaiverPolicy = base.WaiverPolicy.merge(over.WaiverPolicy)
+	out.CustomRules = mergeCustomRules(base.CustomRules, over.CustomRules)
+	if len(base.Plugins)+len(over.Plugins) > 0 {
+		out.Plugins = make(map[string]PluginConfig, len(base.Plugins)+len(over.Plugins))
+		for id, plugin := range base.Plugins {
+			out.Plugins[id] = plugin
+		}
+		for id, plugin := range over.Plugins {
+			out.Plugins[id] = PluginConfig{Params: mergeParams(out.Plugins[id].Params, plugin.Params)}
+		}
+	}
+	if over.Render.KubeVersion != "" {
+		out.Render.KubeVersion = over.Render.KubeVersion
+	}
+	if len(over.Render.APIVersions) > 0 {
+		out.Render.APIVersions = over.Render.APIVersions
+	}
+	out.Render.CMPCommands = mergeStrings(base.Render.CMPCommands, over.Render.CMPCommands)
+	out.CRDSchemas = appendUnique(base.CRDSchemas, over.CRDSchemas)
+	out.Selection = over.Selection
+	return out
+}
+
+func mergeRules(base, over map[string]RuleConfig) map[string]RuleConfig {
+	if len(base)+len(over) == 0 {
+		return nil
+	}
+	out := make(map[string]RuleConfig, len(base)+len(over))
+	for id, rule := range base {
+		out[id] = rule
+	}
+	for id, rule := range over {
+		merged := out[id]
+		if rule.Enabled != nil {
+			merged.Enabled = rule.Enabled
+		}
+		if rule.Severity != "" {
+			merged.Severity = rule.Severity
+		}
+		merged.Params = mergeParams(merged.Params, rule.Params)
+		out[id] = merged
+	}
+	return out
+}
+
+func mergeParams(base, over map[string]interface{}) map[string]interface{} {
+	if len(over) == 0 {
+		return base
+	}
+	out := make(map[string]interface{}, len(base)+len(over))
+	for key, value := range base {
+		out[key] = value
+	}
+	for key, value := range over {
+		out[key] = value
+	}
+	return out
+}
+
+func mergeStrings(base, over map[string]string) map[string]string {
+	if len(over) == 0 {
+		return base
+	}
+	out := make(map[string]string, len(base)+len(over))
+	for key, value := range base {
+		out[key] = value
+	}
+	for key, value := range over {
+		out[key] = value
+	}
+	return out
+}
+
+// mergePolicies takes each policy list or naming rule from over when set.
+func mergePolicies(base, over PolicyConfig) PolicyConfig {
+	out := base
+	if len(over.AllowedRepoURLProtocols) > 0 {
+		out.AllowedRepoURLProtocols = over.AllowedRepoURLProtocols
+	}
+	for _, pair := range []struct{ dst, src *[]string }{
+		{&out.AllowedRepoURLDomains, &over.AllowedRepoURLDomains},
+		{&out.BlockedRepoURLDomains, &over.BlockedRepoURLDomains},
+		{&out.AllowedDestinationServers, &over.AllowedDestinationServers},
+		{&out.AllowedDestinationNamespaces, &over.AllowedDestinationNamespaces},
+		{&out.BlockedNamespaces, &over.BlockedNamespaces},
+		{&out.AllowedProjects, &over.AllowedProjects},
+		{&out.RequiredAnnotations, &over.RequiredAnnotations},
+	} {
+		if len(*pair.src) > 0 {
+			*pair.dst = *pair.src
+		}
+	}
+	for _, pair := range []struct{ dst, src *NameRule }{
+		{&out.NamingConventions.Application, &over.NamingConventions.Application},
+		{&out.NamingConventions.ApplicationSet, &over.NamingConventions.ApplicationSet},
+		{&out.NamingConventions.AppProject, &over.NamingConventions.AppProject},
+		{&out.NamingConventions.Namespace, &over.NamingConventions.Namespace},
+	} {
+		if pair.src.Pattern != "" {
+			pair.dst.Pattern = pair.src.Pattern
+		}
+		if pair.src.MaxLength != 0 {
+			pair.dst.MaxLength = pair.src.MaxLength
+		}
+	}
+	return out
+}
+
+func mergeCustomRules(base, over []CustomRule) []CustomRule {
+	out := append([]CustomRule(nil), base...)
+	for _, rule := range over {
+		replaced := false
+		for i := range out {
+			if out[i].ID == rule.ID {
+				out[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+func appendUnique(base, over []string) []string {
+	out := append([]string(nil), base...)
+	for _, value := range over {
+		found := false
+		for _, existing := range out {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, value)
+		}
+	}
+	return out
+}
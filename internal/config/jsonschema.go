@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaID is the $id of the config JSON Schema published in the repository.
+const SchemaID = "https://raw.githubusercontent.com/argocd-lint/argocd-lint/main/docs/config.schema.json"
+
+// severityKeys are string fields whose values must be a severity.
+var severityKeys = map[string]bool{"severity": true, "severityThreshold": true, "threshold": true}
+
+// severitySchema accepts a severity in any case, as ParseSeverity does,
+// while the enum keeps the canonical spellings for editor completion.
+var severitySchema = []interface{}{
+	map[string]interface{}{"enum": []string{"info", "warn", "error"}},
+	map[string]interface{}{"pattern": `^\s*([Ii][Nn][Ff][Oo]|[Ww][Aa][Rr][Nn]|[Ee][Rr][Rr][Oo][Rr])\s*$`},
+}
+
+// JSONSchema returns a JSON Schema (draft-07) for the config file, generated
+// from the Config struct so editors can complete and check it. Unknown keys
+// are rejected, as in Validate.
+func JSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaID
+	schema["title"] = "argocd-lint configuration"
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			property := typeSchema(field.Type)
+			if severityKeys[name] && field.Type.Kind() == reflect.String {
+				property["anyOf"] = severitySchema
+			}
+			properties[name] = property
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestJSONSchemaMatchesPublishedFile(t *testing.T) {
+	generated, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("generate schema: %v", err)
+	}
+	published, err := os.ReadFile(filepath.Join("..", "..", "docs", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("read published schema: %v", err)
+	}
+	if string(generated) != string(published) {
+		t.Fatalf("docs/config.schema.json is stale; regenerate it with: argocd-lint config schema > docs/config.schema.json")
+	}
+}
+
+func TestJSONSchemaValidatesConfig(t *testing.T) {
+	generated, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("generate schema: %v", err)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(generated))
+	if err != nil {
+		t.Fatalf("compile schema: %v", err)
+	}
+	validate := func(doc string) *gojsonschema.Result {
+		var value map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &value); err != nil {
+			t.Fatalf("parse %q: %v", doc, err)
+		}
+		result, err := schema.Validate(gojsonschema.NewGoLoader(value))
+		if err != nil {
+			t.Fatalf("validate %q: %v", doc, err)
+		}
+		return result
+	}
+	example, err := os.ReadFile(filepath.Join("..", "..", "rules.example.yaml"))
+	if err != nil {
+		t.Fatalf("read example: %v", err)
+	}
+	if result := validate(string(example)); !result.Valid() {
+		t.Fatalf("expected rules.example.yaml to match the schema, got %v", result.Errors())
+	}
+	fields := map[string]bool{}
+	for _, e := range validate("rules:\n  AR001: {severity: fatal}\npolicy: {}\n").Errors() {
+		fields[e.Field()] = true
+	}
+	if len(fields) != 2 || !fields["rules.AR001.severity"] || !fields["(root)"] {
+		t.Fatalf("expected an invalid severity and an unknown key, got %v", fields)
+	}
+	if result := validate("severityThreshold: Error\nrules:\n  AR001: {severity: WARN}\n"); !result.Valid() {
+		t.Fatalf("expected severities in any case to match the schema, got %v", result.Errors())
+	}
+}
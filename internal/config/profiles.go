@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 type profile struct {
 	rules     map[string]RuleConfig
 	threshold string
+	waivers   WaiverPolicy
 }
 
 var builtinProfiles = map[string]profile{
@@ -27,8 +29,11 @@ var builtinProfiles = map[string]profile{
 			"AR007": {Severity: "error"},
 			"AR013": {Severity: "error"},
 			"AR014": {Severity: "error"},
+			"AR018": {Params: map[string]interface{}{"allowWildcardApplications": false}},
+			"AR025": {Severity: "warn"},
 		},
 		threshold: "error",
+		waivers:   WaiverPolicy{MaxDuration: "90d", RequireTicket: true},
 	},
 	"security": {
 		rules: map[string]RuleConfig{
@@ -43,13 +48,35 @@ var builtinProfiles = map[string]profile{
 			"AR010": {Severity: "warn"},
 			"AR013": {Severity: "error"},
 			"AR014": {Severity: "error"},
+			"AR018": {Params: map[string]interface{}{"allowWildcardApplications": false}},
+			"AR025": {Severity: "warn"},
 		},
 		threshold: "error",
+		waivers:   WaiverPolicy{MaxDuration: "90d", RequireTicket: true},
 	},
 }
 
-// ApplyProfiles merges the provided built-in profiles into the configuration.
+// ProfileConfig is a profile defined in the config file under
+// definedProfiles. Profiles, built-in or defined, are applied first, so a
+// profile can extend prod and tweak a few rules.
+type ProfileConfig struct {
+	Profiles     []string              `yaml:"profiles"`
+	Threshold    string                `yaml:"threshold"`
+	Rules        map[string]RuleConfig `yaml:"rules"`
+	WaiverPolicy WaiverPolicy          `yaml:"waiverPolicy"`
+}
+
+// ApplyProfiles merges the provided profiles into the configuration and
+// checks the waivers against the resulting waiver policy. Profiles from
+// definedProfiles take precedence over built-ins of the same name.
 func (cfg *Config) ApplyProfiles(names ...string) error {
+	if err := cfg.applyProfiles(names); err != nil {
+		return err
+	}
+	return cfg.checkWaiverPolicy(time.Now())
+}
+
+func (cfg *Config) applyProfiles(names []string) error {
 	if len(names) == 0 {
 		return nil
 	}
@@ -60,27 +87,125 @@ func (cfg *Config) ApplyProfiles(names ...string) error {
 		if name == "" {
 			continue
 		}
-		profile, ok := builtinProfiles[strings.ToLower(name)]
-		if !ok {
-			return fmt.Errorf("unknown profile %q", name)
+		if err := cfg.applyProfile(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyProfile applies one profile after those it composes. chain holds the
+// defined profiles being applied, to reject cycles.
+func (cfg *Config) applyProfile(name string, chain []string) error {
+	key, defined, ok := cfg.lookupDefinedProfile(name)
+	if ok {
+		for _, applying := range chain {
+			if applying == key {
+				return fmt.Errorf("profile %q includes itself", key)
+			}
+		}
+		for _, included := range defined.Profiles {
+			if err := cfg.applyProfile(included, append(chain, key)); err != nil {
+				return err
+			}
+		}
+		cfg.mergeProfile(profile{rules: defined.Rules, threshold: defined.Threshold, waivers: defined.WaiverPolicy})
+		return nil
+	}
+	builtin, ok := builtinProfiles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	cfg.mergeProfile(builtin)
+	return nil
+}
+
+func (cfg *Config) lookupDefinedProfile(name string) (string, ProfileConfig, bool) {
+	if defined, ok := cfg.DefinedProfiles[name]; ok {
+		return name, defined, true
+	}
+	for key, defined := range cfg.DefinedProfiles {
+		if strings.EqualFold(key, name) {
+			return key, defined, true
+		}
+	}
+	return "", ProfileConfig{}, false
+}
+
+func (cfg *Config) mergeProfile(p profile) {
+	if p.threshold != "" {
+		cfg.Threshold = p.threshold
+	}
+	cfg.WaiverPolicy = cfg.WaiverPolicy.merge(p.waivers)
+	for ruleID, override := range p.rules {
+		existing := cfg.Rules[ruleID]
+		if override.Enabled != nil {
+			existing.Enabled = override.Enabled
+		}
+		if override.Severity != "" {
+			existing.Severity = override.Severity
 		}
-		if profile.threshold != "" {
-			cfg.Threshold = profile.threshold
+		if len(override.Params) > 0 {
+			params := make(map[string]interface{}, len(existing.Params)+len(override.Params))
+			for key, value := range existing.Params {
+				params[key] = value
+			}
+			for key, value := range override.Params {
+				params[key] = value
+			}
+			existing.Params = params
 		}
-		for ruleID, override := range profile.rules {
-			existing := cfg.Rules[ruleID]
-			if override.Enabled != nil {
-				existing.Enabled = override.Enabled
+		cfg.Rules[ruleID] = existing
+	}
+}
+
+// validateDefinedProfiles checks thresholds, rule severities, and that every
+// composed profile exists without cycles.
+func (cfg Config) validateDefinedProfiles() error {
+	names := make([]string, 0, len(cfg.DefinedProfiles))
+	for name := range cfg.DefinedProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		defined := cfg.DefinedProfiles[name]
+		if defined.Threshold != "" {
+			if _, err := ParseSeverity(defined.Threshold); err != nil {
+				return fmt.Errorf("definedProfiles.%s.threshold: %w", name, err)
+			}
+		}
+		for ruleID, rule := range defined.Rules {
+			if rule.Severity == "" {
+				continue
 			}
-			if override.Severity != "" {
-				existing.Severity = override.Severity
+			if _, err := ParseSeverity(rule.Severity); err != nil {
+				return fmt.Errorf("definedProfiles.%s.rules.%s.severity: %w", name, ruleID, err)
 			}
-			cfg.Rules[ruleID] = existing
+		}
+		if err := defined.WaiverPolicy.Validate(); err != nil {
+			return fmt.Errorf("definedProfiles.%s.waiverPolicy: %w", name, err)
+		}
+		scratch := Config{DefinedProfiles: cfg.DefinedProfiles, Rules: map[string]RuleConfig{}}
+		if err := scratch.applyProfile(name, nil); err != nil {
+			return fmt.Errorf("definedProfiles.%s: %w", name, err)
 		}
 	}
 	return nil
 }
 
+// ProfileNames returns the built-in profile names and those defined in the
+// config, sorted.
+func (cfg Config) ProfileNames() []string {
+	names := AvailableProfiles()
+	for name := range cfg.DefinedProfiles {
+		if _, ok := builtinProfiles[strings.ToLower(name)]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // AvailableProfiles returns a sorted list of built-in profile names.
 func AvailableProfiles() []string {
 	names := make([]string, 0, len(builtinProfiles))
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an issue found by Validate, located by line in the config file.
+type Problem struct {
+	Line    int
+	Message string
+	// Warning marks problems that do not stop the config from loading, such
+	// as expired waivers.
+	Warning bool
+}
+
+var (
+	yamlLinePattern     = regexp.MustCompile(`^line (\d+): (.*)$`)
+	yamlUnknownKeyError = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// Validate strictly checks configuration YAML: unknown keys, unknown rule
+// IDs (when knownRules is non-nil), invalid severities, bad glob patterns,
+// unknown profiles, invalid custom rules, invalid or expired waivers, and
+// unset environment variables. Problems are sorted by line.
+func Validate(data []byte, knownRules map[string]bool) []Problem {
+	return validate(data, knownRules, true)
+}
+
+// validate implements Validate, expanding environment references only when
+// expand is set, as decode does. Messages never contain values read from
+// the environment.
+func validate(data []byte, knownRules map[string]bool, expand bool) []Problem {
+	var problems []Problem
+	add := func(line int, warning bool, format string, args ...interface{}) {
+		problems = append(problems, Problem{Line: line, Message: fmt.Sprintf(format, args...), Warning: warning})
+	}
+
+	// Values holding environment references are type-checked after
+	// expansion; the strict pass over the raw file only sees the reference.
+	var root yaml.Node
+	parseErr := yaml.Unmarshal(data, &root)
+	expanded := map[int]bool{}
+	var expansion envExpansion
+	if parseErr == nil && expand {
+		var err error
+		if expansion, err = expandEnv(&root); err != nil {
+			line, text := splitYAMLError(err.Error())
+			add(line, false, "%s", text)
+		}
+		for _, line := range expansion.lines {
+			expanded[line] = true
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var strict Config
+	if err := dec.Decode(&strict); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				line, text := splitYAMLError(msg)
+				if !expanded[line] || !strings.HasPrefix(text, "cannot unmarshal") {
+					add(line, false, "%s", text)
+				}
+			}
+		} else {
+			line, text := splitYAMLError(err.Error())
+			add(line, false, "%s", text)
+			return problems
+		}
+	}
+	if parseErr != nil || len(root.Content) == 0 {
+		return problems
+	}
+	if len(expanded) > 0 {
+		var typed Config
+		var typeErr *yaml.TypeError
+		if err := root.Decode(&typed); errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				if line, text := splitYAMLError(msg); expanded[line] {
+					add(line, false, "%s", text)
+				}
+			}
+		}
+	}
+	doc := root.Content[0]
+	if custom := mappingValue(doc, "customRules"); custom != nil && custom.Kind == yaml.SequenceNode {
+		seen := map[string]bool{}
+		for i, item := range custom.Content {
+			var rule CustomRule
+			if err := item.Decode(&rule); err != nil {
+				continue
+			}
+			if _, err := rule.Compile(); err != nil {
+				add(item.Line, false, "customRules[%d]: %v", i, err)
+			}
+			if knownRules != nil && knownRules[rule.ID] && !seen[rule.ID] {
+				add(lineOf(item, "id"), false, "customRules[%d].id: %s is already a rule ID", i, rule.ID)
+			} else if seen[rule.ID] {
+				add(lineOf(item, "id"), false, "customRules[%d].id: duplicate id %s", i, rule.ID)
+			}
+			seen[rule.ID] = true
+		}
+		if knownRules != nil {
+			// Custom rule IDs may be configured like any other rule.
+			extended := make(map[string]bool, len(knownRules)+len(seen))
+			for id := range knownRules {
+				extended[id] = true
+			}
+			for id := range seen {
+				extended[id] = true
+			}
+			knownRules = extended
+		}
+	}
+	checkRules := func(node *yaml.Node, prefix string) {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if knownRules != nil && !knownRules[key.Value] {
+				add(key.Line, false, "%s%s: unknown rule ID", prefix, key.Value)
+			}
+			if severity := mappingValue(value, "severity"); severity != nil {
+				if _, err := ParseSeverity(severity.Value); err != nil {
+					add(severity.Line, false, "%s%s.severity: %v", prefix, key.Value, err)
+				}
+			}
+		}
+	}
+	checkRules(mappingValue(doc, "rules"), "rules.")
+	for _, group := range []string{"categories", "tags"} {
+		node := mappingValue(doc, group)
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if severity := mappingValue(node.Content[i+1], "severity"); severity != nil {
+				if _, err := ParseSeverity(severity.Value); err != nil {
+					add(severity.Line, false, "%s.%s.severity: %v", group, node.Content[i].Value, err)
+				}
+			}
+		}
+	}
+	checkRules(mappingValue(doc, "plugins"), "plugins.")
+
+	if threshold := mappingValue(doc, "severityThreshold"); threshold != nil {
+		if _, err := ParseSeverity(threshold.Value); err != nil {
+			add(threshold.Line, false, "severityThreshold: %v", err)
+		}
+	}
+	var defined Config
+	if node := mappingValue(doc, "definedProfiles"); node != nil && node.Kind == yaml.MappingNode {
+		if err := node.Decode(&defined.DefinedProfiles); err == nil {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				name, body := node.Content[i].Value, node.Content[i+1]
+				checkRules(mappingValue(body, "rules"), fmt.Sprintf("definedProfiles.%s.rules.", name))
+				if threshold := mappingValue(body, "threshold"); threshold != nil {
+					if _, err := ParseSeverity(threshold.Value); err != nil {
+						add(threshold.Line, false, "definedProfiles.%s.threshold: %v", name, err)
+					}
+				}
+				scratch := Config{DefinedProfiles: defined.DefinedProfiles, Rules: map[string]RuleConfig{}}
+				if err := scratch.applyProfile(name, nil); err != nil && mappingValue(doc, "extends") == nil {
+					add(node.Content[i].Line, false, "definedProfiles.%s: %v", name, err)
+				}
+			}
+		}
+	}
+	// Profiles may be defined in the configs this file extends, which are
+	// not read here.
+	if profiles := mappingValue(doc, "profiles"); profiles != nil && profiles.Kind == yaml.SequenceNode && mappingValue(doc, "extends") == nil {
+		for _, item := range profiles.Content {
+			if _, _, ok := defined.lookupDefinedProfile(item.Value); ok {
+				continue
+			}
+			if _, ok := builtinProfiles[strings.ToLower(item.Value)]; !ok {
+				add(item.Line, false, "profiles: unknown profile %q (available: %s)", item.Value, strings.Join(defined.ProfileNames(), ", "))
+			}
+		}
+	}
+	if overrides := mappingValue(doc, "overrides"); overrides != nil && overrides.Kind == yaml.SequenceNode {
+		for i, item := range overrides.Content {
+			if pattern := mappingValue(item, "pattern"); pattern != nil {
+				if _, err := filepath.Match(pattern.Value, ""); err != nil {
+					add(pattern.Line, false, "overrides[%d].pattern: invalid glob %q", i, pattern.Value)
+				}
+			}
+			checkRules(mappingValue(item, "rules"), fmt.Sprintf("overrides[%d].rules.", i))
+		}
+	}
+	// Waivers are checked against the file's waiverPolicy tightened by the
+	// profiles it selects, as Load does.
+	var policy WaiverPolicy
+	if node := mappingValue(doc, "waiverPolicy"); node != nil {
+		if err := node.Decode(&policy); err == nil {
+			if err := policy.Validate(); err != nil {
+				add(lineOf(node, "maxDuration"), false, "waiverPolicy: %v", err)
+				policy.MaxDuration = ""
+			}
+		}
+	}
+	if profiles := mappingValue(doc, "profiles"); profiles != nil {
+		var names []string
+		if err := profiles.Decode(&names); err == nil {
+			scratch := Config{DefinedProfiles: defined.DefinedProfiles, Rules: map[string]RuleConfig{}}
+			for _, name := range names {
+				_ = scratch.applyProfile(name, nil)
+			}
+			policy = scratch.WaiverPolicy.merge(policy)
+		}
+	}
+	if waivers := mappingValue(doc, "waivers"); waivers != nil && waivers.Kind == yaml.SequenceNode {
+		now := time.Now()
+		for i, item := range waivers.Content {
+			var waiver Waiver
+			if err := item.Decode(&waiver); err != nil {
+				continue
+			}
+			if knownRules != nil && waiver.Rule != "" && !knownRules[waiver.Rule] {
+				add(lineOf(item, "rule"), false, "waivers[%d].rule: unknown rule ID %s", i, waiver.Rule)
+			}
+			for _, field := range []string{"file", "resource"} {
+				if node := mappingValue(item, field); node != nil {
+					if _, err := filepath.Match(node.Value, ""); err != nil {
+						add(node.Line, false, "waivers[%d].%s: invalid glob %q", i, field, node.Value)
+					}
+				}
+			}
+			if err := waiver.Validate(); err != nil {
+				add(item.Line, false, "waivers[%d]: %v", i, err)
+				continue
+			}
+			if expiry, _ := waiver.ExpiryTime(); now.After(expiry) {
+				add(lineOf(item, "expires"), true, "waivers[%d]: expired on %s", i, waiver.Expires)
+			}
+			if err := policy.Check(waiver, now); err != nil {
+				add(item.Line, false, "waivers[%d]: %v", i, err)
+			}
+		}
+	}
+	if exitPolicy := mappingValue(doc, "exitPolicy"); exitPolicy != nil {
+		var policy ExitPolicy
+		if err := exitPolicy.Decode(&policy); err == nil {
+			if err := policy.Validate(); err != nil {
+				add(exitPolicy.Line, false, "exitPolicy: %v", err)
+			}
+		}
+	}
+	if naming := mappingValue(mappingValue(doc, "policies"), "namingConventions"); naming != nil {
+		var conventions NamingConventions
+		if err := naming.Decode(&conventions); err == nil {
+			if err := conventions.Validate(); err != nil {
+				add(naming.Line, false, "policies: %v", err)
+			}
+		}
+	}
+
+	if render := mappingValue(doc, "render"); render != nil {
+		var cfg RenderConfig
+		if err := render.Decode(&cfg); err == nil {
+			if err := cfg.Validate(); err != nil {
+				add(render.Line, false, "render: %v", err)
+			}
+		}
+	}
+
+	for i := range problems {
+		problems[i].Message = expansion.redact(problems[i].Message)
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+func splitYAMLError(msg string) (int, string) {
+	msg = strings.TrimPrefix(msg, "yaml: ")
+	line := 0
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = m[2]
+	}
+	if m := yamlUnknownKeyError.FindStringSubmatch(msg); m != nil {
+		msg = fmt.Sprintf("unknown key %q", m[1])
+	}
+	return line, msg
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func lineOf(node *yaml.Node, key string) int {
+	if value := mappingValue(node, key); value != nil {
+		return value.Line
+	}
+	return node.Line
+}
@@ -3,16 +3,118 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
+// WaiverAnnotation declares waivers on the resource itself. The value holds
+// one or more "RULE:EXPIRES:reason" entries separated by newlines or ";".
+// An entry may end in "|ticket=ID" and "|approvedBy=NAME" attributes, which
+// WaiverPolicy can require as for config waivers.
+const WaiverAnnotation = "argocd-lint.argoproj.io/waive"
+
 // Waiver suppresses findings for a rule/file combination until expiry.
+// Resource optionally narrows the waiver to a "Kind/name" pattern.
+// ApprovedBy and Ticket record who signed it off and where; WaiverPolicy can
+// require them.
 type Waiver struct {
-	Rule    string `yaml:"rule"`
-	File    string `yaml:"file"`
-	Reason  string `yaml:"reason"`
-	Expires string `yaml:"expires"`
+	Rule       string `yaml:"rule"`
+	File       string `yaml:"file"`
+	Resource   string `yaml:"resource,omitempty"`
+	Reason     string `yaml:"reason"`
+	Expires    string `yaml:"expires"`
+	ApprovedBy string `yaml:"approvedBy,omitempty"`
+	Ticket     string `yaml:"ticket,omitempty"`
+}
+
+// WaiverPolicy constrains the waivers of a config. It is enforced when the
+// config loads and when profiles are applied.
+type WaiverPolicy struct {
+	// MaxDuration caps how far ahead of today a waiver may expire, in days
+	// (90d), weeks (12w), or as a Go duration (2160h).
+	MaxDuration     string `yaml:"maxDuration"`
+	RequireTicket   bool   `yaml:"requireTicket"`
+	RequireApprover bool   `yaml:"requireApprover"`
+}
+
+// Validate checks that MaxDuration parses.
+func (p WaiverPolicy) Validate() error {
+	if strings.TrimSpace(p.MaxDuration) == "" {
+		return nil
+	}
+	if _, err := parseWaiverDuration(p.MaxDuration); err != nil {
+		return fmt.Errorf("maxDuration: %w", err)
+	}
+	return nil
+}
+
+// Check reports why w violates the policy as of now, or nil. Waivers that
+// fail Waiver.Validate are reported there instead.
+func (p WaiverPolicy) Check(w Waiver, now time.Time) error {
+	if p.RequireTicket && strings.TrimSpace(w.Ticket) == "" {
+		return fmt.Errorf("ticket is required by waiverPolicy")
+	}
+	if p.RequireApprover && strings.TrimSpace(w.ApprovedBy) == "" {
+		return fmt.Errorf("approvedBy is required by waiverPolicy")
+	}
+	if strings.TrimSpace(p.MaxDuration) == "" {
+		return nil
+	}
+	limit, err := parseWaiverDuration(p.MaxDuration)
+	if err != nil {
+		return err
+	}
+	expires, err := w.ExpiryTime()
+	if err != nil {
+		return nil
+	}
+	if expires.Sub(now) > limit {
+		return fmt.Errorf("expires %s, more than waiverPolicy.maxDuration %s from now", w.Expires, p.MaxDuration)
+	}
+	return nil
+}
+
+// merge tightens p with over: requirements add up and a set MaxDuration
+// wins.
+func (p WaiverPolicy) merge(over WaiverPolicy) WaiverPolicy {
+	if strings.TrimSpace(over.MaxDuration) != "" {
+		p.MaxDuration = over.MaxDuration
+	}
+	p.RequireTicket = p.RequireTicket || over.RequireTicket
+	p.RequireApprover = p.RequireApprover || over.RequireApprover
+	return p
+}
+
+// parseWaiverDuration accepts Nd and Nw besides Go durations.
+func parseWaiverDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (expected e.g. 90d, 12w, or 2160h)", value)
+	}
+	return d, nil
+}
+
+// checkWaiverPolicy applies WaiverPolicy to the config waivers.
+func (cfg Config) checkWaiverPolicy(now time.Time) error {
+	for _, w := range cfg.Waivers {
+		if err := cfg.WaiverPolicy.Check(w, now); err != nil {
+			return fmt.Errorf("waiver %s on %s: %w", w.Rule, w.File, err)
+		}
+	}
+	return nil
 }
 
 // Validate performs static validation at load time.
@@ -60,3 +162,95 @@ func (w Waiver) Matches(finding string, ruleID string) bool {
 	ok, _ := filepath.Match(pattern, finding)
 	return ok
 }
+
+// MatchesFinding determines whether the waiver covers the finding, taking the
+// optional resource pattern into account.
+func (w Waiver) MatchesFinding(f types.Finding) bool {
+	if !w.Matches(f.FilePath, f.RuleID) {
+		return false
+	}
+	pattern := strings.TrimSpace(w.Resource)
+	if pattern == "" {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, f.ResourceKind+"/"+f.ResourceName)
+	return ok
+}
+
+// ParseWaiverAnnotation parses the value of WaiverAnnotation into waivers. File
+// and Resource are left for the caller to scope to the annotated manifest.
+func ParseWaiverAnnotation(value string) ([]Waiver, error) {
+	var waivers []Waiver
+	entries := strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ';' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rule, rest, ok := strings.Cut(entry, ":")
+		expires, reason, ok2 := splitExpiry(rest)
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid waiver %q (expected RULE:EXPIRES:reason)", entry)
+		}
+		reason, attrs, _ := strings.Cut(reason, "|")
+		waiver := Waiver{
+			Rule:    strings.TrimSpace(rule),
+			Expires: strings.TrimSpace(expires),
+			Reason:  strings.TrimSpace(reason),
+		}
+		if err := waiver.setAttributes(attrs); err != nil {
+			return nil, fmt.Errorf("invalid waiver %q: %w", entry, err)
+		}
+		if waiver.Rule == "" {
+			return nil, fmt.Errorf("invalid waiver %q: rule is required", entry)
+		}
+		if waiver.Reason == "" {
+			return nil, fmt.Errorf("invalid waiver %q: reason is required", entry)
+		}
+		if _, err := waiver.ExpiryTime(); err != nil {
+			return nil, fmt.Errorf("invalid waiver %q: %w", entry, err)
+		}
+		waivers = append(waivers, waiver)
+	}
+	return waivers, nil
+}
+
+// setAttributes sets Ticket and ApprovedBy from the "|"-separated key=value
+// attributes of an annotation entry.
+func (w *Waiver) setAttributes(attrs string) error {
+	if strings.TrimSpace(attrs) == "" {
+		return nil
+	}
+	for _, attr := range strings.Split(attrs, "|") {
+		key, value, ok := strings.Cut(attr, "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return fmt.Errorf("attribute %q: expected key=value", strings.TrimSpace(attr))
+		}
+		switch strings.TrimSpace(key) {
+		case "ticket":
+			w.Ticket = value
+		case "approvedBy":
+			w.ApprovedBy = value
+		default:
+			return fmt.Errorf("unknown attribute %q (expected ticket or approvedBy)", strings.TrimSpace(key))
+		}
+	}
+	return nil
+}
+
+// splitExpiry separates the expiry from the reason. RFC3339 timestamps contain
+// colons themselves, so the split point is the first colon that ends a valid
+// expiry, falling back to the first colon for error reporting.
+func splitExpiry(value string) (string, string, bool) {
+	for i := 0; i < len(value); i++ {
+		if value[i] != ':' {
+			continue
+		}
+		if _, err := (Waiver{Expires: value[:i]}).ExpiryTime(); err == nil {
+			return value[:i], value[i+1:], true
+		}
+	}
+	expires, reason, ok := strings.Cut(value, ":")
+	return expires, reason, ok
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/internal/output"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// Source lists live Argo CD resources.
+type Source interface {
+	List(ctx context.Context, namespace string) ([]*manifest.Manifest, error)
+}
+
+// EventRecorder publishes Kubernetes Events.
+type EventRecorder interface {
+	CreateEvent(ctx context.Context, ev cluster.Event) error
+}
+
+// Options configures the scanning loop.
+type Options struct {
+	Namespace string
+	Interval  time.Duration
+	// Lint is the template for every scan; Target is ignored and Manifests is
+	// replaced by the live resources.
+	Lint lint.Options
+	// Events, when non-nil, receives a Warning event whenever the findings of a
+	// resource change.
+	Events EventRecorder
+	Log    io.Writer
+}
+
+// Controller periodically lints live resources and exposes the latest results.
+type Controller struct {
+	runner  *lint.Runner
+	source  Source
+	options Options
+
+	mu       sync.RWMutex
+	metrics  []byte
+	lastErr  error
+	lastScan time.Time
+	seen     map[string]string
+}
+
+// New creates a controller.
+func New(runner *lint.Runner, source Source, opts Options) *Controller {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Minute
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	return &Controller{runner: runner, source: source, options: opts, seen: make(map[string]string)}
+}
+
+// RunOnce performs a single scan and updates metrics and events.
+func (c *Controller) RunOnce(ctx context.Context) (lint.Report, error) {
+	start := time.Now()
+	live, err := c.source.List(ctx, c.options.Namespace)
+	if err != nil {
+		c.recordError(err)
+		return lint.Report{}, fmt.Errorf("list resources: %w", err)
+	}
+	opts := c.options.Lint
+	opts.Target = ""
+	opts.Manifests = live
+	var report lint.Report
+	if len(live) > 0 {
+		report, err = c.runner.RunContext(ctx, opts)
+		if err != nil {
+			c.recordError(err)
+			return lint.Report{}, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := output.WritePrometheus(report, time.Since(start), &buf); err != nil {
+		return report, err
+	}
+	c.mu.Lock()
+	c.metrics = buf.Bytes()
+	c.lastErr = nil
+	c.lastScan = time.Now()
+	c.mu.Unlock()
+	c.emitEvents(ctx, live, report.Findings)
+	fmt.Fprintf(c.options.Log, "scanned %d resource(s), %d finding(s)\n", len(live), len(report.Findings))
+	return report, nil
+}
+
+// Run scans on every interval until ctx is cancelled. Scan failures are
+// logged and surfaced through /healthz rather than stopping the loop.
+func (c *Controller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.RunOnce(ctx); err != nil {
+			fmt.Fprintf(c.options.Log, "scan failed: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handler serves /metrics and /healthz.
+func (c *Controller) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write(c.metrics)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.lastErr != nil {
+			http.Error(w, c.lastErr.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "ok %s\n", c.lastScan.UTC().Format(time.RFC3339))
+	})
+	return mux
+}
+
+// Serve runs the HTTP endpoint until ctx is cancelled.
+func (c *Controller) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: c.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (c *Controller) recordError(err error) {
+	c.mu.Lock()
+	c.lastErr = err
+	c.mu.Unlock()
+}
+
+// emitEvents records one Warning event per resource whose finding set changed
+// since the previous scan, so steady-state findings do not flood the API.
+func (c *Controller) emitEvents(ctx context.Context, live []*manifest.Manifest, findings []types.Finding) {
+	byPath := make(map[string][]types.Finding)
+	for _, f := range findings {
+		byPath[f.FilePath] = append(byPath[f.FilePath], f)
+	}
+	current := make(map[string]string, len(live))
+	for _, m := range live {
+		list := byPath[m.FilePath]
+		digest := findingDigest(list)
+		current[m.FilePath] = digest
+		if c.options.Events == nil || digest == "" || c.seen[m.FilePath] == digest {
+			continue
+		}
+		ev := cluster.Event{
+			Kind:      m.Kind,
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			UID:       cluster.UID(m),
+			Type:      "Warning",
+			Reason:    "LintFindings",
+			Message:   eventMessage(list),
+		}
+		if err := c.options.Events.CreateEvent(ctx, ev); err != nil {
+			fmt.Fprintf(c.options.Log, "event for %s: %v\n", m.FilePath, err)
+			current[m.FilePath] = c.seen[m.FilePath]
+		}
+	}
+	c.seen = current
+}
+
+func findingDigest(findings []types.Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(findings))
+	for _, f := range findings {
+		keys = append(keys, f.RuleID+"|"+f.Message)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+func eventMessage(findings []types.Finding) string {
+	rules := make([]string, 0, len(findings))
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !seen[f.RuleID] {
+			seen[f.RuleID] = true
+			rules = append(rules, f.RuleID)
+		}
+	}
+	sort.Strings(rules)
+	msg := fmt.Sprintf("argocd-lint reported %d finding(s): %s", len(findings), strings.Join(rules, ", "))
+	if len(msg) > 1024 {
+		msg = msg[:1021] + "..."
+	}
+	return msg
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+const liveApplications = `{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"argoproj.io/v1alpha1","kind":"Application","metadata":{"name":"guestbook","namespace":"argocd","uid":"1234","resourceVersion":"99"},"spec":{"project":"default","source":{"repoURL":"https://github.com/example/guestbook.git","path":"guestbook","targetRevision":"HEAD"},"destination":{"server":"https://kubernetes.default.svc","namespace":"guestbook"}},"status":{"sync":{"status":"Synced"}}}]}`
+
+func fakeKubectl(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.log")
+	script := filepath.Join(dir, "kubectl")
+	body := "#!/bin/sh\ncase \"$1\" in\n" +
+		"get) cat <<'JSON'\n" + liveApplications + "\nJSON\n;;\n" +
+		"create) cat >> " + events + "; echo >> " + events + ";;\n" +
+		"esac\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return script, events
+}
+
+func TestRunOnceExposesMetricsAndDeduplicatesEvents(t *testing.T) {
+	script, eventsLog := fakeKubectl(t)
+	client := cluster.NewClient(cluster.Options{KubectlBinary: script})
+	runner, err := lint.NewRunner(config.Config{}, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("runner: %v", err)
+	}
+	ctrl := New(runner, client, Options{Lint: lint.Options{Config: config.Config{}}, Events: client})
+
+	for i := 0; i < 2; i++ {
+		report, err := ctrl.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("scan %d: %v", i, err)
+		}
+		if len(report.Findings) == 0 {
+			t.Fatalf("expected findings for floating targetRevision")
+		}
+		if report.Findings[0].FilePath != "cluster://argocd/Application/guestbook" {
+			t.Fatalf("unexpected finding path %q", report.Findings[0].FilePath)
+		}
+	}
+
+	data, err := os.ReadFile(eventsLog)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if got := strings.Count(string(data), `"kind":"Event"`); got != 1 {
+		t.Fatalf("expected a single event across unchanged scans, got %d", got)
+	}
+	if !strings.Contains(string(data), `"uid":"1234"`) {
+		t.Fatalf("expected event to reference the live object uid, got %s", data)
+	}
+
+	rec := httptest.NewRecorder()
+	ctrl.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `argocd_lint_resource_findings{kind="Application",name="guestbook"`) {
+		t.Fatalf("expected per-resource gauge, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	ctrl.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 200 {
+		t.Fatalf("expected healthy controller, got %d", rec.Code)
+	}
+}
+
+func TestRunOnceReportsListFailure(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "kubectl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'forbidden' 1>&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	runner, err := lint.NewRunner(config.Config{}, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("runner: %v", err)
+	}
+	ctrl := New(runner, cluster.NewClient(cluster.Options{KubectlBinary: script}), Options{})
+	if _, err := ctrl.RunOnce(context.Background()); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Fatalf("expected kubectl error, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	ctrl.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 503 {
+		t.Fatalf("expected unhealthy status, got %d", rec.Code)
+	}
+}
+
+// cancelingSource returns live resources and cancels the scan, as SIGTERM
+// does once the listing is done.
+type cancelingSource struct {
+	client *cluster.Client
+	cancel context.CancelFunc
+}
+
+func (s cancelingSource) List(ctx context.Context, namespace string) ([]*manifest.Manifest, error) {
+	live, err := s.client.List(ctx, namespace)
+	s.cancel()
+	return live, err
+}
+
+func TestRunOnceStopsWhenCancelled(t *testing.T) {
+	script, _ := fakeKubectl(t)
+	runner, err := lint.NewRunner(config.Config{}, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("runner: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := cancelingSource{client: cluster.NewClient(cluster.Options{KubectlBinary: script}), cancel: cancel}
+	ctrl := New(runner, source, Options{})
+	if _, err := ctrl.RunOnce(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the scan to stop with the context, got %v", err)
+	}
+}
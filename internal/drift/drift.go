@@ -0,0 +1,184 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// DefaultNamespace is used for Applications that do not declare a namespace,
+// matching the conventional Argo CD control-plane namespace.
+const DefaultNamespace = "argocd"
+
+// Options controls comparison against live cluster state.
+type Options struct {
+	Enabled bool
+	Cluster cluster.Options
+}
+
+// Checker compares governance-relevant fields of Applications in Git with the
+// live objects of the same name and namespace.
+type Checker struct {
+	cfg    config.Config
+	client *cluster.Client
+	rule   types.RuleMetadata
+}
+
+// NewChecker creates a drift checker.
+func NewChecker(cfg config.Config, opts Options) *Checker {
+	return &Checker{
+		cfg:    cfg,
+		client: cluster.NewClient(opts.Cluster),
+		rule: types.RuleMetadata{
+			ID:              "CLUSTER_DRIFT",
+			Description:     "Live Applications should match the project, destination, revision, and sync policy declared in Git",
+			DefaultSeverity: types.SeverityWarn,
+			AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+			Category:        "drift",
+			Enabled:         true,
+		},
+	}
+}
+
+// Metadata exposes rule metadata for registration.
+func (c *Checker) Metadata() []types.RuleMetadata {
+	return []types.RuleMetadata{c.rule}
+}
+
+// Check fetches the live counterpart of every Application and reports fields
+// that were changed out of band. Applications missing from the cluster are
+// skipped, since they are usually not yet synced.
+func (c *Checker) Check(ctx context.Context, manifests []*manifest.Manifest) ([]types.Finding, error) {
+	var findings []types.Finding
+	for _, m := range manifests {
+		if m.Kind != string(types.ResourceKindApplication) || strings.HasPrefix(m.FilePath, "cluster://") || strings.Contains(m.Name, "{{") {
+			continue
+		}
+		cfg, err := c.cfg.Resolve(c.rule, m.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		if !cfg.Enabled {
+			continue
+		}
+		namespace := m.Namespace
+		if namespace == "" {
+			namespace = DefaultNamespace
+		}
+		live, err := c.client.Get(ctx, "applications.argoproj.io", namespace, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("fetch live application %s/%s: %w", namespace, m.Name, err)
+		}
+		if live == nil {
+			continue
+		}
+		builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+		for _, field := range Compare(m.Object, live.Object) {
+			msg := fmt.Sprintf("live %s is %s but Git declares %s (changed outside Git)", field.Path, render(field.Live), render(field.Desired))
+			findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+		}
+	}
+	return findings, nil
+}
+
+// Difference is a single diverging field.
+type Difference struct {
+	Path    string      `json:"path"`
+	Desired interface{} `json:"git"`
+	Live    interface{} `json:"live"`
+}
+
+// Compare returns the governance-relevant fields that differ between the
+// desired and live Application objects.
+func Compare(desired, live map[string]interface{}) []Difference {
+	paths := []string{"spec.project", "spec.destination", "spec.source.targetRevision"}
+	if sources, ok := lookup(desired, "spec.sources").([]interface{}); ok {
+		for i := range sources {
+			paths = append(paths, fmt.Sprintf("spec.sources[%d].targetRevision", i))
+		}
+	}
+	paths = append(paths, "spec.syncPolicy")
+	var diffs []Difference
+	for _, path := range paths {
+		want := normalize(lookup(desired, path))
+		got := normalize(lookup(live, path))
+		if reflect.DeepEqual(want, got) {
+			continue
+		}
+		diffs = append(diffs, Difference{Path: path, Desired: want, Live: got})
+	}
+	return diffs
+}
+
+// lookup resolves a dotted path with optional [index] segments.
+func lookup(obj map[string]interface{}, path string) interface{} {
+	var current interface{} = obj
+	for _, segment := range strings.Split(path, ".") {
+		key, index := segment, -1
+		if open := strings.Index(segment, "["); open >= 0 && strings.HasSuffix(segment, "]") {
+			key = segment[:open]
+			fmt.Sscanf(segment[open+1:len(segment)-1], "%d", &index)
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+		if index >= 0 {
+			list, ok := current.([]interface{})
+			if !ok || index >= len(list) {
+				return nil
+			}
+			current = list[index]
+		}
+	}
+	return current
+}
+
+// normalize round-trips a value through JSON so YAML integers and live JSON
+// numbers compare equal, and treats empty values as absent.
+func normalize(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return value
+	}
+	switch v := out.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	return out
+}
+
+func render(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
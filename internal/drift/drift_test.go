@@ -0,0 +1,111 @@
+package drift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+const gitApplication = `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  namespace: argocd
+spec:
+  project: team-a
+  source:
+    repoURL: https://github.com/example/guestbook.git
+    path: guestbook
+    targetRevision: v1.2.0
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: guestbook
+  syncPolicy:
+    automated:
+      prune: true
+    retry:
+      limit: 3
+`
+
+const liveApplication = `{"apiVersion":"argoproj.io/v1alpha1","kind":"Application","metadata":{"name":"guestbook","namespace":"argocd"},"spec":{"project":"default","source":{"repoURL":"https://github.com/example/guestbook.git","path":"guestbook","targetRevision":"v1.2.0"},"destination":{"server":"https://kubernetes.default.svc","namespace":"guestbook"},"syncPolicy":{"automated":{"prune":true},"retry":{"limit":3}}},"status":{}}`
+
+func TestCheckReportsOutOfBandChanges(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "kubectl")
+	body := "#!/bin/sh\nfor a in \"$@\"; do [ \"$a\" = guestbook ] && found=1; done\n" +
+		"[ -n \"$found\" ] && cat <<'JSON'\n" + liveApplication + "\nJSON\nexit 0\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	docs, err := manifest.Parser{}.Parse(filepath.Join(dir, "app.yaml"), []byte(gitApplication+"---\n"+strings.Replace(gitApplication, "name: guestbook", "name: unsynced", 1)))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	checker := NewChecker(config.Config{}, Options{Enabled: true, Cluster: cluster.Options{KubectlBinary: script}})
+	findings, err := checker.Check(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected one drift finding, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].RuleID != "CLUSTER_DRIFT" || !strings.Contains(findings[0].Message, `live spec.project is "default" but Git declares "team-a"`) {
+		t.Fatalf("unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestCompareMultiSourceRevisions(t *testing.T) {
+	desired := map[string]interface{}{"spec": map[string]interface{}{
+		"sources": []interface{}{
+			map[string]interface{}{"targetRevision": "1.0.0"},
+			map[string]interface{}{"targetRevision": "main"},
+		},
+	}}
+	live := map[string]interface{}{"spec": map[string]interface{}{
+		"sources": []interface{}{
+			map[string]interface{}{"targetRevision": "1.0.0"},
+			map[string]interface{}{"targetRevision": "hotfix"},
+		},
+		"syncPolicy": map[string]interface{}{},
+	}}
+	diffs := Compare(desired, live)
+	if len(diffs) != 1 || diffs[0].Path != "spec.sources[1].targetRevision" {
+		t.Fatalf("expected sources[1] revision drift only, got %+v", diffs)
+	}
+}
+
+func TestDiffIgnoresStatusDefaultsAndInjectedMetadata(t *testing.T) {
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "guestbook", "labels": map[string]interface{}{"team": "a"}},
+		"spec": map[string]interface{}{
+			"project":    "team-a",
+			"syncPolicy": map[string]interface{}{"automated": map[string]interface{}{"prune": true}},
+		},
+	}
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "guestbook",
+			"uid":             "1234",
+			"labels":          map[string]interface{}{"team": "a", "app.kubernetes.io/instance": "root"},
+			"annotations":     map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+			"resourceVersion": "42",
+		},
+		"spec": map[string]interface{}{
+			"project":              "team-b",
+			"revisionHistoryLimit": 0,
+			"syncPolicy":           map[string]interface{}{"automated": map[string]interface{}{"prune": true, "selfHeal": false}},
+			"ignoreDifferences":    []interface{}{map[string]interface{}{"kind": "Secret"}},
+		},
+		"status": map[string]interface{}{"sync": map[string]interface{}{"status": "Synced"}},
+	}
+	diffs := Diff(desired, live)
+	if len(diffs) != 2 || diffs[0].Path != "spec.ignoreDifferences" || diffs[1].String() != `spec.project: Git "team-a", live "team-b"` {
+		t.Fatalf("expected the out-of-band ignoreDifferences and project only, got %+v", diffs)
+	}
+}
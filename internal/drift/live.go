@@ -0,0 +1,163 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/cluster"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// diffResources maps the kinds compared by Live to their API resources.
+var diffResources = map[string]string{
+	string(types.ResourceKindApplication): "applications.argoproj.io",
+	string(types.ResourceKindAppProject):  "appprojects.argoproj.io",
+}
+
+// injectedMetadata lists labels and annotations that kubectl and Argo CD add
+// to live objects; they are ignored unless Git declares them.
+var injectedMetadata = map[string]bool{
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration": true,
+	"metadata.annotations.argocd.argoproj.io/tracking-id":                   true,
+	"metadata.labels.app.kubernetes.io/instance":                            true,
+}
+
+// ResourceDiff is the comparison of one Git manifest with its live object.
+type ResourceDiff struct {
+	Kind        string       `json:"kind"`
+	Namespace   string       `json:"namespace"`
+	Name        string       `json:"name"`
+	FilePath    string       `json:"file"`
+	Line        int          `json:"line"`
+	Missing     bool         `json:"missing,omitempty"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// Changed reports whether the live object is missing or differs from Git.
+func (d ResourceDiff) Changed() bool {
+	return d.Missing || len(d.Differences) > 0
+}
+
+// Live fetches the live counterpart of every Application and AppProject in
+// manifests and compares the two with Diff. Resources without a namespace
+// are looked up in namespace, or DefaultNamespace when that is empty.
+func Live(ctx context.Context, client *cluster.Client, manifests []*manifest.Manifest, namespace string) ([]ResourceDiff, error) {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	var diffs []ResourceDiff
+	for _, m := range manifests {
+		resource, ok := diffResources[m.Kind]
+		if !ok || strings.Contains(m.Name, "{{") {
+			continue
+		}
+		ns := m.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+		live, err := client.Get(ctx, resource, ns, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("fetch live %s %s/%s: %w", strings.ToLower(m.Kind), ns, m.Name, err)
+		}
+		diff := ResourceDiff{Kind: m.Kind, Namespace: ns, Name: m.Name, FilePath: m.FilePath, Line: m.MetadataLine}
+		if live == nil {
+			diff.Missing = true
+		} else {
+			diff.Differences = Diff(m.Object, live.Object)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// Diff returns every field of spec, labels, annotations, and finalizers
+// that differs between the desired and live objects. Status and other
+// server-populated metadata are not compared, and fields only the live
+// object sets are ignored while they hold a zero value, since those are
+// defaults rather than changes.
+func Diff(desired, live map[string]interface{}) []Difference {
+	var diffs []Difference
+	for _, path := range []string{"metadata.labels", "metadata.annotations", "metadata.finalizers", "spec"} {
+		diffs = appendDiff(diffs, path, normalize(lookup(desired, path)), normalize(lookup(live, path)))
+	}
+	return diffs
+}
+
+func appendDiff(diffs []Difference, path string, want, got interface{}) []Difference {
+	wantMap, wantIsMap := want.(map[string]interface{})
+	gotMap, gotIsMap := got.(map[string]interface{})
+	if want == nil && gotIsMap {
+		// Compare key by key so injected and defaulted keys are skipped.
+		wantMap, wantIsMap = map[string]interface{}{}, true
+	}
+	if wantIsMap && gotIsMap {
+		keys := make([]string, 0, len(wantMap)+len(gotMap))
+		for key := range wantMap {
+			keys = append(keys, key)
+		}
+		for key := range gotMap {
+			if _, ok := wantMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := path + "." + key
+			value, declared := wantMap[key]
+			if !declared && (isDefault(gotMap[key]) || injectedMetadata[child]) {
+				continue
+			}
+			diffs = appendDiff(diffs, child, normalize(value), normalize(gotMap[key]))
+		}
+		return diffs
+	}
+	wantList, wantIsList := want.([]interface{})
+	gotList, gotIsList := got.([]interface{})
+	if wantIsList && gotIsList && len(wantList) == len(gotList) {
+		for i := range wantList {
+			diffs = appendDiff(diffs, fmt.Sprintf("%s[%d]", path, i), normalize(wantList[i]), normalize(gotList[i]))
+		}
+		return diffs
+	}
+	if want == nil && isDefault(got) {
+		return diffs
+	}
+	if reflect.DeepEqual(want, got) {
+		return diffs
+	}
+	return append(diffs, Difference{Path: path, Desired: want, Live: got})
+}
+
+// isDefault reports whether a live value is a zero value, or a map or list
+// of them, which the API server or Argo CD fills in for omitted fields.
+func isDefault(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isDefault(item) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// String describes the difference as "path: Git <value>, live <value>".
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: Git %s, live %s", d.Path, render(d.Desired), render(d.Live))
+}
@@ -1,32 +1,49 @@
 package dryrun
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
 )
 
 // Options controls dry-run validation behaviour.
 type Options struct {
-	Mode              string
-	KubectlBinary     string
-	KubeconformBinary string
-	Kubeconfig        string
-	KubeContext       string
-	Enabled           bool
+	Mode        string
+	Kubeconfig  string
+	KubeContext string
+	Enabled     bool
+	// QPS and Burst limit the API requests of server mode (0 = client-go
+	// defaults).
+	QPS   float32
+	Burst int
+	// Kubeconform configures the embedded kubeconform validator used in
+	// kubeconform mode: schema locations, Kubernetes version, and whether
+	// resources without a schema are skipped.
+	Kubeconform kubeconform.Options
+	// Logger records each server dry-run and kubeconform validation with
+	// its duration.
+	Logger *slog.Logger
+	// Timeout bounds the whole dry-run stage (0 = no limit).
+	Timeout time.Duration
 }
 
-// Validator executes optional dry-run validation using kubectl or kubeconform.
+// Validator executes optional dry-run validation against the API server or
+// with the embedded kubeconform validator.
 type Validator struct {
 	cfg             config.Config
 	workdir         string
 	options         Options
+	logger          *slog.Logger
 	ruleServer      types.RuleMetadata
 	ruleKubeconform types.RuleMetadata
 }
@@ -42,9 +59,10 @@ func NewValidator(cfg config.Config, workdir string, opts Options) *Validator {
 		cfg:     cfg,
 		workdir: workdir,
 		options: opts,
+		logger:  logging.OrDiscard(opts.Logger),
 		ruleServer: types.RuleMetadata{
 			ID:              "DRYRUN_SERVER",
-			Description:     "kubectl --dry-run=server must succeed",
+			Description:     "Server-side apply dry-run must succeed",
 			DefaultSeverity: types.SeverityError,
 			AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
 			Category:        "validation",
@@ -66,103 +84,113 @@ func (v *Validator) Metadata() []types.RuleMetadata {
 	return []types.RuleMetadata{v.ruleServer, v.ruleKubeconform}
 }
 
-// Validate executes the configured dry-run mode against the provided manifests.
-func (v *Validator) Validate(ctx context.Context, manifests []*manifest.Manifest) ([]types.Finding, error) {
+// Rendered holds the resources an Application or ApplicationSet renders to.
+type Rendered struct {
+	Owner     *manifest.Manifest
+	Resources []map[string]interface{}
+}
+
+// Validate executes the configured dry-run mode against the provided
+// manifests and the resources they render to. Findings about a rendered
+// resource point at its owner and name the resource in the message.
+func (v *Validator) Validate(ctx context.Context, manifests []*manifest.Manifest, rendered []Rendered) ([]types.Finding, error) {
 	if !v.options.Enabled || v.options.Mode == "" {
 		return nil, nil
 	}
+	if v.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.options.Timeout)
+		defer cancel()
+	}
 	mode := strings.ToLower(v.options.Mode)
-	files := groupByFile(manifests)
+	targets := collectTargets(manifests, rendered)
+	var findings []types.Finding
+	var err error
 	switch mode {
 	case modeServer:
-		return v.validateKubectl(ctx, files)
+		findings, err = v.validateServer(ctx, targets)
 	case modeKubeconform:
-		return v.validateKubeconform(ctx, files)
+		findings, err = v.validateKubeconform(ctx, targets)
 	default:
 		return nil, fmt.Errorf("unsupported dry-run mode %q", v.options.Mode)
 	}
+	if errors.Is(err, context.DeadlineExceeded) && v.options.Timeout > 0 {
+		return nil, fmt.Errorf("dry-run timed out after %s: %w", v.options.Timeout, err)
+	}
+	return findings, err
 }
 
-func (v *Validator) validateKubectl(ctx context.Context, files map[string][]*manifest.Manifest) ([]types.Finding, error) {
-	var findings []types.Finding
-	for file, manifests := range files {
-		cfg, err := v.cfg.Resolve(v.ruleServer, file)
-		if err != nil {
-			return nil, err
-		}
-		if !cfg.Enabled {
-			continue
-		}
-		args := []string{"apply", "--dry-run=server", "--filename", file, "--validate=true"}
-		if v.options.Kubeconfig != "" {
-			args = append(args, "--kubeconfig", v.options.Kubeconfig)
-		}
-		if v.options.KubeContext != "" {
-			args = append(args, "--context", v.options.KubeContext)
-		}
-		binary := v.options.KubectlBinary
-		if strings.TrimSpace(binary) == "" {
-			binary = "kubectl"
-		}
-		msg, err := runCommand(ctx, v.workdir, binary, args...)
-		if err == nil {
-			continue
-		}
-		for _, m := range manifests {
-			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
-			findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+// target is one object to dry-run: a manifest itself, or a resource
+// rendered from it.
+type target struct {
+	owner  *manifest.Manifest
+	object map[string]interface{}
+	// rendered marks a resource rendered from owner.
+	rendered bool
+}
+
+func collectTargets(manifests []*manifest.Manifest, rendered []Rendered) []target {
+	targets := make([]target, 0, len(manifests))
+	for _, m := range manifests {
+		targets = append(targets, target{owner: m, object: m.Object})
+	}
+	for _, r := range rendered {
+		for _, obj := range r.Resources {
+			targets = append(targets, target{owner: r.Owner, object: obj, rendered: true})
 		}
 	}
-	return findings, nil
+	return targets
+}
+
+// resource names the object as Kind/name.
+func (t target) resource() string {
+	kind, _ := t.object["kind"].(string)
+	metadata, _ := t.object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return kind + "/" + name
+}
+
+// finding reports msg on the owner, naming the rendered resource.
+func (t target) finding(cfg types.ConfiguredRule, msg string) types.Finding {
+	if t.rendered {
+		msg = fmt.Sprintf("rendered %s: %s", t.resource(), msg)
+	}
+	m := t.owner
+	builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+	return builder.NewFinding(msg, cfg.Severity)
 }
 
-func (v *Validator) validateKubeconform(ctx context.Context, files map[string][]*manifest.Manifest) ([]types.Finding, error) {
+// validateKubeconform validates each target against its Kubernetes schema
+// and reports every violation.
+func (v *Validator) validateKubeconform(ctx context.Context, targets []target) ([]types.Finding, error) {
+	var validator *kubeconform.Validator
 	var findings []types.Finding
-	for file, manifests := range files {
-		cfg, err := v.cfg.Resolve(v.ruleKubeconform, file)
+	for _, t := range targets {
+		cfg, err := v.cfg.Resolve(v.ruleKubeconform, t.owner.FilePath)
 		if err != nil {
 			return nil, err
 		}
 		if !cfg.Enabled {
 			continue
 		}
-		binary := v.options.KubeconformBinary
-		if strings.TrimSpace(binary) == "" {
-			binary = "kubeconform"
+		if validator == nil {
+			if validator, err = kubeconform.New(v.options.Kubeconform); err != nil {
+				return nil, err
+			}
 		}
-		args := []string{"--summary", file}
-		msg, err := runCommand(ctx, v.workdir, binary, args...)
-		if err == nil {
-			continue
-		}
-		for _, m := range manifests {
-			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
-			findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+		data, err := yaml.Marshal(t.object)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", t.resource(), err)
 		}
-	}
-	return findings, nil
-}
-
-func groupByFile(manifests []*manifest.Manifest) map[string][]*manifest.Manifest {
-	files := make(map[string][]*manifest.Manifest)
-	for _, m := range manifests {
-		files[m.FilePath] = append(files[m.FilePath], m)
-	}
-	return files
-}
-
-func runCommand(ctx context.Context, dir, binary string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, binary, args...)
-	cmd.Dir = dir
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	err := cmd.Run()
-	output := strings.TrimSpace(strings.Join([]string{stdout.String(), stderr.String()}, "\n"))
-	if output == "" {
+		started := time.Now()
+		failures, err := validator.Validate(ctx, t.owner.FilePath, data)
+		v.logger.Debug("kubeconform validate", "file", t.owner.FilePath, "resource", t.resource(), "duration", time.Since(started), "error", err)
 		if err != nil {
-			output = err.Error()
+			return nil, fmt.Errorf("kubeconform %s: %w", t.owner.FilePath, err)
+		}
+		for _, failure := range failures {
+			findings = append(findings, t.finding(cfg, failure.Message))
 		}
 	}
-	return output, err
+	return findings, nil
 }
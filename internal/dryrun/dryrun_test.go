@@ -4,22 +4,44 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
+// writeSchemas writes an Application schema that requires spec.project and
+// returns kubeconform options that load it from disk.
+func writeSchemas(t *testing.T, dir string) kubeconform.Options {
+	t.Helper()
+	schema := `{"type": "object", "required": ["spec"], "properties": {"spec": {"type": "object", "required": ["project"]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "application-argoproj-v1alpha1.json"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	return kubeconform.Options{SchemaLocations: []string{filepath.Join(dir, "{{ .ResourceKind }}{{ .KindSuffix }}.json")}}
+}
+
+func application(spec map[string]interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		FilePath:   "app.yaml",
+		Kind:       string(types.ResourceKindApplication),
+		APIVersion: "argoproj.io/v1alpha1",
+		Name:       "demo",
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata":   map[string]interface{}{"name": "demo"},
+			"spec":       spec,
+		},
+	}
+}
+
 func TestKubeconformFailureProducesFinding(t *testing.T) {
-	workdir := t.TempDir()
-	script := filepath.Join(workdir, "kubeconform")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'mock error' 1>&2\nexit 4\n"), 0o755); err != nil {
-		t.Fatalf("write script: %v", err)
-	}
-	val := NewValidator(config.Config{}, workdir, Options{Enabled: true, Mode: modeKubeconform, KubeconformBinary: script})
-	app := &manifest.Manifest{FilePath: "app.yaml", Kind: string(types.ResourceKindApplication), Name: "demo"}
-	findings, err := val.Validate(context.Background(), []*manifest.Manifest{app})
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeKubeconform, Kubeconform: writeSchemas(t, t.TempDir())})
+	findings, err := val.Validate(context.Background(), []*manifest.Manifest{application(map[string]interface{}{})}, nil)
 	if err != nil {
 		t.Fatalf("validate: %v", err)
 	}
@@ -29,17 +51,14 @@ func TestKubeconformFailureProducesFinding(t *testing.T) {
 	if findings[0].RuleID != "DRYRUN_KUBECONFORM" {
 		t.Fatalf("expected DRYRUN_KUBECONFORM, got %s", findings[0].RuleID)
 	}
+	if want := "/spec: missing properties: 'project'"; findings[0].Message != want {
+		t.Fatalf("expected message %q, got %q", want, findings[0].Message)
+	}
 }
 
 func TestKubeconformSuccess(t *testing.T) {
-	workdir := t.TempDir()
-	script := filepath.Join(workdir, "kubeconform")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
-		t.Fatalf("write script: %v", err)
-	}
-	val := NewValidator(config.Config{}, workdir, Options{Enabled: true, Mode: modeKubeconform, KubeconformBinary: script})
-	app := &manifest.Manifest{FilePath: "app.yaml", Kind: string(types.ResourceKindApplication), Name: "demo"}
-	findings, err := val.Validate(context.Background(), []*manifest.Manifest{app})
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeKubeconform, Kubeconform: writeSchemas(t, t.TempDir())})
+	findings, err := val.Validate(context.Background(), []*manifest.Manifest{application(map[string]interface{}{"project": "default"})}, nil)
 	if err != nil {
 		t.Fatalf("validate: %v", err)
 	}
@@ -48,46 +67,55 @@ func TestKubeconformSuccess(t *testing.T) {
 	}
 }
 
-func TestKubectlFailureProducesFinding(t *testing.T) {
-	workdir := t.TempDir()
-	script := filepath.Join(workdir, "kubectl")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'server rejected manifest' 1>&2\nexit 1\n"), 0o755); err != nil {
-		t.Fatalf("write script: %v", err)
-	}
-	val := NewValidator(config.Config{}, workdir, Options{Enabled: true, Mode: modeServer, KubectlBinary: script})
-	app := &manifest.Manifest{FilePath: filepath.Join(workdir, "app.yaml"), Kind: string(types.ResourceKindApplication), Name: "demo"}
-	findings, err := val.Validate(context.Background(), []*manifest.Manifest{app})
+func TestKubeconformMissingSchemas(t *testing.T) {
+	opts := kubeconform.Options{SchemaLocations: []string{filepath.Join(t.TempDir(), "{{ .ResourceKind }}.json")}}
+	app := application(map[string]interface{}{"project": "default"})
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeKubeconform, Kubeconform: opts})
+	findings, err := val.Validate(context.Background(), []*manifest.Manifest{app}, nil)
 	if err != nil {
 		t.Fatalf("validate: %v", err)
 	}
-	if len(findings) != 1 {
-		t.Fatalf("expected one finding, got %d", len(findings))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "could not find schema") {
+		t.Fatalf("expected a missing schema finding, got %+v", findings)
 	}
-	if findings[0].RuleID != "DRYRUN_SERVER" {
-		t.Fatalf("expected DRYRUN_SERVER finding, got %s", findings[0].RuleID)
+
+	opts.IgnoreMissingSchemas = true
+	val = NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeKubeconform, Kubeconform: opts})
+	findings, err = val.Validate(context.Background(), []*manifest.Manifest{app}, nil)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected missing schemas to be ignored, got %+v", findings)
 	}
 }
 
-func TestKubectlSuccess(t *testing.T) {
-	workdir := t.TempDir()
-	script := filepath.Join(workdir, "kubectl")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
-		t.Fatalf("write script: %v", err)
-	}
-	val := NewValidator(config.Config{}, workdir, Options{Enabled: true, Mode: modeServer, KubectlBinary: script})
-	app := &manifest.Manifest{FilePath: "app.yaml", Kind: string(types.ResourceKindApplication), Name: "demo"}
-	findings, err := val.Validate(context.Background(), []*manifest.Manifest{app})
+func TestKubeconformValidatesRenderedResources(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{"type": "object", "required": ["data"]}`
+	if err := os.WriteFile(filepath.Join(dir, "configmap-v1.json"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	opts := kubeconform.Options{SchemaLocations: []string{filepath.Join(dir, "{{ .ResourceKind }}{{ .KindSuffix }}.json")}}
+	owner := application(map[string]interface{}{"project": "default"})
+	rendered := []Rendered{{Owner: owner, Resources: []map[string]interface{}{
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings"}},
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "flags"}, "data": map[string]interface{}{"a": "b"}},
+	}}}
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeKubeconform, Kubeconform: opts})
+	findings, err := val.Validate(context.Background(), nil, rendered)
 	if err != nil {
 		t.Fatalf("validate: %v", err)
 	}
-	if len(findings) != 0 {
-		t.Fatalf("expected no findings, got %d", len(findings))
+	want := "rendered ConfigMap/settings: missing properties: 'data'"
+	if len(findings) != 1 || findings[0].Message != want || findings[0].ResourceName != "demo" {
+		t.Fatalf("expected %q on the owning Application, got %+v", want, findings)
 	}
 }
 
 func TestUnsupportedModeReturnsError(t *testing.T) {
 	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: "bogus"})
-	if _, err := val.Validate(context.Background(), nil); err == nil {
+	if _, err := val.Validate(context.Background(), nil, nil); err == nil {
 		t.Fatalf("expected error for unsupported mode")
 	}
 }
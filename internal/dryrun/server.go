@@ -0,0 +1,208 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// fieldManager names argocd-lint as the owner of dry-run applies.
+const fieldManager = "argocd-lint"
+
+// serverClient applies manifests with server-side dry-run.
+type serverClient struct {
+	dynamic   dynamic.Interface
+	mapper    meta.RESTMapper
+	namespace string
+}
+
+// newServerClient builds a dynamic client from the kubeconfig and context in
+// opts, rate limited by QPS and Burst.
+func newServerClient(opts Options) (*serverClient, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.KubeContext}
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+	restConfig, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	if opts.QPS > 0 {
+		restConfig.QPS = opts.QPS
+	}
+	if opts.Burst > 0 {
+		restConfig.Burst = opts.Burst
+	}
+	restConfig.UserAgent = fieldManager
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create discovery client: %w", err)
+	}
+	return &serverClient{
+		dynamic:   client,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disco)),
+		namespace: namespace,
+	}, nil
+}
+
+// apply dry-runs a server-side apply of one object. Namespaced objects
+// without a namespace go to namespace, or the kubeconfig's namespace when it
+// is empty. A rejection by the API server is returned as a message, a kind
+// the server does not serve as a no-match error, and other errors, such as
+// an unreachable server, as errors.
+func (c *serverClient) apply(ctx context.Context, object map[string]interface{}, namespace string) (string, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return err.Error(), nil
+	}
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}, gvk.Version)
+	if err != nil {
+		return "", err
+	}
+	resource := c.dynamic.Resource(mapping.Resource)
+	var target dynamic.ResourceInterface = resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		switch {
+		case obj.GetNamespace() != "":
+			namespace = obj.GetNamespace()
+		case namespace == "":
+			namespace = c.namespace
+		}
+		target = resource.Namespace(namespace)
+	}
+	_, err = target.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: fieldManager,
+		Force:        true,
+	})
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return err.Error(), nil
+	}
+	return "", err
+}
+
+// validateServer dry-runs each target against the API server and reports
+// every rejection. Rendered resources go to their owner's destination
+// namespace, and, as in Argo CD, kinds the server does not serve are
+// skipped when the owner or resource sets SkipDryRunOnMissingResource=true.
+func (v *Validator) validateServer(ctx context.Context, targets []target) ([]types.Finding, error) {
+	var client *serverClient
+	var findings []types.Finding
+	for _, t := range targets {
+		cfg, err := v.cfg.Resolve(v.ruleServer, t.owner.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		if !cfg.Enabled {
+			continue
+		}
+		if client == nil {
+			if client, err = newServerClient(v.options); err != nil {
+				return nil, err
+			}
+		}
+		namespace := ""
+		if t.rendered {
+			namespace = destinationNamespace(t.owner)
+		}
+		started := time.Now()
+		msg, err := client.apply(ctx, t.object, namespace)
+		v.logger.Debug("server dry-run", "file", t.owner.FilePath, "resource", t.resource(), "duration", time.Since(started), "error", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("server dry-run %s: %w", t.resource(), ctxErr)
+		}
+		if meta.IsNoMatchError(err) {
+			if t.rendered && skipMissingResource(t) {
+				continue
+			}
+			apiVersion, _ := t.object["apiVersion"].(string)
+			kind, _ := t.object["kind"].(string)
+			msg, err = fmt.Sprintf("the server does not serve %s %s", apiVersion, kind), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("server dry-run %s: %w", t.resource(), err)
+		}
+		if msg != "" {
+			findings = append(findings, t.finding(cfg, msg))
+		}
+	}
+	return findings, nil
+}
+
+// appSpec returns the spec of an Application, or of the Application
+// template of an ApplicationSet.
+func appSpec(m *manifest.Manifest) map[string]interface{} {
+	spec, _ := m.Object["spec"].(map[string]interface{})
+	if m.Kind == string(types.ResourceKindApplicationSet) {
+		template, _ := spec["template"].(map[string]interface{})
+		spec, _ = template["spec"].(map[string]interface{})
+	}
+	return spec
+}
+
+// destinationNamespace returns the namespace an Application, or the
+// Applications an ApplicationSet generates, deploy to. Templated namespaces
+// are not resolved.
+func destinationNamespace(m *manifest.Manifest) string {
+	spec := appSpec(m)
+	destination, _ := spec["destination"].(map[string]interface{})
+	namespace, _ := destination["namespace"].(string)
+	if strings.Contains(namespace, "{{") {
+		return ""
+	}
+	return namespace
+}
+
+// skipMissingResource reports whether SkipDryRunOnMissingResource=true is set
+// in the owner's syncPolicy.syncOptions or the resource's
+// argocd.argoproj.io/sync-options annotation.
+func skipMissingResource(t target) bool {
+	const option = "SkipDryRunOnMissingResource=true"
+	spec := appSpec(t.owner)
+	syncPolicy, _ := spec["syncPolicy"].(map[string]interface{})
+	options, _ := syncPolicy["syncOptions"].([]interface{})
+	for _, item := range options {
+		if s, _ := item.(string); strings.TrimSpace(s) == option {
+			return true
+		}
+	}
+	metadata, _ := t.object["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	value, _ := annotations["argocd.argoproj.io/sync-options"].(string)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == option {
+			return true
+		}
+	}
+	return false
+}
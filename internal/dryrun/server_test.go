@@ -0,0 +1,196 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+var discoveryDocs = map[string]string{
+	"/api":                       `{"kind": "APIVersions", "versions": ["v1"]}`,
+	"/api/v1":                    `{"kind": "APIResourceList", "groupVersion": "v1", "resources": [{"name": "configmaps", "singularName": "configmap", "namespaced": true, "kind": "ConfigMap", "verbs": ["get", "patch"]}]}`,
+	"/apis":                      `{"kind": "APIGroupList", "apiVersion": "v1", "groups": [{"name": "argoproj.io", "versions": [{"groupVersion": "argoproj.io/v1alpha1", "version": "v1alpha1"}], "preferredVersion": {"groupVersion": "argoproj.io/v1alpha1", "version": "v1alpha1"}}]}`,
+	"/apis/argoproj.io/v1alpha1": `{"kind": "APIResourceList", "groupVersion": "argoproj.io/v1alpha1", "resources": [{"name": "applications", "singularName": "application", "namespaced": true, "kind": "Application", "verbs": ["get", "patch"]}]}`,
+}
+
+// fakeAPIServer serves discovery for Applications and passes apply requests
+// to apply. It returns a kubeconfig for the server whose default namespace
+// is argocd.
+func fakeAPIServer(t *testing.T, apply http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := discoveryDocs[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, body)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			apply(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return writeKubeconfig(t, server.URL)
+}
+
+// writeKubeconfig writes a kubeconfig for the API server at url.
+func writeKubeconfig(t *testing.T, url string) string {
+	t.Helper()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+  - name: fake
+    cluster: {server: %s}
+contexts:
+  - name: fake
+    context: {cluster: fake, user: fake, namespace: argocd}
+users:
+  - name: fake
+    user: {token: test}
+current-context: fake
+`, url)
+	if err := os.WriteFile(kubeconfig, []byte(content), 0o600); err != nil {
+		t.Fatalf("write kubeconfig: %v", err)
+	}
+	return kubeconfig
+}
+
+func TestServerDryRunReportsEachResource(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	kubeconfig := fakeAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		mu.Unlock()
+		var obj map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			t.Errorf("decode apply body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if spec, _ := obj["spec"].(map[string]interface{}); spec["project"] == nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = io.WriteString(w, `{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "Invalid", "code": 422, "message": "Application.argoproj.io \"broken\" is invalid: spec.project: Required value"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(obj)
+	})
+	valid := application(map[string]interface{}{"project": "default"})
+	broken := application(map[string]interface{}{})
+	broken.Name = "broken"
+	broken.Object["metadata"] = map[string]interface{}{"name": "broken", "namespace": "apps"}
+	unknown := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     "Widget",
+		Name:     "gadget",
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "gadget"},
+		},
+	}
+
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeServer, Kubeconfig: kubeconfig, QPS: 50, Burst: 100})
+	findings, err := val.Validate(context.Background(), []*manifest.Manifest{valid, broken, unknown}, nil)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := []string{
+		`DRYRUN_SERVER broken: Application.argoproj.io "broken" is invalid: spec.project: Required value`,
+		`DRYRUN_SERVER gadget: the server does not serve example.com/v1 Widget`,
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, f := range findings {
+		if got := f.RuleID + " " + f.ResourceName + ": " + f.Message; got != want[i] {
+			t.Fatalf("finding %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+	for i, path := range []string{"/apis/argoproj.io/v1alpha1/namespaces/argocd/applications/demo", "/apis/argoproj.io/v1alpha1/namespaces/apps/applications/broken"} {
+		if i >= len(requests) || !strings.HasPrefix(requests[i], path+"?") {
+			t.Fatalf("expected apply %d at %s, got %v", i, path, requests)
+		}
+		for _, param := range []string{"dryRun=All", "fieldManager=argocd-lint", "force=true"} {
+			if !strings.Contains(requests[i], param) {
+				t.Fatalf("expected apply %s to carry %s", requests[i], param)
+			}
+		}
+	}
+}
+
+func TestServerDryRunValidatesRenderedResources(t *testing.T) {
+	var requests []string
+	kubeconfig := fakeAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "Invalid", "code": 422, "message": "ConfigMap \"settings\" is invalid: data[bad key]: Invalid value"}`)
+	})
+	owner := application(map[string]interface{}{
+		"project":     "default",
+		"destination": map[string]interface{}{"namespace": "demo"},
+		"syncPolicy":  map[string]interface{}{"syncOptions": []interface{}{"SkipDryRunOnMissingResource=true"}},
+	})
+	rendered := []Rendered{{Owner: owner, Resources: []map[string]interface{}{
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings"}},
+		{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": map[string]interface{}{"name": "gadget"}},
+	}}}
+
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeServer, Kubeconfig: kubeconfig})
+	findings, err := val.Validate(context.Background(), nil, rendered)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := `DRYRUN_SERVER demo: rendered ConfigMap/settings: ConfigMap "settings" is invalid: data[bad key]: Invalid value`
+	if len(findings) != 1 || findings[0].RuleID+" "+findings[0].ResourceName+": "+findings[0].Message != want {
+		t.Fatalf("expected %q, got %+v", want, findings)
+	}
+	if len(requests) != 1 || requests[0] != "/api/v1/namespaces/demo/configmaps/settings" {
+		t.Fatalf("expected the ConfigMap to be applied in the destination namespace, got %v", requests)
+	}
+}
+
+func TestServerDryRunUnreachableReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	kubeconfig := writeKubeconfig(t, server.URL)
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeServer, Kubeconfig: kubeconfig})
+	if _, err := val.Validate(context.Background(), []*manifest.Manifest{application(map[string]interface{}{"project": "default"})}, nil); err == nil {
+		t.Fatalf("expected an error for an unreachable API server")
+	}
+}
+
+func TestValidateTimeoutReturnsError(t *testing.T) {
+	kubeconfig := fakeAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	val := NewValidator(config.Config{}, "", Options{Enabled: true, Mode: modeServer, Kubeconfig: kubeconfig, Timeout: 100 * time.Millisecond})
+	started := time.Now()
+	_, err := val.Validate(context.Background(), []*manifest.Manifest{application(map[string]interface{}{"project": "default"})}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Fatalf("expected the hung request to be abandoned, took %s", elapsed)
+	}
+}
@@ -0,0 +1,139 @@
+// Package fetch downloads plugin bundles and config files over HTTPS into a
+// local cache, so one policy source can be shared across many repositories.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Client performs downloads. Tests may replace it.
+var Client = &http.Client{Timeout: 5 * time.Minute}
+
+// MaxSize caps the size of a downloaded file.
+const MaxSize = 64 << 20
+
+var checksumPattern = regexp.MustCompile(`^sha256=([0-9a-f]{64})$`)
+
+// IsURL reports whether ref is an HTTPS URL rather than a local path.
+func IsURL(ref string) bool {
+	return strings.HasPrefix(ref, "https://")
+}
+
+// DefaultCacheDir returns argocd-lint under the user cache directory.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache directory: %w", err)
+	}
+	return filepath.Join(base, "argocd-lint"), nil
+}
+
+// File downloads ref into cacheDir and returns the local path, which keeps
+// the base name of the URL. A #sha256=<hex> fragment pins the content: the
+// download must match it, and a cached copy is used without contacting the
+// server. Unpinned URLs are downloaded on every call. An empty cacheDir uses
+// DefaultCacheDir.
+func File(ctx context.Context, ref, cacheDir string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", ref, err)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("%s: only https URLs are supported", ref)
+	}
+	var pinned string
+	if u.Fragment != "" {
+		m := checksumPattern.FindStringSubmatch(u.Fragment)
+		if m == nil {
+			return "", fmt.Errorf("%s: fragment must be sha256=<64 lowercase hex digits>", ref)
+		}
+		pinned = m[1]
+	}
+	u.Fragment = ""
+	if cacheDir == "" {
+		if cacheDir, err = DefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	root := filepath.Join(cacheDir, "https", "sha256")
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "download"
+	}
+	if pinned != "" {
+		cached := filepath.Join(root, pinned, name)
+		if _, err := os.Stat(cached); err == nil {
+			return cached, nil
+		}
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(root, ".fetch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	sum, err := download(ctx, u.String(), filepath.Join(tmp, name))
+	if err != nil {
+		return "", fmt.Errorf("fetch %s: %w", u, err)
+	}
+	if pinned != "" && sum != pinned {
+		return "", fmt.Errorf("fetch %s: checksum mismatch: expected sha256 %s, got %s", u, pinned, sum)
+	}
+	// The same content may be cached under another name or by another run;
+	// renaming over an existing copy is harmless.
+	dir := filepath.Join(root, sum)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(filepath.Join(tmp, name), filepath.Join(dir, name)); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// download writes the response body for rawURL to dest and returns its
+// hex-encoded SHA-256.
+func download(ctx context.Context, rawURL, dest string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(resp.Body, MaxSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if n > MaxSize {
+		return "", fmt.Errorf("response exceeds %d bytes", MaxSize)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileVerifiesAndCachesPinnedDownloads(t *testing.T) {
+	body := "severityThreshold: warn\n"
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/argocd-lint.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	previous := Client
+	Client = server.Client()
+	defer func() { Client = previous }()
+
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	sum := sha256.Sum256([]byte(body))
+	pin := hex.EncodeToString(sum[:])
+	ref := server.URL + "/argocd-lint.yaml#sha256=" + pin
+
+	path, err := File(ctx, ref, cacheDir)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if want := filepath.Join(cacheDir, "https", "sha256", pin, "argocd-lint.yaml"); path != want {
+		t.Fatalf("expected %s, got %s", want, path)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != body {
+		t.Fatalf("unexpected cached content %q (%v)", data, err)
+	}
+	if _, err := File(ctx, ref, cacheDir); err != nil || requests != 1 {
+		t.Fatalf("expected a pinned download to be served from the cache, got %d requests (%v)", requests, err)
+	}
+	if _, err := File(ctx, server.URL+"/argocd-lint.yaml", cacheDir); err != nil || requests != 2 {
+		t.Fatalf("expected unpinned URLs to be downloaded again, got %d requests (%v)", requests, err)
+	}
+
+	wrong := strings.Repeat("0", 64)
+	if _, err := File(ctx, server.URL+"/argocd-lint.yaml#sha256="+wrong, cacheDir); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "https", "sha256", wrong)); !os.IsNotExist(err) {
+		t.Fatalf("expected a mismatched download not to be cached, got %v", err)
+	}
+	for ref, want := range map[string]string{
+		server.URL + "/missing.yaml":            "404",
+		server.URL + "/argocd-lint.yaml#md5=1":  "fragment must be sha256",
+		"http://policies.corp/argocd-lint.yaml": "only https",
+	} {
+		if _, err := File(ctx, ref, cacheDir); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected an error containing %q, got %v", ref, want, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Binary is the git executable used for repository queries.
+var Binary = "git"
+
+// Protocols are the transports LsRemote and ShallowClone may use for repository URLs taken
+// from manifests. Tests may replace it.
+var Protocols = []string{"https", "ssh", "git"}
+
+// CheckRemote rejects repository URLs that git would read as an option and
+// URLs whose transport is not in Protocols, such as ext:: commands, file://
+// URLs, and local paths (transport "file").
+func CheckRemote(repo string) error {
+	repo = strings.TrimSpace(repo)
+	if repo == "" {
+		return fmt.Errorf("repository URL is empty")
+	}
+	if strings.HasPrefix(repo, "-") {
+		return fmt.Errorf("repository URL %q must not start with '-'", repo)
+	}
+	transport := "file"
+	if idx := strings.Index(repo, "://"); idx > 0 {
+		transport = strings.ToLower(repo[:idx])
+	} else if strings.Contains(repo, "::") {
+		transport = repo[:strings.Index(repo, "::")]
+	} else if colon := strings.Index(repo, ":"); colon > 0 && !strings.Contains(repo[:colon], "/") {
+		// scp-like [user@]host:path
+		transport = "ssh"
+	}
+	for _, allowed := range Protocols {
+		if transport == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("repository URL %q uses transport %q; allowed: %s", repo, transport, strings.Join(Protocols, ", "))
+}
+
+// remoteEnv restricts the transports git may use, including for redirects
+// and submodules, to Protocols.
+func remoteEnv() []string {
+	return append(os.Environ(), "GIT_ALLOW_PROTOCOL="+strings.Join(Protocols, ":"))
+}
+
+// RemoteURL returns the fetch URL of the origin remote for the repository at dir.
+func RemoteURL(ctx context.Context, dir string) (string, error) {
+	return run(ctx, dir, "config", "--get", "remote.origin.url")
+}
+
+// TopLevel returns the root of the working tree holding dir.
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	return run(ctx, dir, "rev-parse", "--show-toplevel")
+}
+
+// CurrentBranch returns the checked-out branch name for the repository at dir.
+func CurrentBranch(ctx context.Context, dir string) (string, error) {
+	return run(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// ChangedFiles returns absolute paths of files added, copied, modified, or
+// renamed in the working tree at dir since its merge base with ref, including
+// uncommitted and untracked files.
+func ChangedFiles(ctx context.Context, dir, ref string) ([]string, error) {
+	top, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	base, err := run(ctx, dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := run(ctx, top, "diff", "--name-only", "--diff-filter=ACMR", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := run(ctx, top, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	files := []string{}
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		files = append(files, filepath.Join(top, filepath.FromSlash(line)))
+	}
+	return files, nil
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	return runEnv(ctx, dir, nil, args...)
+}
+
+func runEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, Binary, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Ref is a remote reference reported by git ls-remote.
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// LsRemote lists the HEAD, branch, and tag references advertised by repo,
+// which must pass CheckRemote.
+func LsRemote(ctx context.Context, repo string) ([]Ref, error) {
+	if err := CheckRemote(repo); err != nil {
+		return nil, err
+	}
+	out, err := runEnv(ctx, "", remoteEnv(), "ls-remote", "--tags", "--heads", "--refs", "--", repo)
+	if err != nil {
+		return nil, err
+	}
+	head, err := runEnv(ctx, "", remoteEnv(), "ls-remote", "--", repo, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var refs []Ref
+	for _, line := range strings.Split(head+"\n"+out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs = append(refs, Ref{Name: fields[1], Hash: fields[0]})
+	}
+	return refs, nil
+}
+
+// ShallowClone fetches revision (a branch, tag, or commit; HEAD when empty)
+// of repo, which must pass CheckRemote, into dir with depth 1 and checks it
+// out.
+func ShallowClone(ctx context.Context, repo, revision, dir string) error {
+	if err := CheckRemote(repo); err != nil {
+		return err
+	}
+	if revision == "" {
+		revision = "HEAD"
+	}
+	if strings.HasPrefix(revision, "-") {
+		return fmt.Errorf("revision %q must not start with '-'", revision)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if _, err := run(ctx, dir, "init", "--quiet"); err != nil {
+		return err
+	}
+	if _, err := runEnv(ctx, dir, remoteEnv(), "fetch", "--quiet", "--depth", "1", "--", repo, revision); err != nil {
+		return err
+	}
+	_, err := run(ctx, dir, "checkout", "--quiet", "FETCH_HEAD")
+	return err
+}
+
+// SameRepo reports whether two repoURLs name the same repository, ignoring
+// the scheme, credentials, case, and a trailing .git or slash.
+func SameRepo(a, b string) bool {
+	normalize := func(repo string) string {
+		repo = strings.ToLower(strings.TrimSpace(repo))
+		if idx := strings.Index(repo, "://"); idx >= 0 {
+			repo = repo[idx+3:]
+		} else if at := strings.Index(repo, "@"); at >= 0 {
+			// scp-like git@host:org/repo
+			repo = strings.Replace(repo[at+1:], ":", "/", 1)
+		}
+		if at := strings.Index(repo, "@"); at >= 0 && at < strings.Index(repo+"/", "/") {
+			repo = repo[at+1:]
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	}
+	return a != "" && normalize(a) == normalize(b)
+}
@@ -0,0 +1,140 @@
+// Package kubeconform validates Kubernetes resources against JSON schemas
+// with the embedded kubeconform validator, so no kubeconform binary is needed.
+package kubeconform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// DefaultSchemaLocation selects kubeconform's published Kubernetes schemas.
+const DefaultSchemaLocation = "default"
+
+// Options configures schema validation.
+type Options struct {
+	// SchemaLocations are tried in order for each resource: "default", a
+	// URL, or a local path, optionally templated like kubeconform's
+	// -schema-location. Empty means DefaultSchemaLocation.
+	SchemaLocations []string
+	// KubernetesVersion selects the schema version, e.g. 1.29 or v1.29.3.
+	// Empty validates against the latest schemas.
+	KubernetesVersion string
+	// IgnoreMissingSchemas skips resources without a schema, such as custom
+	// resources, instead of failing them.
+	IgnoreMissingSchemas bool
+}
+
+// Failure is a resource that failed validation.
+type Failure struct {
+	Kind    string
+	Name    string
+	Message string
+}
+
+// Validator validates resource streams. It is safe for concurrent use and
+// downloads each schema once.
+type Validator struct {
+	validator validator.Validator
+}
+
+// New constructs a Validator.
+func New(opts Options) (*Validator, error) {
+	version, err := normalizeVersion(opts.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+	locations := opts.SchemaLocations
+	if len(locations) == 0 {
+		locations = []string{DefaultSchemaLocation}
+	}
+	v, err := validator.New(locations, validator.Opts{
+		KubernetesVersion:    version,
+		IgnoreMissingSchemas: opts.IgnoreMissingSchemas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubeconform: %w", err)
+	}
+	return &Validator{validator: v}, nil
+}
+
+// Validate validates the YAML or JSON resources in data, named name in
+// kubeconform's messages, and returns the ones that are invalid or could not
+// be validated. A done ctx abandons the validation and returns its error.
+func (v *Validator) Validate(ctx context.Context, name string, data []byte) ([]Failure, error) {
+	done := make(chan []validator.Result, 1)
+	go func() {
+		done <- v.validator.ValidateWithContext(ctx, name, io.NopCloser(bytes.NewReader(data)))
+	}()
+	var results []validator.Result
+	select {
+	case results = <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var failures []Failure
+	for _, res := range results {
+		if res.Status != validator.Invalid && res.Status != validator.Error {
+			continue
+		}
+		failure := Failure{Message: message(res)}
+		if sig, err := res.Resource.Signature(); err == nil && sig != nil {
+			failure.Kind, failure.Name = sig.Kind, sig.Name
+		}
+		failures = append(failures, failure)
+	}
+	return failures, nil
+}
+
+// message describes a failed result, listing each schema violation with its
+// path when kubeconform reports them.
+func message(res validator.Result) string {
+	if len(res.ValidationErrors) == 0 {
+		if res.Err != nil {
+			return res.Err.Error()
+		}
+		return "validation failed"
+	}
+	parts := make([]string, 0, len(res.ValidationErrors))
+	for _, ve := range res.ValidationErrors {
+		if ve.Path != "" {
+			parts = append(parts, ve.Path+": "+ve.Msg)
+		} else {
+			parts = append(parts, ve.Msg)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// normalizeVersion converts a Kubernetes version such as v1.29 to the
+// major.minor.patch form kubeconform's schema paths use.
+func normalizeVersion(version string) (string, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	// Schemas are published per release; drop pre-release and build suffixes.
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	if version == "" || version == "master" {
+		return "", nil
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf("kubeconform: invalid Kubernetes version %q (want major.minor[.patch])", version)
+	}
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return "", fmt.Errorf("kubeconform: invalid Kubernetes version %q (want major.minor[.patch])", version)
+		}
+	}
+	if len(parts) == 2 {
+		version += ".0"
+	}
+	return version, nil
+}
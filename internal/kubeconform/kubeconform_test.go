@@ -0,0 +1,80 @@
+package kubeconform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	// Versioned layout, as kubeconform's default location uses.
+	schemaDir := filepath.Join(dir, "v1.29.0")
+	if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	schema := `{"type": "object", "properties": {"spec": {"type": "object", "required": ["ports"]}}}`
+	if err := os.WriteFile(filepath.Join(schemaDir, "service-v1.json"), []byte(schema), 0o600); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	v, err := New(Options{
+		SchemaLocations:      []string{filepath.Join(dir, "{{ .NormalizedKubernetesVersion }}/{{ .ResourceKind }}{{ .KindSuffix }}.json")},
+		KubernetesVersion:    "v1.29",
+		IgnoreMissingSchemas: true,
+	})
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	data := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+spec:
+  ports: []
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: custom
+`)
+	failures, err := v.Validate(context.Background(), "stdin", data)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := Failure{Kind: "Service", Name: "web", Message: "/spec: missing properties: 'ports'"}
+	if len(failures) != 1 || failures[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, failures)
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	cases := map[string]string{
+		"":             "",
+		"master":       "",
+		"1.29":         "1.29.0",
+		"v1.29.3":      "1.29.3",
+		"v1.30.1-gke1": "1.30.1",
+		"1.28.2+k3s1":  "1.28.2",
+	}
+	for in, want := range cases {
+		got, err := normalizeVersion(in)
+		if err != nil {
+			t.Fatalf("normalizeVersion(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("normalizeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"1", "latest", "1.x", "1.2.3.4"} {
+		if _, err := normalizeVersion(in); err == nil {
+			t.Fatalf("normalizeVersion(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package lint
+
+import (
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// FailingFindings returns the findings that should make the run exit
+// non-zero under the severity threshold and the exit policy of cfg, with
+// its per-rule and per-category thresholds. With fail-on "new" baseline aging
+// reminders are ignored, since baseline-covered findings are already
+// filtered from the report.
+func FailingFindings(report Report, threshold types.Severity, cfg config.Config) []types.Finding {
+	mode, err := config.ParseFailOn(cfg.ExitPolicy.FailOn)
+	if err != nil || mode == config.FailOnNone {
+		return nil
+	}
+	var failing []types.Finding
+	for _, f := range report.Findings {
+		if mode == config.FailOnNew && f.RuleID == baselineAgedMeta.ID {
+			continue
+		}
+		category := f.Category
+		if category == "" {
+			category = report.RuleIndex[f.RuleID].Category
+		}
+		limit, ok := cfg.ExitPolicy.Threshold(f.RuleID, category, threshold)
+		if !ok {
+			continue
+		}
+		if types.SeverityOrder[f.Severity] >= types.SeverityOrder[limit] {
+			failing = append(failing, f)
+		}
+	}
+	return failing
+}
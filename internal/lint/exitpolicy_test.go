@@ -0,0 +1,44 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestFailingFindings(t *testing.T) {
+	report := Report{
+		Findings: []types.Finding{
+			{RuleID: "AR002", Category: "security", Severity: types.SeverityWarn},
+			{RuleID: "AR005", Category: "operations", Severity: types.SeverityError},
+			{RuleID: baselineAgedMeta.ID, Severity: types.SeverityWarn},
+		},
+		RuleIndex: map[string]types.RuleMetadata{baselineAgedMeta.ID: baselineAgedMeta},
+	}
+	if got := FailingFindings(report, types.SeverityWarn, config.Config{}); len(got) != 3 {
+		t.Fatalf("expected every warn finding to fail by default, got %d", len(got))
+	}
+	if got := FailingFindings(report, types.SeverityInfo, config.Config{ExitPolicy: config.ExitPolicy{FailOn: config.FailOnNone}}); len(got) != 0 {
+		t.Fatalf("expected report-only mode to never fail, got %d", len(got))
+	}
+	if got := FailingFindings(report, types.SeverityWarn, config.Config{ExitPolicy: config.ExitPolicy{FailOn: config.FailOnNew}}); len(got) != 2 {
+		t.Fatalf("expected baseline aging reminders to be ignored for fail-on new, got %d", len(got))
+	}
+	securityOnly := config.ExitPolicy{CategoryThresholds: map[string]string{"security": "warn", "*": "none"}}
+	got := FailingFindings(report, types.SeverityError, config.Config{ExitPolicy: securityOnly})
+	if len(got) != 1 || got[0].RuleID != "AR002" {
+		t.Fatalf("expected only the security finding to fail, got %+v", got)
+	}
+
+	thresholds := config.Config{
+		ExitPolicy: config.ExitPolicy{CategoryThresholds: map[string]string{"Security": "warn", "AR005": "none", "*": "info"}},
+	}
+	if got := FailingFindings(report, types.SeverityError, thresholds); len(got) != 2 || got[0].RuleID != "AR002" || got[1].RuleID != baselineAgedMeta.ID {
+		t.Fatalf("expected rule ID entries to win over categories, and * to cover the rest, got %+v", got)
+	}
+	thresholds.OverrideThreshold("error")
+	if got := FailingFindings(report, types.SeverityError, thresholds); len(got) != 1 || got[0].RuleID != "AR002" {
+		t.Fatalf("expected --severity-threshold to replace *, got %+v", got)
+	}
+}
@@ -2,17 +2,24 @@ package lint
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/drift"
 	"github.com/argocd-lint/argocd-lint/internal/dryrun"
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
 	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/internal/outdated"
 	"github.com/argocd-lint/argocd-lint/internal/render"
 	"github.com/argocd-lint/argocd-lint/internal/rule"
 	"github.com/argocd-lint/argocd-lint/internal/schema"
@@ -34,6 +41,29 @@ type Options struct {
 	MaxParallel            int
 	Baseline               *Baseline
 	BaselineAgingDays      int
+	Outdated               outdated.Options
+	Drift                  drift.Options
+	// Manifests are pre-parsed resources (for example fetched from a cluster)
+	// linted alongside, or instead of, the files discovered under Target.
+	Manifests []*manifest.Manifest
+	// ChangedFiles, when non-nil, restricts findings to manifests from these
+	// files. All other manifests are still loaded so cross-resource rules can
+	// resolve AppProjects and detect duplicate names.
+	ChangedFiles []string
+	// Targets are additional files or directories linted together with
+	// Target, so cross-resource rules see manifests from all of them.
+	Targets []string
+	// Ignore skips files and directories (from .argocdlintignore and
+	// --exclude) while discovering manifests under the targets.
+	Ignore *loader.Ignore
+	// RepoRoot resolves Application source paths for the rules that follow
+	// them into the repository. It must be the repository root, since those
+	// rules report paths missing below it; leave it empty otherwise.
+	RepoRoot string
+	// Logger receives debug records about discovery, skipped rules, and
+	// stage timings. It is also handed to render and dry-run unless they
+	// have their own.
+	Logger *slog.Logger
 }
 
 // Report is the lint result collection.
@@ -41,6 +71,7 @@ type Report struct {
 	Findings   []types.Finding
 	RuleIndex  map[string]types.RuleMetadata
 	Suppressed []types.Finding
+	Outdated   []outdated.Entry
 }
 
 // Runner orchestrates parsing, validation, and rule checks.
@@ -71,6 +102,29 @@ func NewRunner(cfg config.Config, workdir, schemaVersion string) (*Runner, error
 	}, nil
 }
 
+// AddSchemaDirs loads custom resource schemas from dirs. They replace the
+// embedded Argo CD schemas for the kinds they cover and, when rendering,
+// validate the rendered resources too.
+func (r *Runner) AddSchemaDirs(dirs ...string) error {
+	for _, dir := range dirs {
+		if err := r.schema.AddSchemaDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddCRDs loads the schemas of CustomResourceDefinitions, such as the Argo
+// CD CRDs read from a cluster for schema.VersionFromCluster.
+func (r *Runner) AddCRDs(crds ...map[string]interface{}) error {
+	for _, crd := range crds {
+		if err := r.schema.AddCRD(crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RegisterPlugins registers additional rule plugins.
 func (r *Runner) RegisterPlugins(plugins ...plugin.RulePlugin) {
 	if r.plugins == nil {
@@ -79,68 +133,188 @@ func (r *Runner) RegisterPlugins(plugins ...plugin.RulePlugin) {
 	r.plugins.Register(plugins...)
 }
 
+// Catalog lists metadata for every rule the runner can report, including the
+// render, dry-run, outdated, and drift checks that only run when enabled.
+func (r *Runner) Catalog() ([]types.RuleMetadata, error) {
+	index := r.baseRuleIndex()
+	renderer, err := render.NewRenderer(r.cfg, render.Options{})
+	if err != nil {
+		return nil, err
+	}
+	optional := [][]types.RuleMetadata{
+		renderer.Metadata(),
+		dryrun.NewValidator(r.cfg, r.workdir, dryrun.Options{}).Metadata(),
+		outdated.NewChecker(r.cfg, outdated.Options{}).Metadata(),
+		drift.NewChecker(r.cfg, drift.Options{}).Metadata(),
+	}
+	for _, metas := range optional {
+		for _, meta := range metas {
+			index[meta.ID] = meta
+		}
+	}
+	catalog := make([]types.RuleMetadata, 0, len(index))
+	for _, meta := range index {
+		catalog = append(catalog, meta)
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].ID < catalog[j].ID })
+	return catalog, nil
+}
+
+// baseRuleIndex indexes the schema, built-in, waiver, baseline, and plugin
+// rules that every run can report.
+func (r *Runner) baseRuleIndex() map[string]types.RuleMetadata {
+	ruleIndex := map[string]types.RuleMetadata{}
+	for _, meta := range r.schema.Metadata() {
+		ruleIndex[meta.ID] = meta
+	}
+	for _, rl := range r.rules {
+		ruleIndex[rl.Metadata.ID] = rl.Metadata
+	}
+	ruleIndex[rule.UniqueNameMetadata.ID] = rule.UniqueNameMetadata
+	ruleIndex[waiverExpiredMeta.ID] = waiverExpiredMeta
+	ruleIndex[waiverInvalidMeta.ID] = waiverInvalidMeta
+	ruleIndex[baselineAgedMeta.ID] = baselineAgedMeta
+	if r.plugins != nil {
+		for _, plug := range r.plugins.Plugins() {
+			meta := plug.Metadata()
+			ruleIndex[meta.ID] = meta
+		}
+	}
+	for id := range r.replacedRules() {
+		if meta, ok := ruleIndex[id]; ok {
+			meta.Enabled = false
+			ruleIndex[id] = meta
+		}
+	}
+	return ruleIndex
+}
+
+// replacedRules maps the IDs of built-in rules that registered plugins
+// replace to the replacing plugin rule. A plugin cannot replace another
+// plugin rule.
+func (r *Runner) replacedRules() map[string]string {
+	replaced := map[string]string{}
+	if r.plugins == nil {
+		return replaced
+	}
+	plugins := r.plugins.Plugins()
+	own := make(map[string]bool, len(plugins))
+	for _, plug := range plugins {
+		own[plug.Metadata().ID] = true
+	}
+	for _, plug := range plugins {
+		meta := plug.Metadata()
+		for _, id := range meta.Replaces {
+			if !own[id] {
+				replaced[id] = meta.ID
+			}
+		}
+	}
+	return replaced
+}
+
 // Run executes the linting workflow.
 func (r *Runner) Run(opts Options) (Report, error) {
-	if opts.Target == "" {
+	return r.RunContext(context.Background(), opts)
+}
+
+// RunContext executes the linting workflow until ctx is done. Render and
+// dry-run stages are further bounded by their own Timeout options.
+func (r *Runner) RunContext(ctx context.Context, opts Options) (Report, error) {
+	targetPaths := opts.Targets
+	if opts.Target != "" {
+		targetPaths = append([]string{opts.Target}, opts.Targets...)
+	}
+	if len(targetPaths) == 0 && opts.Manifests == nil {
 		return Report{}, fmt.Errorf("no target specified")
 	}
+	logger := logging.OrDiscard(opts.Logger)
+	if opts.Render.Logger == nil {
+		opts.Render.Logger = logger
+	}
+	if opts.DryRun.Logger == nil {
+		opts.DryRun.Logger = logger
+	}
 	if !opts.IncludeApplications && !opts.IncludeApplicationSets && !opts.IncludeProjects {
 		opts.IncludeApplications = true
 		opts.IncludeApplicationSets = true
 		opts.IncludeProjects = true
 	}
-	files, err := loader.DiscoverFiles(opts.Target)
-	if err != nil {
-		return Report{}, err
-	}
 	var manifests []*manifest.Manifest
-	for _, file := range files {
-		docs, err := r.parser.ParseFile(file)
+	parsed := map[string]bool{}
+	for _, target := range targetPaths {
+		files, err := loader.DiscoverFilesIgnoring(target, opts.Ignore)
 		if err != nil {
 			return Report{}, err
 		}
-		manifests = append(manifests, docs...)
+		logger.Debug("discovered files", "target", target, "count", len(files))
+		for _, file := range files {
+			key, err := filepath.Abs(file)
+			if err != nil {
+				key = filepath.Clean(file)
+			}
+			if parsed[key] {
+				continue
+			}
+			parsed[key] = true
+			docs, err := r.parser.ParseFile(file)
+			if err != nil {
+				return Report{}, err
+			}
+			logger.Debug("parsed file", "file", file, "documents", len(docs))
+			manifests = append(manifests, docs...)
+		}
 	}
+	manifests = append(manifests, opts.Manifests...)
 	included := make([]*manifest.Manifest, 0, len(manifests))
 	for _, m := range manifests {
 		if m == nil {
 			continue
 		}
 		if includeManifest(m, opts.IncludeApplications, opts.IncludeApplicationSets, opts.IncludeProjects) {
-			if r.workdir != "" {
-				if rel, err := filepath.Rel(r.workdir, m.FilePath); err == nil {
-					m.FilePath = rel
-				}
-			}
+			m.FilePath = r.relativePath(m.FilePath)
 			included = append(included, m)
+		} else {
+			logger.Debug("skipped manifest", "file", m.FilePath, "kind", m.Kind, "name", m.Name)
 		}
 	}
-	ctx := &rule.Context{Config: r.cfg, Manifests: included}
-	findings := make([]types.Finding, 0, len(included))
-	ruleIndex := map[string]types.RuleMetadata{}
-	for _, meta := range r.schema.Metadata() {
-		ruleIndex[meta.ID] = meta
-	}
-	for _, rl := range r.rules {
-		ruleIndex[rl.Metadata.ID] = rl.Metadata
+	ruleCtx := &rule.Context{Config: r.cfg, Manifests: included}
+	// Plugins see the same manifest set as built-in cross-resource rules.
+	ctx = plugin.WithManifests(ctx, included)
+	ruleCtx.RepoRoot = opts.RepoRoot
+	if ruleCtx.RepoRoot != "" {
+		if url, err := gitutil.RemoteURL(ctx, ruleCtx.RepoRoot); err == nil {
+			ruleCtx.RepoURL = url
+		}
 	}
-	ruleIndex[waiverExpiredMeta.ID] = waiverExpiredMeta
-	ruleIndex[waiverInvalidMeta.ID] = waiverInvalidMeta
-	ruleIndex[baselineAgedMeta.ID] = baselineAgedMeta
-	if r.plugins != nil {
-		for _, plug := range r.plugins.Plugins() {
-			meta := plug.Metadata()
-			ruleIndex[meta.ID] = meta
+	targets := included
+	var changed map[string]bool
+	if opts.ChangedFiles != nil {
+		changed = make(map[string]bool, len(opts.ChangedFiles))
+		for _, file := range opts.ChangedFiles {
+			changed[filepath.Clean(r.relativePath(file))] = true
+		}
+		targets = make([]*manifest.Manifest, 0, len(changed))
+		for _, m := range included {
+			if changed[filepath.Clean(m.FilePath)] {
+				targets = append(targets, m)
+			}
 		}
 	}
+	findings := make([]types.Finding, 0, len(targets))
+	ruleIndex := r.baseRuleIndex()
 
 	var renderer *render.Renderer
 	if opts.Render.Enabled {
+		if r.schema.HasCustomSchemas() {
+			opts.Render.KeepResources = true
+		}
 		var err error
 		renderer, err = render.NewRenderer(r.cfg, opts.Render)
 		if err != nil {
 			return Report{}, err
 		}
+		defer renderer.Close()
 		for _, meta := range renderer.Metadata() {
 			ruleIndex[meta.ID] = meta
 		}
@@ -154,6 +328,22 @@ func (r *Runner) Run(opts Options) (Report, error) {
 		}
 	}
 
+	var outdatedChecker *outdated.Checker
+	if opts.Outdated.Enabled {
+		outdatedChecker = outdated.NewChecker(r.cfg, opts.Outdated)
+		for _, meta := range outdatedChecker.Metadata() {
+			ruleIndex[meta.ID] = meta
+		}
+	}
+
+	var driftChecker *drift.Checker
+	if opts.Drift.Enabled {
+		driftChecker = drift.NewChecker(r.cfg, opts.Drift)
+		for _, meta := range driftChecker.Metadata() {
+			ruleIndex[meta.ID] = meta
+		}
+	}
+
 	maxParallel := opts.MaxParallel
 	if maxParallel <= 0 {
 		maxParallel = runtime.NumCPU()
@@ -176,7 +366,14 @@ func (r *Runner) Run(opts Options) (Report, error) {
 			errFlag.Store(true)
 		})
 	}
-	for _, manifest := range included {
+	renderCtx := ctx
+	if renderer != nil && opts.Render.Timeout > 0 {
+		var cancel context.CancelFunc
+		renderCtx, cancel = context.WithTimeout(ctx, opts.Render.Timeout)
+		defer cancel()
+	}
+	started := time.Now()
+	for _, manifest := range targets {
 		m := manifest
 		wg.Add(1)
 		go func() {
@@ -189,6 +386,10 @@ func (r *Runner) Run(opts Options) (Report, error) {
 			if errFlag.Load() {
 				return
 			}
+			if err := ctx.Err(); err != nil {
+				setErr(err)
+				return
+			}
 			localFindings := make([]types.Finding, 0, 4)
 			schemaFindings, err := r.schema.Validate(m)
 			if err != nil {
@@ -197,12 +398,18 @@ func (r *Runner) Run(opts Options) (Report, error) {
 			}
 			localFindings = append(localFindings, schemaFindings...)
 			if renderer != nil {
-				renderFindings, err := renderer.Render(m)
+				renderFindings, err := renderer.RenderContext(renderCtx, m)
 				if err != nil {
 					setErr(err)
 					return
 				}
 				localFindings = append(localFindings, renderFindings...)
+				renderedFindings, err := r.schema.ValidateRendered(m, renderer.Resources(m))
+				if err != nil {
+					setErr(err)
+					return
+				}
+				localFindings = append(localFindings, renderedFindings...)
 			}
 			findingsMu.Lock()
 			findings = append(findings, localFindings...)
@@ -211,33 +418,85 @@ func (r *Runner) Run(opts Options) (Report, error) {
 	}
 	wg.Wait()
 	if firstErr != nil {
+		if errors.Is(firstErr, context.DeadlineExceeded) && ctx.Err() == nil {
+			return Report{}, fmt.Errorf("render timed out after %s: %w", opts.Render.Timeout, firstErr)
+		}
 		return Report{}, firstErr
 	}
+	logger.Debug("stage finished", "stage", "schema", "render", renderer != nil, "manifests", len(targets), "duration", time.Since(started))
 
 	if dryRunValidator != nil {
-		dryRunFindings, err := dryRunValidator.Validate(context.Background(), included)
+		started := time.Now()
+		var rendered []dryrun.Rendered
+		if renderer != nil {
+			for _, m := range targets {
+				if resources := renderer.Resources(m); len(resources) > 0 {
+					rendered = append(rendered, dryrun.Rendered{Owner: m, Resources: resources})
+				}
+			}
+		}
+		dryRunFindings, err := dryRunValidator.Validate(ctx, targets, rendered)
 		if err != nil {
 			return Report{}, err
 		}
 		findings = append(findings, dryRunFindings...)
+		logger.Debug("stage finished", "stage", "dry-run", "duration", time.Since(started))
+	}
+
+	var outdatedEntries []outdated.Entry
+	if outdatedChecker != nil {
+		started := time.Now()
+		outdatedFindings, entries, err := outdatedChecker.Check(ctx, targets)
+		if err != nil {
+			return Report{}, err
+		}
+		findings = append(findings, outdatedFindings...)
+		outdatedEntries = entries
+		logger.Debug("stage finished", "stage", "outdated", "duration", time.Since(started))
+	}
+
+	if driftChecker != nil {
+		started := time.Now()
+		driftFindings, err := driftChecker.Check(ctx, targets)
+		if err != nil {
+			return Report{}, err
+		}
+		findings = append(findings, driftFindings...)
+		logger.Debug("stage finished", "stage", "drift", "duration", time.Since(started))
 	}
 
-	for _, m := range included {
+	// Findings of built-in rules that a plugin replaces are dropped, whichever
+	// stage reported them; the plugin reports its own instead.
+	replaced := r.replacedRules()
+	for id, by := range replaced {
+		logger.Debug("rule replaced by plugin", "rule", id, "plugin", by)
+	}
+	findings = withoutRules(findings, replaced)
+
+	started = time.Now()
+
+	for _, m := range targets {
+		if err := ctx.Err(); err != nil {
+			return Report{}, err
+		}
 		for _, rl := range r.rules {
 			if rl.Applies != nil && !rl.Applies(m) {
 				continue
 			}
+			if _, ok := replaced[rl.Metadata.ID]; ok {
+				continue
+			}
 			cfg, err := r.cfg.Resolve(rl.Metadata, m.FilePath)
 			if err != nil {
 				return Report{}, err
 			}
 			if !cfg.Enabled {
+				logger.Debug("rule disabled by config", "rule", rl.Metadata.ID, "file", m.FilePath)
 				continue
 			}
-			findings = append(findings, rl.Check(m, ctx, cfg)...)
+			findings = append(findings, rl.Check(m, ruleCtx, cfg)...)
 		}
 		if r.plugins != nil {
-			ctxWithRule := context.Background()
 			for _, plug := range r.plugins.Plugins() {
 				if applies := plug.AppliesTo(); applies != nil && !applies(m) {
 					continue
@@ -247,9 +506,10 @@ func (r *Runner) Run(opts Options) (Report, error) {
 					return Report{}, err
 				}
 				if !cfg.Enabled {
+					logger.Debug("rule disabled by config", "rule", cfg.Metadata.ID, "file", m.FilePath)
 					continue
 				}
-				results, err := plug.Check(ctxWithRule, m)
+				results, err := plug.Check(plugin.WithParams(ctx, cfg.Params), m)
 				if err != nil {
 					return Report{}, err
 				}
@@ -281,7 +541,13 @@ func (r *Runner) Run(opts Options) (Report, error) {
 		}
 	}
 
-	findings = append(findings, rule.UniqueNameFindings(ctx)...)
+	logger.Debug("stage finished", "stage", "rules", "manifests", len(targets), "duration", time.Since(started))
+
+	for _, f := range withoutRules(rule.UniqueNameFindings(ruleCtx), replaced) {
+		if changed == nil || changed[filepath.Clean(f.FilePath)] {
+			findings = append(findings, f)
+		}
+	}
 
 	sort.SliceStable(findings, func(i, j int) bool {
 		if findings[i].FilePath == findings[j].FilePath {
@@ -296,8 +562,14 @@ func (r *Runner) Run(opts Options) (Report, error) {
 		return findings[i].FilePath < findings[j].FilePath
 	})
 
-	filtered, waiverFindings := applyWaivers(r.cfg, findings, ruleIndex)
+	waiverCfg := r.cfg
+	inline, invalidInline := annotationWaivers(targets, r.cfg.WaiverPolicy, time.Now())
+	if len(inline) > 0 {
+		waiverCfg.Waivers = append(append([]config.Waiver(nil), r.cfg.Waivers...), inline...)
+	}
+	filtered, waiverFindings := applyWaivers(waiverCfg, findings, ruleIndex)
 	filtered = append(filtered, waiverFindings...)
+	filtered = append(filtered, invalidInline...)
 	var agedBaseline, suppressed []types.Finding
 	if opts.Baseline != nil {
 		baselineFiltered, aged, suppressedEntries := opts.Baseline.Filter(filtered, opts.BaselineAgingDays)
@@ -319,7 +591,32 @@ func (r *Runner) Run(opts Options) (Report, error) {
 		return filtered[i].FilePath < filtered[j].FilePath
 	})
 
-	return Report{Findings: filtered, RuleIndex: ruleIndex, Suppressed: suppressed}, nil
+	return Report{Findings: filtered, RuleIndex: ruleIndex, Suppressed: suppressed, Outdated: outdatedEntries}, nil
+}
+
+// withoutRules drops the findings of the given rule IDs.
+func withoutRules(findings []types.Finding, ids map[string]string) []types.Finding {
+	if len(ids) == 0 {
+		return findings
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		if _, ok := ids[f.RuleID]; !ok {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// relativePath reports path relative to the working directory when possible,
+// matching how findings are attributed.
+func (r *Runner) relativePath(path string) string {
+	if r.workdir != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(r.workdir, path); err == nil {
+			return rel
+		}
+	}
+	return path
 }
 
 func includeManifest(m *manifest.Manifest, apps, appsets, projects bool) bool {
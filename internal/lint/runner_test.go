@@ -1,12 +1,21 @@
 package lint
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
 	"github.com/argocd-lint/argocd-lint/internal/dryrun"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
 func writeManifest(t *testing.T, dir, name, content string) string {
@@ -44,6 +53,12 @@ spec:
     automated:
       prune: true
       selfHeal: true
+    retry:
+      limit: 5
+      backoff:
+        duration: 5s
+        factor: 2
+        maxDuration: 3m
 `
 	path := writeManifest(t, dir, "app.yaml", manifest)
 
@@ -123,9 +138,9 @@ spec:
     path: manifests
 `
 	path := writeManifest(t, dir, "app.yaml", manifestContent)
-	script := filepath.Join(dir, "kubeconform")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 3\n"), 0o755); err != nil {
-		t.Fatalf("write script: %v", err)
+	schema := `{"type": "object", "properties": {"spec": {"type": "object", "required": ["syncPolicy"]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "application-argoproj-v1alpha1.json"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
 	}
 	runner, err := NewRunner(config.Config{}, dir, "")
 	if err != nil {
@@ -135,9 +150,9 @@ spec:
 		Target: path,
 		Config: config.Config{},
 		DryRun: dryrun.Options{
-			Enabled:           true,
-			Mode:              "kubeconform",
-			KubeconformBinary: script,
+			Enabled:     true,
+			Mode:        "kubeconform",
+			Kubeconform: kubeconform.Options{SchemaLocations: []string{filepath.Join(dir, "{{ .ResourceKind }}{{ .KindSuffix }}.json")}},
 		},
 	})
 	if err != nil {
@@ -154,3 +169,294 @@ spec:
 		t.Fatalf("expected dry-run finding in report")
 	}
 }
+
+func TestRunnerDebugLogging(t *testing.T) {
+	dir := t.TempDir()
+	path := writeManifest(t, dir, "app.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: demo
+spec:
+  project: workloads
+  destination:
+    namespace: demo
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://example.com/repo.git
+    targetRevision: v1.0.0
+    path: manifests
+`)
+	schemas := kubeconform.Options{SchemaLocations: []string{filepath.Join(dir, "{{ .ResourceKind }}.json")}, IgnoreMissingSchemas: true}
+	disabled := false
+	cfg := config.Config{Rules: map[string]config.RuleConfig{"AR006": {Enabled: &disabled}}}
+	runner, err := NewRunner(cfg, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "debug", "json")
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	_, err = runner.Run(Options{
+		Target: path,
+		Config: cfg,
+		DryRun: dryrun.Options{Enabled: true, Mode: "kubeconform", Kubeconform: schemas},
+		Logger: logger,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, want := range []string{`"msg":"discovered files"`, `"msg":"rule disabled by config","rule":"AR006"`, `"msg":"kubeconform validate","file":"app.yaml","resource":"Application/demo"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("expected log to contain %s, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestRunnerChangedFilesKeepsProjectsForCrossResourceRules(t *testing.T) {
+	dir := t.TempDir()
+	project := `apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: workloads
+spec:
+  sourceRepos:
+    - '*'
+  destinations:
+    - server: '*'
+      namespace: '*'
+`
+	app := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: demo
+spec:
+  project: workloads
+  destination:
+    namespace: demo
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://example.com/repo.git
+    targetRevision: main
+    path: manifests
+`
+	writeManifest(t, dir, "project.yaml", project)
+	changed := writeManifest(t, dir, "app1.yaml", app)
+	writeManifest(t, dir, "app2.yaml", app)
+
+	runner, err := NewRunner(config.Config{}, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	report, err := runner.Run(Options{Target: dir, Config: config.Config{}, ChangedFiles: []string{changed}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	duplicate := false
+	for _, f := range report.Findings {
+		if f.FilePath != "app1.yaml" {
+			t.Fatalf("expected findings only for the changed file, got %s (%s)", f.FilePath, f.RuleID)
+		}
+		if f.RuleID == "AR014" {
+			t.Fatalf("expected AppProject from unchanged file to be resolved: %s", f.Message)
+		}
+		if f.RuleID == "AR011" {
+			duplicate = true
+		}
+	}
+	if !duplicate {
+		t.Fatalf("expected duplicate name against unchanged manifest to be reported")
+	}
+
+	report, err = runner.Run(Options{Target: dir, Config: config.Config{}, ChangedFiles: []string{}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Fatalf("expected no findings when nothing changed, got %d", len(report.Findings))
+	}
+}
+
+func TestRunnerMergesMultipleTargets(t *testing.T) {
+	dir := t.TempDir()
+	projects := filepath.Join(dir, "projects")
+	apps := filepath.Join(dir, "apps")
+	for _, sub := range []string{projects, apps} {
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	writeManifest(t, projects, "project.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: workloads
+spec:
+  sourceRepos:
+    - '*'
+  destinations:
+    - server: '*'
+      namespace: '*'
+`)
+	app := writeManifest(t, apps, "app.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: demo
+spec:
+  project: workloads
+  destination:
+    namespace: demo
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://example.com/repo.git
+    targetRevision: v1.0.0
+    path: manifests
+`)
+
+	runner, err := NewRunner(config.Config{}, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	report, err := runner.Run(Options{Target: apps, Targets: []string{projects, app}, Config: config.Config{}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, f := range report.Findings {
+		if f.RuleID == "AR014" || f.RuleID == "AR011" {
+			t.Fatalf("expected targets to be merged without duplicates, got %s: %s", f.RuleID, f.Message)
+		}
+	}
+}
+
+func TestRunnerHonorsAnnotationWaivers(t *testing.T) {
+	dir := t.TempDir()
+	app := func(name, annotation string) string {
+		return `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: ` + name + `
+  annotations:
+    argocd-lint.argoproj.io/waive: "` + annotation + `"
+spec:
+  project: default
+  destination:
+    namespace: demo
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://example.com/repo.git
+    targetRevision: main
+    path: manifests
+`
+	}
+	future := time.Now().Add(48 * time.Hour).Format("2006-01-02")
+	past := time.Now().Add(-48 * time.Hour).Format("2006-01-02")
+	content := app("waived", "AR001:"+future+":tracking main during migration") + "---\n" +
+		app("expired", "AR001:"+past+":old exception") + "---\n" +
+		app("broken", "AR001:someday:no date")
+	writeManifest(t, dir, "apps.yaml", content)
+
+	runner, err := NewRunner(config.Config{}, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	report, err := runner.Run(Options{Target: dir, Config: config.Config{}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	counts := map[string]int{}
+	for _, f := range report.Findings {
+		if f.RuleID == "AR001" {
+			counts[f.ResourceName]++
+		}
+		counts[f.RuleID]++
+	}
+	if counts["waived"] != 0 {
+		t.Fatalf("expected AR001 on annotated resource to be waived")
+	}
+	if counts["expired"] == 0 || counts["broken"] == 0 {
+		t.Fatalf("expected AR001 to remain for expired and malformed waivers, got %v", counts)
+	}
+	if counts[waiverExpiredMeta.ID] != 1 || counts[waiverInvalidMeta.ID] != 1 {
+		t.Fatalf("expected one WAIVER_EXPIRED and one WAIVER_INVALID finding, got %v", counts)
+	}
+
+	content = app("unticketed", "AR001:"+future+":tracking main") + "---\n" +
+		app("ticketed", "AR001:"+future+":tracking main|ticket=OPS-12|approvedBy=platform")
+	writeManifest(t, dir, "apps.yaml", content)
+	cfg := config.Config{WaiverPolicy: config.WaiverPolicy{RequireTicket: true}}
+	runner, err = NewRunner(cfg, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	report, err = runner.Run(Options{Target: dir, Config: cfg})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	counts = map[string]int{}
+	for _, f := range report.Findings {
+		if f.RuleID == "AR001" {
+			counts[f.ResourceName]++
+		}
+		counts[f.RuleID]++
+	}
+	if counts["ticketed"] != 0 || counts["unticketed"] == 0 || counts[waiverInvalidMeta.ID] != 1 {
+		t.Fatalf("expected waiverPolicy to reject only the waiver without a ticket, got %v", counts)
+	}
+}
+
+type strictRevisionPlugin struct{}
+
+func (strictRevisionPlugin) Metadata() types.RuleMetadata {
+	return types.RuleMetadata{ID: "ORG001", DefaultSeverity: types.SeverityError, Enabled: true, Replaces: []string{"AR001", "AR011"}}
+}
+
+func (strictRevisionPlugin) AppliesTo() plugin.Matcher { return nil }
+
+func (strictRevisionPlugin) Check(_ context.Context, _ *manifest.Manifest) ([]types.Finding, error) {
+	return []types.Finding{{Message: "targetRevision must be a release tag"}}, nil
+}
+
+func TestRunnerPluginsReplaceBuiltInRules(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: demo
+spec:
+  project: workloads
+  destination:
+    namespace: demo
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://example.com/repo.git
+    targetRevision: HEAD
+    path: manifests
+`
+	writeManifest(t, dir, "app1.yaml", manifest)
+	writeManifest(t, dir, "app2.yaml", manifest)
+
+	runner, err := NewRunner(config.Config{}, dir, "")
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	runner.RegisterPlugins(strictRevisionPlugin{})
+	report, err := runner.Run(Options{Target: dir, Config: config.Config{}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	replacing := 0
+	for _, f := range report.Findings {
+		switch f.RuleID {
+		case "AR001", "AR011":
+			t.Fatalf("expected %s to be replaced, got %+v", f.RuleID, f)
+		case "ORG001":
+			replacing++
+		}
+	}
+	if replacing != 2 {
+		t.Fatalf("expected one ORG001 finding per manifest, got %d", replacing)
+	}
+	if meta := report.RuleIndex["AR001"]; meta.Enabled {
+		t.Fatalf("expected the replaced rule to be reported as disabled, got %+v", meta)
+	}
+}
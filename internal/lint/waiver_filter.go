@@ -2,10 +2,12 @@ package lint
 
 import (
 	"fmt"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
@@ -43,7 +45,7 @@ func applyWaivers(cfg config.Config, findings []types.Finding, ruleIndex map[str
 			if waived[i] {
 				continue
 			}
-			if !waiver.Matches(f.FilePath, f.RuleID) {
+			if !waiver.MatchesFinding(f) {
 				continue
 			}
 			if expires.Before(now) {
@@ -69,6 +71,61 @@ func applyWaivers(cfg config.Config, findings []types.Finding, ruleIndex map[str
 	return filtered, extra
 }
 
+// annotationWaivers collects waivers declared through config.WaiverAnnotation,
+// scoped to the annotated resource. Malformed annotations and waivers that
+// violate policy yield WAIVER_INVALID findings instead of suppressing
+// anything.
+func annotationWaivers(manifests []*manifest.Manifest, policy config.WaiverPolicy, now time.Time) ([]config.Waiver, []types.Finding) {
+	var waivers []config.Waiver
+	var invalid []types.Finding
+	for _, m := range manifests {
+		metadata, _ := m.Object["metadata"].(map[string]interface{})
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		value, _ := annotations[config.WaiverAnnotation].(string)
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		invalidFinding := func(msg string) types.Finding {
+			f := newWaiverFinding(waiverInvalidMeta, m.FilePath, fmt.Sprintf("%s annotation on %s/%s: %s", config.WaiverAnnotation, m.Kind, m.Name, msg), types.SeverityWarn)
+			f.Line = m.MetadataLine
+			f.ResourceKind = m.Kind
+			f.ResourceName = m.Name
+			return f
+		}
+		parsed, err := config.ParseWaiverAnnotation(value)
+		if err != nil {
+			invalid = append(invalid, invalidFinding(err.Error()))
+			continue
+		}
+		for _, w := range parsed {
+			if err := policy.Check(w, now); err != nil {
+				invalid = append(invalid, invalidFinding(fmt.Sprintf("waiver for %s: %v", w.Rule, err)))
+				continue
+			}
+			w.File = globEscape(m.FilePath)
+			w.Resource = globEscape(m.Kind + "/" + m.Name)
+			waivers = append(waivers, w)
+		}
+	}
+	return waivers, invalid
+}
+
+// globEscape quotes pattern metacharacters so a literal path matches itself.
+// filepath.Match has no escape character on Windows, so values pass through.
+func globEscape(value string) string {
+	if runtime.GOOS == "windows" {
+		return value
+	}
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func newWaiverFinding(meta types.RuleMetadata, file, message string, severity types.Severity) types.Finding {
 	return types.Finding{
 		RuleID:   meta.ID,
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirectorySource holds the directory options of an Argo CD Application
+// source (spec.source.directory).
+type DirectorySource struct {
+	Recurse bool
+	Include string
+	Exclude string
+}
+
+// Filtered reports whether include or exclude narrows the files deployed.
+func (d DirectorySource) Filtered() bool {
+	return strings.TrimSpace(d.Include) != "" || strings.TrimSpace(d.Exclude) != ""
+}
+
+// DirectorySourceOptions reads the directory options of an Application
+// source manifest.
+func DirectorySourceOptions(source map[string]interface{}) DirectorySource {
+	directory, _ := source["directory"].(map[string]interface{})
+	recurse, _ := directory["recurse"].(bool)
+	include, _ := directory["include"].(string)
+	exclude, _ := directory["exclude"].(string)
+	return DirectorySource{
+		Recurse: recurse,
+		Include: strings.TrimSpace(include),
+		Exclude: strings.TrimSpace(exclude),
+	}
+}
+
+// IsToolDirectory reports whether dir holds a Helm chart or kustomization,
+// which Argo CD renders instead of applying directory options.
+func IsToolDirectory(dir string) bool {
+	for _, name := range []string{"Chart.yaml", "kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectorySourceFiles lists, in lexical order, the YAML, JSON, and Jsonnet
+// files that Argo CD deploys from a directory source at path. Subdirectories
+// are only walked with Recurse, hidden ones never, and each file's path
+// relative to path must match Include (when set) and not Exclude. A missing
+// path has no files.
+func DirectorySourceFiles(path string, opts DirectorySource) ([]string, error) {
+	include := strings.TrimSpace(opts.Include)
+	exclude := strings.TrimSpace(opts.Exclude)
+	var files []string
+	err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file != path && (!opts.Recurse || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isManifestFile(file) && strings.ToLower(filepath.Ext(file)) != ".jsonnet" {
+			return nil
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		if include != "" && !MatchDirectoryPattern(include, rel) {
+			return nil
+		}
+		if exclude != "" && MatchDirectoryPattern(exclude, rel) {
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// MatchDirectoryPattern matches a file path relative to a directory source
+// against its include or exclude pattern. Like Argo CD, a pattern may list
+// alternatives as {a,b}, and a pattern without a "/" also matches the base
+// name alone.
+func MatchDirectoryPattern(pattern, rel string) bool {
+	patterns := []string{pattern}
+	if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}") {
+		patterns = strings.Split(pattern[1:len(pattern)-1], ",")
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if MatchGlob(p, rel) {
+			return true
+		}
+		if !strings.Contains(p, "/") && MatchGlob(p, filepath.Base(rel)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDirectorySourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"deploy.yaml", "config.json", "main.jsonnet", "lib.libsonnet", "README.md", "prod/deploy.yaml", "prod/patch.yml", ".hidden/deploy.yaml"} {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("{}\n"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	cases := []struct {
+		name string
+		opts DirectorySource
+		want []string
+	}{
+		{"top level", DirectorySource{}, []string{"config.json", "deploy.yaml", "main.jsonnet"}},
+		{"recurse", DirectorySource{Recurse: true}, []string{"config.json", "deploy.yaml", "main.jsonnet", "prod/deploy.yaml", "prod/patch.yml"}},
+		{"include alternatives", DirectorySource{Recurse: true, Include: "{*.yml,config.json}"}, []string{"config.json", "prod/patch.yml"}},
+		{"include path", DirectorySource{Recurse: true, Include: "prod/*"}, []string{"prod/deploy.yaml", "prod/patch.yml"}},
+		{"exclude", DirectorySource{Recurse: true, Exclude: "deploy.yaml"}, []string{"config.json", "main.jsonnet", "prod/patch.yml"}},
+		{"no match", DirectorySource{Include: "*.yml"}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := DirectorySourceFiles(dir, tc.opts)
+			if err != nil {
+				t.Fatalf("list files: %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, _ := filepath.Rel(dir, file)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+	if files, err := DirectorySourceFiles(filepath.Join(dir, "missing"), DirectorySource{}); err != nil || len(files) != 0 {
+		t.Fatalf("expected a missing directory to have no files, got %v, %v", files, err)
+	}
+}
+
+func TestDirectorySourceOptions(t *testing.T) {
+	source := map[string]interface{}{"directory": map[string]interface{}{"recurse": true, "include": " *.yaml ", "exclude": "test/*"}}
+	if got, want := DirectorySourceOptions(source), (DirectorySource{Recurse: true, Include: "*.yaml", Exclude: "test/*"}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got := DirectorySourceOptions(map[string]interface{}{}); got != (DirectorySource{}) {
+		t.Fatalf("expected empty options without spec.source.directory, got %+v", got)
+	}
+
+	dir := t.TempDir()
+	if IsToolDirectory(dir) {
+		t.Fatalf("expected an empty directory not to be a tool directory")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Kustomization"), []byte("resources: []\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	if !IsToolDirectory(dir) {
+		t.Fatalf("expected a kustomization directory to be a tool directory")
+	}
+}
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandTargets resolves shell-style patterns among targets into the paths
+// they match. Plain paths are returned unchanged; "**" matches any number
+// of directories. Hidden directories are skipped like in DiscoverFiles.
+func ExpandTargets(targets []string) ([]string, error) {
+	var expanded []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			expanded = append(expanded, p)
+		}
+	}
+	for _, target := range targets {
+		if !hasGlobMeta(target) {
+			add(target)
+			continue
+		}
+		pattern := filepath.Clean(target)
+		base := globBase(pattern)
+		matched := false
+		walkErr := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != base && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !MatchGlob(pattern, p) {
+				return nil
+			}
+			if !d.IsDir() && !isManifestFile(p) {
+				return nil
+			}
+			matched = true
+			add(p)
+			if d.IsDir() {
+				// DiscoverFiles walks matched directories itself.
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, fmt.Errorf("expand %s: %w", target, walkErr)
+		}
+		if !matched {
+			return nil, fmt.Errorf("pattern %s matched no manifests", target)
+		}
+	}
+	return expanded, nil
+}
+
+// MatchGlob reports whether name matches pattern, where each path segment
+// follows path.Match and a "**" segment matches zero or more segments.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(filepath.ToSlash(pattern), "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// globBase returns the directory prefix of pattern that contains no glob
+// characters, which is where the walk starts.
+func globBase(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	var fixed []string
+	for _, segment := range segments {
+		if hasGlobMeta(segment) {
+			break
+		}
+		fixed = append(fixed, segment)
+	}
+	if len(fixed) == 0 {
+		return "."
+	}
+	if len(fixed) == 1 && fixed[0] == "" {
+		return string(filepath.Separator)
+	}
+	return filepath.FromSlash(strings.Join(fixed, "/"))
+}
@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"overlays/**/appset.yaml", "overlays/appset.yaml", true},
+		{"overlays/**/appset.yaml", "overlays/prod/eu/appset.yaml", true},
+		{"overlays/**/appset.yaml", "overlays/prod/app.yaml", false},
+		{"apps/*.yaml", "apps/a.yaml", true},
+		{"apps/*.yaml", "apps/nested/a.yaml", false},
+		{"**", "any/depth/file.yaml", true},
+	}
+	for _, tc := range cases {
+		if got := MatchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Fatalf("MatchGlob(%q, %q) = %t, want %t", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExpandTargets(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"overlays/prod/appset.yaml", "overlays/dev/appset.yaml", "overlays/dev/values.txt", "overlays/.cache/appset.yaml"} {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("kind: ApplicationSet\n"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	plain := filepath.Join(dir, "apps")
+	got, err := ExpandTargets([]string{plain, filepath.Join(dir, "overlays", "**", "appset.yaml")})
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	want := []string{plain, filepath.Join(dir, "overlays", "dev", "appset.yaml"), filepath.Join(dir, "overlays", "prod", "appset.yaml")}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if _, err := ExpandTargets([]string{filepath.Join(dir, "missing", "*.yaml")}); err == nil {
+		t.Fatalf("expected a pattern without matches to fail")
+	}
+}
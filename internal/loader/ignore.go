@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the ignore file read from the lint root.
+const IgnoreFileName = ".argocdlintignore"
+
+// Ignore matches paths against gitignore-style patterns relative to a root.
+type Ignore struct {
+	root     string
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob    string
+	negate  bool
+	dirOnly bool
+}
+
+// LoadIgnore reads root/.argocdlintignore when present and appends the
+// extra patterns (for example from --exclude), which use the same syntax:
+// "#" comments, "!" negation, a trailing "/" for directories only, a leading
+// "/" or inner "/" to anchor to root, and "**" for any depth. Later patterns
+// win.
+func LoadIgnore(root string, extra []string) (*Ignore, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	ignore := &Ignore{root: absRoot}
+	file, err := os.Open(filepath.Join(absRoot, IgnoreFileName))
+	switch {
+	case err == nil:
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			ignore.Add(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", IgnoreFileName, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("open %s: %w", IgnoreFileName, err)
+	}
+	for _, pattern := range extra {
+		ignore.Add(pattern)
+	}
+	return ignore, nil
+}
+
+// Add appends one pattern line; blank lines and comments are skipped.
+func (i *Ignore) Add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	line = filepath.ToSlash(line)
+	if strings.HasPrefix(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	if line == "" {
+		return
+	}
+	p.glob = line
+	i.patterns = append(i.patterns, p)
+}
+
+// Ignored reports whether path should be skipped, either because it
+// matches or because one of its parent directories does. Paths outside the
+// root are never ignored.
+func (i *Ignore) Ignored(path string, isDir bool) bool {
+	if i == nil || len(i.patterns) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(i.root, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for n := 1; n < len(parts); n++ {
+		if i.match(strings.Join(parts[:n], "/"), true) {
+			return true
+		}
+	}
+	return i.match(rel, isDir)
+}
+
+func (i *Ignore) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range i.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if MatchGlob(p.glob, rel) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
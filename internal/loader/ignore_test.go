@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoverFilesIgnoring(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{
+		"apps/app.yaml",
+		"apps/test-fixture.yaml",
+		"charts/vendor/values.yaml",
+		"generated/out.yaml",
+		"generated/keep.yaml",
+		"nested/generated/deep.yaml",
+	} {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("kind: Application\n"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	ignoreFile := "# vendored charts\n/charts/\ngenerated/\n!generated/keep.yaml\n"
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(ignoreFile), 0o600); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+	ignore, err := LoadIgnore(dir, []string{"*-fixture.yaml"})
+	if err != nil {
+		t.Fatalf("load ignore: %v", err)
+	}
+	files, err := DiscoverFilesIgnoring(dir, ignore)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(dir, "apps", "app.yaml") {
+		t.Fatalf("expected only apps/app.yaml, got %v", files)
+	}
+	if !ignore.Ignored(filepath.Join(dir, "generated", "keep.yaml"), false) {
+		t.Fatalf("expected files under an ignored directory to stay ignored")
+	}
+	files, err = DiscoverFilesIgnoring(filepath.Join(dir, "apps", "test-fixture.yaml"), ignore)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("expected excluded file target to be skipped, got %v (%v)", files, err)
+	}
+}
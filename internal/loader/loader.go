@@ -10,11 +10,20 @@ import (
 
 // DiscoverFiles returns manifest file paths within the provided target.
 func DiscoverFiles(target string) ([]string, error) {
+	return DiscoverFilesIgnoring(target, nil)
+}
+
+// DiscoverFilesIgnoring works like DiscoverFiles but skips files and
+// directories matched by ignore, which may be nil.
+func DiscoverFilesIgnoring(target string, ignore *Ignore) ([]string, error) {
 	info, err := os.Stat(target)
 	if err != nil {
 		return nil, fmt.Errorf("stat target: %w", err)
 	}
 	if !info.IsDir() {
+		if ignore.Ignored(target, false) {
+			return nil, nil
+		}
 		if isManifestFile(target) {
 			return []string{target}, nil
 		}
@@ -26,12 +35,12 @@ func DiscoverFiles(target string) ([]string, error) {
 			return err
 		}
 		if d.IsDir() {
-			if path != target && strings.HasPrefix(d.Name(), ".") {
+			if path != target && (strings.HasPrefix(d.Name(), ".") || ignore.Ignored(path, true)) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if isManifestFile(path) {
+		if isManifestFile(path) && !ignore.Ignored(path, false) {
 			files = append(files, path)
 		}
 		return nil
@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewFiltersByLevelAndFormatsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("shown", "file", "apps/app.yaml")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one record, got %q", buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("decode record: %v", err)
+	}
+	if record["msg"] != "shown" || record["file"] != "apps/app.yaml" {
+		t.Fatalf("unexpected record %v", record)
+	}
+	if _, err := New(&buf, "trace", ""); err == nil {
+		t.Fatalf("expected unknown level to fail")
+	}
+	if _, err := New(&buf, "", "xml"); err == nil {
+		t.Fatalf("expected unknown format to fail")
+	}
+}
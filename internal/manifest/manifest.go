@@ -34,6 +34,11 @@ func (Parser) ParseFile(path string) ([]*Manifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("read manifest: %w", err)
 	}
+	return Parser{}.Parse(path, data)
+}
+
+// Parse parses manifest documents from data, attributing them to path.
+func (Parser) Parse(path string, data []byte) ([]*Manifest, error) {
 	dec := yaml.NewDecoder(bytes.NewReader(data))
 	dec.KnownFields(false)
 
@@ -0,0 +1,323 @@
+package outdated
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Options controls the outdated revision analyzer.
+type Options struct {
+	Enabled    bool
+	HTTPClient *http.Client
+}
+
+// Entry describes the freshness of a single pinned source.
+type Entry struct {
+	File           string   `json:"file"`
+	ResourceKind   string   `json:"resourceKind"`
+	ResourceName   string   `json:"resourceName"`
+	RepoURL        string   `json:"repoURL"`
+	Chart          string   `json:"chart,omitempty"`
+	TargetRevision string   `json:"targetRevision"`
+	Latest         string   `json:"latest,omitempty"`
+	Behind         int      `json:"behind"`
+	Newer          []string `json:"newer,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Checker compares pinned revisions with the latest tags or chart versions.
+type Checker struct {
+	cfg     config.Config
+	options Options
+	rule    types.RuleMetadata
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]remoteResult
+}
+
+type remoteResult struct {
+	versions []string
+	head     string
+	err      error
+}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// NewChecker creates an outdated revision checker.
+func NewChecker(cfg config.Config, opts Options) *Checker {
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Checker{
+		cfg:     cfg,
+		options: opts,
+		client:  client,
+		cache:   make(map[string]remoteResult),
+		rule: types.RuleMetadata{
+			ID:              "REVISION_OUTDATED",
+			Description:     "Pinned revisions should track the latest released tag or chart version",
+			DefaultSeverity: types.SeverityInfo,
+			AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+			Category:        "maintenance",
+			Enabled:         true,
+		},
+	}
+}
+
+// Metadata exposes rule metadata for registration.
+func (c *Checker) Metadata() []types.RuleMetadata {
+	return []types.RuleMetadata{c.rule}
+}
+
+// Check queries the remote for each pinned source and reports how far behind it is.
+func (c *Checker) Check(ctx context.Context, manifests []*manifest.Manifest) ([]types.Finding, []Entry, error) {
+	if !c.options.Enabled {
+		return nil, nil, nil
+	}
+	var findings []types.Finding
+	var entries []Entry
+	for _, m := range manifests {
+		cfg, err := c.cfg.Resolve(c.rule, m.FilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !cfg.Enabled {
+			continue
+		}
+		builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+		for _, src := range collectSources(m) {
+			entry, ok := c.checkSource(ctx, src)
+			if !ok {
+				continue
+			}
+			entry.File = m.FilePath
+			entry.ResourceKind = m.Kind
+			entry.ResourceName = m.Name
+			entries = append(entries, entry)
+			if entry.Error != "" {
+				continue
+			}
+			if msg := entry.message(); msg != "" {
+				findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+			}
+		}
+	}
+	return findings, entries, nil
+}
+
+func (c *Checker) checkSource(ctx context.Context, src map[string]interface{}) (Entry, bool) {
+	repo := strings.TrimSpace(stringValue(src, "repoURL"))
+	rev := strings.TrimSpace(stringValue(src, "targetRevision"))
+	chart := strings.TrimSpace(stringValue(src, "chart"))
+	if repo == "" || rev == "" || strings.Contains(repo+rev+chart, "{{") {
+		return Entry{}, false
+	}
+	entry := Entry{RepoURL: repo, Chart: chart, TargetRevision: rev}
+	var result remoteResult
+	if chart != "" {
+		if strings.HasPrefix(repo, "oci://") || !strings.Contains(repo, "://") {
+			return Entry{}, false
+		}
+		result = c.lookup("helm|"+repo+"|"+chart, func() remoteResult { return c.fetchChartVersions(ctx, repo, chart) })
+	} else {
+		if _, err := semver.NewVersion(rev); err != nil && !isCommit(rev) {
+			// Branches and other floating refs are covered by AR001.
+			return Entry{}, false
+		}
+		result = c.lookup("git|"+repo, func() remoteResult { return c.fetchGitTags(ctx, repo) })
+	}
+	if result.err != nil {
+		entry.Error = result.err.Error()
+		return entry, true
+	}
+	if chart == "" && isCommit(rev) {
+		if result.head != "" && !strings.HasPrefix(strings.ToLower(result.head), strings.ToLower(rev)) {
+			entry.Latest = result.head
+			entry.Behind = -1
+		}
+		return entry, true
+	}
+	current, err := semver.NewVersion(rev)
+	if err != nil {
+		return Entry{}, false
+	}
+	for _, raw := range result.versions {
+		candidate, err := semver.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		if candidate.Prerelease() != "" && current.Prerelease() == "" {
+			continue
+		}
+		if candidate.GreaterThan(current) {
+			entry.Newer = append(entry.Newer, raw)
+		}
+	}
+	sort.Slice(entry.Newer, func(i, j int) bool {
+		vi, _ := semver.NewVersion(entry.Newer[i])
+		vj, _ := semver.NewVersion(entry.Newer[j])
+		return vi.GreaterThan(vj)
+	})
+	entry.Behind = len(entry.Newer)
+	if entry.Behind > 0 {
+		entry.Latest = entry.Newer[0]
+	}
+	return entry, true
+}
+
+func (e Entry) message() string {
+	switch {
+	case e.Behind < 0:
+		return fmt.Sprintf("targetRevision %s differs from %s HEAD (%s)", e.TargetRevision, e.RepoURL, shortHash(e.Latest))
+	case e.Behind == 0:
+		return ""
+	case e.Chart != "":
+		return fmt.Sprintf("chart %s %s is %d version(s) behind latest %s", e.Chart, e.TargetRevision, e.Behind, e.Latest)
+	default:
+		return fmt.Sprintf("targetRevision %s is %d release(s) behind latest tag %s", e.TargetRevision, e.Behind, e.Latest)
+	}
+}
+
+func (c *Checker) lookup(key string, fetch func() remoteResult) remoteResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.cache[key]; ok {
+		return cached
+	}
+	result := fetch()
+	c.cache[key] = result
+	return result
+}
+
+func (c *Checker) fetchGitTags(ctx context.Context, repo string) remoteResult {
+	refs, err := gitutil.LsRemote(ctx, repo)
+	if err != nil {
+		return remoteResult{err: err}
+	}
+	var result remoteResult
+	for _, ref := range refs {
+		switch {
+		case ref.Name == "HEAD":
+			result.head = ref.Hash
+		case strings.HasPrefix(ref.Name, "refs/tags/"):
+			result.versions = append(result.versions, strings.TrimPrefix(ref.Name, "refs/tags/"))
+		}
+	}
+	return result
+}
+
+func (c *Checker) fetchChartVersions(ctx context.Context, repo, chart string) remoteResult {
+	indexURL := strings.TrimRight(repo, "/") + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return remoteResult{err: err}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return remoteResult{err: fmt.Errorf("fetch %s: %w", indexURL, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteResult{err: fmt.Errorf("fetch %s: %s", indexURL, resp.Status)}
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return remoteResult{err: fmt.Errorf("read %s: %w", indexURL, err)}
+	}
+	var index struct {
+		Entries map[string][]struct {
+			Version string `yaml:"version"`
+		} `yaml:"entries"`
+	}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return remoteResult{err: fmt.Errorf("parse %s: %w", indexURL, err)}
+	}
+	versions, ok := index.Entries[chart]
+	if !ok {
+		return remoteResult{err: fmt.Errorf("chart %s not found in %s", chart, indexURL)}
+	}
+	var result remoteResult
+	for _, v := range versions {
+		result.versions = append(result.versions, v.Version)
+	}
+	return result
+}
+
+func collectSources(m *manifest.Manifest) []map[string]interface{} {
+	var spec map[string]interface{}
+	switch m.Kind {
+	case string(types.ResourceKindApplication):
+		spec = mapValue(m.Object, "spec")
+	case string(types.ResourceKindApplicationSet):
+		spec = mapValue(m.Object, "spec", "template", "spec")
+	default:
+		return nil
+	}
+	var sources []map[string]interface{}
+	if src := mapValue(spec, "source"); len(src) > 0 {
+		sources = append(sources, src)
+	}
+	if list, ok := spec["sources"].([]interface{}); ok {
+		for _, item := range list {
+			if src, ok := item.(map[string]interface{}); ok {
+				sources = append(sources, src)
+			}
+		}
+	}
+	return sources
+}
+
+// isCommit reports whether rev looks like an abbreviated or full commit SHA
+// rather than a numeric tag.
+func isCommit(rev string) bool {
+	if !commitPattern.MatchString(rev) {
+		return false
+	}
+	return len(rev) == 40 || strings.ContainsAny(strings.ToLower(rev), "abcdef")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func mapValue(obj map[string]interface{}, path ...string) map[string]interface{} {
+	current := obj
+	for _, key := range path {
+		if current == nil {
+			return map[string]interface{}{}
+		}
+		next, _ := current[key].(map[string]interface{})
+		current = next
+	}
+	if current == nil {
+		return map[string]interface{}{}
+	}
+	return current
+}
+
+func stringValue(obj map[string]interface{}, key string) string {
+	if obj == nil {
+		return ""
+	}
+	str, _ := obj[key].(string)
+	return str
+}
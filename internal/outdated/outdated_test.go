@@ -0,0 +1,108 @@
+package outdated
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func appWithSource(src map[string]interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		FilePath:     "app.yaml",
+		Kind:         string(types.ResourceKindApplication),
+		Name:         "demo",
+		MetadataLine: 1,
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{"source": src},
+		},
+	}
+}
+
+func gitRepoWithTags(t *testing.T, tags ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	previous := gitutil.Protocols
+	gitutil.Protocols = []string{"file"}
+	t.Cleanup(func() { gitutil.Protocols = previous })
+	run("init", "-q")
+	for _, tag := range tags {
+		run("commit", "-q", "--allow-empty", "-m", tag)
+		run("tag", tag)
+	}
+	return dir
+}
+
+func TestCheckGitTagsBehind(t *testing.T) {
+	repo := gitRepoWithTags(t, "v1.0.0", "v1.1.0", "v1.2.0-rc.1", "v1.2.0")
+	checker := NewChecker(config.Config{}, Options{Enabled: true})
+	app := appWithSource(map[string]interface{}{"repoURL": repo, "targetRevision": "v1.0.0", "path": "manifests"})
+	findings, entries, err := checker.Check(context.Background(), []*manifest.Manifest{app})
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Behind != 2 || entries[0].Latest != "v1.2.0" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "REVISION_OUTDATED" || findings[0].Severity != types.SeverityInfo {
+		t.Fatalf("expected one info finding, got %+v", findings)
+	}
+}
+
+func TestCheckSkipsBranchesAndUpToDate(t *testing.T) {
+	repo := gitRepoWithTags(t, "v1.0.0")
+	checker := NewChecker(config.Config{}, Options{Enabled: true})
+	current := appWithSource(map[string]interface{}{"repoURL": repo, "targetRevision": "v1.0.0"})
+	branch := appWithSource(map[string]interface{}{"repoURL": repo, "targetRevision": "main"})
+	findings, entries, err := checker.Check(context.Background(), []*manifest.Manifest{current, branch})
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+	if len(entries) != 1 || entries[0].Behind != 0 {
+		t.Fatalf("expected single up-to-date entry, got %+v", entries)
+	}
+}
+
+func TestCheckHelmIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("entries:\n  demo:\n    - version: 2.0.0\n    - version: 1.5.0\n    - version: 1.0.0\n"))
+	}))
+	defer server.Close()
+	checker := NewChecker(config.Config{}, Options{Enabled: true})
+	app := appWithSource(map[string]interface{}{"repoURL": server.URL, "chart": "demo", "targetRevision": "1.5.0"})
+	findings, entries, err := checker.Check(context.Background(), []*manifest.Manifest{app})
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Latest != "2.0.0" || entries[0].Behind != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "chart demo 1.5.0") {
+		t.Fatalf("expected chart finding, got %+v", findings)
+	}
+}
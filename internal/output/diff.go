@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// ReportDiff classifies the findings of a report against an earlier one.
+type ReportDiff struct {
+	New       []types.Finding `json:"new"`
+	Fixed     []types.Finding `json:"fixed"`
+	Unchanged []types.Finding `json:"unchanged"`
+}
+
+// LoadJSONReport reads the findings from a report written with --format json.
+// A file without a findings key is an error rather than an empty report, so
+// other JSON files, such as SARIF, are not mistaken for a clean run.
+func LoadJSONReport(path string) ([]types.Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Findings json.RawMessage `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(payload.Findings) == 0 {
+		return nil, fmt.Errorf("parse %s: no findings key; expected a report written with --format json", path)
+	}
+	var findings []types.Finding
+	if err := json.Unmarshal(payload.Findings, &findings); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return findings, nil
+}
+
+// DiffReports matches findings by rule, file, resource, and message, so a
+// finding that only moved to another line counts as unchanged. Duplicate
+// findings are matched one to one.
+func DiffReports(previous, current []types.Finding) ReportDiff {
+	pending := map[string][]types.Finding{}
+	for _, f := range previous {
+		key := findingFingerprint(f)
+		pending[key] = append(pending[key], f)
+	}
+	diff := ReportDiff{New: []types.Finding{}, Fixed: []types.Finding{}, Unchanged: []types.Finding{}}
+	for _, f := range current {
+		key := findingFingerprint(f)
+		if len(pending[key]) > 0 {
+			pending[key] = pending[key][1:]
+			diff.Unchanged = append(diff.Unchanged, f)
+			continue
+		}
+		diff.New = append(diff.New, f)
+	}
+	for _, f := range previous {
+		key := findingFingerprint(f)
+		if len(pending[key]) > 0 {
+			diff.Fixed = append(diff.Fixed, pending[key][0])
+			pending[key] = pending[key][1:]
+		}
+	}
+	return diff
+}
+
+// WriteReportDiff renders the diff as a table or JSON.
+func WriteReportDiff(diff ReportDiff, format string, w io.Writer) error {
+	switch strings.ToLower(format) {
+	case "", FormatTable:
+		return writeReportDiffTable(diff, w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func writeReportDiffTable(diff ReportDiff, w io.Writer) error {
+	headers := []string{"Status", "Severity", "Rule", "Resource", "Location", "Message"}
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	var rows [][]string
+	add := func(status string, findings []types.Finding) {
+		for _, f := range findings {
+			location := f.FilePath
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.FilePath, f.Line)
+			}
+			row := []string{status, strings.ToUpper(string(f.Severity)), f.RuleID, fmt.Sprintf("%s/%s", f.ResourceKind, f.ResourceName), location, f.Message}
+			rows = append(rows, row)
+			for i, cell := range row {
+				if len(cell) > widths[i] {
+					widths[i] = len(cell)
+				}
+			}
+		}
+	}
+	add("NEW", diff.New)
+	add("FIXED", diff.Fixed)
+	if len(rows) > 0 {
+		separator := buildTableSeparator(widths)
+		if _, err := fmt.Fprintln(w, separator); err != nil {
+			return err
+		}
+		if err := writeTableRow(w, headers, widths, ""); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, separator); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writeTableRow(w, row, widths, ""); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, separator); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Summary: %d new, %d fixed, %d unchanged\n", len(diff.New), len(diff.Fixed), len(diff.Unchanged))
+	return err
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestDiffReports(t *testing.T) {
+	kept := types.Finding{RuleID: "AR001", FilePath: "app.yaml", Line: 4, ResourceKind: "Application", ResourceName: "demo", Message: "floating revision"}
+	moved := kept
+	moved.Line = 9
+	fixed := types.Finding{RuleID: "AR005", FilePath: "app.yaml", ResourceKind: "Application", ResourceName: "demo", Message: "prune disabled"}
+	added := types.Finding{RuleID: "AR002", FilePath: "app.yaml", ResourceKind: "Application", ResourceName: "demo", Message: "default project", Severity: types.SeverityError}
+	duplicate := kept
+
+	diff := DiffReports([]types.Finding{kept, fixed}, []types.Finding{moved, added, duplicate})
+	if len(diff.Unchanged) != 1 || diff.Unchanged[0].Line != 9 {
+		t.Fatalf("expected the moved finding to be unchanged, got %+v", diff.Unchanged)
+	}
+	if len(diff.New) != 2 || diff.New[0].RuleID != "AR002" || diff.New[1].RuleID != "AR001" {
+		t.Fatalf("expected new AR002 and the extra AR001 duplicate, got %+v", diff.New)
+	}
+	if len(diff.Fixed) != 1 || diff.Fixed[0].RuleID != "AR005" {
+		t.Fatalf("expected AR005 to be fixed, got %+v", diff.Fixed)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReportDiff(diff, FormatTable, &buf); err != nil {
+		t.Fatalf("write diff: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Summary: 2 new, 1 fixed, 1 unchanged") {
+		t.Fatalf("unexpected summary:\n%s", buf.String())
+	}
+}
+
+func TestLoadJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(sampleReport(), FormatJSON, &buf); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	findings, err := LoadJSONReport(path)
+	if err != nil {
+		t.Fatalf("load report: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "AR001" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	buf.Reset()
+	if err := Write(sampleReport(), FormatSARIF, &buf); err != nil {
+		t.Fatalf("write sarif: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if _, err := LoadJSONReport(path); err == nil || !strings.Contains(err.Error(), "no findings key") {
+		t.Fatalf("expected a SARIF file to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// GitHubMatcherOwner is the problem matcher owner registered by the github format.
+const GitHubMatcherOwner = "argocd-lint"
+
+// GitHubProblemMatcher is the bundled problem matcher definition that parses
+// the lines produced by the github format.
+//
+//go:embed problem-matcher.json
+var GitHubProblemMatcher []byte
+
+// writeGitHub prints findings in the bundled problem matcher format so GitHub
+// Actions turns them into annotations, and appends a Markdown report to
+// $GITHUB_STEP_SUMMARY when it is available.
+func writeGitHub(report lint.Report, w io.Writer) error {
+	registered := false
+	if dir := os.Getenv("RUNNER_TEMP"); dir != "" {
+		path := filepath.Join(dir, "argocd-lint-problem-matcher.json")
+		if err := os.WriteFile(path, GitHubProblemMatcher, 0o644); err == nil {
+			if _, err := fmt.Fprintf(w, "::add-matcher::%s\n", path); err != nil {
+				return err
+			}
+			registered = true
+		}
+	}
+	for _, f := range report.Findings {
+		if _, err := fmt.Fprintln(w, githubLine(f)); err != nil {
+			return err
+		}
+	}
+	if registered {
+		if _, err := fmt.Fprintf(w, "::remove-matcher owner=%s::\n", GitHubMatcherOwner); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "\nSummary: %s\n", SummaryString(report.Findings)); err != nil {
+		return err
+	}
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("open step summary: %w", err)
+		}
+		defer file.Close()
+		if err := WriteMarkdownSummary(report, file); err != nil {
+			return fmt.Errorf("write step summary: %w", err)
+		}
+	}
+	return nil
+}
+
+func githubLine(f types.Finding) string {
+	line := f.Line
+	if line <= 0 {
+		line = 1
+	}
+	column := f.Column
+	if column <= 0 {
+		column = 1
+	}
+	level := "warning"
+	if f.Severity == types.SeverityError {
+		level = "error"
+	}
+	message := strings.ReplaceAll(f.Message, "\n", " ")
+	if f.ResourceName != "" {
+		message = fmt.Sprintf("%s (%s/%s)", message, f.ResourceKind, f.ResourceName)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s [%s] %s", f.FilePath, line, column, level, f.RuleID, message)
+}
+
+// WriteMarkdownSummary renders a GitHub-flavoured Markdown report with a
+// per-severity and per-rule breakdown followed by the individual findings.
+func WriteMarkdownSummary(report lint.Report, w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## argocd-lint report\n\n")
+	if len(report.Findings) == 0 {
+		b.WriteString(":white_check_mark: No findings.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%s**\n\n", SummaryString(report.Findings))
+
+	counts := map[string]int{}
+	severities := map[string]types.Severity{}
+	for _, f := range report.Findings {
+		counts[f.RuleID]++
+		if current, ok := severities[f.RuleID]; ok {
+			severities[f.RuleID] = types.HigherSeverity(current, f.Severity)
+		} else {
+			severities[f.RuleID] = f.Severity
+		}
+	}
+	ruleIDs := make([]string, 0, len(counts))
+	for id := range counts {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	b.WriteString("| Rule | Severity | Count | Description |\n| --- | --- | ---: | --- |\n")
+	for _, id := range ruleIDs {
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %s |\n", id, strings.ToUpper(string(severities[id])), counts[id], markdownEscape(report.RuleIndex[id].Description))
+	}
+
+	b.WriteString("\n<details><summary>Findings</summary>\n\n")
+	b.WriteString("| Severity | Rule | Resource | Location | Message |\n| --- | --- | --- | --- | --- |\n")
+	for _, f := range report.Findings {
+		location := f.FilePath
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.FilePath, f.Line)
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s/%s | `%s` | %s |\n",
+			strings.ToUpper(string(f.Severity)), f.RuleID, f.ResourceKind, f.ResourceName, location, markdownEscape(f.Message))
+	}
+	b.WriteString("\n</details>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
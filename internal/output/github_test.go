@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHub(t *testing.T) {
+	dir := t.TempDir()
+	summaryPath := filepath.Join(dir, "summary.md")
+	t.Setenv("RUNNER_TEMP", dir)
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	var buf bytes.Buffer
+	if err := Write(sampleReport(), FormatGitHub, &buf); err != nil {
+		t.Fatalf("write github: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "::add-matcher::"+filepath.Join(dir, "argocd-lint-problem-matcher.json")) {
+		t.Fatalf("expected matcher registration, got %s", output)
+	}
+	if !strings.Contains(output, "::remove-matcher owner=argocd-lint::") {
+		t.Fatalf("expected matcher removal, got %s", output)
+	}
+
+	var matcher struct {
+		ProblemMatcher []struct {
+			Pattern []struct {
+				Regexp string `json:"regexp"`
+			} `json:"pattern"`
+		} `json:"problemMatcher"`
+	}
+	if err := json.Unmarshal(GitHubProblemMatcher, &matcher); err != nil {
+		t.Fatalf("parse matcher: %v", err)
+	}
+	pattern := regexp.MustCompile(matcher.ProblemMatcher[0].Pattern[0].Regexp)
+	matched := false
+	for _, line := range strings.Split(output, "\n") {
+		if groups := pattern.FindStringSubmatch(line); groups != nil {
+			matched = true
+			if groups[1] != "demo.yaml" || groups[4] != "warning" || groups[5] != "AR001" {
+				t.Fatalf("unexpected matcher groups %q", groups)
+			}
+		}
+	}
+	if !matched {
+		t.Fatalf("expected a finding line matching the problem matcher, got %s", output)
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+	if !strings.Contains(string(summary), "## argocd-lint report") || !strings.Contains(string(summary), "`AR001`") {
+		t.Fatalf("expected markdown summary with rule table, got %s", summary)
+	}
+}
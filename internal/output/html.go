@@ -0,0 +1,156 @@
+package output
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+)
+
+// htmlFinding is the flattened row rendered by the HTML report.
+type htmlFinding struct {
+	Severity string
+	RuleID   string
+	RuleDesc string
+	HelpURL  string
+	Resource string
+	File     string
+	Line     int
+	Message  string
+}
+
+type htmlReport struct {
+	Generated string
+	Summary   string
+	Findings  []htmlFinding
+	Rules     []string
+	Files     []string
+}
+
+// writeHTML renders a standalone report with client-side filtering by
+// severity, rule, and file. CSS and JS are inlined so the file can be shared
+// as a single attachment.
+func writeHTML(report lint.Report, w io.Writer) error {
+	data := htmlReport{Generated: MetadataStamp(), Summary: SummaryString(report.Findings)}
+	rules := map[string]struct{}{}
+	files := map[string]struct{}{}
+	for _, f := range report.Findings {
+		severity := strings.ToLower(string(f.Severity))
+		if severity == "" {
+			severity = "info"
+		}
+		meta := report.RuleIndex[f.RuleID]
+		data.Findings = append(data.Findings, htmlFinding{
+			Severity: severity,
+			RuleID:   f.RuleID,
+			RuleDesc: meta.Description,
+			HelpURL:  f.HelpURL,
+			Resource: f.ResourceKind + "/" + f.ResourceName,
+			File:     f.FilePath,
+			Line:     f.Line,
+			Message:  f.Message,
+		})
+		rules[f.RuleID] = struct{}{}
+		files[f.FilePath] = struct{}{}
+	}
+	for id := range rules {
+		data.Rules = append(data.Rules, id)
+	}
+	for file := range files {
+		data.Files = append(data.Files, file)
+	}
+	sort.Strings(data.Rules)
+	sort.Strings(data.Files)
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>argocd-lint report</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+.meta { color: #59636e; margin-bottom: 1.5rem; }
+.filters { display: flex; gap: 1rem; flex-wrap: wrap; margin-bottom: 1rem; }
+.filters label { display: flex; flex-direction: column; font-size: 0.85rem; color: #59636e; }
+.filters select, .filters input { margin-top: 0.25rem; padding: 0.3rem; font-size: 0.9rem; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { text-align: left; padding: 0.5rem; border-bottom: 1px solid #d1d9e0; vertical-align: top; }
+th { background: #f6f8fa; }
+.sev { font-weight: 600; text-transform: uppercase; font-size: 0.75rem; padding: 0.1rem 0.4rem; border-radius: 0.25rem; }
+.sev-error { background: #ffebe9; color: #cf222e; }
+.sev-warn { background: #fff8c5; color: #9a6700; }
+.sev-info { background: #ddf4ff; color: #0969da; }
+.empty { color: #59636e; padding: 1rem 0; }
+</style>
+</head>
+<body>
+<h1>argocd-lint report</h1>
+<div class="meta">Generated {{.Generated}} &middot; {{.Summary}} &middot; <span id="visible">{{len .Findings}}</span> shown</div>
+{{if .Findings}}
+<div class="filters">
+  <label>Severity
+    <select id="filter-severity">
+      <option value="">All</option>
+      <option value="error">Error</option>
+      <option value="warn">Warn</option>
+      <option value="info">Info</option>
+    </select>
+  </label>
+  <label>Rule
+    <select id="filter-rule">
+      <option value="">All</option>
+      {{range .Rules}}<option value="{{.}}">{{.}}</option>
+      {{end}}
+    </select>
+  </label>
+  <label>File
+    <input id="filter-file" type="search" placeholder="path contains..." list="files">
+    <datalist id="files">{{range .Files}}<option value="{{.}}">{{end}}</datalist>
+  </label>
+</div>
+<table>
+  <thead><tr><th>Severity</th><th>Rule</th><th>Resource</th><th>Location</th><th>Message</th></tr></thead>
+  <tbody>
+  {{range .Findings}}<tr data-severity="{{.Severity}}" data-rule="{{.RuleID}}" data-file="{{.File}}">
+    <td><span class="sev sev-{{.Severity}}">{{.Severity}}</span></td>
+    <td>{{if .HelpURL}}<a href="{{.HelpURL}}" title="{{.RuleDesc}}">{{.RuleID}}</a>{{else}}<span title="{{.RuleDesc}}">{{.RuleID}}</span>{{end}}</td>
+    <td>{{.Resource}}</td>
+    <td>{{.File}}{{if gt .Line 0}}:{{.Line}}{{end}}</td>
+    <td>{{.Message}}</td>
+  </tr>
+  {{end}}</tbody>
+</table>
+<script>
+(function () {
+  var severity = document.getElementById("filter-severity");
+  var rule = document.getElementById("filter-rule");
+  var file = document.getElementById("filter-file");
+  var rows = document.querySelectorAll("tbody tr");
+  function apply() {
+    var shown = 0;
+    var needle = file.value.toLowerCase();
+    rows.forEach(function (row) {
+      var match = (!severity.value || row.dataset.severity === severity.value) &&
+        (!rule.value || row.dataset.rule === rule.value) &&
+        (!needle || row.dataset.file.toLowerCase().indexOf(needle) !== -1);
+      row.hidden = !match;
+      if (match) { shown++; }
+    });
+    document.getElementById("visible").textContent = shown;
+  }
+  [severity, rule].forEach(function (el) { el.addEventListener("change", apply); });
+  file.addEventListener("input", apply);
+})();
+</script>
+{{else}}
+<p class="empty">No findings.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -1,30 +1,161 @@
 package output
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/internal/outdated"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
 // Format enumerates supported output formats.
 const (
-	FormatTable = "table"
-	FormatJSON  = "json"
-	FormatSARIF = "sarif"
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatSARIF    = "sarif"
+	FormatGitHub   = "github"
+	FormatTeamCity = "teamcity"
+	FormatHTML     = "html"
 )
 
+// WriteOptions tweak how human-readable formats are rendered.
+type WriteOptions struct {
+	// Color wraps severities in ANSI colors in the table format.
+	Color bool
+	// Width truncates table messages so rows fit the terminal; 0 disables it.
+	Width int
+	// GroupBy splits the table into one section per rule, file, or resource.
+	GroupBy string
+	// MinSeverity hides findings below this level from every format; it does
+	// not change the exit code.
+	MinSeverity types.Severity
+	// MaxFindings caps the table rows, ending with an "and N more" footer;
+	// 0 shows everything.
+	MaxFindings int
+}
+
+// Table grouping modes accepted by WriteOptions.GroupBy.
+const (
+	GroupByRule     = "rule"
+	GroupByFile     = "file"
+	GroupByResource = "resource"
+)
+
+// Sink pairs an output format with its destination; an empty Path means the
+// caller's stdout.
+type Sink struct {
+	Format string
+	Path   string
+}
+
+// ParseSinks parses --format values written as "format" or "format=path".
+// At most one sink may target stdout. A non-empty outputPath redirects that
+// stdout sink to a file; whenever no table is requested the table is still
+// printed to stdout so the console keeps a human-readable summary.
+func ParseSinks(formats []string, outputPath string) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(formats)+1)
+	stdoutSink := -1
+	hasTable := false
+	paths := map[string]string{}
+	for _, value := range formats {
+		format, path, _ := strings.Cut(strings.TrimSpace(value), "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+		if !supportedFormat(format) {
+			return nil, fmt.Errorf("unsupported format %q", format)
+		}
+		if path != "" {
+			if previous, ok := paths[path]; ok {
+				return nil, fmt.Errorf("formats %q and %q both write to %s", previous, format, path)
+			}
+			paths[path] = format
+		}
+		if path == "" {
+			if stdoutSink >= 0 {
+				return nil, fmt.Errorf("formats %q and %q both write to stdout; give one a path with format=path", sinks[stdoutSink].Format, format)
+			}
+			stdoutSink = len(sinks)
+		}
+		if format == FormatTable {
+			hasTable = true
+		}
+		sinks = append(sinks, Sink{Format: format, Path: path})
+	}
+	if outputPath != "" {
+		if stdoutSink < 0 {
+			return nil, fmt.Errorf("--output needs a format without an explicit path")
+		}
+		if previous, ok := paths[outputPath]; ok {
+			return nil, fmt.Errorf("formats %q and %q both write to %s", previous, sinks[stdoutSink].Format, outputPath)
+		}
+		sinks[stdoutSink].Path = outputPath
+		stdoutSink = -1
+	}
+	if stdoutSink < 0 && !hasTable {
+		sinks = append(sinks, Sink{Format: FormatTable})
+	}
+	return sinks, nil
+}
+
+// WriteSinks fans the report out to every sink. Sinks without a path are
+// written to stdout with opts; file sinks drop the color and width so files
+// never contain terminal colors or truncated messages, but keep the same
+// grouping, minimum severity, and finding cap.
+func WriteSinks(report lint.Report, sinks []Sink, stdout io.Writer, opts WriteOptions) error {
+	fileOpts := WriteOptions{GroupBy: opts.GroupBy, MinSeverity: opts.MinSeverity, MaxFindings: opts.MaxFindings}
+	for _, sink := range sinks {
+		if sink.Path == "" {
+			if err := WriteWithOptions(report, sink.Format, stdout, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := WriteFile(report, sink.Format, sink.Path, fileOpts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func supportedFormat(format string) bool {
+	switch format {
+	case FormatTable, FormatJSON, FormatSARIF, FormatGitHub, FormatTeamCity, FormatHTML:
+		return true
+	}
+	return false
+}
+
+// WriteFile renders the report to path in the requested format.
+func WriteFile(report lint.Report, format, path string, opts WriteOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if err := WriteWithOptions(report, format, file, opts); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
 // Metrics summarizes lint output for telemetry purposes.
 type Metrics struct {
-	DurationMillis int64         `json:"durationMillis"`
-	TotalFindings  int           `json:"totalFindings"`
+	DurationMillis int64          `json:"durationMillis"`
+	TotalFindings  int            `json:"totalFindings"`
 	BySeverity     map[string]int `json:"bySeverity"`
-	ByRule         []RuleMetric  `json:"byRule"`
+	ByRule         []RuleMetric   `json:"byRule"`
 }
 
 // RuleMetric captures the count for a specific rule.
@@ -36,33 +167,98 @@ type RuleMetric struct {
 
 // Write renders the report to the writer using the requested format.
 func Write(report lint.Report, format string, w io.Writer) error {
+	return WriteWithOptions(report, format, w, WriteOptions{})
+}
+
+// WriteWithOptions renders the report like Write, applying opts to the
+// human-readable table format.
+func WriteWithOptions(report lint.Report, format string, w io.Writer, opts WriteOptions) error {
+	switch opts.GroupBy {
+	case "", GroupByRule, GroupByFile, GroupByResource:
+	default:
+		return fmt.Errorf("unsupported group-by %q (expected rule, file, or resource)", opts.GroupBy)
+	}
+	total := len(report.Findings)
+	report.Findings = filterMinSeverity(report.Findings, opts.MinSeverity)
+	hidden := total - len(report.Findings)
 	switch strings.ToLower(format) {
 	case "", FormatTable:
-		return writeTable(report, w)
+		return writeTable(report, w, opts, hidden)
 	case FormatJSON:
 		return writeJSON(report, w)
 	case FormatSARIF:
 		return writeSARIF(report, w)
+	case FormatGitHub:
+		return writeGitHub(report, w)
+	case FormatTeamCity:
+		return writeTeamCity(report, w)
+	case FormatHTML:
+		return writeHTML(report, w)
 	default:
 		return fmt.Errorf("unsupported format %q", format)
 	}
 }
 
-func writeTable(report lint.Report, w io.Writer) error {
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	// minMessageWidth keeps truncated messages readable on narrow terminals.
+	minMessageWidth = 20
+)
+
+// filterMinSeverity drops findings below minimum; an empty minimum keeps all.
+func filterMinSeverity(findings []types.Finding, minimum types.Severity) []types.Finding {
+	if minimum == "" {
+		return findings
+	}
+	kept := make([]types.Finding, 0, len(findings))
+	for _, f := range findings {
+		if types.SeverityOrder[f.Severity] >= types.SeverityOrder[minimum] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// writeTable prints the findings; hidden counts findings already removed by
+// MinSeverity so the footer can mention them.
+func writeTable(report lint.Report, w io.Writer, opts WriteOptions, hidden int) error {
+	writeFooter := func(more int) error {
+		if more > 0 {
+			if _, err := fmt.Fprintf(w, "... and %d more\n", more); err != nil {
+				return err
+			}
+		}
+		if hidden > 0 {
+			if _, err := fmt.Fprintf(w, "%s below %s not shown\n", pluralize(hidden, "finding"), opts.MinSeverity); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "\nSummary: %s\n", SummaryString(report.Findings))
+		return err
+	}
 	if len(report.Findings) == 0 {
 		if _, err := fmt.Fprintln(w, "No findings."); err != nil {
 			return err
 		}
-		_, err := fmt.Fprintf(w, "\nSummary: %s\n", SummaryString(report.Findings))
-		return err
+		return writeFooter(0)
+	}
+	shown := report.Findings
+	more := 0
+	if opts.MaxFindings > 0 && len(shown) > opts.MaxFindings {
+		more = len(shown) - opts.MaxFindings
+		shown = shown[:opts.MaxFindings]
 	}
 	headers := []string{"Severity", "Rule", "Resource", "Location", "Message"}
 	widths := make([]int, len(headers))
 	for i, header := range headers {
 		widths[i] = len(header)
 	}
-	rows := make([][]string, 0, len(report.Findings))
-	for _, f := range report.Findings {
+	rows := make([][]string, 0, len(shown))
+	colors := make([]string, 0, len(shown))
+	for _, f := range shown {
 		severity := strings.ToUpper(string(f.Severity))
 		if severity == "" {
 			severity = "INFO"
@@ -74,32 +270,137 @@ func writeTable(report lint.Report, w io.Writer) error {
 		}
 		row := []string{severity, f.RuleID, resource, location, f.Message}
 		rows = append(rows, row)
+		colors = append(colors, severityColor(f.Severity, opts.Color))
 		for i, cell := range row {
-			if len(cell) > widths[i] {
-				widths[i] = len(cell)
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
 			}
 		}
 	}
-	separator := buildTableSeparator(widths)
-	if _, err := fmt.Fprintln(w, separator); err != nil {
-		return err
-	}
-	if err := writeTableRow(w, headers, widths); err != nil {
-		return err
+	if opts.Width > 0 {
+		message := len(widths) - 1
+		// Every column adds a leading "|" and two spaces of padding; the
+		// row ends with a closing "|".
+		fixed := 1
+		for i, width := range widths {
+			fixed += width + 3
+			if i == message {
+				fixed -= width
+			}
+		}
+		if available := opts.Width - fixed; available < widths[message] {
+			if available < minMessageWidth {
+				available = minMessageWidth
+			}
+			widths[message] = available
+			for _, row := range rows {
+				row[message] = truncate(row[message], available)
+			}
+		}
 	}
-	if _, err := fmt.Fprintln(w, separator); err != nil {
+	separator := buildTableSeparator(widths)
+	writeSection := func(indexes []int) error {
+		if _, err := fmt.Fprintln(w, separator); err != nil {
+			return err
+		}
+		if err := writeTableRow(w, headers, widths, ""); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, separator); err != nil {
+			return err
+		}
+		for _, i := range indexes {
+			if err := writeTableRow(w, rows[i], widths, colors[i]); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(w, separator)
 		return err
 	}
-	for _, row := range rows {
-		if err := writeTableRow(w, row, widths); err != nil {
+	if opts.GroupBy == "" {
+		all := make([]int, len(rows))
+		for i := range rows {
+			all[i] = i
+		}
+		if err := writeSection(all); err != nil {
 			return err
 		}
+	} else {
+		keys, groups := groupFindings(shown, opts.GroupBy)
+		for n, key := range keys {
+			if n > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			title := key
+			if opts.GroupBy == GroupByRule && report.RuleIndex[key].Description != "" {
+				title = fmt.Sprintf("%s – %s", key, report.RuleIndex[key].Description)
+			}
+			if _, err := fmt.Fprintf(w, "%s (%s)\n", title, pluralize(len(groups[key]), "finding")); err != nil {
+				return err
+			}
+			if err := writeSection(groups[key]); err != nil {
+				return err
+			}
+		}
 	}
-	if _, err := fmt.Fprintln(w, separator); err != nil {
-		return err
+	return writeFooter(more)
+}
+
+// groupFindings buckets finding indexes by the requested key, returning the
+// keys sorted alphabetically. Findings keep their report order within a group.
+func groupFindings(findings []types.Finding, groupBy string) ([]string, map[string][]int) {
+	groups := map[string][]int{}
+	var keys []string
+	for i, f := range findings {
+		var key string
+		switch groupBy {
+		case GroupByRule:
+			key = f.RuleID
+		case GroupByFile:
+			key = f.FilePath
+		default:
+			key = fmt.Sprintf("%s/%s", f.ResourceKind, f.ResourceName)
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
 	}
-	_, err := fmt.Fprintf(w, "\nSummary: %s\n", SummaryString(report.Findings))
-	return err
+	sort.Strings(keys)
+	return keys, groups
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func severityColor(severity types.Severity, enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	switch severity {
+	case types.SeverityError:
+		return ansiRed
+	case types.SeverityWarn:
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
+
+// truncate shortens value to at most width runes, marking the cut with an
+// ellipsis.
+func truncate(value string, width int) string {
+	if utf8.RuneCountInString(value) <= width {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:width-1]) + "…"
 }
 
 func buildTableSeparator(widths []int) string {
@@ -110,11 +411,20 @@ func buildTableSeparator(widths []int) string {
 	return "+" + strings.Join(parts, "+") + "+"
 }
 
-func writeTableRow(w io.Writer, values []string, widths []int) error {
+// writeTableRow pads each cell to its column width. A non-empty color is
+// applied to the first (severity) cell after padding so alignment is kept.
+func writeTableRow(w io.Writer, values []string, widths []int, color string) error {
 	var b strings.Builder
 	b.WriteString("|")
 	for i, width := range widths {
-		fmt.Fprintf(&b, " %-*s ", width, values[i])
+		cell := values[i]
+		if pad := width - utf8.RuneCountInString(cell); pad > 0 {
+			cell += strings.Repeat(" ", pad)
+		}
+		if i == 0 && color != "" {
+			cell = color + cell + ansiReset
+		}
+		b.WriteString(" " + cell + " ")
 		b.WriteString("|")
 	}
 	b.WriteString("\n")
@@ -135,25 +445,62 @@ func writeJSON(report lint.Report, w io.Writer) error {
 	return enc.Encode(payload)
 }
 
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifFix struct {
+	Description     sarifText `json:"description"`
+	ArtifactChanges []struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Replacements     []struct {
+			DeletedRegion   sarifRegion `json:"deletedRegion"`
+			InsertedContent sarifText   `json:"insertedContent"`
+		} `json:"replacements"`
+	} `json:"artifactChanges"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             sarifText              `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	RelatedLocations    []sarifLocation        `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Fixes               []sarifFix             `json:"fixes,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifFingerprintKey names the partialFingerprints entry; bump the version
+// if the hashed fields change.
+const sarifFingerprintKey = "argocdLintFindingHash/v1"
+
 func writeSARIF(report lint.Report, w io.Writer) error {
-	type sarifResult struct {
-		RuleID  string `json:"ruleId"`
-		Level   string `json:"level"`
-		Message struct {
-			Text string `json:"text"`
-		} `json:"message"`
-		Locations []struct {
-			PhysicalLocation struct {
-				ArtifactLocation struct {
-					URI string `json:"uri"`
-				} `json:"artifactLocation"`
-				Region struct {
-					StartLine int `json:"startLine,omitempty"`
-				} `json:"region"`
-			} `json:"physicalLocation"`
-		} `json:"locations"`
-		Properties map[string]interface{} `json:"properties,omitempty"`
-	}
 	type sarifSuggestion struct {
 		Title       string `json:"title"`
 		Description string `json:"description,omitempty"`
@@ -161,15 +508,11 @@ func writeSARIF(report lint.Report, w io.Writer) error {
 		Path        string `json:"path,omitempty"`
 	}
 	type sarifRule struct {
-		ID        string `json:"id"`
-		Name      string `json:"name"`
-		ShortDesc struct {
-			Text string `json:"text"`
-		} `json:"shortDescription"`
-		FullDesc struct {
-			Text string `json:"text"`
-		} `json:"fullDescription"`
-		HelpURI string `json:"helpUri,omitempty"`
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		ShortDesc sarifText `json:"shortDescription"`
+		FullDesc  sarifText `json:"fullDescription"`
+		HelpURI   string    `json:"helpUri,omitempty"`
 	}
 	type sarifTool struct {
 		Driver struct {
@@ -206,32 +549,26 @@ func writeSARIF(report lint.Report, w io.Writer) error {
 		driver.Driver.Rules = append(driver.Driver.Rules, ruleEntry)
 	}
 
-	results := make([]sarifResult, 0, len(report.Findings))
-	for _, finding := range report.Findings {
+	// A non-nil Suppressed slice means a baseline was applied, so active
+	// findings are new relative to it.
+	baselineApplied := report.Suppressed != nil
+	results := make([]sarifResult, 0, len(report.Findings)+len(report.Suppressed))
+	sources := fixSources{}
+	build := func(finding types.Finding) sarifResult {
 		res := sarifResult{RuleID: finding.RuleID, Level: sarifSeverity(finding.Severity)}
 		res.Message.Text = finding.Message
-		location := struct {
-			PhysicalLocation struct {
-				ArtifactLocation struct {
-					URI string `json:"uri"`
-				} `json:"artifactLocation"`
-				Region struct {
-					StartLine int `json:"startLine,omitempty"`
-				} `json:"region"`
-			} `json:"physicalLocation"`
-		}{}
+		var location sarifLocation
 		location.PhysicalLocation.ArtifactLocation.URI = finding.FilePath
 		location.PhysicalLocation.Region.StartLine = finding.Line
-		res.Locations = []struct {
-			PhysicalLocation struct {
-				ArtifactLocation struct {
-					URI string `json:"uri"`
-				} `json:"artifactLocation"`
-				Region struct {
-					StartLine int `json:"startLine,omitempty"`
-				} `json:"region"`
-			} `json:"physicalLocation"`
-		}{location}
+		res.Locations = []sarifLocation{location}
+		for _, related := range finding.RelatedLocations {
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = related.FilePath
+			loc.PhysicalLocation.Region.StartLine = related.Line
+			loc.PhysicalLocation.Region.StartColumn = related.Column
+			res.RelatedLocations = append(res.RelatedLocations, loc)
+		}
+		res.PartialFingerprints = map[string]string{sarifFingerprintKey: findingFingerprint(finding)}
 		if len(finding.Suggestions) > 0 {
 			suggestions := make([]sarifSuggestion, 0, len(finding.Suggestions))
 			for _, suggestion := range finding.Suggestions {
@@ -241,11 +578,27 @@ func writeSARIF(report lint.Report, w io.Writer) error {
 					Patch:       suggestion.Patch,
 					Path:        suggestion.Path,
 				})
+				if fix, ok := sarifFixFromSuggestion(sources, finding, suggestion); ok {
+					res.Fixes = append(res.Fixes, fix)
+				}
 			}
 			res.Properties = map[string]interface{}{
 				"suggestions": suggestions,
 			}
 		}
+		return res
+	}
+	for _, finding := range report.Findings {
+		res := build(finding)
+		if baselineApplied {
+			res.BaselineState = "new"
+		}
+		results = append(results, res)
+	}
+	for _, finding := range report.Suppressed {
+		res := build(finding)
+		res.BaselineState = "unchanged"
+		res.Suppressions = []sarifSuppression{{Kind: "external", Justification: "accepted in argocd-lint baseline"}}
 		results = append(results, res)
 	}
 
@@ -264,6 +617,13 @@ func writeSARIF(report lint.Report, w io.Writer) error {
 	return enc.Encode(payload)
 }
 
+// findingFingerprint hashes the fields that identify a finding independently
+// of its line number, so results stay matched when manifests shift.
+func findingFingerprint(f types.Finding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.RuleID, f.FilePath, f.ResourceKind, f.ResourceName, f.Message}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
 // HighestSeverity returns the highest severity in findings.
 func HighestSeverity(findings []types.Finding) types.Severity {
 	highest := types.SeverityInfo
@@ -382,3 +742,19 @@ func SummaryString(findings []types.Finding) string {
 func MetadataStamp() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
+
+// WriteOutdatedReport persists the outdated revision entries as JSON for
+// dependency-update automation.
+func WriteOutdatedReport(path string, entries []outdated.Entry) error {
+	if entries == nil {
+		entries = []outdated.Entry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode outdated report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write outdated report: %w", err)
+	}
+	return nil
+}
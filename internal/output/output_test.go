@@ -3,6 +3,8 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -72,6 +74,185 @@ func TestWriteTableNoFindings(t *testing.T) {
 	}
 }
 
+func TestWriteTableColorAndTruncation(t *testing.T) {
+	report := sampleReport()
+	report.Findings[0].Message = strings.Repeat("long message ", 20)
+
+	var plain bytes.Buffer
+	if err := Write(report, FormatTable, &plain); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Fatalf("expected no ANSI codes by default")
+	}
+	if !strings.Contains(plain.String(), report.Findings[0].Message) {
+		t.Fatalf("expected full message without a width limit")
+	}
+
+	var styled bytes.Buffer
+	if err := WriteWithOptions(report, FormatTable, &styled, WriteOptions{Color: true, Width: 80}); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	if !strings.Contains(styled.String(), ansiYellow+"WARN") {
+		t.Fatalf("expected warn severity to be yellow:\n%s", styled.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(styled.String()), "\n") {
+		if !strings.HasPrefix(line, "|") && !strings.HasPrefix(line, "+") {
+			continue
+		}
+		line = strings.NewReplacer(ansiYellow, "", ansiReset, "").Replace(line)
+		if n := len([]rune(line)); n > 80 {
+			t.Fatalf("expected rows to fit 80 columns, got %d: %q", n, line)
+		}
+	}
+	if !strings.Contains(styled.String(), "…") {
+		t.Fatalf("expected truncated message to end with an ellipsis")
+	}
+}
+
+func TestWriteTableGroupByRule(t *testing.T) {
+	report := sampleReport()
+	second := report.Findings[0]
+	second.FilePath = "other.yaml"
+	third := report.Findings[0]
+	third.RuleID = "AR002"
+	report.Findings = append(report.Findings, second, third)
+
+	var buf bytes.Buffer
+	if err := WriteWithOptions(report, FormatTable, &buf, WriteOptions{GroupBy: GroupByRule}); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	out := buf.String()
+	first := strings.Index(out, "AR001 – demo (2 findings)")
+	other := strings.Index(out, "AR002 (1 finding)")
+	if first < 0 || other < first {
+		t.Fatalf("expected per-rule headers in rule order:\n%s", out)
+	}
+	if err := WriteWithOptions(report, FormatTable, &buf, WriteOptions{GroupBy: "severity"}); err == nil {
+		t.Fatalf("expected unsupported group-by to fail")
+	}
+}
+
+func TestParseSinks(t *testing.T) {
+	sinks, err := ParseSinks([]string{"sarif=out.sarif"}, "")
+	if err != nil {
+		t.Fatalf("parse sinks: %v", err)
+	}
+	if len(sinks) != 2 || sinks[0] != (Sink{Format: FormatSARIF, Path: "out.sarif"}) || sinks[1] != (Sink{Format: FormatTable}) {
+		t.Fatalf("expected sarif file plus stdout table, got %+v", sinks)
+	}
+	sinks, err = ParseSinks([]string{"table"}, "lint.txt")
+	if err != nil {
+		t.Fatalf("parse sinks: %v", err)
+	}
+	if len(sinks) != 1 || sinks[0].Path != "lint.txt" {
+		t.Fatalf("expected --output to redirect the table, got %+v", sinks)
+	}
+	if _, err := ParseSinks([]string{"yaml"}, ""); err == nil {
+		t.Fatalf("expected unsupported format to fail")
+	}
+	if _, err := ParseSinks([]string{"json=a.json"}, "b.json"); err == nil {
+		t.Fatalf("expected --output without a stdout format to fail")
+	}
+}
+
+func TestWriteSinksFansOut(t *testing.T) {
+	dir := t.TempDir()
+	sinks, err := ParseSinks([]string{"table", "sarif=" + filepath.Join(dir, "report.sarif"), "json=" + filepath.Join(dir, "report.json")}, "")
+	if err != nil {
+		t.Fatalf("parse sinks: %v", err)
+	}
+	var stdout bytes.Buffer
+	if err := WriteSinks(sampleReport(), sinks, &stdout, WriteOptions{Color: true}); err != nil {
+		t.Fatalf("write sinks: %v", err)
+	}
+	if !strings.Contains(stdout.String(), ansiYellow) {
+		t.Fatalf("expected colored table on stdout")
+	}
+	sarifData, err := os.ReadFile(filepath.Join(dir, "report.sarif"))
+	if err != nil || !strings.Contains(string(sarifData), "\"version\": \"2.1.0\"") {
+		t.Fatalf("expected sarif file, got %v", err)
+	}
+	var payload map[string]interface{}
+	jsonData, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil {
+		t.Fatalf("read json report: %v", err)
+	}
+	if err := json.Unmarshal(jsonData, &payload); err != nil || payload["findings"] == nil {
+		t.Fatalf("expected json report with findings, got %v", err)
+	}
+	if _, err := ParseSinks([]string{"json=out", "sarif=out"}, ""); err == nil {
+		t.Fatalf("expected sinks sharing a path to be rejected")
+	}
+
+	stdout.Reset()
+	if err := WriteSinks(sampleReport(), sinks, &stdout, WriteOptions{MinSeverity: types.SeverityError}); err != nil {
+		t.Fatalf("write sinks: %v", err)
+	}
+	var filtered struct {
+		Findings []types.Finding `json:"findings"`
+	}
+	jsonData, err = os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil || json.Unmarshal(jsonData, &filtered) != nil || len(filtered.Findings) != 0 {
+		t.Fatalf("expected the file sink to apply the minimum severity, got %s (%v)", jsonData, err)
+	}
+}
+
+func TestWriteTableMinSeverityAndMaxFindings(t *testing.T) {
+	report := sampleReport()
+	base := report.Findings[0]
+	report.Findings = nil
+	for i := 0; i < 5; i++ {
+		f := base
+		f.Line = i + 1
+		f.Severity = types.SeverityError
+		report.Findings = append(report.Findings, f)
+	}
+	info := base
+	info.Severity = types.SeverityInfo
+	info.Message = "informational"
+	report.Findings = append(report.Findings, info)
+
+	var buf bytes.Buffer
+	opts := WriteOptions{MinSeverity: types.SeverityWarn, MaxFindings: 2}
+	if err := WriteWithOptions(report, FormatTable, &buf, opts); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "informational") {
+		t.Fatalf("expected info finding to be hidden:\n%s", out)
+	}
+	if strings.Count(out, "| ERROR") != 2 {
+		t.Fatalf("expected two rows, got:\n%s", out)
+	}
+	for _, want := range []string{"... and 3 more", "1 finding below warn not shown", "Summary: 5 findings"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteWithOptions(report, FormatJSON, &buf, opts); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var payload struct {
+		Findings []types.Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal json: %v", err)
+	}
+	if len(payload.Findings) != 5 {
+		t.Fatalf("expected min severity but no truncation in json, got %d findings", len(payload.Findings))
+	}
+}
+
+func TestTerminalOptionsIgnoresNonTTY(t *testing.T) {
+	var buf bytes.Buffer
+	if opts := TerminalOptions(&buf, false); opts.Color || opts.Width != 0 {
+		t.Fatalf("expected plain output for non-terminal writers, got %+v", opts)
+	}
+}
+
 func TestWriteSARIF(t *testing.T) {
 	var buf bytes.Buffer
 	if err := Write(sampleReport(), FormatSARIF, &buf); err != nil {
@@ -108,6 +289,147 @@ func TestWriteSARIF(t *testing.T) {
 	if !ok || len(sarifSuggestions) != 1 {
 		t.Fatalf("expected sarif suggestions entry")
 	}
+
+	report := sampleReport()
+	report.Findings[0].RelatedLocations = []types.Location{{FilePath: "chart/templates/deploy.yaml", Line: 12, Column: 3}}
+	buf.Reset()
+	if err := Write(report, FormatSARIF, &buf); err != nil {
+		t.Fatalf("write sarif: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"relatedLocations"`) || !strings.Contains(buf.String(), `"uri": "chart/templates/deploy.yaml"`) {
+		t.Fatalf("expected related locations in sarif output:\n%s", buf.String())
+	}
+}
+
+func TestWriteSARIFFingerprintsFixesAndBaseline(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "demo.yaml")
+	body := "apiVersion: argoproj.io/v1alpha1\nkind: Application\nmetadata:\n  name: demo\nspec:\n  source:\n    targetRevision: main # floating\n"
+	if err := os.WriteFile(manifest, []byte(body), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	report := sampleReport()
+	report.Findings[0].FilePath = manifest
+	report.Findings[0].Line = 4
+	report.Findings[0].Suggestions = []types.Suggestion{{Title: "Pin", Patch: "targetRevision: v1.2.3", Path: "$.spec.source.targetRevision"}}
+	suppressed := report.Findings[0]
+	suppressed.RuleID = "AR002"
+	suppressed.Suggestions = nil
+	report.Suppressed = []types.Finding{suppressed}
+
+	var first, second bytes.Buffer
+	if err := Write(report, FormatSARIF, &first); err != nil {
+		t.Fatalf("write sarif: %v", err)
+	}
+	shifted := report
+	shifted.Findings = []types.Finding{report.Findings[0]}
+	shifted.Findings[0].Line = 5
+	if err := Write(shifted, FormatSARIF, &second); err != nil {
+		t.Fatalf("write sarif: %v", err)
+	}
+
+	type result struct {
+		RuleID              string            `json:"ruleId"`
+		PartialFingerprints map[string]string `json:"partialFingerprints"`
+		BaselineState       string            `json:"baselineState"`
+		Suppressions        []struct {
+			Kind string `json:"kind"`
+		} `json:"suppressions"`
+		Fixes []struct {
+			ArtifactChanges []struct {
+				ArtifactLocation struct {
+					URI string `json:"uri"`
+				} `json:"artifactLocation"`
+				Replacements []struct {
+					DeletedRegion   sarifRegion `json:"deletedRegion"`
+					InsertedContent struct {
+						Text string `json:"text"`
+					} `json:"insertedContent"`
+				} `json:"replacements"`
+			} `json:"artifactChanges"`
+		} `json:"fixes"`
+	}
+	decode := func(buf *bytes.Buffer) []result {
+		var payload struct {
+			Runs []struct {
+				Results []result `json:"results"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+			t.Fatalf("unmarshal sarif: %v", err)
+		}
+		return payload.Runs[0].Results
+	}
+	results := decode(&first)
+	if len(results) != 2 {
+		t.Fatalf("expected active and suppressed results, got %d", len(results))
+	}
+	active, baselined := results[0], results[1]
+	if active.BaselineState != "new" || len(active.Suppressions) != 0 {
+		t.Fatalf("expected active result to be new, got %+v", active)
+	}
+	if baselined.RuleID != "AR002" || baselined.BaselineState != "unchanged" || len(baselined.Suppressions) != 1 || baselined.Suppressions[0].Kind != "external" {
+		t.Fatalf("expected suppressed result to be unchanged and externally suppressed, got %+v", baselined)
+	}
+	fingerprint := active.PartialFingerprints[sarifFingerprintKey]
+	if fingerprint == "" || fingerprint == baselined.PartialFingerprints[sarifFingerprintKey] {
+		t.Fatalf("expected distinct fingerprints per rule, got %q", fingerprint)
+	}
+	if got := decode(&second)[0].PartialFingerprints[sarifFingerprintKey]; got != fingerprint {
+		t.Fatalf("expected fingerprint to be stable across line shifts, got %q vs %q", got, fingerprint)
+	}
+	if len(active.Fixes) != 1 || len(active.Fixes[0].ArtifactChanges) != 1 {
+		t.Fatalf("expected suggestion patch to become a fix, got %+v", active.Fixes)
+	}
+	change := active.Fixes[0].ArtifactChanges[0]
+	if change.ArtifactLocation.URI != manifest || len(change.Replacements) != 1 ||
+		change.Replacements[0].DeletedRegion != (sarifRegion{StartLine: 7, StartColumn: 5, EndLine: 7, EndColumn: 25}) ||
+		change.Replacements[0].InsertedContent.Text != "targetRevision: v1.2.3" {
+		t.Fatalf("expected the fix to replace the targetRevision entry, got %+v", change)
+	}
+}
+
+func TestSARIFFixPlacement(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	body := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n---\napiVersion: argoproj.io/v1alpha1\nkind: Application\nmetadata:\n  name: demo\nspec:\n  destination:\n    server: 'https://kubernetes.default.svc'\n  syncPolicy: {}\n  sources:\n    - repoURL: https://example.com/repo.git\n"
+	if err := os.WriteFile(manifest, []byte(body), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	finding := types.Finding{FilePath: manifest, Line: 9}
+	fix := func(patch, path string) (sarifRegion, string, bool) {
+		t.Helper()
+		got, ok := sarifFixFromSuggestion(fixSources{}, finding, types.Suggestion{Title: "Edit", Patch: patch, Path: path})
+		if !ok {
+			return sarifRegion{}, "", false
+		}
+		replacement := got.ArtifactChanges[0].Replacements[0]
+		return replacement.DeletedRegion, replacement.InsertedContent.Text, true
+	}
+
+	region, text, ok := fix("metadata:\n  labels:\n    app.kubernetes.io/managed-by: argocd", "$.metadata.labels")
+	if !ok || region != (sarifRegion{StartLine: 9, StartColumn: 1, EndLine: 9, EndColumn: 1}) || text != "  labels:\n    app.kubernetes.io/managed-by: argocd\n" {
+		t.Fatalf("expected labels inserted into the Application's metadata, got %+v %q", region, text)
+	}
+	region, text, ok = fix("server: https://cluster.example.com", "$.spec.destination.server")
+	if !ok || region != (sarifRegion{StartLine: 12, StartColumn: 5, EndLine: 12, EndColumn: 45}) || text != "server: https://cluster.example.com" {
+		t.Fatalf("expected the quoted server replaced, got %+v %q", region, text)
+	}
+	for _, tc := range []struct{ patch, path string }{
+		{"automated:\n  prune: true", "$.spec.syncPolicy.automated"},
+		{"targetRevision: v1", "$.spec.sources[0].targetRevision"},
+		{"targetRevision: v1", "$.spec.source.targetRevision"},
+		{"targetRevision: <tag-or-commit>", "$.spec.destination.targetRevision"},
+		{"# move helm: block to a dedicated source entry", "$.spec.destination"},
+		{"namespace: demo", ""},
+		{"- demo", "$.spec.sourceNamespaces[]"},
+	} {
+		if region, text, ok := fix(tc.patch, tc.path); ok {
+			t.Fatalf("expected no fix for %q at %s, got %+v %q", tc.patch, tc.path, region, text)
+		}
+	}
+	finding.FilePath = filepath.Join(filepath.Dir(manifest), "missing.yaml")
+	if _, _, ok := fix("server: https://cluster.example.com", "$.spec.destination.server"); ok {
+		t.Fatalf("expected no fix for an unreadable file")
+	}
 }
 
 func TestHighestSeverity(t *testing.T) {
@@ -161,3 +483,43 @@ func TestWriteMetricsJSON(t *testing.T) {
 		t.Fatalf("expected totalFindings=1")
 	}
 }
+
+func TestWriteTeamCity(t *testing.T) {
+	report := sampleReport()
+	report.Findings[0].Message = "can't use [brackets]"
+	var buf bytes.Buffer
+	if err := Write(report, FormatTeamCity, &buf); err != nil {
+		t.Fatalf("write teamcity: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "##teamcity[inspectionType id='AR001' name='AR001' description='demo' category='test']") {
+		t.Fatalf("expected inspection type message, got %s", output)
+	}
+	if !strings.Contains(output, "message='can|'t use |[brackets|] (Application/demo)'") {
+		t.Fatalf("expected escaped inspection message, got %s", output)
+	}
+	if !strings.Contains(output, "SEVERITY='WARNING'") {
+		t.Fatalf("expected warning severity mapping, got %s", output)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	report := sampleReport()
+	report.Findings[0].Message = "<script>alert(1)</script>"
+	var buf bytes.Buffer
+	if err := Write(report, FormatHTML, &buf); err != nil {
+		t.Fatalf("write html: %v", err)
+	}
+	output := buf.String()
+	if !strings.HasPrefix(output, "<!DOCTYPE html>") {
+		t.Fatalf("expected standalone html document, got %s", output)
+	}
+	if strings.Contains(output, "<script>alert(1)</script>") {
+		t.Fatalf("expected message to be escaped")
+	}
+	for _, want := range []string{`data-severity="warn" data-rule="AR001" data-file="demo.yaml"`, `<option value="AR001">AR001</option>`, "id=\"filter-file\""} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output", want)
+		}
+	}
+}
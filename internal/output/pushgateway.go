@@ -0,0 +1,152 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+)
+
+// PushOptions configures delivery of metrics to a Prometheus Pushgateway.
+type PushOptions struct {
+	URL    string
+	Job    string
+	Labels map[string]string
+	Client *http.Client
+}
+
+// PushMetrics sends summary metrics for the report to a Prometheus Pushgateway.
+// The grouping key is built from the job name plus the provided labels, so
+// repeated runs for the same repo/branch/profile replace the previous sample.
+func PushMetrics(ctx context.Context, report lint.Report, duration time.Duration, opts PushOptions) error {
+	base := strings.TrimRight(strings.TrimSpace(opts.URL), "/")
+	if base == "" {
+		return fmt.Errorf("pushgateway url is required")
+	}
+	job := strings.TrimSpace(opts.Job)
+	if job == "" {
+		job = "argocd-lint"
+	}
+	target := base + "/metrics/job/" + url.PathEscape(job)
+	keys := make([]string, 0, len(opts.Labels))
+	for key, value := range opts.Labels {
+		if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		target += "/" + encodeGroupingLabel(key, opts.Labels[key])
+	}
+
+	var body bytes.Buffer
+	if err := writePrometheusText(computeMetrics(report, duration), time.Now(), &body); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, &body)
+	if err != nil {
+		return fmt.Errorf("build pushgateway request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push metrics: pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// WritePrometheus renders the report in the Prometheus text exposition format,
+// including a per-resource gauge suited to a long-running /metrics endpoint.
+func WritePrometheus(report lint.Report, duration time.Duration, w io.Writer) error {
+	if err := writePrometheusText(computeMetrics(report, duration), time.Now(), w); err != nil {
+		return err
+	}
+	type resourceKey struct{ kind, name, file, severity string }
+	counts := make(map[resourceKey]int)
+	for _, f := range report.Findings {
+		counts[resourceKey{f.ResourceKind, f.ResourceName, f.FilePath, string(f.Severity)}]++
+	}
+	keys := make([]resourceKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].file != keys[j].file {
+			return keys[i].file < keys[j].file
+		}
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].severity < keys[j].severity
+	})
+	var b strings.Builder
+	b.WriteString("# HELP argocd_lint_resource_findings Findings reported by the last lint run, by resource.\n")
+	b.WriteString("# TYPE argocd_lint_resource_findings gauge\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "argocd_lint_resource_findings{kind=%q,name=%q,source=%q,severity=%q} %d\n", key.kind, key.name, key.file, key.severity, counts[key])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePrometheusText(metrics Metrics, now time.Time, w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# HELP argocd_lint_findings_total Total findings reported by the last lint run.\n")
+	b.WriteString("# TYPE argocd_lint_findings_total gauge\n")
+	fmt.Fprintf(&b, "argocd_lint_findings_total %d\n", metrics.TotalFindings)
+
+	b.WriteString("# HELP argocd_lint_findings Findings reported by the last lint run, by severity.\n")
+	b.WriteString("# TYPE argocd_lint_findings gauge\n")
+	severities := make([]string, 0, len(metrics.BySeverity))
+	for sev := range metrics.BySeverity {
+		severities = append(severities, sev)
+	}
+	sort.Strings(severities)
+	for _, sev := range severities {
+		fmt.Fprintf(&b, "argocd_lint_findings{severity=%q} %d\n", sev, metrics.BySeverity[sev])
+	}
+
+	b.WriteString("# HELP argocd_lint_rule_findings Findings reported by the last lint run, by rule.\n")
+	b.WriteString("# TYPE argocd_lint_rule_findings gauge\n")
+	for _, rule := range metrics.ByRule {
+		fmt.Fprintf(&b, "argocd_lint_rule_findings{rule=%q,severity=%q} %d\n", rule.RuleID, rule.Severity, rule.Count)
+	}
+
+	b.WriteString("# HELP argocd_lint_duration_seconds Wall-clock duration of the last lint run.\n")
+	b.WriteString("# TYPE argocd_lint_duration_seconds gauge\n")
+	fmt.Fprintf(&b, "argocd_lint_duration_seconds %.3f\n", float64(metrics.DurationMillis)/1000)
+
+	b.WriteString("# HELP argocd_lint_last_run_timestamp_seconds Unix time of the last lint run.\n")
+	b.WriteString("# TYPE argocd_lint_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&b, "argocd_lint_last_run_timestamp_seconds %d\n", now.Unix())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// encodeGroupingLabel renders a label pair for the Pushgateway URL path. Values
+// containing a slash use the base64 form understood by the Pushgateway.
+func encodeGroupingLabel(key, value string) string {
+	if strings.Contains(value, "/") {
+		return key + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return key + "/" + url.PathEscape(value)
+}
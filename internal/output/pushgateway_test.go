@@ -0,0 +1,63 @@
+package output
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushMetrics(t *testing.T) {
+	var gotPath, gotMethod, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.EscapedPath()
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	opts := PushOptions{
+		URL: server.URL,
+		Labels: map[string]string{
+			"repo":    "https://github.com/org/repo.git",
+			"branch":  "main",
+			"profile": "",
+		},
+	}
+	if err := PushMetrics(context.Background(), sampleReport(), 1500*time.Millisecond, opts); err != nil {
+		t.Fatalf("push metrics: %v", err)
+	}
+	if gotMethod != http.MethodPut {
+		t.Fatalf("expected PUT, got %s", gotMethod)
+	}
+	if !strings.HasPrefix(gotPath, "/metrics/job/argocd-lint/branch/main/repo@base64/") {
+		t.Fatalf("unexpected grouping path %s", gotPath)
+	}
+	if strings.Contains(gotPath, "profile") {
+		t.Fatalf("expected empty profile label to be omitted, got %s", gotPath)
+	}
+	if !strings.Contains(gotBody, "argocd_lint_findings_total 1") {
+		t.Fatalf("expected total findings sample, got %s", gotBody)
+	}
+	if !strings.Contains(gotBody, `argocd_lint_findings{severity="warn"} 1`) {
+		t.Fatalf("expected severity sample, got %s", gotBody)
+	}
+	if !strings.Contains(gotBody, "argocd_lint_duration_seconds 1.500") {
+		t.Fatalf("expected duration sample, got %s", gotBody)
+	}
+}
+
+func TestPushMetricsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad push", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	if err := PushMetrics(context.Background(), sampleReport(), time.Second, PushOptions{URL: server.URL}); err == nil {
+		t.Fatalf("expected error for non-2xx response")
+	}
+}
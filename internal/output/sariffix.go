@@ -0,0 +1,306 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// fixSource is a manifest file parsed for placing SARIF fixes.
+type fixSource struct {
+	lines []string
+	docs  []*yaml.Node
+}
+
+// fixSources reads and parses manifest files once per report. Files that
+// cannot be read or parsed map to nil.
+type fixSources map[string]*fixSource
+
+func (s fixSources) get(path string) *fixSource {
+	if src, ok := s[path]; ok {
+		return src
+	}
+	s[path] = nil
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	src := &fixSource{lines: strings.Split(string(data), "\n")}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		if len(doc.Content) > 0 {
+			src.docs = append(src.docs, doc.Content[0])
+		}
+	}
+	s[path] = src
+	return src
+}
+
+// document returns the mapping at the root of the document holding line, or
+// the only document when line is unknown.
+func (s *fixSource) document(line int) *yaml.Node {
+	var doc *yaml.Node
+	switch {
+	case line <= 0 && len(s.docs) == 1:
+		doc = s.docs[0]
+	case line > 0:
+		for _, candidate := range s.docs {
+			if candidate.Line <= line {
+				doc = candidate
+			}
+		}
+	}
+	if doc == nil || doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc
+}
+
+// patchPlaceholder matches the <value> markers of patches that need editing
+// before they apply.
+var patchPlaceholder = regexp.MustCompile(`<[A-Za-z][A-Za-z0-9-]*>`)
+
+// pathSegment matches one key or [index] of a suggestion path.
+var pathSegment = regexp.MustCompile(`^([^.\[\]]+)?((?:\[[0-9]+\])*)$`)
+
+// splitSuggestionPath splits "$.spec.sources[0].path" into keys and
+// indexes. Paths that append to a list ("[]") cannot be resolved.
+func splitSuggestionPath(path string) ([]string, bool) {
+	if path != "$" && !strings.HasPrefix(path, "$.") {
+		return nil, false
+	}
+	var segments []string
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "$"), "."), ".") {
+		if part == "" {
+			continue
+		}
+		match := pathSegment.FindStringSubmatch(part)
+		if match == nil {
+			return nil, false
+		}
+		if match[1] != "" {
+			segments = append(segments, match[1])
+		}
+		for _, index := range strings.Split(match[2], "]") {
+			if index != "" {
+				segments = append(segments, index+"]")
+			}
+		}
+	}
+	return segments, true
+}
+
+// lookupNode follows segments from node, returning nil when one is missing.
+func lookupNode(node *yaml.Node, segments []string) *yaml.Node {
+	for _, segment := range segments {
+		if node == nil {
+			return nil
+		}
+		if strings.HasPrefix(segment, "[") {
+			index, err := strconv.Atoi(strings.Trim(segment, "[]"))
+			if node.Kind != yaml.SequenceNode || err != nil || index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[index]
+			continue
+		}
+		_, node = mappingEntry(node, segment)
+	}
+	return node
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// sarifFixFromSuggestion converts a suggestion patch into a SARIF fix when
+// the patch can be placed exactly. The patch must be a mapping rooted at a
+// key of Suggestion.Path, whose parent is read from the finding's manifest.
+// Keys the manifest already has are followed down; a single-line scalar the
+// patch sets is replaced in place, and a missing key is inserted above the
+// first entry of its block mapping. Patches holding placeholders, and paths
+// that do not resolve to block mappings in the file, yield no fix; the
+// suggestion is still reported in the result properties.
+func sarifFixFromSuggestion(sources fixSources, finding types.Finding, suggestion types.Suggestion) (sarifFix, bool) {
+	patch := strings.TrimRight(suggestion.Patch, "\n")
+	if strings.TrimSpace(patch) == "" || finding.FilePath == "" || patchPlaceholder.MatchString(patch) {
+		return sarifFix{}, false
+	}
+	segments, ok := splitSuggestionPath(strings.TrimSpace(suggestion.Path))
+	if !ok || len(segments) == 0 {
+		return sarifFix{}, false
+	}
+	var patchDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(patch), &patchDoc); err != nil || len(patchDoc.Content) == 0 {
+		return sarifFix{}, false
+	}
+	patchNode := patchDoc.Content[0]
+	if patchNode.Kind != yaml.MappingNode || len(patchNode.Content) != 2 {
+		return sarifFix{}, false
+	}
+	src := sources.get(finding.FilePath)
+	if src == nil {
+		return sarifFix{}, false
+	}
+	doc := src.document(finding.Line)
+	root := -1
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == patchNode.Content[0].Value {
+			root = i
+			break
+		}
+	}
+	if doc == nil || root < 0 {
+		return sarifFix{}, false
+	}
+	parent := lookupNode(doc, segments[:root])
+	lines := strings.Split(patch, "\n")
+	for {
+		if parent == nil || parent.Kind != yaml.MappingNode || parent.Style&yaml.FlowStyle != 0 {
+			return sarifFix{}, false
+		}
+		patchKey, patchValue := patchNode.Content[0], patchNode.Content[1]
+		key, value := mappingEntry(parent, patchKey.Value)
+		if key == nil {
+			return insertFix(src, suggestion, finding.FilePath, parent, lines)
+		}
+		if value.Kind == yaml.ScalarNode && patchValue.Kind == yaml.ScalarNode && len(lines) == 1 {
+			return replaceFix(src, suggestion, finding.FilePath, key, value, lines[0])
+		}
+		if value.Kind != yaml.MappingNode || patchValue.Kind != yaml.MappingNode || len(patchValue.Content) != 2 || len(lines) < 2 {
+			return sarifFix{}, false
+		}
+		// Follow the key the manifest already has one level down.
+		indent := len(lines[1]) - len(strings.TrimLeft(lines[1], " "))
+		for i, line := range lines[1:] {
+			if len(line)-len(strings.TrimLeft(line, " ")) < indent {
+				return sarifFix{}, false
+			}
+			lines[i] = line[indent:]
+		}
+		lines = lines[:len(lines)-1]
+		parent, patchNode = value, patchValue
+	}
+}
+
+// insertFix inserts lines as a new entry above the first entry of parent.
+func insertFix(src *fixSource, suggestion types.Suggestion, file string, parent *yaml.Node, lines []string) (sarifFix, bool) {
+	if len(parent.Content) == 0 {
+		return sarifFix{}, false
+	}
+	first := parent.Content[0]
+	if first.Line < 1 || first.Line > len(src.lines) {
+		return sarifFix{}, false
+	}
+	prefix := []rune(src.lines[first.Line-1])
+	if first.Column-1 > len(prefix) || strings.TrimSpace(string(prefix[:first.Column-1])) != "" {
+		// The first entry shares its line with a "- " list marker.
+		return sarifFix{}, false
+	}
+	indent := strings.Repeat(" ", first.Column-1)
+	var text strings.Builder
+	for _, line := range lines {
+		text.WriteString(indent + line + "\n")
+	}
+	region := sarifRegion{StartLine: first.Line, StartColumn: 1, EndLine: first.Line, EndColumn: 1}
+	return newSARIFFix(suggestion, file, region, text.String()), true
+}
+
+// replaceFix replaces a "key: value" entry whose scalar value sits on the
+// key's line with line.
+func replaceFix(src *fixSource, suggestion types.Suggestion, file string, key, value *yaml.Node, line string) (sarifFix, bool) {
+	if value.Line != key.Line || key.Line < 1 || key.Line > len(src.lines) || value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return sarifFix{}, false
+	}
+	text := []rune(src.lines[key.Line-1])
+	start := value.Column - 1
+	if start > len(text) {
+		return sarifFix{}, false
+	}
+	end := scalarEnd(text, start, value)
+	if end < 0 {
+		return sarifFix{}, false
+	}
+	region := sarifRegion{StartLine: key.Line, StartColumn: key.Column, EndLine: key.Line, EndColumn: end + 1}
+	return newSARIFFix(suggestion, file, region, strings.TrimSpace(line)), true
+}
+
+// scalarEnd returns the index just past the scalar starting at start, or -1
+// when the scalar does not end on this line.
+func scalarEnd(text []rune, start int, value *yaml.Node) int {
+	switch {
+	case value.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case value.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+		return -1
+	}
+	rest := string(text[start:])
+	if comment := strings.Index(rest, " #"); comment >= 0 {
+		rest = rest[:comment]
+	}
+	rest = strings.TrimRight(rest, " \t\r")
+	if rest != value.Value {
+		return -1
+	}
+	return start + len([]rune(rest))
+}
+
+func newSARIFFix(suggestion types.Suggestion, file string, region sarifRegion, text string) sarifFix {
+	var fix sarifFix
+	fix.Description.Text = suggestion.Title
+	if suggestion.Description != "" {
+		fix.Description.Text = suggestion.Title + ": " + suggestion.Description
+	}
+	fix.ArtifactChanges = make([]struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Replacements     []struct {
+			DeletedRegion   sarifRegion `json:"deletedRegion"`
+			InsertedContent sarifText   `json:"insertedContent"`
+		} `json:"replacements"`
+	}, 1)
+	change := &fix.ArtifactChanges[0]
+	change.ArtifactLocation.URI = file
+	change.Replacements = make([]struct {
+		DeletedRegion   sarifRegion `json:"deletedRegion"`
+		InsertedContent sarifText   `json:"insertedContent"`
+	}, 1)
+	change.Replacements[0].DeletedRegion = region
+	change.Replacements[0].InsertedContent.Text = text
+	return fix
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/lint"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+var teamcityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+)
+
+// writeTeamCity emits inspection service messages so TeamCity lists findings
+// on the build's Inspections tab.
+func writeTeamCity(report lint.Report, w io.Writer) error {
+	seen := map[string]struct{}{}
+	var ruleIDs []string
+	for _, f := range report.Findings {
+		if _, ok := seen[f.RuleID]; ok {
+			continue
+		}
+		seen[f.RuleID] = struct{}{}
+		ruleIDs = append(ruleIDs, f.RuleID)
+	}
+	sort.Strings(ruleIDs)
+	for _, id := range ruleIDs {
+		meta := report.RuleIndex[id]
+		description := meta.Description
+		if description == "" {
+			description = id
+		}
+		category := meta.Category
+		if category == "" {
+			category = "argocd-lint"
+		}
+		if _, err := fmt.Fprintf(w, "##teamcity[inspectionType id='%s' name='%s' description='%s' category='%s']\n",
+			teamcityEscape(id), teamcityEscape(id), teamcityEscape(description), teamcityEscape(category)); err != nil {
+			return err
+		}
+	}
+	for _, f := range report.Findings {
+		message := f.Message
+		if f.ResourceName != "" {
+			message = fmt.Sprintf("%s (%s/%s)", message, f.ResourceKind, f.ResourceName)
+		}
+		line := f.Line
+		if line <= 0 {
+			line = 1
+		}
+		if _, err := fmt.Fprintf(w, "##teamcity[inspection typeId='%s' message='%s' file='%s' line='%d' SEVERITY='%s']\n",
+			teamcityEscape(f.RuleID), teamcityEscape(message), teamcityEscape(f.FilePath), line, teamcitySeverity(f.Severity)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "##teamcity[buildStatisticValue key='argocdLintFindings' value='%d']\n", len(report.Findings))
+	return err
+}
+
+func teamcityEscape(value string) string {
+	return teamcityEscaper.Replace(value)
+}
+
+func teamcitySeverity(sev types.Severity) string {
+	switch sev {
+	case types.SeverityError:
+		return "ERROR"
+	case types.SeverityWarn:
+		return "WARNING"
+	default:
+		return "INFO"
+	}
+}
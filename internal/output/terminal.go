@@ -0,0 +1,34 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TerminalOptions inspects w and returns options suited to it: colors and
+// width truncation are only enabled when w is an interactive terminal.
+// Colors are also disabled by noColor or the NO_COLOR environment variable.
+func TerminalOptions(w io.Writer, noColor bool) WriteOptions {
+	file, ok := w.(*os.File)
+	if !ok || !isTerminal(file) {
+		return WriteOptions{}
+	}
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+	opts := WriteOptions{Color: !noColor && !noColorEnv}
+	if columns, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil && columns > 0 {
+		opts.Width = columns
+	} else {
+		opts.Width = terminalWidth(file)
+	}
+	return opts
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package output
+
+import "os"
+
+// terminalWidth is unknown on this platform; set COLUMNS to enable truncation.
+func terminalWidth(*os.File) int {
+	return 0
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package output
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth asks the kernel for the window size of f, returning 0 when
+// it is unknown.
+func terminalWidth(f *os.File) int {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,153 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+var directoryRuleMeta = types.RuleMetadata{
+	ID:              "RENDER_DIRECTORY",
+	Description:     "Manifests deployed by directory sources must parse",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo: []types.ResourceKind{
+		types.ResourceKindApplication,
+		types.ResourceKindApplicationSet,
+	},
+	Category: "render",
+	Enabled:  true,
+}
+
+// shouldRenderDirectory reports whether a source is a directory source that
+// deploys files after directory.recurse, include, and exclude apply. Helm
+// charts and kustomizations take precedence, as in Argo CD.
+func (r *Renderer) shouldRenderDirectory(src map[string]interface{}, path string) bool {
+	if loader.IsToolDirectory(path) {
+		return false
+	}
+	files, err := loader.DirectorySourceFiles(path, loader.DirectorySourceOptions(src))
+	return err == nil && len(files) > 0
+}
+
+// renderDirectory collects the files one directory source deploys: plain
+// YAML and JSON manifests as they are, and .jsonnet files evaluated. It
+// returns the combined output and a RENDER_DIRECTORY or RENDER_JSONNET
+// finding for each file that fails to parse or evaluate.
+func (r *Renderer) renderDirectory(ctx context.Context, path string, src map[string]interface{}, m *manifest.Manifest) ([]types.Finding, []byte, error) {
+	plainCfg, err := r.cfg.Resolve(directoryRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	jsonnetCfg, err := r.cfg.Resolve(jsonnetRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !plainCfg.Enabled && !jsonnetCfg.Enabled {
+		return nil, nil, nil
+	}
+	directory := getMap(src, "directory")
+	cacheKey := ""
+	if r.cacheEnabled {
+		var options map[string]interface{}
+		if len(directory) > 0 {
+			options = directory
+		}
+		cacheKey = renderCacheKey("directory", path, options)
+		if entry, ok := r.lookupCache(cacheKey); ok {
+			return cloneFindings(entry.findings), entry.output, entry.err
+		}
+	}
+	files, err := loader.DirectorySourceFiles(path, loader.DirectorySourceOptions(src))
+	if err != nil {
+		return nil, nil, err
+	}
+	plain := types.FindingBuilder{Rule: plainCfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+	evaluated := types.FindingBuilder{Rule: jsonnetCfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+	sourceCtx, cancel := r.sourceContext(ctx)
+	defer cancel()
+	var findings []types.Finding
+	var output bytes.Buffer
+	for _, file := range files {
+		if strings.ToLower(filepath.Ext(file)) != ".jsonnet" {
+			if !plainCfg.Enabled {
+				continue
+			}
+			data, err := readManifestFile(file)
+			if err != nil {
+				loc, ok := yamlErrorLocation(file, err)
+				findings = append(findings, withLocation(plain.NewFinding(fmt.Sprintf("manifest %s does not parse: %v", file, err), plainCfg.Severity), loc, ok))
+				continue
+			}
+			output.WriteString("---\n")
+			output.Write(data)
+			output.WriteString("\n")
+			continue
+		}
+		if !jsonnetCfg.Enabled {
+			continue
+		}
+		rendered, err := runInProcess(sourceCtx, func() ([]byte, error) {
+			return r.evaluateJsonnet(file, getMap(directory, "jsonnet"))
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, fmt.Errorf("jsonnet in %s: %w", path, ctxErr)
+		}
+		if err := r.checkRender(ctx, sourceCtx, nil, err); err != nil {
+			loc, ok := jsonnetErrorLocation(err)
+			findings = append(findings, withLocation(evaluated.NewFinding(fmt.Sprintf("jsonnet evaluation failed in %s: %v", file, err), jsonnetCfg.Severity), loc, ok))
+			if sourceCtx.Err() != nil {
+				break
+			}
+			continue
+		}
+		output.Write(rendered)
+	}
+	if sourceCtx.Err() == nil {
+		if err := r.checkRender(ctx, sourceCtx, output.Bytes(), nil); err != nil {
+			builder, severity := plain, plainCfg.Severity
+			if !plainCfg.Enabled {
+				builder, severity = evaluated, jsonnetCfg.Severity
+			}
+			findings = append(findings, builder.NewFinding(fmt.Sprintf("directory source %s failed to render: %v", path, err), severity))
+			output.Reset()
+		}
+	}
+	var result []byte
+	if output.Len() > 0 {
+		result = output.Bytes()
+	}
+	if r.cacheEnabled {
+		r.storeCache(cacheKey, findings, result, nil)
+	}
+	return findings, result, nil
+}
+
+// readManifestFile reads a YAML or JSON manifest and checks that every
+// document in it parses, as Argo CD does before applying a directory.
+func readManifestFile(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return bytes.TrimRight(data, "\n"), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
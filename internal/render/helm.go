@@ -0,0 +1,255 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+// defaultReleaseName names the release when the source sets no
+// helm.releaseName.
+const defaultReleaseName = "argocd-lint-render"
+
+// templateChart renders the chart at path in-process the way Argo CD runs
+// helm template, including hooks and, unless helm.skipCrds is set, CRDs.
+// Charts see the configured Kubernetes and API versions as capabilities.
+// Template errors keep the file and line reported by the Helm engine.
+func (r *Renderer) templateChart(ctx context.Context, path string, helmCfg map[string]interface{}) ([]byte, error) {
+	started := time.Now()
+	output, err := r.runTemplate(ctx, path, helmCfg)
+	r.logger.Debug("helm template", "chart", path, "duration", time.Since(started), "error", err)
+	return output, err
+}
+
+func (r *Renderer) runTemplate(ctx context.Context, path string, helmCfg map[string]interface{}) ([]byte, error) {
+	if version := strings.TrimSpace(getString(helmCfg, "version")); version != "" && version != "v3" {
+		return nil, fmt.Errorf("helm.version %s is not supported; charts render with Helm v3", version)
+	}
+	chrt, err := r.loadChart(path)
+	if err != nil {
+		return nil, err
+	}
+	if deps := chrt.Metadata.Dependencies; len(deps) > 0 {
+		if err := action.CheckDependencies(chrt, deps); err != nil {
+			return nil, err
+		}
+	}
+	vals, err := chartValues(r.sourceRoot(path), path, helmCfg)
+	if err != nil {
+		return nil, err
+	}
+	skipCRDs, _ := helmCfg["skipCrds"].(bool)
+
+	client := action.NewInstall(&action.Configuration{Log: func(format string, v ...interface{}) {
+		r.logger.Debug(fmt.Sprintf(format, v...), "chart", path)
+	}})
+	client.DryRun = true
+	client.DryRunOption = "true"
+	client.ClientOnly = true
+	client.Replace = true
+	client.IncludeCRDs = !skipCRDs
+	client.SkipCRDs = skipCRDs
+	client.Namespace = "default"
+	client.ReleaseName = defaultReleaseName
+	if name := strings.TrimSpace(getString(helmCfg, "releaseName")); name != "" {
+		client.ReleaseName = name
+	}
+	client.KubeVersion = r.kubeVersion
+	if v := strings.TrimSpace(getString(helmCfg, "kubeVersion")); v != "" {
+		if client.KubeVersion, err = chartutil.ParseKubeVersion(v); err != nil {
+			return nil, fmt.Errorf("helm.kubeVersion: %w", err)
+		}
+	}
+	client.APIVersions = r.apiVersions
+	if items := getSlice(helmCfg, "apiVersions"); len(items) > 0 {
+		client.APIVersions = nil
+		for _, item := range items {
+			if version, ok := item.(string); ok && strings.TrimSpace(version) != "" {
+				client.APIVersions = append(client.APIVersions, strings.TrimSpace(version))
+			}
+		}
+	}
+	rel, err := client.RunWithContext(ctx, chrt, vals)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	fmt.Fprintln(&out, strings.TrimSpace(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&out, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return out.Bytes(), nil
+}
+
+// loadChart returns a fresh copy of the chart at path. The chart's files are
+// read from disk once per run and parsed again for each render, because
+// installing a chart prunes disabled dependencies and imports values in
+// place.
+func (r *Renderer) loadChart(path string) (*chart.Chart, error) {
+	r.chartsMu.Lock()
+	files, ok := r.charts[path]
+	r.chartsMu.Unlock()
+	if ok {
+		return loader.LoadFiles(files)
+	}
+	loaded, err := loader.LoadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files = make([]*loader.BufferedFile, 0, len(loaded.Raw))
+	for _, f := range loaded.Raw {
+		files = append(files, &loader.BufferedFile{Name: f.Name, Data: f.Data})
+	}
+	r.chartsMu.Lock()
+	r.charts[path] = files
+	r.chartsMu.Unlock()
+	return loaded, nil
+}
+
+// chartValues merges the values of a helm source in the order Argo CD passes
+// them to helm template: valueFiles, then valuesObject (or the inline values
+// string), then parameters, forceString parameters, and fileParameters.
+// Relative paths resolve against the chart directory and absolute ones
+// against root; files outside root are errors.
+func chartValues(root, path string, helmCfg map[string]interface{}) (map[string]interface{}, error) {
+	ignoreMissing, _ := helmCfg["ignoreMissingValueFiles"].(bool)
+	var opts values.Options
+	for _, item := range getSlice(helmCfg, "valueFiles") {
+		var file string
+		switch v := item.(type) {
+		case refPath:
+			file = string(v)
+		case string:
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			resolved, err := sourceFile(root, path, v)
+			if err != nil {
+				return nil, fmt.Errorf("value file %s: %w", v, err)
+			}
+			file = resolved
+		default:
+			continue
+		}
+		if ignoreMissing && !exists(file) {
+			continue
+		}
+		opts.ValueFiles = append(opts.ValueFiles, file)
+	}
+	for _, item := range getSlice(helmCfg, "parameters") {
+		param, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := strings.TrimSpace(getString(param, "name"))
+		if name == "" {
+			continue
+		}
+		value := fmt.Sprintf("%s=%s", name, getString(param, "value"))
+		if force, _ := param["forceString"].(bool); force {
+			opts.StringValues = append(opts.StringValues, value)
+		} else {
+			opts.Values = append(opts.Values, value)
+		}
+	}
+	for _, item := range getSlice(helmCfg, "fileParameters") {
+		param, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, file := strings.TrimSpace(getString(param, "name")), getString(param, "path")
+		if name == "" || file == "" {
+			continue
+		}
+		resolved, err := sourceFile(root, path, file)
+		if err != nil {
+			return nil, fmt.Errorf("file parameter %s: %w", name, err)
+		}
+		opts.FileValues = append(opts.FileValues, fmt.Sprintf("%s=%s", name, resolved))
+	}
+
+	files := values.Options{ValueFiles: opts.ValueFiles}
+	vals, err := files.MergeValues(getter.Providers{})
+	if err != nil {
+		return nil, err
+	}
+	inline, _ := copyValue(getMap(helmCfg, "valuesObject")).(map[string]interface{})
+	if len(inline) == 0 {
+		if raw := getString(helmCfg, "values"); strings.TrimSpace(raw) != "" {
+			if err := yaml.Unmarshal([]byte(raw), &inline); err != nil {
+				return nil, fmt.Errorf("parse helm.values: %w", err)
+			}
+		}
+	}
+	vals = mergeValues(vals, inline)
+	for _, value := range opts.Values {
+		if err := strvals.ParseInto(value, vals); err != nil {
+			return nil, fmt.Errorf("helm parameter %s: %w", value, err)
+		}
+	}
+	for _, value := range opts.StringValues {
+		if err := strvals.ParseIntoString(value, vals); err != nil {
+			return nil, fmt.Errorf("helm parameter %s: %w", value, err)
+		}
+	}
+	readFile := func(rs []rune) (interface{}, error) {
+		data, err := os.ReadFile(string(rs))
+		return string(data), err
+	}
+	for _, value := range opts.FileValues {
+		if err := strvals.ParseIntoFile(value, vals, readFile); err != nil {
+			return nil, fmt.Errorf("helm file parameter %s: %w", value, err)
+		}
+	}
+	return vals, nil
+}
+
+// mergeValues deep-merges b over a the way helm merges values files.
+func mergeValues(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if next, ok := v.(map[string]interface{}); ok {
+			if prev, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeValues(prev, next)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// copyValue deep-copies manifest values so that parameters applied on top
+// of valuesObject do not modify the Application being linted.
+func copyValue(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, item := range typed {
+			out[k] = copyValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = copyValue(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"github.com/google/go-jsonnet"
+)
+
+var jsonnetRuleMeta = types.RuleMetadata{
+	ID:              "RENDER_JSONNET",
+	Description:     "Jsonnet evaluation must succeed for directory sources",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo: []types.ResourceKind{
+		types.ResourceKindApplication,
+		types.ResourceKindApplicationSet,
+	},
+	Category: "render",
+	Enabled:  true,
+}
+
+// evaluateJsonnet evaluates file with the extVars, tlas, and libs of a
+// directory.jsonnet block and returns its resources as a YAML stream. Library
+// paths are relative to the repository root; relative imports resolve
+// against the importing file.
+func (r *Renderer) evaluateJsonnet(file string, options map[string]interface{}) ([]byte, error) {
+	started := time.Now()
+	vm := jsonnet.MakeVM()
+	for _, item := range getSlice(options, "extVars") {
+		name, value, code, ok := jsonnetVar(item)
+		switch {
+		case !ok:
+		case code:
+			vm.ExtCode(name, value)
+		default:
+			vm.ExtVar(name, value)
+		}
+	}
+	for _, item := range getSlice(options, "tlas") {
+		name, value, code, ok := jsonnetVar(item)
+		switch {
+		case !ok:
+		case code:
+			vm.TLACode(name, value)
+		default:
+			vm.TLAVar(name, value)
+		}
+	}
+	var libs []string
+	for _, item := range getSlice(options, "libs") {
+		if lib, ok := item.(string); ok && strings.TrimSpace(lib) != "" {
+			if !filepath.IsAbs(lib) {
+				lib = filepath.Join(r.repoRoot, lib)
+			}
+			libs = append(libs, lib)
+		}
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: libs})
+
+	evaluated, err := vm.EvaluateFile(file)
+	r.logger.Debug("jsonnet evaluate", "file", file, "duration", time.Since(started), "error", err)
+	if err != nil {
+		return nil, err
+	}
+	return jsonnetResources(evaluated)
+}
+
+// jsonnetVar reads a name/value/code entry of extVars or tlas.
+func jsonnetVar(item interface{}) (string, string, bool, bool) {
+	entry, ok := item.(map[string]interface{})
+	if !ok {
+		return "", "", false, false
+	}
+	name := strings.TrimSpace(getString(entry, "name"))
+	code, _ := entry["code"].(bool)
+	return name, getString(entry, "value"), code, name != ""
+}
+
+// jsonnetResources converts evaluated jsonnet, a single object or an array of
+// objects as Argo CD accepts, into a YAML stream of JSON documents.
+func jsonnetResources(evaluated string) ([]byte, error) {
+	var objects []json.RawMessage
+	trimmed := strings.TrimSpace(evaluated)
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &objects); err != nil {
+			return nil, fmt.Errorf("output is not an array of objects: %w", err)
+		}
+	} else {
+		objects = []json.RawMessage{json.RawMessage(trimmed)}
+	}
+	var out bytes.Buffer
+	for _, raw := range objects {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("output is not a Kubernetes object: %w", err)
+		}
+		compact, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "---\n%s\n", compact)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/loader"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// kustomizationFiles are the file names kustomize accepts for a kustomization.
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// parseLoadRestrictor maps a --load-restrictor value to kustomize load
+// restrictions. An empty value selects LoadRestrictionsRootOnly.
+func parseLoadRestrictor(value string) (kusttypes.LoadRestrictions, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "loadrestrictionsrootonly", "rootonly":
+		return kusttypes.LoadRestrictionsRootOnly, nil
+	case "loadrestrictionsnone", "none":
+		return kusttypes.LoadRestrictionsNone, nil
+	}
+	return kusttypes.LoadRestrictionsUnknown, fmt.Errorf("unsupported kustomize load restrictor %q (use LoadRestrictionsRootOnly or LoadRestrictionsNone)", value)
+}
+
+// buildKustomization builds the overlay at path, with the Application's
+// kustomize options applied, using the embedded kustomize API or the
+// kustomize binary when one is configured.
+func (r *Renderer) buildKustomization(ctx context.Context, path string, options map[string]interface{}) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	target, cleanup, err := writeOverlay(r.sourceRoot(path), path, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if r.kustomizeDenyRemote {
+		if err := checkRemoteBases(target, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	if r.kustomizeBinary != "" {
+		args := []string{"build", target}
+		if r.loadRestrictions == kusttypes.LoadRestrictionsNone {
+			args = append(args, "--load-restrictor", kusttypes.LoadRestrictionsNone.String())
+		}
+		cmd := exec.CommandContext(ctx, r.kustomizeBinary, args...)
+		cmd.Dir = path
+		stdout, output, err := r.runCommand(cmd)
+		if err != nil {
+			if trimmed := trimOutput(output); trimmed != "" && !errors.As(err, new(errOutputLimit)) {
+				err = fmt.Errorf("%w: %s", err, trimmed)
+			}
+			return nil, err
+		}
+		return stdout, nil
+	}
+
+	started := time.Now()
+	opts := krusty.MakeDefaultOptions()
+	opts.Reorder = krusty.ReorderOptionUnspecified
+	opts.LoadRestrictions = r.loadRestrictions
+	output, err := runInProcess(ctx, func() ([]byte, error) {
+		resources, err := krusty.MakeKustomizer(opts).Run(filesys.MakeFsOnDisk(), target)
+		if err != nil {
+			return nil, err
+		}
+		return resources.AsYaml()
+	})
+	r.logger.Debug("kustomize build", "dir", path, "duration", time.Since(started), "error", err)
+	return output, err
+}
+
+// writeOverlay applies the kustomize options of an Application source the
+// way Argo CD does before building: it writes a temporary overlay that uses
+// path as its base and sets namePrefix, nameSuffix, images, commonLabels,
+// patches, and replicas. It returns path itself when no option is set.
+// Patch files are inlined, since the overlay's root-only loader may not read
+// files below the symlinked base, and must lie inside root.
+func writeOverlay(root, path string, options map[string]interface{}) (string, func(), error) {
+	overlay := map[string]interface{}{}
+	for _, key := range []string{"namePrefix", "nameSuffix"} {
+		if value := getString(options, key); value != "" {
+			overlay[key] = value
+		}
+	}
+	if labels := getMap(options, "commonLabels"); len(labels) > 0 {
+		overlay["commonLabels"] = labels
+	}
+	var images []map[string]string
+	for _, item := range getSlice(options, "images") {
+		if image, ok := item.(string); ok && strings.TrimSpace(image) != "" {
+			images = append(images, parseImageOverride(strings.TrimSpace(image)))
+		}
+	}
+	if len(images) > 0 {
+		overlay["images"] = images
+	}
+	var replicas []map[string]interface{}
+	for _, item := range getSlice(options, "replicas") {
+		replica, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(replica["count"])))
+		if err != nil {
+			return "", nil, fmt.Errorf("kustomize.replicas: invalid count %v for %s", replica["count"], getString(replica, "name"))
+		}
+		replicas = append(replicas, map[string]interface{}{"name": getString(replica, "name"), "count": count})
+	}
+	if len(replicas) > 0 {
+		overlay["replicas"] = replicas
+	}
+	var patches []map[string]interface{}
+	for _, item := range getSlice(options, "patches") {
+		patch, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inlined := make(map[string]interface{}, len(patch))
+		for key, value := range patch {
+			inlined[key] = value
+		}
+		if file := getString(patch, "path"); file != "" {
+			resolved, err := sourceFile(root, path, file)
+			if err != nil {
+				return "", nil, fmt.Errorf("kustomize.patches: %w", err)
+			}
+			content, err := os.ReadFile(resolved)
+			if err != nil {
+				return "", nil, fmt.Errorf("kustomize.patches: %w", err)
+			}
+			delete(inlined, "path")
+			inlined["patch"] = string(content)
+		}
+		patches = append(patches, inlined)
+	}
+	if len(patches) > 0 {
+		overlay["patches"] = patches
+	}
+	if len(overlay) == 0 {
+		return path, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "argocd-lint-overlay-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	overlay["resources"] = []string{"base"}
+	data, err := yaml.Marshal(overlay)
+	if err == nil {
+		err = os.Symlink(path, filepath.Join(dir, "base"))
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "kustomization.yaml"), data, 0o600)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write kustomize overlay: %w", err)
+	}
+	return dir, cleanup, nil
+}
+
+// parseImageOverride converts an Argo CD kustomize.images entry, as accepted
+// by kustomize edit set image ([name=]newName[:tag][@digest]), into a
+// kustomization images entry.
+func parseImageOverride(value string) map[string]string {
+	name, image := "", value
+	if idx := strings.Index(value, "="); idx >= 0 {
+		name, image = value[:idx], value[idx+1:]
+	}
+	entry := map[string]string{}
+	newName := image
+	if idx := strings.Index(image, "@"); idx >= 0 {
+		newName, entry["digest"] = image[:idx], image[idx+1:]
+	} else if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		newName, entry["newTag"] = image[:idx], image[idx+1:]
+	}
+	if name == "" {
+		name = newName
+	}
+	entry["name"] = name
+	if newName != name {
+		entry["newName"] = newName
+	}
+	return entry
+}
+
+// checkRemoteBases walks the kustomizations reachable from dir and rejects
+// resources, bases, and components that kustomize would fetch over the
+// network. seen guards against cycles.
+func checkRemoteBases(dir string, seen map[string]bool) error {
+	if seen[dir] {
+		return nil
+	}
+	seen[dir] = true
+	var data []byte
+	for _, name := range kustomizationFiles {
+		if content, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			data = content
+			break
+		}
+	}
+	var kustomization struct {
+		Resources  []string `yaml:"resources"`
+		Bases      []string `yaml:"bases"`
+		Components []string `yaml:"components"`
+	}
+	if data == nil || yaml.Unmarshal(data, &kustomization) != nil {
+		// Kustomize reports missing and malformed kustomizations itself.
+		return nil
+	}
+	entries := append(append(kustomization.Resources, kustomization.Bases...), kustomization.Components...)
+	for _, entry := range entries {
+		local := filepath.Join(dir, entry)
+		info, err := os.Stat(local)
+		switch {
+		case err == nil && info.IsDir():
+			if err := checkRemoteBases(local, seen); err != nil {
+				return err
+			}
+		case err == nil:
+		case isRemoteBase(entry):
+			return fmt.Errorf("remote base %s in %s is not allowed", entry, dir)
+		}
+	}
+	return nil
+}
+
+// isRemoteBase reports whether a kustomization entry is a URL or git
+// repository reference rather than a local path.
+func isRemoteBase(entry string) bool {
+	return loader.IsRemoteFile(entry) ||
+		strings.Contains(entry, "://") ||
+		strings.HasPrefix(entry, "git@") ||
+		strings.HasPrefix(entry, "github.com/") ||
+		strings.Contains(entry, "?ref=")
+}
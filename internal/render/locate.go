@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+var (
+	// helmTemplatePattern matches chart files as the Helm engine names them,
+	// <chart>/templates/<file>:<line>[:<column>], including subcharts.
+	helmTemplatePattern = regexp.MustCompile(`([^\s:()'"]+/(?:charts/[^\s/:()'"]+/)*templates/[^\s:()'"]+):(\d+)(?::(\d+))?`)
+	helmYAMLPattern     = regexp.MustCompile(`YAML parse error on ([^\s:]+):`)
+
+	kustomizeFilePattern        = regexp.MustCompile(`yaml: line (\d+): .* in File: (\S+)`)
+	kustomizeInvalidPattern     = regexp.MustCompile(`invalid Kustomization: yaml: line (\d+)`)
+	kustomizeResourcePattern    = regexp.MustCompile(`accumulating resources from '([^']+)'`)
+	jsonnetErrorLocationPattern = regexp.MustCompile(`(\S+\.(?:jsonnet|libsonnet)):(\d+):(\d+)`)
+	yamlLinePattern             = regexp.MustCompile(`line (\d+)`)
+)
+
+// helmErrorLocation finds the chart file and line a Helm error names. The
+// engine prefixes files with the chart name, which is replaced by chartDir.
+func helmErrorLocation(chartDir string, err error) (types.Location, bool) {
+	msg := err.Error()
+	if m := helmTemplatePattern.FindStringSubmatch(msg); m != nil {
+		_, rel, _ := strings.Cut(m[1], "/")
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		return types.Location{FilePath: filepath.Join(chartDir, rel), Line: line, Column: column}, true
+	}
+	if m := helmYAMLPattern.FindStringSubmatch(msg); m != nil {
+		// The YAML line refers to the rendered output, not the template.
+		_, rel, _ := strings.Cut(m[1], "/")
+		return types.Location{FilePath: filepath.Join(chartDir, rel)}, true
+	}
+	return types.Location{}, false
+}
+
+// kustomizeErrorLocation finds the file and line a kustomize build error
+// names: a malformed resource, a malformed kustomization, or the
+// kustomization entry of a resource that failed to load.
+func kustomizeErrorLocation(dir string, err error) (types.Location, bool) {
+	msg := err.Error()
+	if m := kustomizeFilePattern.FindStringSubmatch(msg); m != nil {
+		file := m[2]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if exists(file) {
+			line, _ := strconv.Atoi(m[1])
+			return types.Location{FilePath: file, Line: line}, true
+		}
+	}
+	kustomization := ""
+	for _, name := range kustomizationFiles {
+		if file := filepath.Join(dir, name); exists(file) {
+			kustomization = file
+			break
+		}
+	}
+	if kustomization == "" {
+		return types.Location{}, false
+	}
+	if m := kustomizeInvalidPattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return types.Location{FilePath: kustomization, Line: line}, true
+	}
+	matches := kustomizeResourcePattern.FindAllStringSubmatch(msg, -1)
+	if len(matches) == 0 {
+		return types.Location{}, false
+	}
+	// Nested failures name each kustomization entry on the way down; point
+	// at the one this kustomization lists.
+	for _, m := range matches {
+		if line := lineContaining(kustomization, m[1]); line > 0 {
+			return types.Location{FilePath: kustomization, Line: line}, true
+		}
+	}
+	return types.Location{FilePath: kustomization}, true
+}
+
+// jsonnetErrorLocation finds the file, line, and column a go-jsonnet error
+// names first.
+func jsonnetErrorLocation(err error) (types.Location, bool) {
+	m := jsonnetErrorLocationPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return types.Location{}, false
+	}
+	line, _ := strconv.Atoi(m[2])
+	column, _ := strconv.Atoi(m[3])
+	return types.Location{FilePath: m[1], Line: line, Column: column}, true
+}
+
+// yamlErrorLocation points at the line of file a YAML parse error names, or
+// at the file alone.
+func yamlErrorLocation(file string, err error) (types.Location, bool) {
+	loc := types.Location{FilePath: file}
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		loc.Line, _ = strconv.Atoi(m[1])
+	}
+	return loc, true
+}
+
+// lineContaining returns the first line of file that contains text, or 0.
+func lineContaining(file, text string) int {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		if strings.Contains(scanner.Text(), text) {
+			return line
+		}
+	}
+	return 0
+}
+
+// withLocation attaches loc to finding, relative to the working directory
+// like manifest paths when it lies below it.
+func withLocation(finding types.Finding, loc types.Location, ok bool) types.Finding {
+	if !ok {
+		return finding
+	}
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(loc.FilePath) {
+		if rel, err := filepath.Rel(wd, loc.FilePath); err == nil && !strings.HasPrefix(rel, "..") {
+			loc.FilePath = rel
+		}
+	}
+	finding.RelatedLocations = []types.Location{loc}
+	return finding
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+var pluginRuleMeta = types.RuleMetadata{
+	ID:              "RENDER_PLUGIN",
+	Description:     "Emulated Config Management Plugin commands must succeed for plugin sources",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo: []types.ResourceKind{
+		types.ResourceKindApplication,
+		types.ResourceKindApplicationSet,
+	},
+	Category: "render",
+	Enabled:  true,
+}
+
+// anyPlugin names the command that emulates plugins without their own.
+const anyPlugin = "*"
+
+// pluginCommand returns the command configured to emulate the Config
+// Management Plugin of src, if src is a plugin source.
+func (r *Renderer) pluginCommand(src map[string]interface{}) (string, bool) {
+	if _, ok := src["plugin"]; !ok {
+		return "", false
+	}
+	if command, ok := r.cmpCommands[strings.TrimSpace(getString(src, "plugin", "name"))]; ok {
+		return command, true
+	}
+	command, ok := r.cmpCommands[anyPlugin]
+	return command, ok
+}
+
+// renderPlugin emulates the Config Management Plugin of one source by running
+// its configured command in the source directory with sh -c, the way a
+// plugin's generate command runs in the repo server. It returns the command's
+// output on success and a RENDER_PLUGIN finding on failure.
+func (r *Renderer) renderPlugin(ctx context.Context, path string, src map[string]interface{}, m *manifest.Manifest) ([]types.Finding, []byte, error) {
+	cfg, err := r.cfg.Resolve(pluginRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	command, ok := r.pluginCommand(src)
+	if !cfg.Enabled || !ok {
+		return nil, nil, nil
+	}
+	env := pluginEnv(src, m)
+	cacheKey := ""
+	if r.cacheEnabled {
+		cacheKey = renderCacheKey("plugin", path, map[string]interface{}{"command": command, "env": env})
+		if entry, ok := r.lookupCache(cacheKey); ok {
+			return cloneFindings(entry.findings), entry.output, entry.err
+		}
+	}
+	sourceCtx, cancel := r.sourceContext(ctx)
+	cmd := exec.CommandContext(sourceCtx, "sh", "-c", command)
+	cmd.Dir = path
+	cmd.Env = append(os.Environ(), env...)
+	output, combined, err := r.runCommand(cmd)
+	err = r.checkRender(ctx, sourceCtx, output, err)
+	cancel()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, nil, fmt.Errorf("plugin command in %s: %w", path, ctxErr)
+	}
+	if err == nil {
+		if r.cacheEnabled {
+			r.storeCache(cacheKey, nil, output, nil)
+		}
+		return nil, output, nil
+	}
+	msg := fmt.Sprintf("plugin command %q failed in %s: %v", command, path, err)
+	if trimmed := trimOutput(combined); trimmed != "" {
+		msg = fmt.Sprintf("%s: %s", msg, trimmed)
+	}
+	result := []types.Finding{childFinding(cfg, m, msg)}
+	if r.cacheEnabled {
+		r.storeCache(cacheKey, result, nil, nil)
+	}
+	return result, nil, nil
+}
+
+// pluginEnv returns the build environment Argo CD passes to plugin commands:
+// the ARGOCD_APP_* variables, plugin.env entries prefixed with ARGOCD_ENV_,
+// and plugin.parameters as ARGOCD_APP_PARAMETERS JSON.
+func pluginEnv(src map[string]interface{}, m *manifest.Manifest) []string {
+	specPath := []string{"spec"}
+	if m.Kind == string(types.ResourceKindApplicationSet) {
+		specPath = []string{"spec", "template", "spec"}
+	}
+	env := []string{
+		"ARGOCD_APP_NAME=" + m.Name,
+		"ARGOCD_APP_NAMESPACE=" + getString(m.Object, append(specPath, "destination", "namespace")...),
+		"ARGOCD_APP_SOURCE_PATH=" + getString(src, "path"),
+		"ARGOCD_APP_SOURCE_REPO_URL=" + getString(src, "repoURL"),
+		"ARGOCD_APP_SOURCE_TARGET_REVISION=" + getString(src, "targetRevision"),
+	}
+	// Sort so that the cache key does not depend on the order of entries.
+	var custom []string
+	for _, item := range getSlice(src, "plugin", "env") {
+		entry, ok := item.(map[string]interface{})
+		if name := strings.TrimSpace(getString(entry, "name")); ok && name != "" {
+			custom = append(custom, "ARGOCD_ENV_"+name+"="+getString(entry, "value"))
+		}
+	}
+	sort.Strings(custom)
+	env = append(env, custom...)
+	if params := getSlice(src, "plugin", "parameters"); len(params) > 0 {
+		if encoded, err := json.Marshal(params); err == nil {
+			env = append(env, "ARGOCD_APP_PARAMETERS="+string(encoded))
+		}
+	}
+	return env
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+// refPath is a value file already resolved to, and confined within, the
+// repository of a ref source.
+type refPath string
+
+// refClone records a shallow clone of a ref source's repository.
+type refClone struct {
+	dir string
+	err error
+}
+
+// resolveValueFiles returns the helm settings of src with $ref/... value
+// files of a multi-source Application rewritten to absolute paths in the
+// referenced source's repository: the local repository root when the ref
+// source points at the chart's repoURL, or a shallow clone of its repoURL at
+// targetRevision otherwise. Unknown refs and paths that leave the ref
+// source's repository are errors rather than skipped.
+func (r *Renderer) resolveValueFiles(ctx context.Context, src map[string]interface{}, m *manifest.Manifest) (map[string]interface{}, error) {
+	helmCfg := getMap(src, "helm")
+	files := getSlice(helmCfg, "valueFiles")
+	hasRefs := false
+	for _, item := range files {
+		if file, ok := item.(string); ok && strings.HasPrefix(file, "$") {
+			hasRefs = true
+		}
+	}
+	if !hasRefs {
+		return helmCfg, nil
+	}
+	refs := map[string]map[string]interface{}{}
+	for _, source := range r.collectSources(m) {
+		if name := strings.TrimSpace(getString(source, "ref")); name != "" {
+			refs[name] = source
+		}
+	}
+	resolved := make([]interface{}, 0, len(files))
+	for _, item := range files {
+		file, ok := item.(string)
+		if !ok || !strings.HasPrefix(file, "$") {
+			resolved = append(resolved, item)
+			continue
+		}
+		name, rest, _ := strings.Cut(file[1:], "/")
+		ref, ok := refs[name]
+		if !ok {
+			return nil, fmt.Errorf("value file %s: no source with ref %q", file, name)
+		}
+		root, err := r.refRoot(ctx, src, ref)
+		if err != nil {
+			return nil, fmt.Errorf("value file %s: %w", file, err)
+		}
+		path, err := withinRoot(root, rest)
+		if err != nil {
+			return nil, fmt.Errorf("value file %s: %w", file, err)
+		}
+		resolved = append(resolved, refPath(path))
+	}
+	out := make(map[string]interface{}, len(helmCfg))
+	for k, v := range helmCfg {
+		out[k] = v
+	}
+	out["valueFiles"] = resolved
+	return out, nil
+}
+
+// refRoot returns the local directory holding the repository of a ref
+// source.
+func (r *Renderer) refRoot(ctx context.Context, src, ref map[string]interface{}) (string, error) {
+	repo := strings.TrimSpace(getString(ref, "repoURL"))
+	if repo == "" || gitutil.SameRepo(repo, getString(src, "repoURL")) {
+		return r.repoRoot, nil
+	}
+	revision := strings.TrimSpace(getString(ref, "targetRevision"))
+	key := repo + "@" + revision
+	r.clonesMu.Lock()
+	defer r.clonesMu.Unlock()
+	if clone, ok := r.clones[key]; ok {
+		return clone.dir, clone.err
+	}
+	if r.cloneDir == "" {
+		dir, err := os.MkdirTemp("", "argocd-lint-refs-")
+		if err != nil {
+			return "", err
+		}
+		r.cloneDir = dir
+	}
+	dir := filepath.Join(r.cloneDir, strconv.Itoa(len(r.clones)))
+	started := time.Now()
+	err := gitutil.ShallowClone(ctx, repo, revision, dir)
+	r.logger.Debug("git clone", "repo", repo, "revision", revision, "duration", time.Since(started), "error", err)
+	r.clones[key] = refClone{dir: dir, err: err}
+	return dir, err
+}
+
+// Close removes the repositories cloned for $ref value files.
+func (r *Renderer) Close() error {
+	r.clonesMu.Lock()
+	defer r.clonesMu.Unlock()
+	if r.cloneDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(r.cloneDir)
+	r.cloneDir = ""
+	r.clones = map[string]refClone{}
+	return err
+}
@@ -1,45 +1,130 @@
 package render
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
+	"github.com/argocd-lint/argocd-lint/internal/logging"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
 )
 
 // Options configures rendering behaviour.
 type Options struct {
-	Enabled         bool
-	HelmBinary      string
+	Enabled bool
+	// KustomizeBinary runs this kustomize binary instead of the embedded
+	// kustomize API when set.
 	KustomizeBinary string
-	RepoRoot        string
-	CacheEnabled    bool
+	// KustomizeDenyRemote rejects kustomizations that reference remote
+	// bases, resources, or components instead of fetching them.
+	KustomizeDenyRemote bool
+	// KustomizeLoadRestrictor is LoadRestrictionsRootOnly (the default) or
+	// LoadRestrictionsNone, as with kustomize build --load-restrictor.
+	KustomizeLoadRestrictor string
+	RepoRoot                string
+	CacheEnabled            bool
+	// Logger records each helm template and kustomize invocation with its
+	// duration.
+	Logger *slog.Logger
+	// Timeout bounds the render stage of a lint run (0 = no limit). The lint
+	// runner applies it to the context passed to RenderContext.
+	Timeout time.Duration
+	// Kubeconform, when set, validates rendered output against Kubernetes
+	// schemas and reports failures as RENDER_KUBECONFORM. Resources without
+	// a schema, such as custom resources, are always skipped, and schemas
+	// default to KubeVersion.
+	Kubeconform *kubeconform.Options
+	// Parallel caps how many sources render at once across all lint workers
+	// (0 = no cap beyond the worker count).
+	Parallel int
+	// SourceTimeout bounds each render of a single source (0 = no limit). A
+	// source that runs over is reported as a render failure instead of
+	// aborting the run.
+	SourceTimeout time.Duration
+	// MaxOutputBytes caps the rendered output of a single source (0 = no
+	// limit). Larger output is reported as a render failure and not linted.
+	MaxOutputBytes int64
+	// KubeVersion and APIVersions set the capabilities Helm charts render
+	// against, as with helm template --kube-version and --api-versions. A
+	// source's helm.kubeVersion and helm.apiVersions take precedence.
+	KubeVersion string
+	APIVersions []string
+	// CMPCommands maps Config Management Plugin names to shell commands
+	// that emulate them; "*" handles plugins without their own command.
+	// Plugin sources without a command are not rendered.
+	CMPCommands map[string]string
+	// SnapshotDir holds one render snapshot per Application. Rendered output
+	// is compared with it and drift is reported as RENDER_SNAPSHOT, or, with
+	// WriteSnapshots, the snapshots are recorded instead.
+	SnapshotDir    string
+	WriteSnapshots bool
+	// KeepResources records the resources each Application renders to for
+	// Resources, so the dry-run stage can validate them.
+	KeepResources bool
 }
 
-// Renderer executes Helm/Kustomize renders and reports findings when they fail.
+// Renderer renders Helm charts, Kustomize overlays, and Jsonnet directories
+// in-process and reports findings when they fail.
 type Renderer struct {
-	cfg             config.Config
-	helmBinary      string
-	kustomizeBinary string
-	repoRoot        string
-	cacheEnabled    bool
-	cacheMu         sync.Mutex
-	cache           map[string]renderCacheEntry
+	cfg                 config.Config
+	enabled             bool
+	kustomizeBinary     string
+	kustomizeDenyRemote bool
+	loadRestrictions    kusttypes.LoadRestrictions
+	kubeconform         *kubeconform.Validator
+	repoRoot            string
+	cacheEnabled        bool
+	logger              *slog.Logger
+	cacheMu             sync.Mutex
+	cache               map[string]renderCacheEntry
+	chartsMu            sync.Mutex
+	charts              map[string][]*loader.BufferedFile
+	clonesMu            sync.Mutex
+	clones              map[string]refClone
+	cloneDir            string
+	// slots holds one token per render allowed to run; nil means no cap.
+	slots         chan struct{}
+	sourceTimeout time.Duration
+	maxOutput     int64
+	kubeVersion   *chartutil.KubeVersion
+	apiVersions   []string
+	cmpCommands   map[string]string
+	snapshotDir   string
+	// writeSnapshots records snapshots instead of comparing with them.
+	writeSnapshots bool
+	// resources holds the rendered resources of each Application when
+	// KeepResources is set; nil otherwise.
+	resourcesMu sync.Mutex
+	resources   map[*manifest.Manifest][]map[string]interface{}
 }
 
 type renderCacheEntry struct {
 	findings []types.Finding
+	output   []byte
 	err      error
 }
 
+// defaultLargeAppThreshold is the rendered resource count above which AR034
+// recommends sync options for large Applications.
+const defaultLargeAppThreshold = 200
+
 var (
 	helmRuleMeta = types.RuleMetadata{
 		ID:              "RENDER_HELM",
@@ -64,20 +149,49 @@ var (
 		Category: "render",
 		Enabled:  true,
 	}
+
+	largeAppRuleMeta = types.RuleMetadata{
+		ID:              "AR034",
+		Description:     "Large rendered Applications should enable ApplyOutOfSyncOnly and ServerSideApply",
+		Rationale:       "Large Applications compare and apply every resource on each sync; ApplyOutOfSyncOnly and ServerSideApply keep syncs fast and avoid annotation size limits.",
+		Example:         "syncOptions:\n  - ApplyOutOfSyncOnly=true\n  - ServerSideApply=true",
+		Remediation:     "Add ApplyOutOfSyncOnly=true and ServerSideApply=true to syncPolicy.syncOptions.",
+		DefaultSeverity: types.SeverityInfo,
+		AppliesTo: []types.ResourceKind{
+			types.ResourceKindApplication,
+			types.ResourceKindApplicationSet,
+		},
+		HelpURL:  "https://argo-cd.readthedocs.io/en/stable/user-guide/sync-options/#selective-sync",
+		Category: "advisory",
+		Enabled:  true,
+	}
 )
 
 // NewRenderer constructs a Renderer from configuration.
 func NewRenderer(cfg config.Config, opts Options) (*Renderer, error) {
 	if !opts.Enabled {
-		return &Renderer{cfg: cfg}, nil
+		return &Renderer{cfg: cfg, logger: logging.OrDiscard(opts.Logger)}, nil
+	}
+	loadRestrictions, err := parseLoadRestrictor(opts.KustomizeLoadRestrictor)
+	if err != nil {
+		return nil, err
 	}
-	helmBin := strings.TrimSpace(opts.HelmBinary)
-	if helmBin == "" {
-		helmBin = "helm"
+	var kubeVersion *chartutil.KubeVersion
+	if v := strings.TrimSpace(opts.KubeVersion); v != "" {
+		if kubeVersion, err = chartutil.ParseKubeVersion(v); err != nil {
+			return nil, fmt.Errorf("invalid kube version %q: %w", v, err)
+		}
 	}
-	kustomizeBin := strings.TrimSpace(opts.KustomizeBinary)
-	if kustomizeBin == "" {
-		kustomizeBin = "kustomize"
+	var validator *kubeconform.Validator
+	if opts.Kubeconform != nil {
+		schemaOpts := *opts.Kubeconform
+		schemaOpts.IgnoreMissingSchemas = true
+		if schemaOpts.KubernetesVersion == "" {
+			schemaOpts.KubernetesVersion = opts.KubeVersion
+		}
+		if validator, err = kubeconform.New(schemaOpts); err != nil {
+			return nil, err
+		}
 	}
 	repoRoot := opts.RepoRoot
 	if repoRoot == "" {
@@ -87,27 +201,68 @@ func NewRenderer(cfg config.Config, opts Options) (*Renderer, error) {
 		}
 		repoRoot = wd
 	}
+	var slots chan struct{}
+	if opts.Parallel > 0 {
+		slots = make(chan struct{}, opts.Parallel)
+	}
+	var resources map[*manifest.Manifest][]map[string]interface{}
+	if opts.KeepResources {
+		resources = make(map[*manifest.Manifest][]map[string]interface{})
+	}
 	return &Renderer{
-		cfg:             cfg,
-		helmBinary:      helmBin,
-		kustomizeBinary: kustomizeBin,
-		repoRoot:        repoRoot,
-		cacheEnabled:    opts.CacheEnabled,
-		cache:           make(map[string]renderCacheEntry),
+		cfg:                 cfg,
+		enabled:             true,
+		kustomizeBinary:     strings.TrimSpace(opts.KustomizeBinary),
+		kustomizeDenyRemote: opts.KustomizeDenyRemote,
+		loadRestrictions:    loadRestrictions,
+		kubeconform:         validator,
+		repoRoot:            repoRoot,
+		cacheEnabled:        opts.CacheEnabled,
+		logger:              logging.OrDiscard(opts.Logger),
+		cache:               make(map[string]renderCacheEntry),
+		charts:              make(map[string][]*loader.BufferedFile),
+		clones:              make(map[string]refClone),
+		slots:               slots,
+		sourceTimeout:       opts.SourceTimeout,
+		maxOutput:           opts.MaxOutputBytes,
+		kubeVersion:         kubeVersion,
+		apiVersions:         opts.APIVersions,
+		cmpCommands:         opts.CMPCommands,
+		snapshotDir:         strings.TrimSpace(opts.SnapshotDir),
+		writeSnapshots:      opts.WriteSnapshots,
+		resources:           resources,
 	}, nil
 }
 
 // Metadata exposes rule metadata for registration with reporting.
 func (r *Renderer) Metadata() []types.RuleMetadata {
-	return []types.RuleMetadata{helmRuleMeta, kustomizeRuleMeta}
+	return []types.RuleMetadata{helmRuleMeta, kustomizeRuleMeta, jsonnetRuleMeta, directoryRuleMeta, pluginRuleMeta, largeAppRuleMeta, kubeconformRuleMeta, pinnedImageRuleMeta, resourceLimitsRuleMeta, snapshotRuleMeta}
+}
+
+// Resources returns the resources m rendered to, in source order. It is
+// empty unless the Renderer keeps resources and m has been rendered.
+func (r *Renderer) Resources(m *manifest.Manifest) []map[string]interface{} {
+	r.resourcesMu.Lock()
+	defer r.resourcesMu.Unlock()
+	return r.resources[m]
 }
 
-// Render attempts to render Helm/Kustomize sources referenced by the manifest.
+// Render attempts to render the Helm, Kustomize, Jsonnet, and emulated
+// Config Management Plugin sources referenced by the manifest.
 func (r *Renderer) Render(m *manifest.Manifest) ([]types.Finding, error) {
+	return r.RenderContext(context.Background(), m)
+}
+
+// RenderContext is Render with a context. Once ctx is done, in-process
+// Helm and Kustomize builds stop before their next step, and subprocesses
+// (--kustomize-binary and plugin commands) are killed. A cancelled or
+// expired context is returned as an error rather than reported as a render
+// failure.
+func (r *Renderer) RenderContext(ctx context.Context, m *manifest.Manifest) ([]types.Finding, error) {
 	if m == nil {
 		return nil, errors.New("manifest is nil")
 	}
-	if r.helmBinary == "" && r.kustomizeBinary == "" {
+	if !r.enabled {
 		return nil, nil
 	}
 
@@ -117,6 +272,8 @@ func (r *Renderer) Render(m *manifest.Manifest) ([]types.Finding, error) {
 	}
 
 	var findings []types.Finding
+	var outputs [][]byte
+	attempted, failed := false, false
 	for _, src := range sources {
 		path := strings.TrimSpace(getString(src, "path"))
 		if path == "" {
@@ -134,75 +291,174 @@ func (r *Renderer) Render(m *manifest.Manifest) ([]types.Finding, error) {
 			continue
 		}
 
-		if r.shouldRenderHelm(src, absPath) {
-			rendered, err := r.renderHelm(absPath, src, m)
-			if err != nil {
-				return nil, err
+		var renderers []func(context.Context, string, map[string]interface{}, *manifest.Manifest) ([]types.Finding, []byte, error)
+		if _, ok := src["plugin"]; ok {
+			// An explicit plugin replaces Helm, Kustomize, and directory
+			// detection, as in Argo CD.
+			renderers = append(renderers, r.renderPlugin)
+		} else {
+			if r.shouldRenderHelm(src, absPath) {
+				renderers = append(renderers, r.renderHelm)
+			}
+			if r.shouldRenderKustomize(src, absPath) {
+				renderers = append(renderers, r.renderKustomize)
+			}
+			if r.shouldRenderDirectory(src, absPath) {
+				renderers = append(renderers, r.renderDirectory)
 			}
-			findings = append(findings, rendered...)
 		}
-		if r.shouldRenderKustomize(src, absPath) {
-			rendered, err := r.renderKustomize(absPath, m)
+		attempted = attempted || len(renderers) > 0
+		for _, render := range renderers {
+			if err := r.acquireSlot(ctx); err != nil {
+				return nil, err
+			}
+			rendered, output, err := render(ctx, absPath, src, m)
+			r.releaseSlot()
 			if err != nil {
 				return nil, err
 			}
 			findings = append(findings, rendered...)
+			failed = failed || len(rendered) > 0
+			if output != nil {
+				outputs = append(outputs, output)
+			}
+		}
+	}
+
+	resources := 0
+	var kept []map[string]interface{}
+	for _, output := range outputs {
+		objects := decodeResources(output)
+		resources += len(objects)
+		kept = append(kept, objects...)
+		workload, err := r.workloadFindings(m, objects)
+		if err != nil {
+			return nil, err
 		}
+		findings = append(findings, workload...)
+		schema, err := r.kubeconformFindings(ctx, m, output)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, schema...)
+	}
+
+	if r.resources != nil && len(kept) > 0 {
+		r.resourcesMu.Lock()
+		r.resources[m] = kept
+		r.resourcesMu.Unlock()
 	}
 
-	return findings, nil
+	// A partial render would be recorded or reported as drift; the render
+	// failure is reported instead. Sources that render no resources still
+	// get a snapshot, so resources disappearing from them show up as drift.
+	if r.snapshotDir != "" && !failed && attempted {
+		snapshot, err := r.snapshotFindings(m, outputs)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, snapshot...)
+	}
+
+	largeApp, err := r.largeAppFindings(m, resources)
+	if err != nil {
+		return nil, err
+	}
+	return append(findings, largeApp...), nil
 }
 
-func (r *Renderer) renderHelm(path string, src map[string]interface{}, m *manifest.Manifest) ([]types.Finding, error) {
-	cfg, err := r.cfg.Resolve(helmRuleMeta, m.FilePath)
+// largeAppFindings recommends ApplyOutOfSyncOnly and ServerSideApply when the
+// rendered resource count exceeds the AR034 resourceThreshold param.
+func (r *Renderer) largeAppFindings(m *manifest.Manifest, resources int) ([]types.Finding, error) {
+	cfg, err := r.cfg.Resolve(largeAppRuleMeta, m.FilePath)
 	if err != nil {
 		return nil, err
 	}
-	if !cfg.Enabled || r.helmBinary == "" {
+	threshold := defaultLargeAppThreshold
+	if v, ok := cfg.IntParam("resourceThreshold"); ok && v > 0 {
+		threshold = v
+	}
+	if !cfg.Enabled || resources <= threshold {
 		return nil, nil
 	}
-	cacheKey := ""
-	if r.cacheEnabled {
-		cacheKey = renderCacheKey("helm", path, src)
-		if findings, err, ok := r.lookupCache(cacheKey); ok {
-			return cloneFindings(findings), err
+	syncPolicyPath := []string{"spec", "syncPolicy"}
+	if m.Kind == string(types.ResourceKindApplicationSet) {
+		syncPolicyPath = []string{"spec", "template", "spec", "syncPolicy"}
+	}
+	enabled := map[string]bool{}
+	for _, item := range getSlice(m.Object, append(syncPolicyPath, "syncOptions")...) {
+		if str, ok := item.(string); ok {
+			enabled[strings.TrimSpace(str)] = true
 		}
 	}
-	args := []string{"template", "argocd-lint-render", "."}
-	helmCfg := getMap(src, "helm")
-	valueFiles := getSlice(helmCfg, "valueFiles")
-	for _, item := range valueFiles {
-		if str, ok := item.(string); ok && str != "" {
-			args = append(args, "--values", filepath.Join(path, str))
+	var missing []string
+	for _, option := range []string{"ApplyOutOfSyncOnly=true", "ServerSideApply=true"} {
+		if !enabled[option] {
+			missing = append(missing, option)
 		}
 	}
-	parameters := getSlice(helmCfg, "parameters")
-	for _, item := range parameters {
-		param, ok := item.(map[string]interface{})
-		if !ok {
-			continue
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	builder := types.FindingBuilder{
+		Rule:         cfg,
+		FilePath:     m.FilePath,
+		Line:         m.MetadataLine,
+		ResourceName: m.Name,
+		ResourceKind: m.Kind,
+	}
+	finding := builder.NewFinding(fmt.Sprintf("rendered output has %d resources (threshold %d); consider syncOptions %s", resources, threshold, strings.Join(missing, ", ")), cfg.Severity)
+	finding.Suggestions = []types.Suggestion{
+		{
+			Title:       "Enable large-app sync options",
+			Description: "ApplyOutOfSyncOnly skips unchanged resources and ServerSideApply avoids oversized last-applied annotations.",
+			Patch:       "syncOptions:\n  - " + strings.Join(missing, "\n  - "),
+			Path:        "$." + strings.Join(syncPolicyPath, ".") + ".syncOptions",
+		},
+	}
+	return []types.Finding{finding}, nil
+}
+
+// renderHelm renders the chart of one source. It returns the rendered
+// output on success and a RENDER_HELM finding on failure.
+func (r *Renderer) renderHelm(ctx context.Context, path string, src map[string]interface{}, m *manifest.Manifest) ([]types.Finding, []byte, error) {
+	cfg, err := r.cfg.Resolve(helmRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+	helmCfg, err := r.resolveValueFiles(ctx, src, m)
+	cacheKey := ""
+	if err == nil && r.cacheEnabled {
+		// Key on the resolved value files: the same $ref path can point
+		// into different repositories for different Applications.
+		keySrc := make(map[string]interface{}, len(src))
+		for k, v := range src {
+			keySrc[k] = v
 		}
-		name := strings.TrimSpace(getString(param, "name"))
-		value := getString(param, "value")
-		if name == "" {
-			continue
+		keySrc["helm"] = helmCfg
+		cacheKey = renderCacheKey("helm", path, keySrc)
+		if entry, ok := r.lookupCache(cacheKey); ok {
+			return cloneFindings(entry.findings), entry.output, entry.err
 		}
-		args = append(args, "--set", fmt.Sprintf("%s=%s", name, value))
 	}
-	releaseName := strings.TrimSpace(getString(helmCfg, "releaseName"))
-	if releaseName != "" {
-		args = append(args, "--release-name")
-		args = append(args, releaseName)
+	var output []byte
+	if err == nil {
+		sourceCtx, cancel := r.sourceContext(ctx)
+		output, err = r.templateChart(sourceCtx, path, helmCfg)
+		err = r.checkRender(ctx, sourceCtx, output, err)
+		cancel()
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, nil, fmt.Errorf("helm template in %s: %w", path, ctxErr)
 	}
-
-	cmd := exec.Command(r.helmBinary, args...)
-	cmd.Dir = path
-	output, err := cmd.CombinedOutput()
 	if err == nil {
 		if r.cacheEnabled {
-			r.storeCache(cacheKey, nil, nil)
+			r.storeCache(cacheKey, nil, output, nil)
 		}
-		return nil, nil
+		return nil, output, nil
 	}
 	builder := types.FindingBuilder{
 		Rule:         cfg,
@@ -212,40 +468,48 @@ func (r *Renderer) renderHelm(path string, src map[string]interface{}, m *manife
 		ResourceKind: m.Kind,
 	}
 	msg := fmt.Sprintf("helm template failed in %s: %v", path, err)
-	trimmed := trimOutput(output)
-	if trimmed != "" {
-		msg = fmt.Sprintf("%s: %s", msg, trimmed)
-	}
-	result := []types.Finding{builder.NewFinding(msg, cfg.Severity)}
+	loc, ok := helmErrorLocation(path, err)
+	result := []types.Finding{withLocation(builder.NewFinding(msg, cfg.Severity), loc, ok)}
 	if r.cacheEnabled {
-		r.storeCache(cacheKey, result, nil)
+		r.storeCache(cacheKey, result, nil, nil)
 	}
-	return result, nil
+	return result, nil, nil
 }
 
-func (r *Renderer) renderKustomize(path string, m *manifest.Manifest) ([]types.Finding, error) {
+// renderKustomize builds the overlay of one source with its kustomize
+// options applied. It returns the rendered output on success and a
+// RENDER_KUSTOMIZE finding on failure.
+func (r *Renderer) renderKustomize(ctx context.Context, path string, src map[string]interface{}, m *manifest.Manifest) ([]types.Finding, []byte, error) {
 	cfg, err := r.cfg.Resolve(kustomizeRuleMeta, m.FilePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if !cfg.Enabled || r.kustomizeBinary == "" {
-		return nil, nil
+	if !cfg.Enabled {
+		return nil, nil, nil
 	}
 	cacheKey := ""
 	if r.cacheEnabled {
-		cacheKey = renderCacheKey("kustomize", path, nil)
-		if findings, err, ok := r.lookupCache(cacheKey); ok {
-			return cloneFindings(findings), err
+		var options map[string]interface{}
+		if kus := getMap(src, "kustomize"); len(kus) > 0 {
+			options = kus
 		}
+		cacheKey = renderCacheKey("kustomize", path, options)
+		if entry, ok := r.lookupCache(cacheKey); ok {
+			return cloneFindings(entry.findings), entry.output, entry.err
+		}
+	}
+	sourceCtx, cancel := r.sourceContext(ctx)
+	output, err := r.buildKustomization(sourceCtx, path, getMap(src, "kustomize"))
+	err = r.checkRender(ctx, sourceCtx, output, err)
+	cancel()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, nil, fmt.Errorf("kustomize build in %s: %w", path, ctxErr)
 	}
-	cmd := exec.Command(r.kustomizeBinary, "build", path)
-	cmd.Dir = path
-	output, err := cmd.CombinedOutput()
 	if err == nil {
 		if r.cacheEnabled {
-			r.storeCache(cacheKey, nil, nil)
+			r.storeCache(cacheKey, nil, output, nil)
 		}
-		return nil, nil
+		return nil, output, nil
 	}
 	builder := types.FindingBuilder{
 		Rule:         cfg,
@@ -255,21 +519,158 @@ func (r *Renderer) renderKustomize(path string, m *manifest.Manifest) ([]types.F
 		ResourceKind: m.Kind,
 	}
 	msg := fmt.Sprintf("kustomize build failed in %s: %v", path, err)
-	trimmed := trimOutput(output)
-	if trimmed != "" {
-		msg = fmt.Sprintf("%s: %s", msg, trimmed)
-	}
-	result := []types.Finding{builder.NewFinding(msg, cfg.Severity)}
+	loc, ok := kustomizeErrorLocation(path, err)
+	result := []types.Finding{withLocation(builder.NewFinding(msg, cfg.Severity), loc, ok)}
 	if r.cacheEnabled {
-		r.storeCache(cacheKey, result, nil)
+		r.storeCache(cacheKey, result, nil, nil)
 	}
-	return result, nil
+	return result, nil, nil
 }
 
-func (r *Renderer) shouldRenderHelm(src map[string]interface{}, path string) bool {
-	if r.helmBinary == "" {
-		return false
+// runCommand runs cmd and returns its output alongside the combined output
+// used in failure messages.
+func (r *Renderer) runCommand(cmd *exec.Cmd) ([]byte, []byte, error) {
+	var stdout, combined bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, &combined)
+	var limited *limitWriter
+	if r.maxOutput > 0 {
+		limited = &limitWriter{w: cmd.Stdout, limit: r.maxOutput}
+		cmd.Stdout = limited
+	}
+	cmd.Stderr = &combined
+	// Do not wait on pipes held open by children of a killed command.
+	cmd.WaitDelay = time.Second
+	started := time.Now()
+	err := cmd.Run()
+	if limited != nil && limited.exceeded {
+		// The command failed on the closed pipe; report why.
+		err = errOutputLimit{limit: limited.limit}
+	}
+	r.logger.Debug("external command", "command", cmd.Args[0], "args", cmd.Args[1:], "dir", cmd.Dir, "duration", time.Since(started), "error", err)
+	return stdout.Bytes(), combined.Bytes(), err
+}
+
+// acquireSlot waits for a free render slot when Options.Parallel caps
+// concurrent renders.
+func (r *Renderer) acquireSlot(ctx context.Context) error {
+	if r.slots == nil {
+		return nil
+	}
+	select {
+	case r.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Renderer) releaseSlot() {
+	if r.slots != nil {
+		<-r.slots
+	}
+}
+
+// sourceContext bounds the render of one source by Options.SourceTimeout.
+func (r *Renderer) sourceContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.sourceTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.sourceTimeout)
+}
+
+// checkRender turns a per-source timeout and output over the size cap into
+// render errors, leaving errors of the run's own context to the caller.
+func (r *Renderer) checkRender(ctx, sourceCtx context.Context, output []byte, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	if errors.Is(sourceCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", r.sourceTimeout)
+	}
+	if err == nil && r.maxOutput > 0 && int64(len(output)) > r.maxOutput {
+		return errOutputLimit{limit: r.maxOutput}
+	}
+	return err
+}
+
+// errOutputLimit reports rendered output over Options.MaxOutputBytes.
+type errOutputLimit struct {
+	limit int64
+}
+
+func (e errOutputLimit) Error() string {
+	return fmt.Sprintf("rendered output exceeds the %d byte limit", e.limit)
+}
+
+// limitWriter fails writes once more than limit bytes were written, which
+// stops a command from buffering unbounded output.
+type limitWriter struct {
+	w        io.Writer
+	limit    int64
+	written  int64
+	exceeded bool
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) > l.limit {
+		l.exceeded = true
+		return 0, errOutputLimit{limit: l.limit}
 	}
+	l.written += int64(len(p))
+	return l.w.Write(p)
+}
+
+// runInProcess runs fn and returns early with ctx's error when ctx is done
+// first; fn does not start at all once ctx is done. The embedded kustomize
+// and jsonnet engines cannot be interrupted, so an abandoned call finishes in
+// the background and its result is dropped.
+func runInProcess(ctx context.Context, fn func() ([]byte, error)) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := fn()
+		done <- result{output, err}
+	}()
+	select {
+	case res := <-done:
+		return res.output, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// decodeResources returns the Kubernetes objects in rendered YAML, expanding
+// List kinds. Decoding stops at the first unparseable document.
+func decodeResources(output []byte) []map[string]interface{} {
+	dec := yaml.NewDecoder(bytes.NewReader(output))
+	var objects []map[string]interface{}
+	for {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return objects
+		}
+		kind, _ := doc["kind"].(string)
+		switch {
+		case kind == "":
+		case strings.HasSuffix(kind, "List"):
+			for _, item := range getSlice(doc, "items") {
+				if obj, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, obj)
+				}
+			}
+		default:
+			objects = append(objects, doc)
+		}
+	}
+}
+
+func (r *Renderer) shouldRenderHelm(src map[string]interface{}, path string) bool {
 	if strings.TrimSpace(getString(src, "chart")) != "" {
 		if exists(filepath.Join(path, "Chart.yaml")) {
 			return true
@@ -284,9 +685,6 @@ func (r *Renderer) shouldRenderHelm(src map[string]interface{}, path string) boo
 }
 
 func (r *Renderer) shouldRenderKustomize(src map[string]interface{}, path string) bool {
-	if r.kustomizeBinary == "" {
-		return false
-	}
 	if exists(filepath.Join(path, "kustomization.yaml")) || exists(filepath.Join(path, "kustomization.yml")) || exists(filepath.Join(path, "Kustomization")) {
 		return true
 	}
@@ -334,26 +732,23 @@ func trimOutput(output []byte) string {
 	return trimmed
 }
 
-func (r *Renderer) lookupCache(key string) ([]types.Finding, error, bool) {
+func (r *Renderer) lookupCache(key string) (renderCacheEntry, bool) {
 	if !r.cacheEnabled || key == "" {
-		return nil, nil, false
+		return renderCacheEntry{}, false
 	}
 	r.cacheMu.Lock()
 	entry, ok := r.cache[key]
 	r.cacheMu.Unlock()
-	if !ok {
-		return nil, nil, false
-	}
-	return entry.findings, entry.err, true
+	return entry, ok
 }
 
-func (r *Renderer) storeCache(key string, findings []types.Finding, err error) {
+func (r *Renderer) storeCache(key string, findings []types.Finding, output []byte, err error) {
 	if !r.cacheEnabled || key == "" {
 		return
 	}
 	clone := cloneFindings(findings)
 	r.cacheMu.Lock()
-	r.cache[key] = renderCacheEntry{findings: clone, err: err}
+	r.cache[key] = renderCacheEntry{findings: clone, output: output, err: err}
 	r.cacheMu.Unlock()
 }
 
@@ -380,6 +775,57 @@ func cloneFindings(src []types.Finding) []types.Finding {
 	return clone
 }
 
+// withinRoot joins the slash-separated rel to root and rejects results
+// outside root, so manifests cannot point renderers at arbitrary host files.
+func withinRoot(root, rel string) (string, error) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	inside, err := filepath.Rel(root, path)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside %s", rel, root)
+	}
+	return path, nil
+}
+
+// sourceRoot returns the directory files named by the source at dir are
+// confined to: the repository root, or dir itself for sources outside it.
+func (r *Renderer) sourceRoot(dir string) string {
+	root, err := filepath.Abs(r.repoRoot)
+	if err != nil {
+		return dir
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return dir
+}
+
+// sourceFile resolves a file named by the source at dir the way Argo CD
+// does, relative to dir or, when absolute, to root, and rejects files
+// outside root.
+func sourceFile(root, dir, file string) (string, error) {
+	if filepath.IsAbs(file) {
+		return withinRoot(root, file)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(filepath.Join(dir, file))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absFile)
+	if err != nil {
+		return "", fmt.Errorf("path %s is outside %s", file, root)
+	}
+	if _, err := withinRoot(absRoot, filepath.ToSlash(rel)); err != nil {
+		return "", fmt.Errorf("path %s is outside %s", file, root)
+	}
+	return absFile, nil
+}
+
 func exists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
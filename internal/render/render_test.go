@@ -1,12 +1,22 @@
 package render
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/kubeconform"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
 )
 
 func fakeManifest(kind string) *manifest.Manifest {
@@ -31,28 +41,33 @@ func fakeManifest(kind string) *manifest.Manifest {
 	}
 }
 
-func shPath() string {
-	if p, err := filepath.Abs("/bin/sh"); err == nil {
-		return p
+// writeChart creates dir/chart with the given templates.
+func writeChart(t *testing.T, dir string, templates map[string]string) {
+	t.Helper()
+	templateDir := filepath.Join(dir, "chart", "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "chart", "Chart.yaml"), []byte("apiVersion: v2\nname: demo\nversion: 0.1.0\n"), 0o600); err != nil {
+		t.Fatalf("write chart: %v", err)
+	}
+	for name, body := range templates {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write template %s: %v", name, err)
+		}
 	}
-	return "sh"
 }
 
 func TestRendererHelmFailure(t *testing.T) {
 	dir := t.TempDir()
-	chartDir := filepath.Join(dir, "chart")
-	if err := os.Mkdir(chartDir, 0o755); err != nil {
-		t.Fatalf("mkdir: %v", err)
-	}
-	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: demo\nversion: 0.1.0\n"), 0o600); err != nil {
-		t.Fatalf("write chart: %v", err)
-	}
+	writeChart(t, dir, map[string]string{
+		"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\ndata:\n  tag: {{ required \"tag is required\" .Values.tag }}\n",
+	})
 
 	cfg := config.Config{}
 	renderer, err := NewRenderer(cfg, Options{
-		Enabled:    true,
-		HelmBinary: shPath(),
-		RepoRoot:   dir,
+		Enabled:  true,
+		RepoRoot: dir,
 	})
 	if err != nil {
 		t.Fatalf("new renderer: %v", err)
@@ -69,6 +84,20 @@ func TestRendererHelmFailure(t *testing.T) {
 	if findings[0].RuleID != "RENDER_HELM" {
 		t.Fatalf("expected RENDER_HELM rule, got %s", findings[0].RuleID)
 	}
+	if !strings.Contains(findings[0].Message, "demo/templates/configmap.yaml:6:") || !strings.Contains(findings[0].Message, "tag is required") {
+		t.Fatalf("expected the template file and line in the message, got %q", findings[0].Message)
+	}
+	want := types.Location{FilePath: filepath.Join(dir, "chart", "templates", "configmap.yaml"), Line: 6, Column: 10}
+	if len(findings[0].RelatedLocations) != 1 || findings[0].RelatedLocations[0] != want {
+		t.Fatalf("expected related location %+v, got %+v", want, findings[0].RelatedLocations)
+	}
+
+	manifest.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})["helm"] = map[string]interface{}{
+		"parameters": []interface{}{map[string]interface{}{"name": "tag", "value": "1.0.0"}},
+	}
+	if findings, err := renderer.Render(manifest); err != nil || len(findings) != 0 {
+		t.Fatalf("expected parameters to satisfy the template, got %+v (%v)", findings, err)
+	}
 }
 
 func TestRendererDisabled(t *testing.T) {
@@ -84,3 +113,809 @@ func TestRendererDisabled(t *testing.T) {
 		t.Fatalf("expected no findings when disabled")
 	}
 }
+
+func TestRendererLargeAppAdvisory(t *testing.T) {
+	dir := t.TempDir()
+	writeChart(t, dir, map[string]string{
+		"configmaps.yaml": "{{- range $i := until 3 }}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm{{ $i }}\n{{- end }}\n",
+		"list.yaml":       "apiVersion: v1\nkind: List\nitems:\n  - kind: Secret\n  - kind: Service\n",
+	})
+
+	cfg := config.Config{Rules: map[string]config.RuleConfig{
+		"AR034": {Params: map[string]interface{}{"resourceThreshold": 4}},
+	}}
+	renderer, err := NewRenderer(cfg, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	app := fakeManifest("Application")
+	app.Object["spec"].(map[string]interface{})["syncPolicy"] = map[string]interface{}{
+		"syncOptions": []interface{}{"ServerSideApply=true"},
+	}
+	findings, err := renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "AR034" {
+		t.Fatalf("expected one AR034 finding, got %+v", findings)
+	}
+	if findings[0].Message != "rendered output has 5 resources (threshold 4); consider syncOptions ApplyOutOfSyncOnly=true" {
+		t.Fatalf("unexpected message %q", findings[0].Message)
+	}
+
+	renderer, err = NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	if findings, err := renderer.Render(app); err != nil || len(findings) != 0 {
+		t.Fatalf("expected no findings below the default threshold, got %+v (%v)", findings, err)
+	}
+}
+
+func TestRenderContextStopsHungCommand(t *testing.T) {
+	dir := t.TempDir()
+	overlayDir := filepath.Join(dir, "chart")
+	if err := os.Mkdir(overlayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), []byte("resources: []\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	kustomize := filepath.Join(dir, "kustomize")
+	if err := os.WriteFile(kustomize, []byte("#!/bin/sh\nexec sleep 5\n"), 0o755); err != nil {
+		t.Fatalf("write kustomize: %v", err)
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, KustomizeBinary: kustomize, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	findings, err := renderer.RenderContext(ctx, fakeManifest("Application"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error instead of findings, got %+v (%v)", findings, err)
+	}
+}
+
+func TestRunInProcessStopsAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := runInProcess(ctx, func() ([]byte, error) {
+		<-release
+		return nil, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the blocked call to be abandoned at the deadline, got %v", err)
+	}
+
+	started := false
+	if _, err := runInProcess(ctx, func() ([]byte, error) { started = true; return nil, nil }); !errors.Is(err, context.DeadlineExceeded) || started {
+		t.Fatalf("expected no call once the context is done, got %v (started %t)", err, started)
+	}
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "chart"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "chart", "kustomization.yaml"), []byte("resources: []\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err := renderer.RenderContext(ctx, fakeManifest("Application"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the in-process kustomize build to stop with the context, got %+v (%v)", findings, err)
+	}
+}
+
+func TestRendererChecksRenderedWorkloads(t *testing.T) {
+	dir := t.TempDir()
+	rendered := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.local:5000/migrate
+          resources: {limits: {cpu: 100m, memory: 64Mi}}
+      containers:
+        - name: app
+          image: nginx:latest
+          resources: {limits: {cpu: 500m}}
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: report
+              image: ghcr.io/acme/report:1.2.3
+              resources: {limits: {cpu: 100m, memory: 64Mi}}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+	writeChart(t, dir, map[string]string{"workloads.yaml": rendered})
+	schemas := filepath.Join(dir, "schemas")
+	if err := os.MkdirAll(schemas, 0o755); err != nil {
+		t.Fatalf("mkdir schemas: %v", err)
+	}
+	schema := `{"type": "object", "required": ["spec"]}`
+	if err := os.WriteFile(filepath.Join(schemas, "service-v1.json"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	kubeconformOpts := &kubeconform.Options{SchemaLocations: []string{filepath.Join(schemas, "{{ .ResourceKind }}{{ .KindSuffix }}.json")}}
+
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, Kubeconform: kubeconformOpts, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err := renderer.Render(fakeManifest("Application"))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := []string{
+		`AR039: rendered Deployment/web container "migrate" image registry.local:5000/migrate has no tag`,
+		`AR039: rendered Deployment/web container "app" image nginx:latest uses the latest tag`,
+		`AR040: rendered Deployment/web container "app" has no memory limit`,
+		`RENDER_KUBECONFORM: rendered Service/web failed schema validation: missing properties: 'spec'`,
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, f := range findings {
+		if got := f.RuleID + ": " + f.Message; got != want[i] {
+			t.Fatalf("finding %d: expected %q, got %q", i, want[i], got)
+		}
+		if f.FilePath != "app.yaml" || f.ResourceName != "demo" || f.ResourceKind != "Application" {
+			t.Fatalf("expected finding attributed to the Application, got %+v", f)
+		}
+	}
+
+	disabled := false
+	cfg := config.Config{Rules: map[string]config.RuleConfig{
+		"AR039": {Params: map[string]interface{}{"requireDigest": true}},
+		"AR040": {Enabled: &disabled},
+	}}
+	renderer, err = NewRenderer(cfg, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err = renderer.Render(fakeManifest("Application"))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 3 || findings[2].Message != `rendered CronJob/report container "report" image ghcr.io/acme/report:1.2.3 is not pinned to a digest` {
+		t.Fatalf("expected requireDigest to flag every tagged image, got %+v", findings)
+	}
+}
+
+func TestRendererBuildsKustomizationInProcess(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"chart/kustomization.yaml":  "namePrefix: prod-\nresources:\n  - ../shared/deployment.yaml\n",
+		"shared/deployment.yaml":    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers:\n        - name: app\n          image: nginx:1.25\n",
+		"remote/kustomization.yaml": "resources:\n  - https://github.com/acme/deploy//base?ref=v1\n",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	render := func(opts Options, path string) []types.Finding {
+		t.Helper()
+		opts.Enabled, opts.RepoRoot = true, dir
+		renderer, err := NewRenderer(config.Config{}, opts)
+		if err != nil {
+			t.Fatalf("new renderer: %v", err)
+		}
+		app := fakeManifest("Application")
+		app.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})["path"] = path
+		findings, err := renderer.Render(app)
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		return findings
+	}
+
+	findings := render(Options{}, "chart")
+	if len(findings) != 1 || findings[0].RuleID != "RENDER_KUSTOMIZE" || !strings.Contains(findings[0].Message, "security; file") {
+		t.Fatalf("expected the root-only load restrictor to reject ../shared, got %+v", findings)
+	}
+	findings = render(Options{KustomizeLoadRestrictor: "LoadRestrictionsNone"}, "chart")
+	if len(findings) != 1 || findings[0].Message != `rendered Deployment/prod-web container "app" has no cpu or memory limit` {
+		t.Fatalf("expected the overlay to build with namePrefix applied, got %+v", findings)
+	}
+	findings = render(Options{KustomizeDenyRemote: true}, "remote")
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "remote base https://github.com/acme/deploy//base?ref=v1 in") {
+		t.Fatalf("expected the remote base to be rejected, got %+v", findings)
+	}
+	if _, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir, KustomizeLoadRestrictor: "loose"}); err == nil {
+		t.Fatalf("expected an unknown load restrictor to be rejected")
+	}
+}
+
+func TestTemplateChartHonorsHelmSourceOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeChart(t, dir, map[string]string{
+		"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\ndata:\n" +
+			"  file: {{ .Values.file | quote }}\n  inline: {{ .Values.nested.inline | quote }}\n" +
+			"  kept: {{ .Values.nested.kept | quote }}\n  forced: {{ kindOf .Values.forced | quote }}\n" +
+			"  cert: {{ .Values.cert | quote }}\n",
+	})
+	chartDir := filepath.Join(dir, "chart")
+	for name, body := range map[string]string{
+		"values-prod.yaml": "name: prod\nfile: from-file\nnested: {inline: from-file, kept: from-file}\n",
+		"ca.pem":           "PEM",
+		"crds/crd.yaml":    "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.acme.io\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(chartDir, name)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(chartDir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	valuesObject := map[string]interface{}{"nested": map[string]interface{}{"inline": "from-object"}}
+	helmCfg := map[string]interface{}{
+		"valueFiles":              []interface{}{"values-prod.yaml", "values-missing.yaml"},
+		"ignoreMissingValueFiles": true,
+		"values":                  "nested: {inline: from-string}\n",
+		"valuesObject":            valuesObject,
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "forced", "value": "true", "forceString": true},
+			map[string]interface{}{"name": "nested.extra", "value": "1"},
+		},
+		"fileParameters": []interface{}{map[string]interface{}{"name": "cert", "path": "ca.pem"}},
+	}
+	output, err := renderer.templateChart(context.Background(), chartDir, helmCfg)
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	for _, want := range []string{"name: prod", `file: "from-file"`, `inline: "from-object"`, `kept: "from-file"`, `forced: "string"`, `cert: "PEM"`, "name: widgets.acme.io"} {
+		if !strings.Contains(string(output), want) {
+			t.Fatalf("expected %q in rendered output:\n%s", want, output)
+		}
+	}
+	if _, ok := valuesObject["nested"].(map[string]interface{})["extra"]; ok {
+		t.Fatalf("expected parameters not to modify the Application's valuesObject")
+	}
+
+	delete(helmCfg, "valuesObject")
+	helmCfg["skipCrds"] = true
+	output, err = renderer.templateChart(context.Background(), chartDir, helmCfg)
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	if !strings.Contains(string(output), `inline: "from-string"`) || strings.Contains(string(output), "widgets.acme.io") {
+		t.Fatalf("expected inline values and no CRDs, got:\n%s", output)
+	}
+
+	helmCfg["ignoreMissingValueFiles"] = false
+	if _, err := renderer.templateChart(context.Background(), chartDir, helmCfg); err == nil || !strings.Contains(err.Error(), "values-missing.yaml") {
+		t.Fatalf("expected a missing value file error, got %v", err)
+	}
+
+	helmCfg["valueFiles"] = []interface{}{"../../../etc/passwd"}
+	if _, err := renderer.templateChart(context.Background(), chartDir, helmCfg); err == nil || !strings.Contains(err.Error(), "is outside") {
+		t.Fatalf("expected a value file outside the repository to fail, got %v", err)
+	}
+	helmCfg["valueFiles"] = []interface{}{}
+	helmCfg["fileParameters"] = []interface{}{map[string]interface{}{"name": "cert", "path": "../../../etc/passwd"}}
+	if _, err := renderer.templateChart(context.Background(), chartDir, helmCfg); err == nil || !strings.Contains(err.Error(), "is outside") {
+		t.Fatalf("expected a file parameter outside the repository to fail, got %v", err)
+	}
+	if _, err := renderer.templateChart(context.Background(), chartDir, map[string]interface{}{"version": "v2"}); err == nil {
+		t.Fatalf("expected helm.version v2 to be rejected")
+	}
+}
+
+func TestTemplateChartUsesCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	writeChart(t, dir, map[string]string{
+		"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\ndata:\n" +
+			"  kube: {{ .Capabilities.KubeVersion.Version | quote }}\n" +
+			"  monitoring: {{ .Capabilities.APIVersions.Has \"monitoring.coreos.com/v1\" | quote }}\n",
+	})
+	chartDir := filepath.Join(dir, "chart")
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir, KubeVersion: "1.29.3", APIVersions: []string{"monitoring.coreos.com/v1"}})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	output, err := renderer.templateChart(context.Background(), chartDir, nil)
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	if !strings.Contains(string(output), `kube: "v1.29.3"`) || !strings.Contains(string(output), `monitoring: "true"`) {
+		t.Fatalf("expected the configured capabilities, got:\n%s", output)
+	}
+
+	helmCfg := map[string]interface{}{"kubeVersion": "1.27", "apiVersions": []interface{}{"acme.io/v1"}}
+	output, err = renderer.templateChart(context.Background(), chartDir, helmCfg)
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	if !strings.Contains(string(output), `kube: "v1.27.0"`) || !strings.Contains(string(output), `monitoring: "false"`) {
+		t.Fatalf("expected the source's capabilities to take precedence, got:\n%s", output)
+	}
+
+	if _, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir, KubeVersion: "latest"}); err == nil {
+		t.Fatalf("expected an invalid kube version to be rejected")
+	}
+}
+
+func TestBuildKustomizationAppliesSourceOptions(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"kustomization.yaml": "resources:\n  - deployment.yaml\n",
+		"deployment.yaml":    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 1\n  template:\n    spec:\n      containers:\n        - name: app\n          image: nginx\n",
+		"limits.yaml":        "- op: add\n  path: /spec/template/spec/containers/0/resources\n  value: {limits: {cpu: 100m}}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	options := map[string]interface{}{
+		"namePrefix":   "prod-",
+		"nameSuffix":   "-v2",
+		"images":       []interface{}{"nginx=ghcr.io/acme/nginx:1.25"},
+		"commonLabels": map[string]interface{}{"team": "web"},
+		"replicas":     []interface{}{map[string]interface{}{"name": "web", "count": "3"}},
+		"patches": []interface{}{map[string]interface{}{
+			"path":   "limits.yaml",
+			"target": map[string]interface{}{"kind": "Deployment"},
+		}},
+	}
+	output, err := renderer.buildKustomization(context.Background(), dir, options)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	for _, want := range []string{"name: prod-web-v2", "image: ghcr.io/acme/nginx:1.25", "team: web", "replicas: 3", "cpu: 100m"} {
+		if !strings.Contains(string(output), want) {
+			t.Fatalf("expected %q in rendered output:\n%s", want, output)
+		}
+	}
+
+	options["patches"] = []interface{}{map[string]interface{}{"path": "../../etc/passwd"}}
+	if _, err := renderer.buildKustomization(context.Background(), dir, options); err == nil || !strings.Contains(err.Error(), "is outside") {
+		t.Fatalf("expected a patch outside the repository to fail, got %v", err)
+	}
+	options["patches"] = nil
+
+	options["replicas"] = []interface{}{map[string]interface{}{"name": "web", "count": "three"}}
+	if _, err := renderer.buildKustomization(context.Background(), dir, options); err == nil || !strings.Contains(err.Error(), "invalid count three") {
+		t.Fatalf("expected an invalid replica count error, got %v", err)
+	}
+}
+
+func TestRendererResolvesRefValueFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	writeChart(t, dir, map[string]string{
+		"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\ndata:\n" +
+			"  local: {{ required \"local is required\" .Values.local }}\n  remote: {{ required \"remote is required\" .Values.remote }}\n",
+	})
+	if err := os.MkdirAll(filepath.Join(dir, "envs"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "envs", "prod.yaml"), []byte("local: from-repo-root\n"), 0o600); err != nil {
+		t.Fatalf("write values: %v", err)
+	}
+	remote := t.TempDir()
+	if err := os.WriteFile(filepath.Join(remote, "prod.yaml"), []byte("remote: from-clone\n"), 0o600); err != nil {
+		t.Fatalf("write remote values: %v", err)
+	}
+	for _, args := range [][]string{{"init", "--quiet"}, {"add", "."}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "values"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = remote
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	app := fakeManifest("Application")
+	spec := app.Object["spec"].(map[string]interface{})
+	delete(spec, "source")
+	spec["sources"] = []interface{}{
+		map[string]interface{}{
+			"repoURL": "https://example.com/repo.git",
+			"path":    "chart",
+			"helm":    map[string]interface{}{"valueFiles": []interface{}{"$values/envs/prod.yaml", "$config/prod.yaml"}},
+		},
+		map[string]interface{}{"repoURL": "git@example.com:repo", "ref": "values"},
+		map[string]interface{}{"repoURL": remote, "ref": "config"},
+	}
+	previous := gitutil.Protocols
+	gitutil.Protocols = []string{"file"}
+	defer func() { gitutil.Protocols = previous }()
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	defer renderer.Close()
+	if findings, err := renderer.Render(app); err != nil || len(findings) != 0 {
+		t.Fatalf("expected both ref value files to resolve, got %+v (%v)", findings, err)
+	}
+
+	spec["sources"].([]interface{})[2].(map[string]interface{})["ref"] = "other"
+	findings, err := renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, `value file $config/prod.yaml: no source with ref "config"`) {
+		t.Fatalf("expected an unknown ref to be reported, got %+v", findings)
+	}
+
+	spec["sources"].([]interface{})[0].(map[string]interface{})["helm"] = map[string]interface{}{"valueFiles": []interface{}{"$values/../../etc/passwd"}}
+	findings, err = renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "is outside") {
+		t.Fatalf("expected a value file outside the ref checkout to be rejected, got %+v", findings)
+	}
+}
+
+func TestRendererEvaluatesJsonnetDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"chart/main.jsonnet":       "local lib = import 'deployment.libsonnet';\nfunction(replicas) [lib.deployment(std.extVar('env') + '-web', replicas)]\n",
+		"chart/broken.jsonnet":     "{ kind: 'ConfigMap', \n",
+		"chart/skip.jsonnet":       "error 'excluded'\n",
+		"lib/deployment.libsonnet": "{ deployment(name, replicas):: { apiVersion: 'apps/v1', kind: 'Deployment', metadata: { name: name }, spec: { replicas: replicas, template: { spec: { containers: [{ name: 'app', image: 'nginx:latest', resources: { limits: { cpu: '1', memory: '1Gi' } } }] } } } } }\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	app := fakeManifest("Application")
+	app.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})["directory"] = map[string]interface{}{
+		"exclude": "skip.jsonnet",
+		"jsonnet": map[string]interface{}{
+			"extVars": []interface{}{map[string]interface{}{"name": "env", "value": "prod"}},
+			"tlas":    []interface{}{map[string]interface{}{"name": "replicas", "value": "3", "code": true}},
+			"libs":    []interface{}{"lib"},
+		},
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err := renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.RuleID+": "+f.Message)
+	}
+	if len(findings) != 2 ||
+		findings[0].RuleID != "RENDER_JSONNET" || !strings.Contains(findings[0].Message, "broken.jsonnet:2") ||
+		findings[1].RuleID != "AR039" || !strings.Contains(findings[1].Message, "Deployment/prod-web") {
+		t.Fatalf("expected a jsonnet error and an AR039 finding for the rendered Deployment, got:\n%s", strings.Join(got, "\n"))
+	}
+
+	output, err := renderer.evaluateJsonnet(filepath.Join(dir, "chart", "main.jsonnet"), getMap(app.Object, "spec", "source", "directory", "jsonnet"))
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if !strings.Contains(string(output), `"replicas":3`) {
+		t.Fatalf("expected the tla to set replicas, got:\n%s", output)
+	}
+}
+
+func TestRendererBoundsEachSource(t *testing.T) {
+	dir := t.TempDir()
+	overlayDir := filepath.Join(dir, "chart")
+	if err := os.Mkdir(overlayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), []byte("resources: [configmap.yaml]\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "configmap.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\n"), 0o600); err != nil {
+		t.Fatalf("write configmap: %v", err)
+	}
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	cases := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"timeout", Options{KustomizeBinary: script("slow", "exec sleep 5"), SourceTimeout: 100 * time.Millisecond}, "timed out after 100ms"},
+		{"binary output", Options{KustomizeBinary: script("noisy", "exec yes 'kind: ConfigMap'"), MaxOutputBytes: 1 << 16}, "rendered output exceeds the 65536 byte limit"},
+		{"in-process output", Options{MaxOutputBytes: 1}, "rendered output exceeds the 1 byte limit"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Enabled, tc.opts.RepoRoot = true, dir
+			renderer, err := NewRenderer(config.Config{}, tc.opts)
+			if err != nil {
+				t.Fatalf("new renderer: %v", err)
+			}
+			findings, err := renderer.Render(fakeManifest("Application"))
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if len(findings) != 1 || findings[0].RuleID != "RENDER_KUSTOMIZE" || !strings.HasSuffix(findings[0].Message, tc.want) {
+				t.Fatalf("expected a RENDER_KUSTOMIZE finding ending in %q, got %+v", tc.want, findings)
+			}
+		})
+	}
+}
+
+func TestRendererCapsConcurrentRenders(t *testing.T) {
+	dir := t.TempDir()
+	overlayDir := filepath.Join(dir, "chart")
+	if err := os.Mkdir(overlayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), []byte("resources: []\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	// The script fails when another render holds the lock directory.
+	lock := filepath.Join(dir, "lock")
+	kustomize := filepath.Join(dir, "kustomize")
+	body := fmt.Sprintf("#!/bin/sh\nmkdir %[1]q 2>/dev/null || { echo overlapping render >&2; exit 1; }\nsleep 0.05\nrmdir %[1]q\n", lock)
+	if err := os.WriteFile(kustomize, []byte(body), 0o755); err != nil {
+		t.Fatalf("write kustomize: %v", err)
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, KustomizeBinary: kustomize, RepoRoot: dir, Parallel: 1})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	var wg sync.WaitGroup
+	results := make([][]types.Finding, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = renderer.Render(fakeManifest("Application"))
+		}(i)
+	}
+	wg.Wait()
+	for _, findings := range results {
+		if len(findings) != 0 {
+			t.Fatalf("expected renders to run one at a time, got %+v", findings)
+		}
+	}
+}
+
+func TestRendererEmulatesConfigManagementPlugins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "chart"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	app := fakeManifest("Application")
+	source := app.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})
+	source["plugin"] = map[string]interface{}{
+		"name": "envsubst",
+		"env":  []interface{}{map[string]interface{}{"name": "IMAGE", "value": "nginx:latest"}},
+	}
+	command := `printf 'kind: Pod\nmetadata: {name: %s}\nspec:\n  containers: [{name: app, image: %s, resources: {limits: {cpu: 1, memory: 1Gi}}}]\n' "$ARGOCD_APP_NAME" "$ARGOCD_ENV_IMAGE"`
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir, CMPCommands: map[string]string{"envsubst": command, "*": "exit 3"}})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err := renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "AR039" || !strings.Contains(findings[0].Message, `Pod/demo container "app" image nginx:latest`) {
+		t.Fatalf("expected the emulated plugin output to be linted, got %+v", findings)
+	}
+
+	source["plugin"] = map[string]interface{}{}
+	findings, err = renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "RENDER_PLUGIN" || !strings.Contains(findings[0].Message, `plugin command "exit 3" failed`) {
+		t.Fatalf("expected a RENDER_PLUGIN failure from the fallback command, got %+v", findings)
+	}
+}
+
+func TestRenderErrorLocations(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"kustomization.yaml": "namePrefix: demo-\nresources:\n  - deployment.yaml\n  - missing.yaml\n",
+		"deployment.yaml":    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+		"broken.yaml":        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n bad: y\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	_, err = renderer.buildKustomization(context.Background(), dir, nil)
+	if err == nil {
+		t.Fatalf("expected a missing resource error")
+	}
+	kustomization := filepath.Join(dir, "kustomization.yaml")
+	if loc, ok := kustomizeErrorLocation(dir, err); !ok || loc != (types.Location{FilePath: kustomization, Line: 4}) {
+		t.Fatalf("expected the missing resource entry, got %+v (%v): %v", loc, ok, err)
+	}
+
+	if err := os.WriteFile(kustomization, []byte("resources:\n  - broken.yaml\n"), 0o600); err != nil {
+		t.Fatalf("write kustomization: %v", err)
+	}
+	_, err = renderer.buildKustomization(context.Background(), dir, nil)
+	if err == nil {
+		t.Fatalf("expected a malformed resource error")
+	}
+	if loc, ok := kustomizeErrorLocation(dir, err); !ok || loc != (types.Location{FilePath: filepath.Join(dir, "broken.yaml"), Line: 4}) {
+		t.Fatalf("expected the malformed resource, got %+v (%v): %v", loc, ok, err)
+	}
+
+	jsonnetFile := filepath.Join(dir, "main.jsonnet")
+	if err := os.WriteFile(jsonnetFile, []byte("local x = 1;\n{ a: error 'boom' }\n"), 0o600); err != nil {
+		t.Fatalf("write jsonnet: %v", err)
+	}
+	_, err = renderer.evaluateJsonnet(jsonnetFile, nil)
+	if err == nil {
+		t.Fatalf("expected a jsonnet runtime error")
+	}
+	if loc, ok := jsonnetErrorLocation(err); !ok || loc != (types.Location{FilePath: jsonnetFile, Line: 2, Column: 6}) {
+		t.Fatalf("expected the failing jsonnet expression, got %+v (%v): %v", loc, ok, err)
+	}
+
+	yamlErr := errors.New("YAML parse error on demo/charts/db/templates/svc.yaml: error converting YAML to JSON: yaml: line 2: mapping values are not allowed")
+	if loc, ok := helmErrorLocation("/charts/demo", yamlErr); !ok || loc != (types.Location{FilePath: "/charts/demo/charts/db/templates/svc.yaml"}) {
+		t.Fatalf("expected the subchart template without a line, got %+v (%v)", loc, ok)
+	}
+}
+
+func TestRendererSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	configMap := func(name, value string) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\ndata:\n  key: %s\n", name, value)
+	}
+	writeChart(t, dir, map[string]string{"a.yaml": configMap("a", "one"), "b.yaml": configMap("b", "one")})
+	snapshots := filepath.Join(dir, "snapshots")
+	render := func(write bool) []types.Finding {
+		t.Helper()
+		renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir, SnapshotDir: snapshots, WriteSnapshots: write})
+		if err != nil {
+			t.Fatalf("new renderer: %v", err)
+		}
+		findings, err := renderer.Render(fakeManifest("Application"))
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		return findings
+	}
+
+	if findings := render(false); len(findings) != 1 || findings[0].RuleID != "RENDER_SNAPSHOT" || !strings.Contains(findings[0].Message, "no render snapshot at") {
+		t.Fatalf("expected a missing snapshot finding, got %+v", findings)
+	}
+	if findings := render(true); len(findings) != 0 {
+		t.Fatalf("expected snapshots to be written without findings, got %+v", findings)
+	}
+	data, err := os.ReadFile(filepath.Join(snapshots, "application", "demo.yaml"))
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "---\napiVersion: v1\ndata:\n    key: one\nkind: ConfigMap\nmetadata:\n    name: a\n") {
+		t.Fatalf("expected a normalized snapshot, got:\n%s", data)
+	}
+	if findings := render(false); len(findings) != 0 {
+		t.Fatalf("expected no drift against a fresh snapshot, got %+v", findings)
+	}
+
+	writeChart(t, dir, map[string]string{"a.yaml": configMap("a", "two"), "c.yaml": configMap("c", "one")})
+	if err := os.Remove(filepath.Join(dir, "chart", "templates", "b.yaml")); err != nil {
+		t.Fatalf("remove template: %v", err)
+	}
+	findings := render(false)
+	if len(findings) != 1 || !strings.HasSuffix(findings[0].Message, ": changed ConfigMap/a; added ConfigMap/c; removed ConfigMap/b") {
+		t.Fatalf("expected drift naming each resource, got %+v", findings)
+	}
+
+	widget := func(apiVersion string) string {
+		return "apiVersion: " + apiVersion + "\nkind: Widget\nmetadata:\n  name: a\n"
+	}
+	writeChart(t, dir, map[string]string{"a.yaml": widget("apps/v1"), "c.yaml": widget("example.com/v1")})
+	render(true)
+	if findings := render(false); len(findings) != 0 {
+		t.Fatalf("expected kinds from different API groups to stay apart, got %+v", findings)
+	}
+	writeChart(t, dir, map[string]string{"a.yaml": "", "c.yaml": ""})
+	findings = render(false)
+	if len(findings) != 1 || !strings.HasSuffix(findings[0].Message, ": removed Widget.apps/a, Widget.example.com/a") {
+		t.Fatalf("expected drift when the render becomes empty, got %+v", findings)
+	}
+	render(true)
+	if data, err := os.ReadFile(filepath.Join(snapshots, "application", "demo.yaml")); err != nil || len(data) != 0 {
+		t.Fatalf("expected an empty snapshot to be written, got %q (%v)", data, err)
+	}
+
+	writeChart(t, dir, map[string]string{"a.yaml": "{{ fail \"broken\" }}"})
+	if findings := render(false); len(findings) != 1 || findings[0].RuleID != "RENDER_HELM" {
+		t.Fatalf("expected only the render failure when the chart breaks, got %+v", findings)
+	}
+}
+
+func TestRendererCollectsDirectorySources(t *testing.T) {
+	dir := t.TempDir()
+	pod := func(image string) string {
+		return "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n    - name: app\n      image: " + image + "\n      resources: {limits: {cpu: 1, memory: 1Gi}}\n"
+	}
+	for name, body := range map[string]string{
+		"chart/pod.yaml":          pod("nginx:latest"),
+		"chart/broken.yaml":       "kind: ConfigMap\nmetadata:\n\tname: tabbed\n",
+		"chart/prod/pod.yaml":     pod("nginx:1.27"),
+		"chart/prod/ignored.json": `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "ignored"}, "spec": {"containers": [{"name": "app", "image": "redis"}]}}`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	app := fakeManifest("Application")
+	source := app.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})
+	renderer, err := NewRenderer(config.Config{}, Options{Enabled: true, RepoRoot: dir})
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	findings, err := renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 2 ||
+		findings[0].RuleID != "RENDER_DIRECTORY" || !strings.Contains(findings[0].Message, "broken.yaml does not parse") ||
+		len(findings[0].RelatedLocations) != 1 || findings[0].RelatedLocations[0].Line != 3 ||
+		findings[1].RuleID != "AR039" || !strings.Contains(findings[1].Message, "nginx:latest") {
+		t.Fatalf("expected a parse failure and an AR039 finding for the top-level Pod, got %+v", findings)
+	}
+
+	source["directory"] = map[string]interface{}{"recurse": true, "include": "prod/*", "exclude": "*.json"}
+	findings, err = renderer.Render(app)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected include and exclude to narrow the source to the pinned Pod, got %+v", findings)
+	}
+}
@@ -0,0 +1,159 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+var snapshotRuleMeta = types.RuleMetadata{
+	ID:              "RENDER_SNAPSHOT",
+	Description:     "Rendered output must match the committed render snapshot",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo: []types.ResourceKind{
+		types.ResourceKindApplication,
+		types.ResourceKindApplicationSet,
+	},
+	Category: "render",
+	Enabled:  true,
+}
+
+// snapshotPath names the snapshot of m below dir:
+// <kind>/[<namespace>/]<name>.yaml.
+func snapshotPath(dir string, m *manifest.Manifest) string {
+	parts := []string{dir, strings.ToLower(m.Kind)}
+	if m.Namespace != "" {
+		parts = append(parts, m.Namespace)
+	}
+	return filepath.Join(append(parts, m.Name+".yaml")...)
+}
+
+// snapshotResource is one rendered resource of a snapshot.
+type snapshotResource struct {
+	key  string
+	data []byte
+}
+
+// normalizeSnapshot orders the rendered resources by kind, API group,
+// namespace, and name and re-encodes them with sorted keys, so snapshots only
+// change when the resources do.
+func normalizeSnapshot(outputs [][]byte) ([]snapshotResource, error) {
+	var resources []snapshotResource
+	for _, output := range outputs {
+		for _, obj := range decodeResources(output) {
+			key := snapshotKey(obj)
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, snapshotResource{key: key, data: data})
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].key < resources[j].key })
+	return resources, nil
+}
+
+// snapshotKey identifies a rendered resource as <kind>[.<group>]/[<namespace>/]<name>,
+// so that kinds sharing a name across API groups stay apart.
+func snapshotKey(obj map[string]interface{}) string {
+	kind := getString(obj, "kind")
+	if group, _, ok := strings.Cut(getString(obj, "apiVersion"), "/"); ok && group != "" {
+		kind += "." + group
+	}
+	name := getString(obj, "metadata", "name")
+	if ns := getString(obj, "metadata", "namespace"); ns != "" {
+		return kind + "/" + ns + "/" + name
+	}
+	return kind + "/" + name
+}
+
+func encodeSnapshot(resources []snapshotResource) []byte {
+	var out bytes.Buffer
+	for _, res := range resources {
+		out.WriteString("---\n")
+		out.Write(res.data)
+	}
+	return out.Bytes()
+}
+
+// snapshotFindings writes the snapshot of m when Options.WriteSnapshots is
+// set, and otherwise compares the rendered outputs with the committed
+// snapshot, reporting added, removed, and changed resources.
+func (r *Renderer) snapshotFindings(m *manifest.Manifest, outputs [][]byte) ([]types.Finding, error) {
+	cfg, err := r.cfg.Resolve(snapshotRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled && !r.writeSnapshots {
+		return nil, nil
+	}
+	current, err := normalizeSnapshot(outputs)
+	if err != nil {
+		return nil, err
+	}
+	path := snapshotPath(r.snapshotDir, m)
+	if r.writeSnapshots {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("write render snapshot: %w", err)
+		}
+		if err := os.WriteFile(path, encodeSnapshot(current), 0o644); err != nil {
+			return nil, fmt.Errorf("write render snapshot: %w", err)
+		}
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		msg := fmt.Sprintf("no render snapshot at %s; record one with argocd-lint render --write-snapshots %s", path, r.snapshotDir)
+		return []types.Finding{childFinding(cfg, m, msg)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read render snapshot: %w", err)
+	}
+	committed, err := normalizeSnapshot([][]byte{data})
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[string][]byte, len(committed))
+	for _, res := range committed {
+		previous[res.key] = res.data
+	}
+	var added, changed, removed []string
+	seen := make(map[string]bool, len(current))
+	for _, res := range current {
+		seen[res.key] = true
+		old, ok := previous[res.key]
+		switch {
+		case !ok:
+			added = append(added, res.key)
+		case !bytes.Equal(old, res.data):
+			changed = append(changed, res.key)
+		}
+	}
+	for _, res := range committed {
+		if !seen[res.key] {
+			removed = append(removed, res.key)
+		}
+	}
+	if len(added)+len(changed)+len(removed) == 0 {
+		return nil, nil
+	}
+	var parts []string
+	for _, group := range []struct {
+		label string
+		keys  []string
+	}{{"changed", changed}, {"added", added}, {"removed", removed}} {
+		if len(group.keys) > 0 {
+			parts = append(parts, group.label+" "+strings.Join(group.keys, ", "))
+		}
+	}
+	msg := fmt.Sprintf("rendered output differs from snapshot %s: %s", path, strings.Join(parts, "; "))
+	return []types.Finding{childFinding(cfg, m, msg)}, nil
+}
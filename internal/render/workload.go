@@ -0,0 +1,196 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+var (
+	kubeconformRuleMeta = types.RuleMetadata{
+		ID:              "RENDER_KUBECONFORM",
+		Description:     "Rendered resources must pass kubeconform schema validation",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo: []types.ResourceKind{
+			types.ResourceKindApplication,
+			types.ResourceKindApplicationSet,
+		},
+		Category: "validation",
+		Enabled:  true,
+	}
+
+	pinnedImageRuleMeta = types.RuleMetadata{
+		ID:              "AR039",
+		Description:     "Rendered containers should pin images to a tag other than latest or to a digest",
+		Rationale:       "latest and untagged images change without a commit, so the cluster can run code Git does not describe.",
+		Example:         "image: ghcr.io/example/api:1.4.2",
+		Remediation:     "Pin container images to a version tag or a digest.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo: []types.ResourceKind{
+			types.ResourceKindApplication,
+			types.ResourceKindApplicationSet,
+		},
+		HelpURL:  "https://kubernetes.io/docs/concepts/containers/images/#image-names",
+		Category: "workload",
+		Enabled:  true,
+	}
+
+	resourceLimitsRuleMeta = types.RuleMetadata{
+		ID:              "AR040",
+		Description:     "Rendered containers should set CPU and memory limits",
+		Rationale:       "Containers without limits can starve other workloads on the node.",
+		Example:         "resources:\n  limits:\n    cpu: 500m\n    memory: 256Mi",
+		Remediation:     "Set resources.limits.cpu and resources.limits.memory on every container.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo: []types.ResourceKind{
+			types.ResourceKindApplication,
+			types.ResourceKindApplicationSet,
+		},
+		HelpURL:  "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/",
+		Category: "workload",
+		Enabled:  true,
+	}
+)
+
+// defaultRequiredLimits is the AR040 resources param default.
+var defaultRequiredLimits = []string{"cpu", "memory"}
+
+// podSpecPaths locates the pod spec inside each workload kind.
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// workloadFindings runs AR039 and AR040 against the containers of rendered
+// workloads. Findings point at the owning Application and name the child
+// resource and container in the message.
+func (r *Renderer) workloadFindings(m *manifest.Manifest, objects []map[string]interface{}) ([]types.Finding, error) {
+	images, err := r.cfg.Resolve(pinnedImageRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := r.cfg.Resolve(resourceLimitsRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !images.Enabled && !limits.Enabled {
+		return nil, nil
+	}
+	requireDigest, _ := images.BoolParam("requireDigest")
+	required := defaultRequiredLimits
+	if v, ok := limits.StringSliceParam("resources"); ok {
+		required = v
+	}
+
+	var findings []types.Finding
+	for _, obj := range objects {
+		kind := getString(obj, "kind")
+		path, ok := podSpecPaths[kind]
+		if !ok {
+			continue
+		}
+		child := kind + "/" + getString(obj, "metadata", "name")
+		podSpec := getMap(obj, path...)
+		for _, field := range []string{"initContainers", "containers"} {
+			for _, item := range getSlice(podSpec, field) {
+				container, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name := getString(container, "name")
+				if images.Enabled {
+					if problem := imagePinProblem(getString(container, "image"), requireDigest); problem != "" {
+						findings = append(findings, childFinding(images, m, fmt.Sprintf("rendered %s container %q %s", child, name, problem)))
+					}
+				}
+				if limits.Enabled {
+					var missing []string
+					for _, resource := range required {
+						if _, ok := getMap(container, "resources", "limits")[resource]; !ok {
+							missing = append(missing, resource)
+						}
+					}
+					if len(missing) > 0 {
+						findings = append(findings, childFinding(limits, m, fmt.Sprintf("rendered %s container %q has no %s limit", child, name, strings.Join(missing, " or "))))
+					}
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// imagePinProblem describes why image is not pinned, or returns "" when it
+// is. A registry port is not mistaken for a tag.
+func imagePinProblem(image string, requireDigest bool) string {
+	image = strings.TrimSpace(image)
+	switch {
+	case image == "":
+		return "has no image"
+	case strings.Contains(image, "@"):
+		return ""
+	case requireDigest:
+		return fmt.Sprintf("image %s is not pinned to a digest", image)
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	idx := strings.LastIndex(name, ":")
+	if idx < 0 {
+		return fmt.Sprintf("image %s has no tag", image)
+	}
+	if name[idx+1:] == "latest" {
+		return fmt.Sprintf("image %s uses the latest tag", image)
+	}
+	return ""
+}
+
+// kubeconformFindings validates rendered output with the embedded
+// kubeconform validator when one is configured. Resources without a
+// published schema, such as custom resources, are skipped rather than
+// reported.
+func (r *Renderer) kubeconformFindings(ctx context.Context, m *manifest.Manifest, output []byte) ([]types.Finding, error) {
+	if r.kubeconform == nil || len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+	cfg, err := r.cfg.Resolve(kubeconformRuleMeta, m.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	started := time.Now()
+	failures, err := r.kubeconform.Validate(ctx, m.Name, output)
+	r.logger.Debug("kubeconform validate", "resource", m.Kind+"/"+m.Name, "duration", time.Since(started), "error", err)
+	if err != nil {
+		return nil, fmt.Errorf("kubeconform for %s: %w", m.Name, err)
+	}
+	var findings []types.Finding
+	for _, failure := range failures {
+		findings = append(findings, childFinding(cfg, m, fmt.Sprintf("rendered %s/%s failed schema validation: %s", failure.Kind, failure.Name, failure.Message)))
+	}
+	return findings, nil
+}
+
+// childFinding attributes a finding about a rendered resource to the
+// Application or ApplicationSet that produced it.
+func childFinding(cfg types.ConfiguredRule, m *manifest.Manifest, msg string) types.Finding {
+	builder := types.FindingBuilder{
+		Rule:         cfg,
+		FilePath:     m.FilePath,
+		Line:         m.MetadataLine,
+		ResourceName: m.Name,
+		ResourceKind: m.Kind,
+	}
+	return builder.NewFinding(msg, cfg.Severity)
+}
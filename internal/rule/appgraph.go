@@ -0,0 +1,139 @@
+package rule
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleAppOfAppsCycles() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR037",
+		Description:     "App-of-apps hierarchies must not contain cycles",
+		Rationale:       "A cycle of Applications that deploy each other never settles and makes deletion cascade unpredictably.",
+		Remediation:     "Remove the Application that points back up the hierarchy.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/cluster-bootstrapping/",
+		Category:        "consistency",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplication) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			if ctx.RepoRoot == "" {
+				return nil
+			}
+			ctx.appGraphOnce.Do(func() { ctx.appGraph = buildAppGraph(ctx.RepoRoot, ctx.RepoURL, ctx.Manifests) })
+			cycle := findAppCycle(ctx.appGraph, m.Name)
+			if cycle == nil {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			return []types.Finding{builder.NewFinding(fmt.Sprintf("app-of-apps cycle %s; the Applications manage each other and syncs deadlock", strings.Join(cycle, " -> ")), cfg.Severity)}
+		},
+	}
+}
+
+// buildAppGraph maps each Application name to the Applications declared in
+// its local source directories, following discovered children transitively.
+// Sources in a repository other than repoURL, the origin of root, are not on
+// disk and are skipped.
+func buildAppGraph(root, repoURL string, manifests []*manifest.Manifest) map[string][]string {
+	graph := map[string][]string{}
+	dirCache := map[string][]*manifest.Manifest{}
+	var queue []*manifest.Manifest
+	for _, m := range manifests {
+		if m.Kind == string(types.ResourceKindApplication) {
+			queue = append(queue, m)
+		}
+	}
+	for len(queue) > 0 {
+		app := queue[0]
+		queue = queue[1:]
+		if _, seen := graph[app.Name]; seen {
+			continue
+		}
+		children := []string{}
+		for _, entry := range applicationSources(app) {
+			path := strings.TrimSpace(getStringMap(entry.source, "path"))
+			if path == "" || strings.Contains(path, "{{") || getStringMap(entry.source, "chart") != "" {
+				continue
+			}
+			if repo := getStringMap(entry.source, "repoURL"); repoURL != "" && repo != "" && !gitutil.SameRepo(repo, repoURL) {
+				continue
+			}
+			directory := loader.DirectorySourceOptions(entry.source)
+			dir := filepath.Clean(filepath.Join(root, path))
+			key := fmt.Sprintf("%s|%+v", dir, directory)
+			found, ok := dirCache[key]
+			if !ok {
+				found = applicationsInDir(dir, directory)
+				dirCache[key] = found
+			}
+			for _, child := range found {
+				children = append(children, child.Name)
+				queue = append(queue, child)
+			}
+		}
+		sort.Strings(children)
+		graph[app.Name] = children
+	}
+	return graph
+}
+
+// applicationsInDir returns the Applications a directory source at dir
+// deploys, honouring its recurse, include, and exclude options.
+func applicationsInDir(dir string, directory loader.DirectorySource) []*manifest.Manifest {
+	files, _ := loader.DirectorySourceFiles(dir, directory)
+	var apps []*manifest.Manifest
+	for _, file := range files {
+		if strings.HasSuffix(strings.ToLower(file), ".jsonnet") {
+			continue
+		}
+		docs, err := manifest.Parser{}.ParseFile(file)
+		if err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			if doc.Kind == string(types.ResourceKindApplication) && doc.Name != "" {
+				apps = append(apps, doc)
+			}
+		}
+	}
+	return apps
+}
+
+// findAppCycle returns the shortest path from start back to itself, or nil.
+func findAppCycle(graph map[string][]string, start string) []string {
+	parent := map[string]string{}
+	queue := []string{start}
+	visited := map[string]bool{start: true}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range graph[node] {
+			if child == start {
+				path := []string{start}
+				for cur := node; cur != start; cur = parent[cur] {
+					path = append([]string{cur}, path...)
+				}
+				return append([]string{start}, path...)
+			}
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			parent[child] = node
+			queue = append(queue, child)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package rule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+func writeAppManifest(t *testing.T, path, name, sourcePath string) {
+	t.Helper()
+	content := "apiVersion: argoproj.io/v1alpha1\nkind: Application\nmetadata:\n  name: " + name + "\nspec:\n  project: platform\n  source:\n    repoURL: https://git.example.com/platform.git\n    targetRevision: v1.0.0\n    path: " + sourcePath + "\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+}
+
+func TestRuleAppOfAppsCycles(t *testing.T) {
+	root := t.TempDir()
+	writeAppManifest(t, filepath.Join(root, "root.yaml"), "root", "apps")
+	writeAppManifest(t, filepath.Join(root, "apps", "platform.yaml"), "platform", "platform")
+	writeAppManifest(t, filepath.Join(root, "platform", "addons.yaml"), "addons", "apps")
+	writeAppManifest(t, filepath.Join(root, "platform", "nested", "leaf.yaml"), "leaf", "workloads")
+
+	docs, err := manifest.Parser{}.ParseFile(filepath.Join(root, "root.yaml"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rootApp := docs[0]
+	rl := ruleAppOfAppsCycles()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, rootApp.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	if findings := rl.Check(rootApp, &Context{Config: cfg, Manifests: docs}, configured); len(findings) != 0 {
+		t.Fatalf("expected no findings without a repo root, got %+v", findings)
+	}
+
+	ctx := &Context{Config: cfg, Manifests: docs, RepoRoot: root}
+	if findings := rl.Check(rootApp, ctx, configured); len(findings) != 0 {
+		t.Fatalf("expected root to sit outside the cycle, got %+v", findings)
+	}
+	platform := &manifest.Manifest{Kind: rootApp.Kind, Name: "platform", FilePath: "apps/platform.yaml"}
+	findings := rl.Check(platform, ctx, configured)
+	if len(findings) != 1 || findings[0].Message != "app-of-apps cycle platform -> addons -> platform; the Applications manage each other and syncs deadlock" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if ctx.appGraph["platform"] == nil || len(ctx.appGraph["platform"]) != 1 {
+		t.Fatalf("expected non-recursive directory source to skip nested manifests, got %v", ctx.appGraph["platform"])
+	}
+
+	excluded := &manifest.Manifest{Kind: rootApp.Kind, Name: "excluded", Object: map[string]interface{}{
+		"spec": map[string]interface{}{"source": map[string]interface{}{
+			"path":      "platform",
+			"directory": map[string]interface{}{"exclude": "addons.yaml"},
+		}},
+	}}
+	if graph := buildAppGraph(root, "", []*manifest.Manifest{excluded}); len(graph["excluded"]) != 0 {
+		t.Fatalf("expected directory.exclude to hide addons.yaml, got %v", graph["excluded"])
+	}
+
+	// A source in another repository names a path there, not in this checkout.
+	other := t.TempDir()
+	writeAppManifest(t, filepath.Join(other, "apps", "root.yaml"), "root", "apps")
+	docs, err = manifest.Parser{}.ParseFile(filepath.Join(other, "apps", "root.yaml"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	ctx = &Context{Config: cfg, Manifests: docs, RepoRoot: other, RepoURL: "https://github.com/me/team-apps.git"}
+	if findings := rl.Check(docs[0], ctx, configured); len(findings) != 0 {
+		t.Fatalf("expected a source in another repository to be skipped, got %+v", findings)
+	}
+	ctx = &Context{Config: cfg, Manifests: docs, RepoRoot: other, RepoURL: "git@git.example.com:platform.git"}
+	if findings := rl.Check(docs[0], ctx, configured); len(findings) != 1 {
+		t.Fatalf("expected a source in the checked-out repository to be followed, got %+v", findings)
+	}
+}
@@ -0,0 +1,307 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleApplicationSetRollingSync() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR024",
+		Description:     "ApplicationSet progressive sync strategies must declare valid RollingSync steps",
+		Rationale:       "Invalid RollingSync steps stop the ApplicationSet controller from progressing the rollout.",
+		Example:         "strategy:\n  type: RollingSync\n  rollingSync:\n    steps:\n      - matchExpressions:\n          - key: env\n            operator: In\n            values: [staging]",
+		Remediation:     "Give every step matchExpressions with a key, a valid operator, and values, and disable automated sync in the template.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/applicationset/Progressive-Syncs/",
+		Category:        "operations",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplicationSet) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			strategy := getMap(m.Object, "spec", "strategy")
+			if len(strategy) == 0 {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			switch strategyType := strings.TrimSpace(getStringMap(strategy, "type")); strategyType {
+			case "AllAtOnce":
+			case "RollingSync":
+				steps := getSlice(strategy, "rollingSync", "steps")
+				if len(steps) == 0 {
+					finding := builder.NewFinding("spec.strategy.type is RollingSync but rollingSync.steps is empty", cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Declare rollout steps",
+							Description: "Each step selects generated Applications by label and is synced before the next one starts.",
+							Patch:       "rollingSync:\n  steps:\n    - matchExpressions:\n        - key: envLabel\n          operator: In\n          values:\n            - staging\n    - matchExpressions:\n        - key: envLabel\n          operator: In\n          values:\n            - prod\n      maxUpdate: 25%",
+							Path:        "$.spec.strategy.rollingSync.steps",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				for idx, raw := range steps {
+					step, ok := raw.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					findings = append(findings, checkRollingSyncStep(builder, cfg, idx, step)...)
+				}
+			default:
+				findings = append(findings, builder.NewFinding(fmt.Sprintf("spec.strategy.type '%s' must be AllAtOnce or RollingSync", strategyType), cfg.Severity))
+			}
+			if !automatedSyncEnabled(getMap(m.Object, "spec", "template", "spec", "syncPolicy")) {
+				finding := builder.NewFinding("spec.strategy is declared but the template does not enable automated sync; steps only progress when Applications sync", types.SeverityWarn)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Enable automated sync in the template",
+						Description: "The ApplicationSet controller drives progressive syncs through the generated Applications' automated policy.",
+						Patch:       "syncPolicy:\n  automated:\n    prune: true\n    selfHeal: true",
+						Path:        "$.spec.template.spec.syncPolicy.automated",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+// automatedSyncEnabled reports whether syncPolicy enables automated sync.
+// An empty automated block enables it with the defaults; only a missing or
+// null block, or automated.enabled false, leaves it off.
+func automatedSyncEnabled(syncPolicy map[string]interface{}) bool {
+	automated, ok := syncPolicy["automated"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	enabled, ok := automated["enabled"].(bool)
+	return !ok || enabled
+}
+
+// checkRollingSyncStep validates the selector and maxUpdate of one RollingSync step.
+func checkRollingSyncStep(builder types.FindingBuilder, cfg types.ConfiguredRule, idx int, step map[string]interface{}) []types.Finding {
+	path := fmt.Sprintf("$.spec.strategy.rollingSync.steps[%d]", idx)
+	var findings []types.Finding
+	expressions := getSlice(step, "matchExpressions")
+	if len(expressions) == 0 {
+		finding := builder.NewFinding(fmt.Sprintf("rollingSync.steps[%d] has no matchExpressions; the step selects no Applications", idx), cfg.Severity)
+		finding.Suggestions = []types.Suggestion{
+			{
+				Title:       "Select Applications by label",
+				Description: "Steps match labels set on the generated Applications.",
+				Patch:       "matchExpressions:\n  - key: envLabel\n    operator: In\n    values:\n      - staging",
+				Path:        path + ".matchExpressions",
+			},
+		}
+		findings = append(findings, finding)
+	}
+	for exprIdx, raw := range expressions {
+		expr, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(getStringMap(expr, "key")) == "" {
+			findings = append(findings, builder.NewFinding(fmt.Sprintf("rollingSync.steps[%d].matchExpressions[%d].key is empty", idx, exprIdx), cfg.Severity))
+		}
+		if op := strings.TrimSpace(getStringMap(expr, "operator")); op != "In" && op != "NotIn" {
+			findings = append(findings, builder.NewFinding(fmt.Sprintf("rollingSync.steps[%d].matchExpressions[%d].operator '%s' must be In or NotIn", idx, exprIdx, op), cfg.Severity))
+		}
+	}
+	if raw, ok := step["maxUpdate"]; ok && !validMaxUpdate(raw) {
+		finding := builder.NewFinding(fmt.Sprintf("rollingSync.steps[%d].maxUpdate '%v' must be a non-negative integer or a percentage such as 25%%", idx, raw), cfg.Severity)
+		finding.Suggestions = []types.Suggestion{
+			{
+				Title:       "Use an integer or percentage",
+				Description: "maxUpdate limits how many Applications in the step sync at once.",
+				Patch:       "maxUpdate: 25%",
+				Path:        path + ".maxUpdate",
+			},
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func validMaxUpdate(raw interface{}) bool {
+	if value, ok := raw.(string); ok {
+		percent, isPercent := strings.CutSuffix(strings.TrimSpace(value), "%")
+		if !isPercent {
+			return false
+		}
+		n, err := strconv.Atoi(percent)
+		return err == nil && n >= 0 && n <= 100
+	}
+	n, err := strconv.Atoi(fmt.Sprint(raw))
+	return err == nil && n >= 0
+}
+
+func ruleApplicationSetPreserveResources() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR025",
+		Description:     "ApplicationSets generating finalized Applications should preserve resources on deletion",
+		Rationale:       "When an ApplicationSet is deleted its Applications are deleted too, and their finalizers then delete every deployed resource.",
+		Example:         "syncPolicy:\n  preserveResourcesOnDeletion: true",
+		Remediation:     "Set syncPolicy.preserveResourcesOnDeletion: true on the ApplicationSet, or drop the finalizer from the template.",
+		DefaultSeverity: types.SeverityInfo,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/applicationset/Application-Deletion/",
+		Category:        "safety",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplicationSet) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			if preserve, _ := getMap(m.Object, "spec", "syncPolicy")["preserveResourcesOnDeletion"].(bool); preserve {
+				return nil
+			}
+			finalized := false
+			for _, item := range getSlice(m.Object, "spec", "template", "metadata", "finalizers") {
+				if str, ok := item.(string); ok && strings.HasPrefix(str, "resources-finalizer.argocd.argoproj.io") {
+					finalized = true
+					break
+				}
+			}
+			if !finalized {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			finding := builder.NewFinding("generated Applications carry resources-finalizer.argocd.argoproj.io; deleting the ApplicationSet cascades to every deployed workload", cfg.Severity)
+			finding.Suggestions = []types.Suggestion{
+				{
+					Title:       "Preserve resources on deletion",
+					Description: "Keep deployed resources when the ApplicationSet or its generated Applications are deleted.",
+					Patch:       "spec:\n  syncPolicy:\n    preserveResourcesOnDeletion: true",
+					Path:        "$.spec.syncPolicy.preserveResourcesOnDeletion",
+				},
+			}
+			return []types.Finding{finding}
+		},
+	}
+}
+
+func ruleGitGeneratorRevisionPinned() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR026",
+		Description:     "ApplicationSet git generator revisions must be pinned to an immutable value",
+		Rationale:       "Git generators that follow a branch add and remove Applications as soon as the branch changes.",
+		Example:         "generators:\n  - git:\n      repoURL: https://github.com/example/deploy.git\n      revision: v1.4.2",
+		Remediation:     "Pin the generator revision to a tag or commit SHA.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/applicationset/Generators-Git/",
+		Category:        "best-practice",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplicationSet) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			return checkGitGenerators(builder, getSlice(m.Object, "spec", "generators"), "$.spec.generators")
+		},
+	}
+}
+
+// checkGitGenerators walks generators, including those nested in matrix and
+// merge generators, and checks every git generator revision.
+func checkGitGenerators(builder types.FindingBuilder, generators []interface{}, path string) []types.Finding {
+	var findings []types.Finding
+	for idx, raw := range generators {
+		generator, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		genPath := fmt.Sprintf("%s[%d]", path, idx)
+		if git := getMap(generator, "git"); len(git) > 0 {
+			revPath := genPath + ".git.revision"
+			rev := strings.TrimSpace(getStringMap(git, "revision"))
+			switch {
+			case rev == "":
+				finding := builder.NewFinding("git generator revision is empty and follows the default branch; pin to a tag or commit", types.SeverityWarn)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Pin revision to an immutable reference",
+						Description: "Set revision to a specific tag or commit so generated Applications only change on purpose.",
+						Patch:       "revision: <tag-or-commit>",
+						Path:        revPath,
+					},
+				}
+				findings = append(findings, finding)
+			case !strings.Contains(rev, "{{"):
+				findings = append(findings, checkRevisionValue(builder, "git generator revision", "", rev, revPath)...)
+			}
+		}
+		for _, nested := range []string{"matrix", "merge"} {
+			if children := getSlice(generator, nested, "generators"); len(children) > 0 {
+				findings = append(findings, checkGitGenerators(builder, children, genPath+"."+nested+".generators")...)
+			}
+		}
+	}
+	return findings
+}
+
+func ruleIgnoreApplicationDifferencesScoped() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR033",
+		Description:     "ApplicationSet ignoreApplicationDifferences entries must be tightly scoped",
+		Rationale:       "Broad ignoreApplicationDifferences entries let manual edits to generated Applications persist unnoticed.",
+		Example:         "ignoreApplicationDifferences:\n  - name: api\n    jsonPointers:\n      - /spec/source/targetRevision",
+		Remediation:     "Name the Applications and list only the fields that are meant to be edited by hand.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/applicationset/Controlling-Resource-Modification/#ignore-certain-changes-to-applications",
+		Category:        "drift",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplicationSet) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for idx, raw := range getSlice(m.Object, "spec", "ignoreApplicationDifferences") {
+				path := fmt.Sprintf("$.spec.ignoreApplicationDifferences[%d]", idx)
+				entry, ok := raw.(map[string]interface{})
+				if !ok {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("ignoreApplicationDifferences[%d] is not an object", idx), cfg.Severity))
+					continue
+				}
+				if name := strings.TrimSpace(getStringMap(entry, "name")); strings.Contains(name, "*") {
+					finding := builder.NewFinding(fmt.Sprintf("ignoreApplicationDifferences[%d].name '%s' is a wildcard; omit name to target every Application or name one explicitly", idx, name), types.SeverityError)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Name the generated Application",
+							Description: "Scope the entry to the Application whose fields may diverge from the template.",
+							Patch:       "name: <application-name>",
+							Path:        path + ".name",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				if len(getSlice(entry, "jsonPointers")) == 0 && len(getSlice(entry, "jqPathExpressions")) == 0 {
+					finding := builder.NewFinding(fmt.Sprintf("ignoreApplicationDifferences[%d] lacks jsonPointers or jqPathExpressions", idx), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "List the fields to preserve",
+							Description: "Only the listed fields of generated Applications are left untouched by the controller.",
+							Patch:       "jsonPointers:\n  - /spec/source/targetRevision",
+							Path:        path + ".jsonPointers",
+						},
+					}
+					findings = append(findings, finding)
+				}
+			}
+			return findings
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func applicationSetManifest(spec map[string]interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "demo",
+		Object:   map[string]interface{}{"spec": spec},
+	}
+}
+
+func TestRuleApplicationSetRollingSync(t *testing.T) {
+	appSet := applicationSetManifest(map[string]interface{}{
+		"strategy": map[string]interface{}{
+			"type": "RollingSync",
+			"rollingSync": map[string]interface{}{
+				"steps": []interface{}{
+					map[string]interface{}{
+						"matchExpressions": []interface{}{
+							map[string]interface{}{"key": "env", "operator": "In", "values": []interface{}{"staging"}},
+						},
+						"maxUpdate": 1,
+					},
+					map[string]interface{}{
+						"matchExpressions": []interface{}{
+							map[string]interface{}{"key": "env", "operator": "Exists"},
+						},
+						"maxUpdate": "25%",
+					},
+					map[string]interface{}{"maxUpdate": "two"},
+				},
+			},
+		},
+	})
+	findings := checkRule(t, ruleApplicationSetRollingSync(), config.Config{}, appSet)
+	want := []string{
+		"steps[1].matchExpressions[0].operator 'Exists'",
+		"steps[2] has no matchExpressions",
+		"steps[2].maxUpdate 'two'",
+		"does not enable automated sync",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if findings[3].Severity != types.SeverityWarn {
+		t.Fatalf("expected automated sync finding to warn, got %s", findings[3].Severity)
+	}
+
+	appSet = applicationSetManifest(map[string]interface{}{
+		"strategy": map[string]interface{}{"type": "AllAtOnce"},
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"syncPolicy": map[string]interface{}{"automated": map[string]interface{}{"prune": true}},
+		}},
+	})
+	if findings := checkRule(t, ruleApplicationSetRollingSync(), config.Config{}, appSet); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+
+	for automated, wantFinding := range map[string]bool{"empty": false, "disabled": true} {
+		block := map[string]interface{}{}
+		if automated == "disabled" {
+			block["enabled"] = false
+		}
+		appSet = applicationSetManifest(map[string]interface{}{
+			"strategy": map[string]interface{}{"type": "AllAtOnce"},
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"syncPolicy": map[string]interface{}{"automated": block},
+			}},
+		})
+		if findings := checkRule(t, ruleApplicationSetRollingSync(), config.Config{}, appSet); (len(findings) == 1) != wantFinding {
+			t.Fatalf("%s automated block: unexpected findings %+v", automated, findings)
+		}
+	}
+}
+
+func TestRuleApplicationSetPreserveResources(t *testing.T) {
+	template := map[string]interface{}{
+		"metadata": map[string]interface{}{"finalizers": []interface{}{"resources-finalizer.argocd.argoproj.io/background"}},
+	}
+	appSet := applicationSetManifest(map[string]interface{}{"template": template})
+	findings := checkRule(t, ruleApplicationSetPreserveResources(), config.Config{}, appSet)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityInfo {
+		t.Fatalf("expected one info finding, got %+v", findings)
+	}
+	var prod config.Config
+	if err := prod.ApplyProfiles("prod"); err != nil {
+		t.Fatalf("apply profile: %v", err)
+	}
+	findings = checkRule(t, ruleApplicationSetPreserveResources(), prod, appSet)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityWarn {
+		t.Fatalf("expected prod profile to warn, got %+v", findings)
+	}
+
+	appSet = applicationSetManifest(map[string]interface{}{
+		"template":   template,
+		"syncPolicy": map[string]interface{}{"preserveResourcesOnDeletion": true},
+	})
+	if findings := checkRule(t, ruleApplicationSetPreserveResources(), config.Config{}, appSet); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestRuleGitGeneratorRevisionPinned(t *testing.T) {
+	appSet := applicationSetManifest(map[string]interface{}{
+		"generators": []interface{}{
+			map[string]interface{}{"git": map[string]interface{}{"repoURL": "https://example.com/repo.git", "revision": "HEAD"}},
+			map[string]interface{}{"git": map[string]interface{}{"repoURL": "https://example.com/repo.git", "revision": "v1.2.0"}},
+			map[string]interface{}{"matrix": map[string]interface{}{"generators": []interface{}{
+				map[string]interface{}{"clusters": map[string]interface{}{}},
+				map[string]interface{}{"git": map[string]interface{}{"repoURL": "https://example.com/repo.git", "revision": "main"}},
+			}}},
+			map[string]interface{}{"git": map[string]interface{}{"repoURL": "https://example.com/repo.git", "revision": "{{ .revision }}"}},
+		},
+	})
+	findings := checkRule(t, ruleGitGeneratorRevisionPinned(), config.Config{}, appSet)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Message != "git generator revision 'HEAD' is not immutable" || findings[0].Severity != types.SeverityError {
+		t.Fatalf("unexpected HEAD finding: %+v", findings[0])
+	}
+	if findings[1].Message != "git generator revision 'main' refers to a mutable ref" {
+		t.Fatalf("unexpected branch finding: %+v", findings[1])
+	}
+	if path := findings[1].Suggestions[0].Path; path != "$.spec.generators[2].matrix.generators[1].git.revision" {
+		t.Fatalf("unexpected suggestion path %s", path)
+	}
+	if patch := findings[1].Suggestions[0].Patch; patch != "" {
+		t.Fatalf("expected a description-only suggestion, got patch %q", patch)
+	}
+}
+
+func TestRuleIgnoreApplicationDifferencesScoped(t *testing.T) {
+	appSet := applicationSetManifest(map[string]interface{}{
+		"ignoreApplicationDifferences": []interface{}{
+			map[string]interface{}{"jsonPointers": []interface{}{"/spec/source/targetRevision"}},
+			map[string]interface{}{"name": "team-*", "jqPathExpressions": []interface{}{".spec.source.helm.values"}},
+			map[string]interface{}{"name": "guestbook"},
+			"invalid",
+		},
+	})
+	findings := checkRule(t, ruleIgnoreApplicationDifferencesScoped(), config.Config{}, appSet)
+	want := []string{
+		"ignoreApplicationDifferences[1].name 'team-*' is a wildcard",
+		"ignoreApplicationDifferences[2] lacks jsonPointers or jqPathExpressions",
+		"ignoreApplicationDifferences[3] is not an object",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if findings[0].Severity != types.SeverityError {
+		t.Fatalf("expected wildcard name to be an error, got %s", findings[0].Severity)
+	}
+}
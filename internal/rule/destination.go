@@ -0,0 +1,128 @@
+package rule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleDestinationServerOrName() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR023",
+		Description:     "Destination must set either server or name, not both",
+		Rationale:       "Argo CD rejects a destination that sets both server and name, and one that sets neither.",
+		Example:         "destination:\n  server: https://kubernetes.default.svc\n  namespace: payments",
+		Remediation:     "Keep either destination.server or destination.name.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/declarative-setup/#applications",
+		Category:        "configuration",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"spec", "destination"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "spec", "destination"}
+			}
+			dest := getMap(m.Object, path...)
+			server := strings.TrimSpace(getStringMap(dest, "server"))
+			name := strings.TrimSpace(getStringMap(dest, "name"))
+			if server == "" || name == "" {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			jsonPath := "$." + strings.Join(path, ".")
+			finding := builder.NewFinding(jsonPath[2:]+" sets both server and name; Argo CD rejects the Application", cfg.Severity)
+			finding.Suggestions = []types.Suggestion{
+				{
+					Title:       "Keep only one cluster selector",
+					Description: "Use destination.name to reference a registered cluster by name, or destination.server for its API URL.",
+					Patch:       "destination:\n  name: " + name,
+					Path:        jsonPath + ".server",
+				},
+			}
+			return []types.Finding{finding}
+		},
+	}
+}
+
+const inClusterServer = "https://kubernetes.default.svc"
+
+func ruleCrossProjectDestinations() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR035",
+		Description:     "Applications from different AppProjects should not deploy to the same cluster and namespace",
+		Rationale:       "Applications from different projects that share a cluster and namespace can overwrite each other's resources and escape their project's limits.",
+		Remediation:     "Move one of the Applications to its own namespace, or into the same project.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplication) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			key, ok := destinationKey(m)
+			if !ok {
+				return nil
+			}
+			project := applicationProject(m)
+			var conflicts []string
+			for _, other := range ctx.Manifests {
+				if other == m || other.Kind != string(types.ResourceKindApplication) {
+					continue
+				}
+				otherKey, ok := destinationKey(other)
+				if !ok || otherKey != key {
+					continue
+				}
+				if otherProject := applicationProject(other); otherProject != project {
+					conflicts = append(conflicts, fmt.Sprintf("'%s' (project '%s')", other.Name, otherProject))
+				}
+			}
+			if len(conflicts) == 0 {
+				return nil
+			}
+			sort.Strings(conflicts)
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			msg := fmt.Sprintf("destination %s (project '%s') is also targeted by Application %s; ownership may conflict", key, project, strings.Join(conflicts, ", "))
+			return []types.Finding{builder.NewFinding(msg, cfg.Severity)}
+		},
+	}
+}
+
+// destinationKey identifies the cluster and namespace an Application deploys
+// to. The in-cluster name and server are treated as the same cluster.
+func destinationKey(m *manifest.Manifest) (string, bool) {
+	dest := destinationFromMap(getMap(m.Object, "spec", "destination"))
+	if dest == nil || dest.Namespace == "" {
+		return "", false
+	}
+	cluster := dest.Server
+	if cluster == "" {
+		cluster = dest.Name
+	}
+	if cluster == "in-cluster" {
+		cluster = inClusterServer
+	}
+	if cluster == "" || strings.Contains(cluster, "{{") || strings.Contains(dest.Namespace, "{{") {
+		return "", false
+	}
+	return strings.TrimSuffix(cluster, "/") + "/" + dest.Namespace, true
+}
+
+func applicationProject(m *manifest.Manifest) string {
+	if project := strings.TrimSpace(getString(m.Object, "spec", "project")); project != "" {
+		return project
+	}
+	return "default"
+}
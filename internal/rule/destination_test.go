@@ -0,0 +1,79 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestRuleDestinationServerOrName(t *testing.T) {
+	both := map[string]interface{}{"server": "https://kubernetes.default.svc", "name": "in-cluster", "namespace": "apps"}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object:   map[string]interface{}{"spec": map[string]interface{}{"destination": both}},
+	}
+	findings := checkRule(t, ruleDestinationServerOrName(), config.Config{}, app)
+	if len(findings) != 1 || findings[0].Message != "spec.destination sets both server and name; Argo CD rejects the Application" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	appSet := &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "demo",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": map[string]interface{}{"destination": both}},
+		}},
+	}
+	findings = checkRule(t, ruleDestinationServerOrName(), config.Config{}, appSet)
+	if len(findings) != 1 || findings[0].Suggestions[0].Path != "$.spec.template.spec.destination.server" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	app.Object = map[string]interface{}{"spec": map[string]interface{}{"destination": map[string]interface{}{"name": "in-cluster", "namespace": "apps"}}}
+	if findings := checkRule(t, ruleDestinationServerOrName(), config.Config{}, app); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestRuleCrossProjectDestinations(t *testing.T) {
+	app := func(name, project string, dest map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{
+			FilePath: name + ".yaml",
+			Kind:     string(types.ResourceKindApplication),
+			Name:     name,
+			Object: map[string]interface{}{"spec": map[string]interface{}{
+				"project":     project,
+				"destination": dest,
+			}},
+		}
+	}
+	payments := app("payments", "team-a", map[string]interface{}{"server": "https://kubernetes.default.svc", "namespace": "shared"})
+	ledger := app("ledger", "team-b", map[string]interface{}{"name": "in-cluster", "namespace": "shared"})
+	billing := app("billing", "team-a", map[string]interface{}{"server": "https://kubernetes.default.svc", "namespace": "shared"})
+	other := app("other", "team-c", map[string]interface{}{"server": "https://kubernetes.default.svc", "namespace": "other"})
+
+	rl := ruleCrossProjectDestinations()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, payments.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	ctx := &Context{Config: cfg, Manifests: []*manifest.Manifest{payments, ledger, billing, other}}
+
+	findings := rl.Check(payments, ctx, configured)
+	if len(findings) != 1 || findings[0].Message != "destination https://kubernetes.default.svc/shared (project 'team-a') is also targeted by Application 'ledger' (project 'team-b'); ownership may conflict" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if findings := rl.Check(ledger, ctx, configured); len(findings) != 1 || !strings.Contains(findings[0].Message, "'billing' (project 'team-a'), 'payments' (project 'team-a')") {
+		t.Fatalf("unexpected findings for ledger: %+v", findings)
+	}
+	if findings := rl.Check(other, ctx, configured); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+}
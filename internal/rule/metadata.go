@@ -0,0 +1,168 @@
+package rule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleNamingConventions() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR027",
+		Description:     "Resource names and destination namespaces must follow policies.namingConventions",
+		Rationale:       "Consistent names make resources easy to find and let policies match them by pattern.",
+		Remediation:     "Rename the resource or namespace to match the pattern in policies.namingConventions.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			switch m.Kind {
+			case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+				return true
+			}
+			return false
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			conventions := ctx.Config.Policies.NamingConventions
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			check := func(label, value string, rule config.NameRule, path string) {
+				msg := checkName(label, value, rule)
+				if msg == "" {
+					return
+				}
+				finding := builder.NewFinding(msg, cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Rename to match the naming convention",
+						Description: describeNameRule(rule),
+						Patch:       fmt.Sprintf("%s: <name>", path[strings.LastIndex(path, ".")+1:]),
+						Path:        path,
+					},
+				}
+				findings = append(findings, finding)
+			}
+			switch m.Kind {
+			case string(types.ResourceKindApplication):
+				check("Application name", m.Name, conventions.Application, "$.metadata.name")
+				check("destination namespace", getString(m.Object, "spec", "destination", "namespace"), conventions.Namespace, "$.spec.destination.namespace")
+			case string(types.ResourceKindApplicationSet):
+				check("ApplicationSet name", m.Name, conventions.ApplicationSet, "$.metadata.name")
+				check("template Application name", getString(m.Object, "spec", "template", "metadata", "name"), conventions.Application, "$.spec.template.metadata.name")
+				check("template destination namespace", getString(m.Object, "spec", "template", "spec", "destination", "namespace"), conventions.Namespace, "$.spec.template.spec.destination.namespace")
+			case string(types.ResourceKindAppProject):
+				check("AppProject name", m.Name, conventions.AppProject, "$.metadata.name")
+			}
+			return findings
+		},
+	}
+}
+
+// checkName returns a message when value violates rule. Empty values are
+// skipped. Templated values are only checked for length, counting the text
+// outside {{ }} expressions, since the rendered name is at least that long.
+func checkName(label, value string, rule config.NameRule) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if strings.Contains(value, "{{") {
+		literal := templateExpression.ReplaceAllString(value, "")
+		if rule.MaxLength > 0 && len(literal) > rule.MaxLength {
+			return fmt.Sprintf("%s '%s' is at least %d characters once rendered; the limit is %d", label, value, len(literal), rule.MaxLength)
+		}
+		return ""
+	}
+	if rule.MaxLength > 0 && len(value) > rule.MaxLength {
+		return fmt.Sprintf("%s '%s' is %d characters; the limit is %d", label, value, len(value), rule.MaxLength)
+	}
+	if rule.Pattern == "" {
+		return ""
+	}
+	pattern, err := regexp.Compile(rule.Pattern)
+	if err != nil || pattern.MatchString(value) {
+		return ""
+	}
+	return fmt.Sprintf("%s '%s' does not match pattern %s", label, value, rule.Pattern)
+}
+
+var templateExpression = regexp.MustCompile(`\{\{.*?\}\}`)
+
+func describeNameRule(rule config.NameRule) string {
+	var parts []string
+	if rule.Pattern != "" {
+		parts = append(parts, "match "+rule.Pattern)
+	}
+	if rule.MaxLength > 0 {
+		parts = append(parts, fmt.Sprintf("stay within %d characters", rule.MaxLength))
+	}
+	return "Names must " + strings.Join(parts, " and ") + "."
+}
+
+func ruleRequiredAnnotations() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR028",
+		Description:     "Resources must carry the annotations listed in policies.requiredAnnotations",
+		Rationale:       "Required annotations, such as an owner or on-call contact, are how other teams find who is responsible for a resource.",
+		Example:         "metadata:\n  annotations:\n    team.example.com/owner: payments",
+		Remediation:     "Add each annotation listed in policies.requiredAnnotations.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			switch m.Kind {
+			case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+				return true
+			}
+			return false
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			required := normalizeValues(ctx.Config.Policies.RequiredAnnotations)
+			if len(required) == 0 {
+				return nil
+			}
+			annotations := getMap(m.Object, "metadata", "annotations")
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, key := range required {
+				if strings.TrimSpace(getStringMap(annotations, key)) != "" {
+					continue
+				}
+				finding := builder.NewFinding(fmt.Sprintf("metadata.annotations is missing required key '%s'", key), cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       fmt.Sprintf("Add the %s annotation", key),
+						Description: "Platform policy requires this annotation on every Argo CD resource.",
+						Patch:       fmt.Sprintf("metadata:\n  annotations:\n    %s: <value>", key),
+						Path:        "$.metadata.annotations",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+// normalizeValues trims configured values and drops empty ones.
+func normalizeValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestRuleNamingConventions(t *testing.T) {
+	cfg := config.Config{Policies: config.PolicyConfig{NamingConventions: config.NamingConventions{
+		Application:    config.NameRule{Pattern: "^[a-z0-9-]+$"},
+		ApplicationSet: config.NameRule{Pattern: "^[a-z0-9-]+$", MaxLength: 10},
+		Namespace:      config.NameRule{Pattern: "^team-[a-z]+$"},
+	}}}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "Guestbook_App",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"namespace": "guestbook"},
+		}},
+	}
+	findings := checkRule(t, ruleNamingConventions(), cfg, app)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "Application name 'Guestbook_App' does not match") {
+		t.Fatalf("unexpected name finding: %s", findings[0].Message)
+	}
+	if findings[1].Suggestions[0].Path != "$.spec.destination.namespace" {
+		t.Fatalf("unexpected namespace suggestion: %+v", findings[1].Suggestions)
+	}
+
+	appSet := &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "platform-addons",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "{{ .name }}-addons"},
+				"spec":     map[string]interface{}{"destination": map[string]interface{}{"namespace": "team-platform"}},
+			},
+		}},
+	}
+	findings = checkRule(t, ruleNamingConventions(), cfg, appSet)
+	if len(findings) != 1 || findings[0].Message != "ApplicationSet name 'platform-addons' is 15 characters; the limit is 10" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	cfg.Policies.NamingConventions.Application.MaxLength = 20
+	appSet.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["metadata"] = map[string]interface{}{"name": "{{ .cluster }}-platform-observability"}
+	findings = checkRule(t, ruleNamingConventions(), cfg, appSet)
+	if len(findings) != 2 || findings[1].Message != "template Application name '{{ .cluster }}-platform-observability' is at least 23 characters once rendered; the limit is 20" {
+		t.Fatalf("expected the literal part of a templated name to be checked, got %+v", findings)
+	}
+
+	if findings := checkRule(t, ruleNamingConventions(), config.Config{}, app); len(findings) != 0 {
+		t.Fatalf("expected no findings without conventions, got %+v", findings)
+	}
+}
+
+func TestRuleRequiredAnnotations(t *testing.T) {
+	cfg := config.Config{Policies: config.PolicyConfig{RequiredAnnotations: []string{"backstage.io/owner", "cost-center", " "}}}
+	project := projectManifest(map[string]interface{}{})
+	project.Object["metadata"] = map[string]interface{}{
+		"annotations": map[string]interface{}{"backstage.io/owner": "team-a", "cost-center": ""},
+	}
+	findings := checkRule(t, ruleRequiredAnnotations(), cfg, project)
+	if len(findings) != 1 || findings[0].Message != "metadata.annotations is missing required key 'cost-center'" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if patch := findings[0].Suggestions[0].Patch; !strings.Contains(patch, "cost-center: <value>") {
+		t.Fatalf("unexpected patch %q", patch)
+	}
+	if findings := checkRule(t, ruleRequiredAnnotations(), config.Config{}, project); len(findings) != 0 {
+		t.Fatalf("expected no findings without policy, got %+v", findings)
+	}
+}
@@ -0,0 +1,156 @@
+package rule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+const notificationSubscribePrefix = "notifications.argoproj.io/subscribe."
+
+// builtinNotificationTriggers lists the triggers shipped in the Argo CD
+// notifications catalog.
+var builtinNotificationTriggers = []string{
+	"on-created",
+	"on-deleted",
+	"on-deployed",
+	"on-health-degraded",
+	"on-sync-failed",
+	"on-sync-running",
+	"on-sync-status-unknown",
+	"on-sync-succeeded",
+}
+
+func ruleNotificationSubscriptions() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR029",
+		Description:     "Notification subscription annotations must use known triggers and list recipients once",
+		Rationale:       "Subscriptions to unknown triggers never fire, and repeated recipients get every notification twice.",
+		Example:         "metadata:\n  annotations:\n    notifications.argoproj.io/subscribe.on-sync-failed.slack: deployments",
+		Remediation:     "Use a trigger defined in argocd-notifications-cm and list each recipient once.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/notifications/subscriptions/",
+		Category:        "configuration",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			switch m.Kind {
+			case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+				return true
+			}
+			return false
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"metadata", "annotations"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "metadata", "annotations"}
+			}
+			annotations := getMap(m.Object, path...)
+			triggers := append([]string(nil), builtinNotificationTriggers...)
+			if custom, ok := cfg.StringSliceParam("triggers"); ok {
+				triggers = append(triggers, custom...)
+			}
+			keys := make([]string, 0, len(annotations))
+			for key := range annotations {
+				if strings.HasPrefix(key, notificationSubscribePrefix) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			jsonPath := "$." + strings.Join(path, ".")
+			var findings []types.Finding
+			for _, key := range keys {
+				value, _ := annotations[key].(string)
+				if strings.Contains(key, "{{") || strings.Contains(value, "{{") {
+					continue
+				}
+				for _, msg := range checkNotificationSubscription(key, value, triggers) {
+					finding := builder.NewFinding(msg.text, cfg.Severity)
+					if msg.patch != "" {
+						finding.Suggestions = []types.Suggestion{
+							{
+								Title:       "Fix the subscription annotation",
+								Description: "Subscriptions use notifications.argoproj.io/subscribe.<trigger>.<service>: <recipient>[;<recipient>].",
+								Patch:       msg.patch,
+								Path:        jsonPath,
+							},
+						}
+					}
+					findings = append(findings, finding)
+				}
+			}
+			return findings
+		},
+	}
+}
+
+type notificationMessage struct {
+	text  string
+	patch string
+}
+
+// checkNotificationSubscription validates one subscribe annotation. The key
+// suffix is either <service> (default triggers) or <trigger>.<service>.
+func checkNotificationSubscription(key, value string, triggers []string) []notificationMessage {
+	var msgs []notificationMessage
+	suffix := strings.TrimPrefix(key, notificationSubscribePrefix)
+	trigger, service, hasTrigger := strings.Cut(suffix, ".")
+	if !hasTrigger {
+		trigger, service = "", suffix
+	}
+	if strings.TrimSpace(service) == "" || (hasTrigger && trigger == "") {
+		msgs = append(msgs, notificationMessage{text: fmt.Sprintf("annotation '%s' must name a trigger and service", key)})
+	}
+	if trigger != "" && !stringInSlice(trigger, triggers) {
+		text := fmt.Sprintf("annotation '%s' uses unknown trigger '%s'; the subscription never fires", key, trigger)
+		var patch string
+		if closest := closestName(trigger, triggers); closest != "" {
+			text += fmt.Sprintf(" (did you mean '%s'?)", closest)
+			patch = fmt.Sprintf("%s%s.%s: %s", notificationSubscribePrefix, closest, service, value)
+		}
+		msgs = append(msgs, notificationMessage{text: text, patch: patch})
+	}
+	seen := map[string]bool{}
+	var recipients, duplicates []string
+	for _, recipient := range strings.Split(value, ";") {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == "" {
+			continue
+		}
+		if seen[recipient] {
+			duplicates = append(duplicates, recipient)
+			continue
+		}
+		seen[recipient] = true
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		msgs = append(msgs, notificationMessage{
+			text:  fmt.Sprintf("annotation '%s' has no recipients", key),
+			patch: fmt.Sprintf("%s: <recipient>", key),
+		})
+	}
+	if len(duplicates) > 0 {
+		msgs = append(msgs, notificationMessage{
+			text:  fmt.Sprintf("annotation '%s' lists recipients more than once: %s", key, strings.Join(duplicates, ", ")),
+			patch: fmt.Sprintf("%s: %s", key, strings.Join(recipients, ";")),
+		})
+	}
+	return msgs
+}
+
+func stringInSlice(value string, values []string) bool {
+	for _, candidate := range values {
+		if strings.TrimSpace(candidate) == value {
+			return true
+		}
+	}
+	return false
+}
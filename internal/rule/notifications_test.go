@@ -0,0 +1,50 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestRuleNotificationSubscriptions(t *testing.T) {
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+			"notifications.argoproj.io/subscribe.on-sync-succeeded.slack": "deploys",
+			"notifications.argoproj.io/subscribe.on-sync-succeded.slack":  "deploys",
+			"notifications.argoproj.io/subscribe.on-deployed.teams":       " ; ",
+			"notifications.argoproj.io/subscribe.email":                   "a@example.com;b@example.com;a@example.com",
+			"notifications.argoproj.io/subscribe.on-rollout-done.webhook": "ci",
+		}}},
+	}
+	findings := checkRule(t, ruleNotificationSubscriptions(), config.Config{}, app)
+	want := []string{
+		"subscribe.email' lists recipients more than once: a@example.com",
+		"subscribe.on-deployed.teams' has no recipients",
+		"unknown trigger 'on-rollout-done'",
+		"unknown trigger 'on-sync-succeded'; the subscription never fires (did you mean 'on-sync-succeeded'?)",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if patch := findings[0].Suggestions[0].Patch; patch != "notifications.argoproj.io/subscribe.email: a@example.com;b@example.com" {
+		t.Fatalf("unexpected patch %q", patch)
+	}
+
+	cfg := config.Config{Rules: map[string]config.RuleConfig{
+		"AR029": {Params: map[string]interface{}{"triggers": []interface{}{"on-rollout-done"}}},
+	}}
+	if findings := checkRule(t, ruleNotificationSubscriptions(), cfg, app); len(findings) != 3 {
+		t.Fatalf("expected custom trigger to be accepted, got %+v", findings)
+	}
+}
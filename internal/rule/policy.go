@@ -0,0 +1,178 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleDestinationPolicy() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR044",
+		Description:     "Destinations must use allowed clusters and namespaces and avoid policies.blockedNamespaces",
+		Rationale:       "Destinations outside the allowed clusters and namespaces deploy where the organisation's policy does not permit.",
+		Example:         "destination:\n  server: https://kubernetes.default.svc\n  namespace: payments",
+		Remediation:     "Use a cluster and namespace listed in policies, or update the policy.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+		Category:        "security",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			switch m.Kind {
+			case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+				return true
+			}
+			return false
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			policies := ctx.Config.Policies
+			servers := normalizeValues(policies.AllowedDestinationServers)
+			namespaces := normalizeValues(policies.AllowedDestinationNamespaces)
+			blocked := normalizeValues(policies.BlockedNamespaces)
+			if len(servers)+len(namespaces)+len(blocked) == 0 {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			check := func(dest map[string]interface{}, path string) {
+				if len(dest) == 0 {
+					return
+				}
+				server := strings.TrimSpace(getStringMap(dest, "server"))
+				name := strings.TrimSpace(getStringMap(dest, "name"))
+				namespace := strings.TrimSpace(getStringMap(dest, "namespace"))
+				cluster := server
+				if cluster == "" {
+					cluster = name
+				}
+				if len(servers) > 0 && cluster != "" && !strings.Contains(cluster, "{{") &&
+					!patternMatches(server, servers) && !patternMatches(name, servers) {
+					finding := builder.NewFinding(fmt.Sprintf("%s targets cluster '%s', which is not in policies.allowedDestinationServers (%s)", path[2:], cluster, strings.Join(servers, ",")), cfg.Severity)
+					key := "server"
+					if server == "" {
+						key = "name"
+					}
+					suggestion := types.Suggestion{
+						Title:       "Deploy to an approved cluster",
+						Description: "Platform policy restricts which clusters Argo CD may deploy to.",
+						Path:        path + "." + key,
+					}
+					if allowed := literalPattern(servers); allowed != "" {
+						suggestion.Patch = key + ": " + allowed
+					}
+					finding.Suggestions = []types.Suggestion{suggestion}
+					findings = append(findings, finding)
+				}
+				if namespace == "" || strings.Contains(namespace, "{{") {
+					return
+				}
+				if patternMatches(namespace, blocked) {
+					finding := builder.NewFinding(fmt.Sprintf("%s.namespace '%s' is blocked by policies.blockedNamespaces", path[2:], namespace), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Deploy to a workload namespace",
+							Description: "System namespaces are reserved for the platform team.",
+							Patch:       "namespace: <namespace>",
+							Path:        path + ".namespace",
+						},
+					}
+					findings = append(findings, finding)
+					return
+				}
+				if len(namespaces) > 0 && !patternMatches(namespace, namespaces) {
+					msg := fmt.Sprintf("%s.namespace '%s' is not in policies.allowedDestinationNamespaces (%s)", path[2:], namespace, strings.Join(namespaces, ","))
+					findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+				}
+			}
+			switch m.Kind {
+			case string(types.ResourceKindApplication):
+				check(getMap(m.Object, "spec", "destination"), "$.spec.destination")
+			case string(types.ResourceKindApplicationSet):
+				check(getMap(m.Object, "spec", "template", "spec", "destination"), "$.spec.template.spec.destination")
+			case string(types.ResourceKindAppProject):
+				for i, raw := range getSlice(m.Object, "spec", "destinations") {
+					if dest, ok := raw.(map[string]interface{}); ok {
+						check(dest, fmt.Sprintf("$.spec.destinations[%d]", i))
+					}
+				}
+			}
+			return findings
+		},
+	}
+}
+
+func ruleAllowedProjects() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR045",
+		Description:     "Applications must use a project listed in policies.allowedProjects",
+		Rationale:       "Projects outside policies.allowedProjects are not reviewed for the guardrails the organisation expects.",
+		Example:         "spec:\n  project: payments",
+		Remediation:     "Move the Application to one of the allowed projects.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			allowed := normalizeValues(ctx.Config.Policies.AllowedProjects)
+			if len(allowed) == 0 {
+				return nil
+			}
+			project := applicationProject(m)
+			path := "$.spec.project"
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				project = appSetProjectName(m)
+				path = "$.spec.template.spec.project"
+			}
+			if project == "" || strings.Contains(project, "{{") || patternMatches(project, allowed) {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			finding := builder.NewFinding(fmt.Sprintf("project '%s' is not in policies.allowedProjects (%s)", project, strings.Join(allowed, ",")), cfg.Severity)
+			finding.Suggestions = []types.Suggestion{
+				{
+					Title:       "Use an approved AppProject",
+					Description: "Platform policy limits Applications to the listed projects.",
+					Patch:       "project: " + allowed[0],
+					Path:        path,
+				},
+			}
+			return []types.Finding{finding}
+		},
+	}
+}
+
+// literalPattern returns the first pattern without glob characters, which
+// can be suggested as a value as is.
+func literalPattern(patterns []string) string {
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// patternMatches reports whether value matches any glob pattern, ignoring
+// case.
+func patternMatches(value string, patterns []string) bool {
+	if value == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if globMatch(strings.ToLower(pattern), strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
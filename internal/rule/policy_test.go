@@ -0,0 +1,110 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestRuleRepoURLPolicyBlockedDomains(t *testing.T) {
+	cfg := config.Config{Policies: config.PolicyConfig{BlockedRepoURLDomains: []string{"*.pastebin.example", "gist.github.com"}}}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "guestbook",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"sources": []interface{}{
+				map[string]interface{}{"repoURL": "https://gist.github.com/someone/abc.git"},
+				map[string]interface{}{"repoURL": "https://github.com/org/repo.git"},
+			},
+		}},
+	}
+	findings := checkRule(t, ruleRepoURLPolicy(), cfg, app)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "'gist.github.com', which is blocked") {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestRuleDestinationPolicy(t *testing.T) {
+	cfg := config.Config{Policies: config.PolicyConfig{
+		AllowedDestinationServers:    []string{"https://kubernetes.default.svc", "prod-*"},
+		AllowedDestinationNamespaces: []string{"team-*"},
+		BlockedNamespaces:            []string{"kube-system", "argocd"},
+	}}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "guestbook",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"server": "https://rogue.example:6443", "namespace": "Kube-System"},
+		}},
+	}
+	findings := checkRule(t, ruleDestinationPolicy(), cfg, app)
+	if len(findings) != 2 {
+		t.Fatalf("expected cluster and blocked namespace findings, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "targets cluster 'https://rogue.example:6443'") {
+		t.Fatalf("unexpected cluster finding: %s", findings[0].Message)
+	}
+	if s := findings[0].Suggestions[0]; s.Patch != "server: https://kubernetes.default.svc" || s.Path != "$.spec.destination.server" {
+		t.Fatalf("unexpected cluster suggestion: %+v", s)
+	}
+	if findings[1].Message != "spec.destination.namespace 'Kube-System' is blocked by policies.blockedNamespaces" {
+		t.Fatalf("unexpected namespace finding: %s", findings[1].Message)
+	}
+
+	project := projectManifest(map[string]interface{}{"destinations": []interface{}{
+		map[string]interface{}{"name": "prod-eu", "namespace": "team-a"},
+		map[string]interface{}{"name": "prod-us", "namespace": "payments"},
+	}})
+	findings = checkRule(t, ruleDestinationPolicy(), cfg, project)
+	if len(findings) != 1 || !strings.HasPrefix(findings[0].Message, "spec.destinations[1].namespace 'payments' is not in") {
+		t.Fatalf("unexpected project findings: %+v", findings)
+	}
+
+	globOnly := config.Config{Policies: config.PolicyConfig{AllowedDestinationServers: []string{"prod-*"}}}
+	findings = checkRule(t, ruleDestinationPolicy(), globOnly, project)
+	if len(findings) != 0 {
+		t.Fatalf("expected prod-* to allow both destinations, got %+v", findings)
+	}
+	findings = checkRule(t, ruleDestinationPolicy(), globOnly, app)
+	if len(findings) != 1 || findings[0].Suggestions[0].Patch != "" || findings[0].Suggestions[0].Path != "$.spec.destination.server" {
+		t.Fatalf("expected a suggestion without a glob patch, got %+v", findings)
+	}
+
+	appSet := &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "addons",
+		Object: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"server": "{{ .server }}", "namespace": "team-{{ .name }}"},
+		}}}},
+	}
+	if findings := checkRule(t, ruleDestinationPolicy(), cfg, appSet); len(findings) != 0 {
+		t.Fatalf("expected templated destinations to be skipped, got %+v", findings)
+	}
+}
+
+func TestRuleAllowedProjects(t *testing.T) {
+	cfg := config.Config{Policies: config.PolicyConfig{AllowedProjects: []string{"team-*"}}}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "guestbook",
+		Object:   map[string]interface{}{"spec": map[string]interface{}{}},
+	}
+	findings := checkRule(t, ruleAllowedProjects(), cfg, app)
+	if len(findings) != 1 || findings[0].Message != "project 'default' is not in policies.allowedProjects (team-*)" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	app.Object["spec"] = map[string]interface{}{"project": "team-payments"}
+	if findings := checkRule(t, ruleAllowedProjects(), cfg, app); len(findings) != 0 {
+		t.Fatalf("expected allowed project, got %+v", findings)
+	}
+	if findings := checkRule(t, ruleAllowedProjects(), config.Config{}, &manifest.Manifest{Kind: app.Kind, Object: map[string]interface{}{}}); len(findings) != 0 {
+		t.Fatalf("expected no findings without policy, got %+v", findings)
+	}
+}
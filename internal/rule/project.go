@@ -0,0 +1,313 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleProjectRolePolicies() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR016",
+		Description:     "AppProject role policies must be well-formed, scoped to the project, and avoid blanket wildcards",
+		Rationale:       "Malformed role policies are dropped by Argo CD, and wildcard policies grant more than the role needs.",
+		Example:         "policies:\n  - p, proj:payments:deployer, applications, sync, payments/*, allow",
+		Remediation:     "Write policies as p, proj:<project>:<role>, <resource>, <action>, <project>/<object>, allow|deny and name actions explicitly.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/projects/#project-roles",
+		Category:        "security",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindAppProject) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for roleIdx, rawRole := range getSlice(m.Object, "spec", "roles") {
+				role, ok := rawRole.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				roleName := getStringMap(role, "name")
+				for policyIdx, rawPolicy := range getSlice(role, "policies") {
+					line, ok := rawPolicy.(string)
+					if !ok {
+						continue
+					}
+					path := fmt.Sprintf("$.spec.roles[%d].policies[%d]", roleIdx, policyIdx)
+					for _, msg := range checkRolePolicy(m.Name, roleName, line) {
+						finding := builder.NewFinding(msg, cfg.Severity)
+						finding.Suggestions = []types.Suggestion{
+							{
+								Title:       "Scope the policy to this project",
+								Description: "Project role policies use the form p, proj:<project>:<role>, <resource>, <action>, <project>/<object>, allow|deny.",
+								Patch:       fmt.Sprintf("- p, proj:%s:%s, applications, get, %s/*, allow", m.Name, roleName, m.Name),
+								Path:        path,
+							},
+						}
+						findings = append(findings, finding)
+					}
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// checkRolePolicy validates a single Casbin policy line of a project role.
+func checkRolePolicy(project, role, line string) []string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.Contains(trimmed, "{{") {
+		return nil
+	}
+	fields := strings.Split(trimmed, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if len(fields) != 6 || fields[0] != "p" {
+		return []string{fmt.Sprintf("role '%s' policy %q is malformed; expected 'p, <subject>, <resource>, <action>, <object>, <effect>'", role, trimmed)}
+	}
+	subject, resource, action, object, effect := fields[1], fields[2], fields[3], fields[4], fields[5]
+	var msgs []string
+	for i, value := range fields[1:5] {
+		if value == "" {
+			msgs = append(msgs, fmt.Sprintf("role '%s' policy %q has an empty field %d", role, trimmed, i+2))
+		}
+	}
+	if effect != "allow" && effect != "deny" {
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy %q has effect '%s'; use allow or deny", role, trimmed, effect))
+	}
+	parts := strings.Split(subject, ":")
+	switch {
+	case len(parts) != 3 || parts[0] != "proj":
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy subject '%s' must be proj:%s:%s", role, subject, project, role))
+	case parts[1] != project:
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy subject '%s' references project '%s' instead of '%s'", role, subject, parts[1], project))
+	case role != "" && parts[2] != role:
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy subject '%s' references role '%s'", role, subject, parts[2]))
+	}
+	if objectProject, _, ok := strings.Cut(object, "/"); ok && objectProject != project && objectProject != "" {
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy object '%s' targets project '%s' instead of '%s'", role, object, objectProject, project))
+	}
+	if resource == "*" && action == "*" && effect == "allow" {
+		msgs = append(msgs, fmt.Sprintf("role '%s' policy %q allows every action on every resource", role, trimmed))
+	}
+	return msgs
+}
+
+func ruleProjectResourceScope() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR017",
+		Description:     "AppProjects should scope cluster-scoped and namespaced resource kinds explicitly",
+		Rationale:       "Without explicit resource allow lists, a project's Applications may create any kind, including cluster-scoped ones such as ClusterRoles.",
+		Example:         "clusterResourceWhitelist: []\nnamespaceResourceWhitelist:\n  - group: apps\n    kind: Deployment",
+		Remediation:     "List the cluster-scoped and namespaced kinds the project needs.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/declarative-setup/#projects",
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindAppProject) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for idx, raw := range getSlice(m.Object, "spec", "clusterResourceWhitelist") {
+				entry, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if strings.TrimSpace(getStringMap(entry, "group")) != "*" || strings.TrimSpace(getStringMap(entry, "kind")) != "*" {
+					continue
+				}
+				finding := builder.NewFinding("spec.clusterResourceWhitelist allows every cluster-scoped kind (group '*', kind '*')", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "List the cluster-scoped kinds the project needs",
+						Description: "Whitelisting all cluster-scoped kinds lets members create ClusterRoles, CRDs, and webhooks.",
+						Patch:       "- group: ''\n  kind: Namespace",
+						Path:        fmt.Sprintf("$.spec.clusterResourceWhitelist[%d]", idx),
+					},
+				}
+				findings = append(findings, finding)
+			}
+			_, hasBlacklist := getMap(m.Object, "spec")["namespaceResourceBlacklist"]
+			_, hasWhitelist := getMap(m.Object, "spec")["namespaceResourceWhitelist"]
+			if !hasBlacklist && !hasWhitelist {
+				finding := builder.NewFinding("spec declares neither namespaceResourceBlacklist nor namespaceResourceWhitelist; every namespaced kind is allowed", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Block sensitive namespaced kinds",
+						Description: "Deny kinds such as ResourceQuota, LimitRange, and NetworkPolicy that tenants should not manage, or whitelist the kinds they need.",
+						Patch:       "spec:\n  namespaceResourceBlacklist:\n    - group: ''\n      kind: ResourceQuota\n    - group: ''\n      kind: LimitRange\n    - group: networking.k8s.io\n      kind: NetworkPolicy",
+						Path:        "$.spec.namespaceResourceBlacklist",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+func ruleProjectOrphanedResources() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR019",
+		Description:     "AppProjects should surface orphaned resources and not ignore every kind",
+		Rationale:       "Orphaned resource monitoring surfaces objects left behind in the project's namespaces; ignoring every kind turns it off.",
+		Example:         "orphanedResources:\n  warn: true",
+		Remediation:     "Enable orphanedResources and ignore only the specific kinds or names that are expected.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/orphaned-resources/",
+		Category:        "drift",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindAppProject) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			orphaned := getMap(m.Object, "spec", "orphanedResources")
+			var findings []types.Finding
+			if warn, _ := orphaned["warn"].(bool); !warn {
+				finding := builder.NewFinding("spec.orphanedResources.warn is not enabled; resources created outside Git go unnoticed", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Enable orphaned resource warnings",
+						Description: "Argo CD raises an OrphanedResourceWarning condition for namespace resources not tracked by any Application.",
+						Patch:       "spec:\n  orphanedResources:\n    warn: true",
+						Path:        "$.spec.orphanedResources.warn",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			for idx, raw := range getSlice(orphaned, "ignore") {
+				entry, ok := raw.(map[string]interface{})
+				if !ok || strings.TrimSpace(getStringMap(entry, "kind")) != "*" {
+					continue
+				}
+				finding := builder.NewFinding("spec.orphanedResources.ignore uses kind '*', which hides every orphaned resource", types.SeverityError)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Ignore specific kinds only",
+						Description: "Limit ignore entries to the kinds and names that are expected to exist outside Git.",
+						Patch:       "- group: ''\n  kind: ConfigMap\n  name: <name>",
+						Path:        fmt.Sprintf("$.spec.orphanedResources.ignore[%d]", idx),
+					},
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+func ruleProjectUnused() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR036",
+		Description:     "AppProjects should be referenced by at least one Application or ApplicationSet",
+		Rationale:       "An unused AppProject still grants access to its sources and destinations and is easy to forget.",
+		Remediation:     "Delete the project, or assign the Applications that should use it.",
+		DefaultSeverity: types.SeverityInfo,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindAppProject) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			for _, other := range ctx.Manifests {
+				var project string
+				switch other.Kind {
+				case string(types.ResourceKindApplication):
+					project = applicationProject(other)
+				case string(types.ResourceKindApplicationSet):
+					project = appSetProjectName(other)
+				default:
+					continue
+				}
+				// A templated project may resolve to this one.
+				if project == m.Name || strings.Contains(project, "{{") {
+					return nil
+				}
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			return []types.Finding{builder.NewFinding(fmt.Sprintf("AppProject '%s' is not referenced by any Application or ApplicationSet in the lint target; remove it if it is stale", m.Name), cfg.Severity)}
+		},
+	}
+}
+
+// defaultControlPlaneNamespace is where Argo CD reconciles Applications
+// without apps-in-any-namespace.
+const defaultControlPlaneNamespace = "argocd"
+
+func ruleProjectSourceNamespaces() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR038",
+		Description:     "Applications outside the Argo CD namespace must be allowed by their AppProject sourceNamespaces",
+		Rationale:       "Argo CD only reconciles Applications outside its own namespace when their project lists that namespace in sourceNamespaces.",
+		Example:         "spec:\n  sourceNamespaces:\n    - team-payments",
+		Remediation:     "Add the Application's namespace to the project's sourceNamespaces, or move the Application to the Argo CD namespace.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/app-any-namespace/",
+		Category:        "governance",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindApplication) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			controlPlane := defaultControlPlaneNamespace
+			if v, ok := cfg.StringParam("controlPlaneNamespace"); ok && strings.TrimSpace(v) != "" {
+				controlPlane = strings.TrimSpace(v)
+			}
+			namespace := strings.TrimSpace(getString(m.Object, "metadata", "namespace"))
+			if namespace == "" || namespace == controlPlane || strings.Contains(namespace, "{{") {
+				return nil
+			}
+			projectName := applicationProject(m)
+			policy, ok := collectAppProjects(ctx.Manifests)[projectName]
+			if !ok {
+				return nil
+			}
+			for _, pattern := range policy.SourceNamespaces {
+				if globMatch(pattern, namespace) {
+					return nil
+				}
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			finding := builder.NewFinding(fmt.Sprintf("Application namespace '%s' is not listed in AppProject '%s' sourceNamespaces; Argo CD will refuse the Application", namespace, projectName), cfg.Severity)
+			// The project usually lives in another file, so only the move
+			// suggestion carries a patch for this one.
+			projectFile := ""
+			for _, other := range ctx.Manifests {
+				if other != nil && other.Kind == string(types.ResourceKindAppProject) && other.Name == projectName {
+					projectFile = " in " + other.FilePath
+					break
+				}
+			}
+			finding.Suggestions = []types.Suggestion{
+				{
+					Title:       "Allow the namespace in the AppProject",
+					Description: fmt.Sprintf("Add '%s' to spec.sourceNamespaces of AppProject '%s'%s.", namespace, projectName, projectFile),
+				},
+				{
+					Title:       "Move the Application to the Argo CD namespace",
+					Description: fmt.Sprintf("Applications in the '%s' namespace need no sourceNamespaces entry.", controlPlane),
+					Patch:       "namespace: " + controlPlane,
+					Path:        "$.metadata.namespace",
+				},
+			}
+			return []types.Finding{finding}
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func projectManifest(spec map[string]interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		FilePath:     "project.yaml",
+		Kind:         string(types.ResourceKindAppProject),
+		Name:         "team-a",
+		MetadataLine: 1,
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "team-a"},
+			"spec":     spec,
+		},
+	}
+}
+
+func checkRule(t *testing.T, rl Rule, cfg config.Config, m *manifest.Manifest) []types.Finding {
+	t.Helper()
+	configured, err := cfg.Resolve(rl.Metadata, m.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	return rl.Check(m, &Context{Config: cfg, Manifests: []*manifest.Manifest{m}}, configured)
+}
+
+func TestRuleProjectRolePolicies(t *testing.T) {
+	project := projectManifest(map[string]interface{}{
+		"roles": []interface{}{
+			map[string]interface{}{
+				"name": "deployer",
+				"policies": []interface{}{
+					"p, proj:team-a:deployer, applications, sync, team-a/*, allow",
+					"p, proj:team-a:deployer, applications, get, team-b/*, allow",
+					"p, proj:team-b:deployer, applications, get, team-a/*, allow",
+					"p, proj:team-a:deployer, *, *, team-a/*, allow",
+					"p, proj:team-a:deployer, applications, sync",
+					"p, proj:team-a:deployer, applications, sync, team-a/*, permit",
+				},
+			},
+		},
+	})
+	findings := checkRule(t, ruleProjectRolePolicies(), config.Config{}, project)
+	want := []string{
+		"targets project 'team-b'",
+		"references project 'team-b'",
+		"allows every action on every resource",
+		"is malformed",
+		"use allow or deny",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if findings[0].Suggestions[0].Path != "$.spec.roles[0].policies[1]" {
+		t.Fatalf("unexpected suggestion path %s", findings[0].Suggestions[0].Path)
+	}
+}
+
+func TestRuleProjectResourceScope(t *testing.T) {
+	project := projectManifest(map[string]interface{}{
+		"clusterResourceWhitelist": []interface{}{
+			map[string]interface{}{"group": "", "kind": "Namespace"},
+			map[string]interface{}{"group": "*", "kind": "*"},
+		},
+	})
+	findings := checkRule(t, ruleProjectResourceScope(), config.Config{}, project)
+	if len(findings) != 2 {
+		t.Fatalf("expected wildcard and missing namespace scope findings, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Suggestions[0].Path != "$.spec.clusterResourceWhitelist[1]" {
+		t.Fatalf("unexpected suggestion path %s", findings[0].Suggestions[0].Path)
+	}
+
+	project.Object["spec"].(map[string]interface{})["namespaceResourceBlacklist"] = []interface{}{
+		map[string]interface{}{"group": "", "kind": "ResourceQuota"},
+	}
+	project.Object["spec"].(map[string]interface{})["clusterResourceWhitelist"] = []interface{}{}
+	if findings := checkRule(t, ruleProjectResourceScope(), config.Config{}, project); len(findings) != 0 {
+		t.Fatalf("expected scoped project to pass, got %+v", findings)
+	}
+}
+
+func TestRuleProjectOrphanedResources(t *testing.T) {
+	project := projectManifest(map[string]interface{}{})
+	findings := checkRule(t, ruleProjectOrphanedResources(), config.Config{}, project)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityWarn {
+		t.Fatalf("expected warning for missing orphanedResources.warn, got %+v", findings)
+	}
+
+	project = projectManifest(map[string]interface{}{
+		"orphanedResources": map[string]interface{}{
+			"warn": true,
+			"ignore": []interface{}{
+				map[string]interface{}{"group": "", "kind": "ConfigMap", "name": "kube-root-ca.crt"},
+				map[string]interface{}{"group": "*", "kind": "*"},
+			},
+		},
+	})
+	findings = checkRule(t, ruleProjectOrphanedResources(), config.Config{}, project)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityError {
+		t.Fatalf("expected error for wildcard ignore, got %+v", findings)
+	}
+	if findings[0].Suggestions[0].Path != "$.spec.orphanedResources.ignore[1]" {
+		t.Fatalf("unexpected suggestion path %s", findings[0].Suggestions[0].Path)
+	}
+}
+
+func TestRuleProjectUnused(t *testing.T) {
+	project := projectManifest(map[string]interface{}{})
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object:   map[string]interface{}{"spec": map[string]interface{}{"project": "team-b"}},
+	}
+	rl := ruleProjectUnused()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, project.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	findings := rl.Check(project, &Context{Config: cfg, Manifests: []*manifest.Manifest{project, app}}, configured)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "AppProject 'team-a' is not referenced") {
+		t.Fatalf("expected unused project finding, got %+v", findings)
+	}
+
+	appSet := &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "teams",
+		Object: map[string]interface{}{"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": map[string]interface{}{"project": "{{ .team }}"}},
+		}},
+	}
+	if findings := rl.Check(project, &Context{Config: cfg, Manifests: []*manifest.Manifest{project, app, appSet}}, configured); len(findings) != 0 {
+		t.Fatalf("expected templated project to count as a reference, got %+v", findings)
+	}
+	app.Object["spec"].(map[string]interface{})["project"] = "team-a"
+	if findings := rl.Check(project, &Context{Config: cfg, Manifests: []*manifest.Manifest{project, app}}, configured); len(findings) != 0 {
+		t.Fatalf("expected referenced project to pass, got %+v", findings)
+	}
+}
+
+func TestRuleProjectSourceNamespaces(t *testing.T) {
+	project := projectManifest(map[string]interface{}{"sourceNamespaces": []interface{}{"team-a-*"}})
+	app := func(namespace string) *manifest.Manifest {
+		return &manifest.Manifest{
+			FilePath: "app.yaml",
+			Kind:     string(types.ResourceKindApplication),
+			Name:     "demo",
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "demo", "namespace": namespace},
+				"spec":     map[string]interface{}{"project": "team-a"},
+			},
+		}
+	}
+	rl := ruleProjectSourceNamespaces()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, "app.yaml")
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	check := func(m *manifest.Manifest) []types.Finding {
+		return rl.Check(m, &Context{Config: cfg, Manifests: []*manifest.Manifest{project, m}}, configured)
+	}
+	findings := check(app("payments"))
+	if len(findings) != 1 || findings[0].Message != "Application namespace 'payments' is not listed in AppProject 'team-a' sourceNamespaces; Argo CD will refuse the Application" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if s := findings[0].Suggestions; len(s) != 2 || s[0].Path != "" || s[1].Path != "$.metadata.namespace" || s[1].Patch != "namespace: argocd" {
+		t.Fatalf("expected the patch to anchor at metadata.namespace, got %+v", s)
+	}
+	for _, namespace := range []string{"argocd", "team-a-apps", ""} {
+		if findings := check(app(namespace)); len(findings) != 0 {
+			t.Fatalf("namespace %q: expected no findings, got %+v", namespace, findings)
+		}
+	}
+}
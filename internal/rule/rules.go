@@ -5,7 +5,9 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/argocd-lint/argocd-lint/internal/appsetplan"
 	"github.com/argocd-lint/argocd-lint/internal/config"
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/types"
@@ -15,6 +17,16 @@ import (
 type Context struct {
 	Config    config.Config
 	Manifests []*manifest.Manifest
+	// RepoRoot resolves Application source paths to local directories. It is
+	// set when path resolution is enabled (--render or --repo-root); empty
+	// disables checks that follow source paths.
+	RepoRoot string
+	// RepoURL is the origin remote of the repository at RepoRoot, when
+	// known. Sources from other repositories are not resolved locally.
+	RepoURL string
+
+	appGraphOnce sync.Once
+	appGraph     map[string][]string
 }
 
 // Rule is a lint rule definition.
@@ -40,6 +52,34 @@ func DefaultRules() []Rule {
 		ruleRepoURLPolicy(),
 		ruleProjectAccess(),
 		ruleAppProjectGuardrails(),
+		ruleSyncOptionsKnown(),
+		ruleProjectRolePolicies(),
+		ruleProjectResourceScope(),
+		ruleSyncWindows(),
+		ruleProjectOrphanedResources(),
+		ruleAutomatedSyncRetry(),
+		ruleManagedNamespaceMetadata(),
+		ruleMultiSourceRefs(),
+		ruleDestinationServerOrName(),
+		ruleApplicationSetRollingSync(),
+		ruleApplicationSetPreserveResources(),
+		ruleGitGeneratorRevisionPinned(),
+		ruleNamingConventions(),
+		ruleRequiredAnnotations(),
+		ruleNotificationSubscriptions(),
+		ruleSecretMaterial(),
+		ruleKustomizeImagesPinned(),
+		ruleHelmChartVersionPinned(),
+		ruleIgnoreApplicationDifferencesScoped(),
+		ruleCrossProjectDestinations(),
+		ruleProjectUnused(),
+		ruleAppOfAppsCycles(),
+		ruleProjectSourceNamespaces(),
+		ruleConfigManagementPlugins(),
+		ruleDirectoryPatternsMatch(),
+		ruleSourcePathExists(),
+		ruleDestinationPolicy(),
+		ruleAllowedProjects(),
 	}
 }
 
@@ -53,6 +93,9 @@ func ruleTargetRevisionPinned() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR001",
 		Description:     "targetRevision must be pinned to an immutable value",
+		Rationale:       "A branch or HEAD moves under the Application, so a sync can deploy commits nobody reviewed for this environment and rollbacks cannot name what was running.",
+		Example:         "targetRevision: v1.4.2",
+		Remediation:     "Set targetRevision to a release tag or a full commit SHA and bump it through a pull request.",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
 		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/application_sources/",
@@ -92,7 +135,25 @@ func ruleTargetRevisionPinned() Rule {
 	}
 }
 
+// isFloatingRevision reports whether rev names a mutable branch. The
+// floatingRevisions parameter replaces the built-in branch list.
+func isFloatingRevision(cfg types.ConfiguredRule, rev string) bool {
+	if branches, ok := cfg.StringSliceParam("floatingRevisions"); ok {
+		for _, branch := range branches {
+			if globMatch(strings.TrimSpace(branch), rev) {
+				return true
+			}
+		}
+		return false
+	}
+	return floatingRevisionPattern.MatchString(rev)
+}
+
 func checkRevision(builder types.FindingBuilder, src map[string]interface{}) []types.Finding {
+	if strings.TrimSpace(getStringMap(src, "chart")) != "" {
+		// Chart versions are validated by AR032.
+		return nil
+	}
 	var findings []types.Finding
 	rev := getString(src, "targetRevision")
 	if rev == "" {
@@ -108,39 +169,51 @@ func checkRevision(builder types.FindingBuilder, src map[string]interface{}) []t
 		findings = append(findings, finding)
 		return findings
 	}
+	return append(findings, checkRevisionValue(builder, "targetRevision", "targetRevision", rev, "$.spec.source.targetRevision")...)
+}
+
+// checkRevisionValue flags HEAD, floating branches, and wildcards in a
+// non-empty revision field. Suggestions patch key when it is set, and only
+// describe the change otherwise.
+func checkRevisionValue(builder types.FindingBuilder, field, key, rev, path string) []types.Finding {
+	var findings []types.Finding
+	patch := ""
+	if key != "" {
+		patch = key + ": <tag-or-commit>"
+	}
 	if rev == "HEAD" {
-		finding := builder.NewFinding("targetRevision 'HEAD' is not immutable", types.SeverityError)
+		finding := builder.NewFinding(fmt.Sprintf("%s 'HEAD' is not immutable", field), types.SeverityError)
 		finding.Suggestions = []types.Suggestion{
 			{
 				Title:       "Replace HEAD with immutable revision",
-				Description: "Pin targetRevision to a stable tag or commit instead of HEAD.",
-				Patch:       "targetRevision: <tag-or-commit>",
-				Path:        "$.spec.source.targetRevision",
+				Description: fmt.Sprintf("Pin %s to a stable tag or commit instead of HEAD.", field),
+				Patch:       patch,
+				Path:        path,
 			},
 		}
 		findings = append(findings, finding)
 		return findings
 	}
-	if floatingRevisionPattern.MatchString(rev) {
-		finding := builder.NewFinding(fmt.Sprintf("targetRevision '%s' refers to a mutable ref", rev), types.SeverityError)
+	if isFloatingRevision(builder.Rule, rev) {
+		finding := builder.NewFinding(fmt.Sprintf("%s '%s' refers to a mutable ref", field, rev), types.SeverityError)
 		finding.Suggestions = []types.Suggestion{
 			{
-				Title:       "Pin targetRevision to an immutable reference",
+				Title:       fmt.Sprintf("Pin %s to an immutable reference", field),
 				Description: "Use a specific tag or commit instead of a floating branch name.",
-				Patch:       "targetRevision: <tag-or-commit>",
-				Path:        "$.spec.source.targetRevision",
+				Patch:       patch,
+				Path:        path,
 			},
 		}
 		findings = append(findings, finding)
 	}
 	if wildcardPattern.MatchString(rev) || semverWildcard.MatchString(rev) {
-		finding := builder.NewFinding(fmt.Sprintf("targetRevision '%s' contains wildcard; prefer exact tag", rev), types.SeverityWarn)
+		finding := builder.NewFinding(fmt.Sprintf("%s '%s' contains wildcard; prefer exact tag", field, rev), types.SeverityWarn)
 		finding.Suggestions = []types.Suggestion{
 			{
 				Title:       "Replace wildcard with exact revision",
-				Description: "Set targetRevision to a precise tag or commit to ensure deterministic syncs.",
-				Patch:       "targetRevision: <tag-or-commit>",
-				Path:        "$.spec.source.targetRevision",
+				Description: fmt.Sprintf("Set %s to a precise tag or commit to ensure deterministic syncs.", field),
+				Patch:       patch,
+				Path:        path,
 			},
 		}
 		findings = append(findings, finding)
@@ -152,6 +225,9 @@ func ruleProjectNotDefault() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR002",
 		Description:     "Applications must target a non-default project",
+		Rationale:       "The default project allows every source, destination, and resource kind, so Applications in it bypass the guardrails an AppProject provides.",
+		Example:         "spec:\n  project: payments",
+		Remediation:     "Create an AppProject scoped to the team or environment and set spec.project to it.",
 		DefaultSeverity: types.SeverityError,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
 		Category:        "security",
@@ -202,6 +278,9 @@ func ruleDestinationNamespace() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR003",
 		Description:     "Destination namespace must be declared for namespace-scoped applications",
+		Rationale:       "Without a destination namespace, namespaced resources land in whatever namespace their manifests name, or in default.",
+		Example:         "destination:\n  namespace: payments",
+		Remediation:     "Set spec.destination.namespace, or enable CreateNamespace=true together with it.",
 		DefaultSeverity: types.SeverityError,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "safety",
@@ -232,6 +311,9 @@ func ruleSyncPolicyExplicit() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR004",
 		Description:     "Applications should declare syncPolicy automated or manual",
+		Rationale:       "An Application without a syncPolicy leaves readers guessing whether it is synced by hand on purpose.",
+		Example:         "syncPolicy:\n  automated: {}",
+		Remediation:     "Add syncPolicy.automated, or an empty syncPolicy with a comment saying syncs are manual.",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "operations",
@@ -255,6 +337,9 @@ func ruleSyncPolicyAutomatedSafety() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR005",
 		Description:     "Automated sync should enable prune and selfHeal when required",
+		Rationale:       "Automated sync without prune leaves deleted resources running, and without selfHeal manual changes in the cluster are never reverted.",
+		Example:         "syncPolicy:\n  automated:\n    prune: true\n    selfHeal: true",
+		Remediation:     "Enable prune and selfHeal under syncPolicy.automated, or disable the rule for Applications that must keep removed resources.",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "operations",
@@ -287,6 +372,9 @@ func ruleFinalizerAware() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR006",
 		Description:     "Applications should explicitly opt-in/out of finalizers",
+		Rationale:       "The resources-finalizer decides whether deleting the Application deletes what it deployed; leaving it implicit makes deletions surprising.",
+		Example:         "metadata:\n  finalizers:\n    - resources-finalizer.argocd.argoproj.io",
+		Remediation:     "Add the resources-finalizer when deleting the Application should prune its resources, or document why it is absent.",
 		DefaultSeverity: types.SeverityInfo,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "safety",
@@ -313,6 +401,9 @@ func ruleIgnoreDifferencesScoped() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR007",
 		Description:     "ignoreDifferences entries must be tightly scoped",
+		Rationale:       "Broad ignoreDifferences entries hide real drift, including changes that should have been reverted.",
+		Example:         "ignoreDifferences:\n  - group: apps\n    kind: Deployment\n    name: api\n    jsonPointers:\n      - /spec/replicas",
+		Remediation:     "Name the group, kind, and resource, and list only the JSON pointers or jq expressions that are expected to drift.",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "drift",
@@ -353,6 +444,9 @@ func ruleApplicationSetGoTemplateOptions() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR008",
 		Description:     "ApplicationSets should enable missingkey=error to surface template issues",
+		Rationale:       "With the default missingkey handling a typo in a parameter renders as an empty string or \"<no value>\" instead of failing the generation.",
+		Example:         "goTemplate: true\ngoTemplateOptions:\n  - missingkey=error",
+		Remediation:     "Enable goTemplate and add missingkey=error to goTemplateOptions.",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplicationSet},
 		Category:        "best-practice",
@@ -399,6 +493,9 @@ func ruleSourceConsistency() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR009",
 		Description:     "Application sources must be defined consistently",
+		Rationale:       "Argo CD ignores source when sources is set, and a source without repoURL or a path or chart cannot be rendered.",
+		Example:         "source:\n  repoURL: https://github.com/example/deploy.git\n  path: apps/api\n  targetRevision: v1.4.2",
+		Remediation:     "Use either source or sources, and give each entry a repoURL plus a path or chart.",
 		DefaultSeverity: types.SeverityError,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
 		Category:        "configuration",
@@ -439,7 +536,7 @@ func validateSource(builder types.FindingBuilder, src map[string]interface{}, so
 	if pathVal != "" && chartVal != "" {
 		findings = append(findings, builder.NewFinding("source.path and source.chart cannot both be set", types.SeverityError))
 	}
-	if pathVal == "" && chartVal == "" {
+	if pathVal == "" && chartVal == "" && strings.TrimSpace(getStringMap(src, "ref")) == "" {
 		findings = append(findings, builder.NewFinding("provide source.path for Git or source.chart for Helm", types.SeverityWarn))
 	}
 	if directory := getMap(src, "directory"); len(directory) > 0 {
@@ -489,6 +586,9 @@ func ruleRecommendedLabels() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR010",
 		Description:     "Metadata should include app.kubernetes.io/name label",
+		Rationale:       "Tools and dashboards group resources by the recommended app.kubernetes.io labels.",
+		Example:         "metadata:\n  labels:\n    app.kubernetes.io/name: api",
+		Remediation:     "Add the app.kubernetes.io/name label and an argocd.argoproj.io/owner annotation, plus any labels listed in the requiredLabels param.",
 		DefaultSeverity: types.SeverityInfo,
 		AppliesTo: []types.ResourceKind{
 			types.ResourceKindApplication,
@@ -506,6 +606,25 @@ func ruleRecommendedLabels() Rule {
 			annotations := getMap(m.Object, "metadata", "annotations")
 			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
 			var findings []types.Finding
+			if required, ok := cfg.StringSliceParam("requiredLabels"); ok {
+				for _, key := range required {
+					key = strings.TrimSpace(key)
+					if _, present := labels[key]; key == "" || present {
+						continue
+					}
+					finding := builder.NewFinding(fmt.Sprintf("Add required label %s to metadata", key), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       fmt.Sprintf("Set %s label", key),
+							Description: "The rule configuration lists this label as required.",
+							Patch:       fmt.Sprintf("metadata:\n  labels:\n    %s: <value>", key),
+							Path:        "$.metadata.labels",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				return append(findings, ownerFindings(builder, labels, annotations)...)
+			}
 			if _, ok := labels["app.kubernetes.io/name"]; !ok {
 				finding := builder.NewFinding("Add app.kubernetes.io/name label to metadata", types.SeverityInfo)
 				finding.Suggestions = []types.Suggestion{
@@ -530,29 +649,37 @@ func ruleRecommendedLabels() Rule {
 				}
 				findings = append(findings, finding)
 			}
-			if _, ok := labels["argocd.argoproj.io/owner"]; !ok {
-				if _, annOk := annotations["argocd.argoproj.io/owner"]; !annOk {
-					finding := builder.NewFinding("Annotate owner via argocd.argoproj.io/owner", types.SeverityInfo)
-					finding.Suggestions = []types.Suggestion{
-						{
-							Title:       "Specify responsible team",
-							Description: "Add argocd.argoproj.io/owner label or annotation to document ownership.",
-							Patch:       "metadata:\n  annotations:\n    argocd.argoproj.io/owner: <team>",
-							Path:        "$.metadata.annotations",
-						},
-					}
-					findings = append(findings, finding)
-				}
-			}
-			return findings
+			return append(findings, ownerFindings(builder, labels, annotations)...)
+		},
+	}
+}
+
+func ownerFindings(builder types.FindingBuilder, labels, annotations map[string]interface{}) []types.Finding {
+	if _, ok := labels["argocd.argoproj.io/owner"]; ok {
+		return nil
+	}
+	if _, ok := annotations["argocd.argoproj.io/owner"]; ok {
+		return nil
+	}
+	finding := builder.NewFinding("Annotate owner via argocd.argoproj.io/owner", types.SeverityInfo)
+	finding.Suggestions = []types.Suggestion{
+		{
+			Title:       "Specify responsible team",
+			Description: "Add argocd.argoproj.io/owner label or annotation to document ownership.",
+			Patch:       "metadata:\n  annotations:\n    argocd.argoproj.io/owner: <team>",
+			Path:        "$.metadata.annotations",
 		},
 	}
+	return []types.Finding{finding}
 }
 
 func ruleRepoURLPolicy() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR013",
-		Description:     "source.repoURL must match approved protocols and domains",
+		Description:     "source.repoURL must match approved protocols and domains and avoid blocked domains",
+		Rationale:       "Repositories on unapproved hosts or protocols can deploy code that has not gone through the organisation's review.",
+		Example:         "repoURL: https://github.com/example/deploy.git",
+		Remediation:     "Move the source to an approved host, or update policies.allowedRepoURLProtocols, allowedRepoURLDomains, and blockedRepoURLDomains.",
 		DefaultSeverity: types.SeverityError,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
 		Category:        "security",
@@ -567,7 +694,8 @@ func ruleRepoURLPolicy() Rule {
 			policies := ctx.Config.Policies
 			allowedProtocols := normalizeList(policies.AllowedRepoURLProtocols)
 			allowedDomains := normalizeList(policies.AllowedRepoURLDomains)
-			if len(allowedProtocols) == 0 && len(allowedDomains) == 0 {
+			blockedDomains := normalizeList(policies.BlockedRepoURLDomains)
+			if len(allowedProtocols) == 0 && len(allowedDomains) == 0 && len(blockedDomains) == 0 {
 				return nil
 			}
 			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
@@ -587,6 +715,11 @@ func ruleRepoURLPolicy() Rule {
 					msg := fmt.Sprintf("source.repoURL '%s' omits a protocol (allowed: %s)", repo, strings.Join(allowedProtocols, ","))
 					findings = append(findings, builder.NewFinding(msg, cfg.Severity))
 				}
+				if host != "" && len(blockedDomains) > 0 && domainAllowed(host, blockedDomains) {
+					msg := fmt.Sprintf("source.repoURL '%s' resolves to '%s', which is blocked (%s)", repo, host, strings.Join(blockedDomains, ","))
+					findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+					continue
+				}
 				if len(allowedDomains) > 0 {
 					if host == "" {
 						msg := fmt.Sprintf("source.repoURL '%s' has no host; cannot validate against domains (%s)", repo, strings.Join(allowedDomains, ","))
@@ -608,6 +741,8 @@ func ruleProjectAccess() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR014",
 		Description:     "Applications must reference existing AppProjects and stay within declared access scopes",
+		Rationale:       "Argo CD refuses to sync an Application whose project is missing or does not permit its source and destination.",
+		Remediation:     "Create the AppProject, or add the Application's repository and destination to it.",
 		DefaultSeverity: types.SeverityError,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
 		Category:        "governance",
@@ -659,6 +794,9 @@ func ruleAppProjectGuardrails() Rule {
 	meta := types.RuleMetadata{
 		ID:              "AR012",
 		Description:     "AppProjects should scope allowed sources and destinations",
+		Rationale:       "A project that allows every source and destination does not constrain the Applications assigned to it.",
+		Example:         "spec:\n  sourceRepos:\n    - https://github.com/example/*\n  destinations:\n    - server: https://kubernetes.default.svc\n      namespace: payments-*",
+		Remediation:     "List the repositories and destinations the project needs instead of \"*\".",
 		DefaultSeverity: types.SeverityWarn,
 		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
 		Category:        "governance",
@@ -954,8 +1092,9 @@ func domainAllowed(domain string, patterns []string) bool {
 }
 
 type projectPolicy struct {
-	SourceRepos  []string
-	Destinations []projectDestination
+	SourceRepos      []string
+	Destinations     []projectDestination
+	SourceNamespaces []string
 }
 
 type projectDestination struct {
@@ -988,7 +1127,7 @@ func collectAppProjects(manifests []*manifest.Manifest) map[string]projectPolicy
 		if len(dests) == 0 {
 			dests = append(dests, projectDestination{Server: "*", Namespace: "*", Name: "*"})
 		}
-		projects[m.Name] = projectPolicy{SourceRepos: repos, Destinations: dests}
+		projects[m.Name] = projectPolicy{SourceRepos: repos, Destinations: dests, SourceNamespaces: sliceToStrings(getSlice(spec, "sourceNamespaces"))}
 	}
 	return projects
 }
@@ -1119,38 +1258,72 @@ func globMatch(pattern, value string) bool {
 	return matched
 }
 
-// UniqueNameFindings flags duplicate Application names across manifests.
+// UniqueNameMetadata describes AR011, which runs across all manifests via
+// UniqueNameFindings rather than per manifest.
+var UniqueNameMetadata = types.RuleMetadata{
+	ID:              "AR011",
+	Description:     "Application, ApplicationSet, and AppProject names must be unique across manifests",
+	Rationale:       "Two manifests with the same kind and name overwrite each other when applied, and the one that wins depends on sync order.",
+	Remediation:     "Rename one of the resources, or remove the duplicate manifest.",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+	Category:        "consistency",
+	Enabled:         true,
+}
+
+// UniqueNameFindings flags duplicate Application, ApplicationSet, and
+// AppProject names across manifests, and Applications whose name collides
+// with one generated by an ApplicationSet.
 func UniqueNameFindings(ctx *Context) []types.Finding {
-	meta := types.RuleMetadata{
-		ID:              "AR011",
-		Description:     "Application names must be unique across manifests",
-		DefaultSeverity: types.SeverityError,
-		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
-		Category:        "consistency",
-		Enabled:         true,
-	}
+	meta := UniqueNameMetadata
 	var findings []types.Finding
-	seen := map[string][]*manifest.Manifest{}
+	report := func(m *manifest.Manifest, msg string) {
+		cfg, err := ctx.Config.Resolve(meta, m.FilePath)
+		if err != nil {
+			cfg = types.ConfiguredRule{Metadata: meta, Severity: meta.DefaultSeverity, Enabled: meta.Enabled}
+		}
+		if !cfg.Enabled {
+			return
+		}
+		builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+		findings = append(findings, builder.NewFinding(msg, cfg.Severity))
+	}
+	seen := map[string]map[string][]*manifest.Manifest{}
 	for _, m := range ctx.Manifests {
-		if m.Kind != string(types.ResourceKindApplication) {
+		switch m.Kind {
+		case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+		default:
 			continue
 		}
-		seen[m.Name] = append(seen[m.Name], m)
+		if seen[m.Kind] == nil {
+			seen[m.Kind] = map[string][]*manifest.Manifest{}
+		}
+		seen[m.Kind][m.Name] = append(seen[m.Kind][m.Name], m)
 	}
-	for name, manifests := range seen {
-		if len(manifests) <= 1 {
+	for kind, names := range seen {
+		for name, manifests := range names {
+			if len(manifests) <= 1 {
+				continue
+			}
+			for _, m := range manifests {
+				report(m, fmt.Sprintf("%s name '%s' is declared in multiple manifests", kind, name))
+			}
+		}
+	}
+	applications := seen[string(types.ResourceKindApplication)]
+	for _, appSet := range ctx.Manifests {
+		if appSet.Kind != string(types.ResourceKindApplicationSet) || len(applications) == 0 {
 			continue
 		}
-		for _, m := range manifests {
-			cfg, err := ctx.Config.Resolve(meta, m.FilePath)
-			if err != nil {
-				cfg = types.ConfiguredRule{Metadata: meta, Severity: meta.DefaultSeverity, Enabled: meta.Enabled}
-			}
-			if !cfg.Enabled {
-				continue
+		generated, err := appsetplan.GeneratedNames(appSet)
+		if err != nil {
+			// Generators the plan engine cannot expand are skipped.
+			continue
+		}
+		for _, name := range generated {
+			for _, m := range applications[name] {
+				report(m, fmt.Sprintf("Application name '%s' collides with an Application generated by ApplicationSet '%s'", name, appSet.Name))
 			}
-			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
-			findings = append(findings, builder.NewFinding(fmt.Sprintf("Application name '%s' is declared in multiple manifests", name), types.SeverityError))
 		}
 	}
 	return findings
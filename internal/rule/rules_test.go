@@ -209,3 +209,98 @@ func TestRuleAppProjectGuardrails(t *testing.T) {
 		t.Fatalf("expected multiple guardrail findings, got %d", len(findings))
 	}
 }
+
+func TestRuleParamsFromConfig(t *testing.T) {
+	cfg := config.Config{Rules: map[string]config.RuleConfig{
+		"AR001": {Params: map[string]interface{}{"floatingRevisions": []interface{}{"develop", "release/*"}}},
+		"AR010": {Params: map[string]interface{}{"requiredLabels": []interface{}{"team", "cost-center"}}},
+	}}
+	ctx := &Context{Config: cfg}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":      map[string]interface{}{"team": "payments"},
+				"annotations": map[string]interface{}{"argocd.argoproj.io/owner": "payments"},
+			},
+			"spec": map[string]interface{}{
+				"source": map[string]interface{}{"repoURL": "https://example.com/a.git", "targetRevision": "release/1.4"},
+			},
+		},
+	}
+
+	revision := ruleTargetRevisionPinned()
+	configured, err := cfg.Resolve(revision.Metadata, app.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	findings := revision.Check(app, ctx, configured)
+	if len(findings) != 1 || findings[0].Message != "targetRevision 'release/1.4' refers to a mutable ref" {
+		t.Fatalf("expected configured floating branch to be flagged, got %+v", findings)
+	}
+	app.Object["spec"].(map[string]interface{})["source"].(map[string]interface{})["targetRevision"] = "main"
+	if findings := revision.Check(app, ctx, configured); len(findings) != 0 {
+		t.Fatalf("expected floatingRevisions to replace the built-in branch list, got %+v", findings)
+	}
+
+	labels := ruleRecommendedLabels()
+	configured, err = cfg.Resolve(labels.Metadata, app.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	findings = labels.Check(app, ctx, configured)
+	if len(findings) != 1 || findings[0].Message != "Add required label cost-center to metadata" {
+		t.Fatalf("expected missing cost-center label only, got %+v", findings)
+	}
+}
+
+func TestUniqueNameFindingsCoversAllKinds(t *testing.T) {
+	named := func(kind, name, file string, spec map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{FilePath: file, Kind: kind, Name: name, Object: map[string]interface{}{"spec": spec}}
+	}
+	appSetSpec := map[string]interface{}{
+		"generators": []interface{}{
+			map[string]interface{}{"list": map[string]interface{}{"elements": []interface{}{
+				map[string]interface{}{"env": "dev"},
+				map[string]interface{}{"env": "prod"},
+			}}},
+			map[string]interface{}{"git": map[string]interface{}{
+				"repoURL":     "https://example.com/repo.git",
+				"directories": []interface{}{map[string]interface{}{"path": "apps/*"}},
+			}},
+		},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web-{{env}}"},
+			"spec":     map[string]interface{}{"project": "team-a"},
+		},
+	}
+	ctx := &Context{Manifests: []*manifest.Manifest{
+		named(string(types.ResourceKindApplicationSet), "web", "appset-a.yaml", appSetSpec),
+		named(string(types.ResourceKindApplicationSet), "web", "appset-b.yaml", map[string]interface{}{}),
+		named(string(types.ResourceKindAppProject), "team-a", "project-a.yaml", map[string]interface{}{}),
+		named(string(types.ResourceKindAppProject), "team-a", "project-b.yaml", map[string]interface{}{}),
+		named(string(types.ResourceKindApplication), "web-prod", "app.yaml", map[string]interface{}{}),
+		named(string(types.ResourceKindApplication), "team-a", "other.yaml", map[string]interface{}{}),
+	}}
+	messages := map[string]string{}
+	for _, f := range UniqueNameFindings(ctx) {
+		messages[f.FilePath] = f.Message
+	}
+	want := map[string]string{
+		"appset-a.yaml":  "ApplicationSet name 'web' is declared in multiple manifests",
+		"appset-b.yaml":  "ApplicationSet name 'web' is declared in multiple manifests",
+		"project-a.yaml": "AppProject name 'team-a' is declared in multiple manifests",
+		"project-b.yaml": "AppProject name 'team-a' is declared in multiple manifests",
+		"app.yaml":       "Application name 'web-prod' collides with an Application generated by ApplicationSet 'web'",
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), messages)
+	}
+	for file, msg := range want {
+		if messages[file] != msg {
+			t.Fatalf("%s: expected %q, got %q", file, msg, messages[file])
+		}
+	}
+}
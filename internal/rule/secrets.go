@@ -0,0 +1,195 @@
+package rule
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+const (
+	defaultSecretEntropyThreshold = 4.0
+	defaultSecretMinTokenLength   = 24
+)
+
+var (
+	awsAccessKeyPattern  = regexp.MustCompile(`\b(?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`)
+	privateKeyPattern    = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	sensitiveKeyPattern  = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|api[-_]?key|access[-_]?key|private[-_]?key|credentials?)`)
+	referenceKeyPattern  = regexp.MustCompile(`(?i)(name|ref|existing\w*|file|path|enabled|length|policy|ttl|duration|expiry)$`)
+	inlineAssignment     = regexp.MustCompile(`(?m)^\s*-?\s*["']?([\w.-]+)["']?\s*[:=]\s*["']?([^\s"'#]*)`)
+	entropyTokenPattern  = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+	hexTokenPattern      = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	referenceValuePrefix = []string{"$", "<", "{{", "vault:", "ref+", "secretref", "env:"}
+)
+
+func ruleSecretMaterial() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR030",
+		Description:     "Manifests must not embed credentials, keys, or high-entropy tokens",
+		Rationale:       "Secrets committed to Git are readable by everyone with access to the repository and stay in its history.",
+		Remediation:     "Remove the value, rotate it, and load it through a secret manager or a sealed or external secret.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet, types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/secret-management/",
+		Category:        "security",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			switch m.Kind {
+			case string(types.ResourceKindApplication), string(types.ResourceKindApplicationSet), string(types.ResourceKindAppProject):
+				return true
+			}
+			return false
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			scanner := secretScanner{threshold: defaultSecretEntropyThreshold, minLength: defaultSecretMinTokenLength}
+			if v, ok := cfg.FloatParam("entropyThreshold"); ok {
+				scanner.threshold = v
+			}
+			if v, ok := cfg.IntParam("minTokenLength"); ok {
+				scanner.minLength = v
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			scanner.walk(getMap(m.Object, "spec"), "$.spec", "spec", func(path, reason string) {
+				finding := builder.NewFinding(fmt.Sprintf("%s contains %s", strings.TrimPrefix(path, "$."), reason), cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Move the value into a Secret",
+						Description: "Reference a Kubernetes Secret, External Secrets, Sealed Secrets, or a secret-management plugin instead of committing the literal.",
+						Patch:       "valueFrom:\n  secretKeyRef:\n    name: <secret>\n    key: <key>",
+						Path:        path,
+					},
+				}
+				findings = append(findings, finding)
+			})
+			return findings
+		},
+	}
+}
+
+// secretScanner detects credential material in manifest values. Hex-only
+// tokens are ignored for entropy so commit SHAs and digests stay quiet.
+type secretScanner struct {
+	threshold float64
+	minLength int
+}
+
+func (s secretScanner) walk(value interface{}, path, key string, report func(path, reason string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		// Helm parameters and plugin env entries carry the effective key in name.
+		paramName, _ := v["name"].(string)
+		for _, k := range keys {
+			childKey := k
+			if k == "value" && paramName != "" {
+				childKey = paramName
+			}
+			s.walk(v[k], path+"."+k, childKey, report)
+		}
+	case []interface{}:
+		for i, item := range v {
+			s.walk(item, fmt.Sprintf("%s[%d]", path, i), key, report)
+		}
+	case string:
+		if reason := s.scan(key, v); reason != "" {
+			report(path, reason)
+		}
+	}
+}
+
+// scan returns a description of the secret material in value, or "".
+func (s secretScanner) scan(key, value string) string {
+	if awsAccessKeyPattern.MatchString(value) {
+		return "an AWS access key ID"
+	}
+	if privateKeyPattern.MatchString(value) {
+		return "a private key"
+	}
+	if isSensitiveKey(key) && isLiteralSecret(value) {
+		return fmt.Sprintf("a hard-coded credential for '%s'", key)
+	}
+	if strings.Contains(value, "\n") {
+		for _, match := range inlineAssignment.FindAllStringSubmatch(value, -1) {
+			if isSensitiveKey(match[1]) && isLiteralSecret(match[2]) {
+				return fmt.Sprintf("a hard-coded credential for '%s'", match[1])
+			}
+		}
+	}
+	if strings.Contains(value, "{{") {
+		return ""
+	}
+	for _, token := range entropyTokenPattern.FindAllString(value, -1) {
+		if len(token) < s.minLength || hexTokenPattern.MatchString(token) || !mixedAlphanumeric(token) {
+			continue
+		}
+		if shannonEntropy(token) >= s.threshold {
+			return "a high-entropy token"
+		}
+	}
+	return ""
+}
+
+func isSensitiveKey(key string) bool {
+	last := key
+	if idx := strings.LastIndexAny(key, "."); idx >= 0 {
+		last = key[idx+1:]
+	}
+	return sensitiveKeyPattern.MatchString(last) && !referenceKeyPattern.MatchString(last)
+}
+
+func isLiteralSecret(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Contains(value, "\n") {
+		return false
+	}
+	lower := strings.ToLower(value)
+	for _, prefix := range referenceValuePrefix {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	return !strings.Contains(value, "{{")
+}
+
+func mixedAlphanumeric(token string) bool {
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// shannonEntropy returns the bits of entropy per character of token.
+func shannonEntropy(token string) float64 {
+	counts := map[rune]int{}
+	for _, r := range token {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(token))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,438 @@
+package rule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/gitutil"
+	"github.com/argocd-lint/argocd-lint/internal/loader"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func ruleMultiSourceRefs() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR022",
+		Description:     "Multi-source refs must be unique, resolvable, and kept separate from rendered sources",
+		Rationale:       "A ref that is duplicated or never used makes $ref value files resolve to the wrong source or fail at render time.",
+		Example:         "sources:\n  - repoURL: https://github.com/example/values.git\n    targetRevision: v1.0.0\n    ref: values\n  - chart: api\n    repoURL: https://charts.example.com\n    targetRevision: 1.2.3\n    helm:\n      valueFiles:\n        - $values/api/values.yaml",
+		Remediation:     "Give each ref source a unique name, reference it from a value file, and keep path and chart off ref-only sources.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/multiple_sources/",
+		Category:        "configuration",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"spec", "sources"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "spec", "sources"}
+			}
+			sources := getSlice(m.Object, path...)
+			if len(sources) == 0 {
+				return nil
+			}
+			jsonPath := "$." + strings.Join(path, ".")
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			refs := map[string]int{}
+			rendered := 0
+			for idx, raw := range sources {
+				src, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				ref := strings.TrimSpace(getStringMap(src, "ref"))
+				hasContent := strings.TrimSpace(getStringMap(src, "path")) != "" || strings.TrimSpace(getStringMap(src, "chart")) != ""
+				if ref == "" {
+					if hasContent {
+						rendered++
+					}
+					continue
+				}
+				if first, dup := refs[ref]; dup {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("sources[%d].ref '%s' duplicates sources[%d]; ref names must be unique", idx, ref, first), cfg.Severity))
+					continue
+				}
+				refs[ref] = idx
+				if hasContent {
+					finding := builder.NewFinding(fmt.Sprintf("sources[%d] declares ref '%s' and path/chart; its manifests are deployed as well as referenced", idx, ref), types.SeverityWarn)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Keep the ref source values-only",
+							Description: "Remove path/chart from the ref source and render manifests from a separate source.",
+							Patch:       fmt.Sprintf("- repoURL: <values-repo>\n  targetRevision: <tag>\n  ref: %s", ref),
+							Path:        fmt.Sprintf("%s[%d]", jsonPath, idx),
+						},
+					}
+					findings = append(findings, finding)
+					rendered++
+				}
+			}
+			if rendered == 0 {
+				findings = append(findings, builder.NewFinding("no entry in spec.sources sets path or chart; the Application renders nothing", cfg.Severity))
+			}
+			for idx, raw := range sources {
+				src, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for fileIdx, rawFile := range getSlice(src, "helm", "valueFiles") {
+					file, ok := rawFile.(string)
+					if !ok || !strings.HasPrefix(file, "$") || strings.Contains(file, "{{") {
+						continue
+					}
+					name, _, _ := strings.Cut(strings.TrimPrefix(file, "$"), "/")
+					if _, ok := refs[name]; ok {
+						continue
+					}
+					finding := builder.NewFinding(fmt.Sprintf("sources[%d].helm.valueFiles entry '%s' references undeclared ref '%s'", idx, file, name), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Declare the referenced source",
+							Description: "Add a source with a matching ref so Argo CD can resolve the values file.",
+							Patch:       fmt.Sprintf("- repoURL: <values-repo>\n  targetRevision: <tag>\n  ref: %s", name),
+							Path:        fmt.Sprintf("%s[%d].helm.valueFiles[%d]", jsonPath, idx, fileIdx),
+						},
+					}
+					findings = append(findings, finding)
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// sourceEntry is a source of an Application or ApplicationSet template
+// together with its JSONPath.
+type sourceEntry struct {
+	path   string
+	source map[string]interface{}
+}
+
+// applicationSources returns spec.source and spec.sources[] of an
+// Application, or of the template of an ApplicationSet.
+func applicationSources(m *manifest.Manifest) []sourceEntry {
+	specPath := []string{"spec"}
+	if m.Kind == string(types.ResourceKindApplicationSet) {
+		specPath = []string{"spec", "template", "spec"}
+	}
+	spec := getMap(m.Object, specPath...)
+	prefix := "$." + strings.Join(specPath, ".")
+	var entries []sourceEntry
+	if src := getMap(spec, "source"); len(src) > 0 {
+		entries = append(entries, sourceEntry{path: prefix + ".source", source: src})
+	}
+	for idx, raw := range getSlice(spec, "sources") {
+		if src, ok := raw.(map[string]interface{}); ok {
+			entries = append(entries, sourceEntry{path: fmt.Sprintf("%s.sources[%d]", prefix, idx), source: src})
+		}
+	}
+	return entries
+}
+
+func ruleKustomizeImagesPinned() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR031",
+		Description:     "Kustomize image overrides must use a digest or a pinned tag",
+		Rationale:       "Image tags are mutable, so the same overlay can deploy different images over time.",
+		Example:         "kustomize:\n  images:\n    - ghcr.io/example/api:1.4.2",
+		Remediation:     "Pin kustomize.images entries to a version tag or a digest.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/kustomize/",
+		Category:        "best-practice",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, entry := range applicationSources(m) {
+				for idx, raw := range getSlice(entry.source, "kustomize", "images") {
+					image, ok := raw.(string)
+					if !ok || strings.Contains(image, "{{") {
+						continue
+					}
+					msg := checkKustomizeImage(strings.TrimSpace(image))
+					if msg == "" {
+						continue
+					}
+					finding := builder.NewFinding(msg, cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Pin the image",
+							Description: "Use an immutable digest or an exact version tag so syncs deploy the same image every time.",
+							Patch:       "- <name>=<registry>/<image>@sha256:<digest>",
+							Path:        fmt.Sprintf("%s.kustomize.images[%d]", entry.path, idx),
+						},
+					}
+					findings = append(findings, finding)
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// checkKustomizeImage validates an image override of the form
+// [<name>=]<image>[:<tag>][@<digest>].
+func checkKustomizeImage(image string) string {
+	target := image
+	if _, override, ok := strings.Cut(image, "="); ok {
+		target = override
+	}
+	if strings.Contains(target, "@") {
+		return ""
+	}
+	tag := ""
+	if idx := strings.LastIndex(target, ":"); idx > strings.LastIndex(target, "/") {
+		tag = target[idx+1:]
+	}
+	switch tag {
+	case "":
+		return fmt.Sprintf("kustomize image '%s' has no tag or digest and resolves to latest", image)
+	case "latest":
+		return fmt.Sprintf("kustomize image '%s' uses the mutable 'latest' tag", image)
+	}
+	return ""
+}
+
+var exactChartVersion = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+func ruleHelmChartVersionPinned() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR032",
+		Description:     "Helm chart sources must pin targetRevision to an exact chart version",
+		Rationale:       "Chart version ranges resolve to whatever was published last, so a chart release changes the deployment without a commit.",
+		Example:         "chart: api\ntargetRevision: 1.2.3",
+		Remediation:     "Set targetRevision to an exact chart version.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/helm/",
+		Category:        "best-practice",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, entry := range applicationSources(m) {
+				chart := strings.TrimSpace(getStringMap(entry.source, "chart"))
+				if chart == "" {
+					continue
+				}
+				version := strings.TrimSpace(getStringMap(entry.source, "targetRevision"))
+				if strings.Contains(version, "{{") || exactChartVersion.MatchString(version) {
+					continue
+				}
+				msg := fmt.Sprintf("chart '%s' targetRevision '%s' is not an exact semver version", chart, version)
+				if version == "" {
+					msg = fmt.Sprintf("chart '%s' has no targetRevision and follows the latest published version", chart)
+				}
+				finding := builder.NewFinding(msg, cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Pin the chart version",
+						Description: "Ranges such as >=1.0.0, 1.x, or * pick up new chart releases without a Git change.",
+						Patch:       "targetRevision: 1.2.3",
+						Path:        entry.path + ".targetRevision",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+func ruleConfigManagementPlugins() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR041",
+		Description:     "Config Management Plugin sources are not rendered offline and plugin.env must be well-formed",
+		Rationale:       "Config Management Plugin output cannot be rendered offline, and malformed plugin.env entries are rejected by the repo server.",
+		Example:         "plugin:\n  name: envsubst\n  env:\n    - name: REGION\n      value: eu-west-1",
+		Remediation:     "Give each plugin.env entry a unique name and a value, and render plugin sources in CI if they need checking.",
+		DefaultSeverity: types.SeverityInfo,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/operator-manual/config-management-plugins/",
+		Category:        "configuration",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, entry := range applicationSources(m) {
+				raw, ok := entry.source["plugin"]
+				if !ok {
+					continue
+				}
+				plugin, _ := raw.(map[string]interface{})
+				name := strings.TrimSpace(getStringMap(plugin, "name"))
+				if name == "" {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("%s is rendered by a discovered Config Management Plugin and is not validated offline", entry.path), cfg.Severity))
+				} else {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("%s is rendered by Config Management Plugin '%s' and is not validated offline", entry.path, name), cfg.Severity))
+				}
+				seen := map[string]int{}
+				for idx, item := range getSlice(plugin, "env") {
+					env, _ := item.(map[string]interface{})
+					key := strings.TrimSpace(getStringMap(env, "name"))
+					path := fmt.Sprintf("%s.plugin.env[%d]", entry.path, idx)
+					if key == "" {
+						findings = append(findings, builder.NewFinding(fmt.Sprintf("%s has no name", path), types.SeverityError))
+						continue
+					}
+					if first, dup := seen[key]; dup {
+						findings = append(findings, builder.NewFinding(fmt.Sprintf("%s duplicates env[%d] name '%s'; the plugin only sees one value", path, first, key), types.SeverityError))
+						continue
+					}
+					seen[key] = idx
+				}
+			}
+			return findings
+		},
+	}
+}
+
+func ruleDirectoryPatternsMatch() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR042",
+		Description:     "Directory include and exclude patterns must match at least one file",
+		Rationale:       "Include and exclude patterns that match nothing leave a directory source empty or deploying more than intended.",
+		Example:         "directory:\n  include: \"{deployment.yaml,service.yaml}\"",
+		Remediation:     "Fix the glob so that it matches files under the source path.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/directory/",
+		Category:        "consistency",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			if ctx.RepoRoot == "" {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, entry := range applicationSources(m) {
+				directory := loader.DirectorySourceOptions(entry.source)
+				path := strings.TrimSpace(getStringMap(entry.source, "path"))
+				if !directory.Filtered() || path == "" || strings.Contains(path, "{{") {
+					continue
+				}
+				dir := filepath.Join(ctx.RepoRoot, path)
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() || loader.IsToolDirectory(dir) {
+					continue
+				}
+				files, err := loader.DirectorySourceFiles(dir, directory)
+				if err != nil || len(files) > 0 {
+					continue
+				}
+				var patterns []string
+				if directory.Include != "" {
+					patterns = append(patterns, fmt.Sprintf("include '%s'", directory.Include))
+				}
+				if directory.Exclude != "" {
+					patterns = append(patterns, fmt.Sprintf("exclude '%s'", directory.Exclude))
+				}
+				scope := "in"
+				if directory.Recurse {
+					scope = "under"
+				}
+				verb := "matches"
+				if len(patterns) > 1 {
+					verb = "match"
+				}
+				findings = append(findings, builder.NewFinding(fmt.Sprintf("%s.directory %s %s no files %s %s; the source deploys nothing", entry.path, strings.Join(patterns, " and "), verb, scope, path), cfg.Severity))
+			}
+			return findings
+		},
+	}
+}
+
+func ruleSourcePathExists() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR043",
+		Description:     "Source paths must exist in the repository and contain manifests",
+		Rationale:       "A missing or empty source path makes the Application sync an empty manifest set and can prune everything it deployed.",
+		Remediation:     "Point source.path at a directory in the repository that holds manifests, a chart, or a kustomization.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/application-specification/",
+		Category:        "consistency",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			if ctx.RepoRoot == "" {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for _, entry := range applicationSources(m) {
+				path := strings.TrimSpace(getStringMap(entry.source, "path"))
+				if path == "" || strings.Contains(path, "{{") || getStringMap(entry.source, "chart") != "" {
+					continue
+				}
+				if repo := getStringMap(entry.source, "repoURL"); ctx.RepoURL != "" && repo != "" && !gitutil.SameRepo(repo, ctx.RepoURL) {
+					// Another repository; its paths are not on disk here.
+					continue
+				}
+				dir := filepath.Join(ctx.RepoRoot, path)
+				info, err := os.Stat(dir)
+				switch {
+				case err != nil:
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("%s.path '%s' does not exist in the repository; Argo CD reports app path does not exist", entry.path, path), cfg.Severity))
+					continue
+				case !info.IsDir():
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("%s.path '%s' is a file, not a directory", entry.path, path), cfg.Severity))
+					continue
+				}
+				if _, plugin := entry.source["plugin"]; plugin || loader.IsToolDirectory(dir) {
+					continue
+				}
+				// Patterns that match nothing are reported by AR042.
+				directory := loader.DirectorySourceOptions(entry.source)
+				files, err := loader.DirectorySourceFiles(dir, loader.DirectorySource{Recurse: directory.Recurse})
+				if err == nil && len(files) == 0 {
+					scope := "in"
+					if directory.Recurse {
+						scope = "under"
+					}
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("%s.path '%s' has no YAML, JSON, or Jsonnet manifests %s it; the source deploys nothing", entry.path, path, scope), types.SeverityWarn))
+				}
+			}
+			return findings
+		},
+	}
+}
@@ -0,0 +1,266 @@
+package rule
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func multiSourceApp(sources ...interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{"sources": sources},
+		},
+	}
+}
+
+func TestRuleMultiSourceRefs(t *testing.T) {
+	app := multiSourceApp(
+		map[string]interface{}{
+			"repoURL": "https://charts.example.com",
+			"chart":   "web",
+			"helm": map[string]interface{}{
+				"valueFiles": []interface{}{"$values/web/prod.yaml", "$config/extra.yaml", "defaults.yaml"},
+			},
+		},
+		map[string]interface{}{"repoURL": "https://git.example.com/values.git", "ref": "values"},
+		map[string]interface{}{"repoURL": "https://git.example.com/other.git", "ref": "values"},
+		map[string]interface{}{"repoURL": "https://git.example.com/mixed.git", "ref": "mixed", "path": "manifests"},
+	)
+	findings := checkRule(t, ruleMultiSourceRefs(), config.Config{}, app)
+	want := []string{
+		"sources[2].ref 'values' duplicates sources[1]",
+		"declares ref 'mixed' and path/chart",
+		"references undeclared ref 'config'",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if findings[2].Suggestions[0].Path != "$.spec.sources[0].helm.valueFiles[1]" {
+		t.Fatalf("unexpected suggestion path %s", findings[2].Suggestions[0].Path)
+	}
+}
+
+func TestRuleMultiSourceRefsRequiresRenderedSource(t *testing.T) {
+	app := multiSourceApp(map[string]interface{}{"repoURL": "https://git.example.com/values.git", "ref": "values"})
+	findings := checkRule(t, ruleMultiSourceRefs(), config.Config{}, app)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "renders nothing") {
+		t.Fatalf("expected missing rendered source finding, got %+v", findings)
+	}
+	if findings := checkRule(t, ruleSourceConsistency(), config.Config{}, app); len(findings) != 0 {
+		t.Fatalf("expected AR009 to accept ref-only sources, got %+v", findings)
+	}
+}
+
+func TestRuleKustomizeImagesPinned(t *testing.T) {
+	app := multiSourceApp(map[string]interface{}{
+		"repoURL": "https://git.example.com/app.git",
+		"path":    "overlays/prod",
+		"kustomize": map[string]interface{}{"images": []interface{}{
+			"nginx",
+			"nginx:1.25.3",
+			"api=registry.example.com:5000/api:latest",
+			"worker=registry.example.com:5000/worker",
+			"cache=redis@sha256:0123456789abcdef",
+			"{{ .image }}",
+		}},
+	})
+	findings := checkRule(t, ruleKustomizeImagesPinned(), config.Config{}, app)
+	want := []string{
+		"'nginx' has no tag or digest",
+		"'api=registry.example.com:5000/api:latest' uses the mutable 'latest' tag",
+		"'worker=registry.example.com:5000/worker' has no tag or digest",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	if path := findings[1].Suggestions[0].Path; path != "$.spec.sources[0].kustomize.images[2]" {
+		t.Fatalf("unexpected suggestion path %s", path)
+	}
+}
+
+func TestRuleHelmChartVersionPinned(t *testing.T) {
+	chart := func(version string) map[string]interface{} {
+		return map[string]interface{}{"repoURL": "https://charts.example.com", "chart": "web", "targetRevision": version}
+	}
+	app := multiSourceApp(chart("1.2.3"), chart("v2.0.0-rc.1+build.5"), chart(">=1.0.0"), chart("1.x"), chart("*"), chart(""), chart("{{ .version }}"))
+	findings := checkRule(t, ruleHelmChartVersionPinned(), config.Config{}, app)
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got %+v", findings)
+	}
+	if findings[0].Message != "chart 'web' targetRevision '>=1.0.0' is not an exact semver version" {
+		t.Fatalf("unexpected message %q", findings[0].Message)
+	}
+	if !strings.Contains(findings[3].Message, "has no targetRevision") {
+		t.Fatalf("unexpected message %q", findings[3].Message)
+	}
+	single := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object:   map[string]interface{}{"spec": map[string]interface{}{"source": chart("1.x")}},
+	}
+	if findings := checkRule(t, ruleTargetRevisionPinned(), config.Config{}, single); len(findings) != 0 {
+		t.Fatalf("expected AR001 to leave chart versions to AR032, got %+v", findings)
+	}
+}
+
+func TestRuleConfigManagementPlugins(t *testing.T) {
+	app := multiSourceApp(
+		map[string]interface{}{
+			"repoURL": "https://git.example.com/app.git",
+			"path":    "app",
+			"plugin": map[string]interface{}{
+				"name": "sops",
+				"env": []interface{}{
+					map[string]interface{}{"name": "STAGE", "value": "prod"},
+					map[string]interface{}{"name": "", "value": "orphan"},
+					map[string]interface{}{"name": "STAGE", "value": "dev"},
+				},
+			},
+		},
+		map[string]interface{}{"repoURL": "https://git.example.com/other.git", "path": "other", "plugin": map[string]interface{}{}},
+		map[string]interface{}{"repoURL": "https://git.example.com/plain.git", "path": "plain"},
+	)
+	findings := checkRule(t, ruleConfigManagementPlugins(), config.Config{}, app)
+	want := []struct {
+		severity types.Severity
+		message  string
+	}{
+		{types.SeverityInfo, "$.spec.sources[0] is rendered by Config Management Plugin 'sops'"},
+		{types.SeverityError, "$.spec.sources[0].plugin.env[1] has no name"},
+		{types.SeverityError, "$.spec.sources[0].plugin.env[2] duplicates env[0] name 'STAGE'"},
+		{types.SeverityInfo, "$.spec.sources[1] is rendered by a discovered Config Management Plugin"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, w := range want {
+		if findings[i].Severity != w.severity || !strings.HasPrefix(findings[i].Message, w.message) {
+			t.Fatalf("finding %d: expected %s %q, got %s %q", i, w.severity, w.message, findings[i].Severity, findings[i].Message)
+		}
+	}
+}
+
+func TestRuleDirectoryPatternsMatch(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"manifests/deploy.yaml", "manifests/prod/service.yaml", "chart/Chart.yaml"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("kind: ConfigMap\n"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	source := func(path string, directory map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"repoURL": "https://git.example.com/app.git", "path": path, "directory": directory}
+	}
+	app := multiSourceApp(
+		source("manifests", map[string]interface{}{"include": "*.json"}),
+		source("manifests", map[string]interface{}{"include": "service.yaml", "recurse": true}),
+		source("manifests", map[string]interface{}{"include": "*.txt", "exclude": "*.yaml", "recurse": true}),
+		source("chart", map[string]interface{}{"include": "*.json"}),
+		source("missing", map[string]interface{}{"include": "*.json"}),
+	)
+	rl := ruleDirectoryPatternsMatch()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, app.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	if findings := rl.Check(app, &Context{Config: cfg}, configured); len(findings) != 0 {
+		t.Fatalf("expected no findings without a repo root, got %+v", findings)
+	}
+	findings := rl.Check(app, &Context{Config: cfg, RepoRoot: root}, configured)
+	want := []string{
+		"$.spec.sources[0].directory include '*.json' matches no files in manifests; the source deploys nothing",
+		"$.spec.sources[2].directory include '*.txt' and exclude '*.yaml' match no files under manifests; the source deploys nothing",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, finding := range findings {
+		if finding.Message != want[i] || finding.Severity != types.SeverityError {
+			t.Fatalf("finding %d: got %q (%s), want %q", i, finding.Message, finding.Severity, want[i])
+		}
+	}
+}
+
+func TestRuleSourcePathExists(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"apps/web/deploy.yaml", "apps/nested/prod/deploy.yaml", "apps/docs/README.md", "charts/web/Chart.yaml", "apps/values.yaml"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("kind: ConfigMap\n"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	source := func(repo, path string, extra map[string]interface{}) map[string]interface{} {
+		src := map[string]interface{}{"repoURL": repo, "path": path}
+		for k, v := range extra {
+			src[k] = v
+		}
+		return src
+	}
+	const repo = "https://git.example.com/platform.git"
+	app := multiSourceApp(
+		source(repo, "apps/web", nil),
+		source(repo, "apps/wbe", nil),
+		source("git@git.example.com:platform", "apps/values.yaml", nil),
+		source(repo, "apps/docs", nil),
+		source(repo, "apps/nested", nil),
+		source(repo, "apps/nested", map[string]interface{}{"directory": map[string]interface{}{"recurse": true}}),
+		source(repo, "charts/web", nil),
+		source(repo, "apps/{{path.basename}}", nil),
+		source("https://git.example.com/other.git", "missing", nil),
+		source(repo, "apps/docs", map[string]interface{}{"plugin": map[string]interface{}{"name": "sops"}}),
+	)
+	rl := ruleSourcePathExists()
+	cfg := config.Config{}
+	configured, err := cfg.Resolve(rl.Metadata, app.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	if findings := rl.Check(app, &Context{Config: cfg}, configured); len(findings) != 0 {
+		t.Fatalf("expected no findings without a repo root, got %+v", findings)
+	}
+	findings := rl.Check(app, &Context{Config: cfg, RepoRoot: root, RepoURL: "https://GIT.example.com/platform"}, configured)
+	want := []struct {
+		severity types.Severity
+		message  string
+	}{
+		{types.SeverityError, "$.spec.sources[1].path 'apps/wbe' does not exist in the repository; Argo CD reports app path does not exist"},
+		{types.SeverityError, "$.spec.sources[2].path 'apps/values.yaml' is a file, not a directory"},
+		{types.SeverityWarn, "$.spec.sources[3].path 'apps/docs' has no YAML, JSON, or Jsonnet manifests in it; the source deploys nothing"},
+		{types.SeverityWarn, "$.spec.sources[4].path 'apps/nested' has no YAML, JSON, or Jsonnet manifests in it; the source deploys nothing"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, finding := range findings {
+		if finding.Message != want[i].message || finding.Severity != want[i].severity {
+			t.Fatalf("finding %d: got %q (%s), want %q (%s)", i, finding.Message, finding.Severity, want[i].message, want[i].severity)
+		}
+	}
+}
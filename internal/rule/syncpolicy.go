@@ -0,0 +1,317 @@
+package rule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// knownSyncOptions maps Application-level sync options to their accepted
+// values, mirroring the Argo CD sync options documentation.
+var knownSyncOptions = map[string][]string{
+	"Validate":                    {"true", "false"},
+	"CreateNamespace":             {"true", "false"},
+	"PruneLast":                   {"true", "false"},
+	"ApplyOutOfSyncOnly":          {"true", "false"},
+	"PrunePropagationPolicy":      {"foreground", "background", "orphan"},
+	"Replace":                     {"true", "false"},
+	"ServerSideApply":             {"true", "false"},
+	"FailOnSharedResource":        {"true", "false"},
+	"RespectIgnoreDifferences":    {"true", "false"},
+	"SkipDryRunOnMissingResource": {"true", "false"},
+	"ClientSideApplyMigration":    {"true", "false"},
+}
+
+func ruleSyncOptionsKnown() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR015",
+		Description:     "syncOptions entries must be known Argo CD sync options with valid values",
+		Rationale:       "Unknown syncOptions are ignored by Argo CD, so a misspelt option silently has no effect.",
+		Example:         "syncOptions:\n  - CreateNamespace=true\n  - ServerSideApply=true",
+		Remediation:     "Fix the option name or value; see the Argo CD sync options documentation for the accepted list.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/sync-options/",
+		Category:        "operations",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"spec", "syncPolicy", "syncOptions"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "spec", "syncPolicy", "syncOptions"}
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			var findings []types.Finding
+			for idx, item := range getSlice(m.Object, path...) {
+				option, ok := item.(string)
+				if !ok || strings.Contains(option, "{{") {
+					continue
+				}
+				msg, suggestion := checkSyncOption(strings.TrimSpace(option))
+				if msg == "" {
+					continue
+				}
+				finding := builder.NewFinding(msg, cfg.Severity)
+				if suggestion != "" {
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       fmt.Sprintf("Use %s", suggestion),
+							Description: "Unknown sync options are ignored by Argo CD at sync time.",
+							Patch:       "- " + suggestion,
+							Path:        fmt.Sprintf("$.%s[%d]", strings.Join(path, "."), idx),
+						},
+					}
+				}
+				findings = append(findings, finding)
+			}
+			return findings
+		},
+	}
+}
+
+// checkSyncOption returns a message for an invalid option and, when the
+// intent is recognisable, the corrected spelling.
+func checkSyncOption(option string) (string, string) {
+	key, value, ok := strings.Cut(option, "=")
+	if !ok {
+		return fmt.Sprintf("syncOption '%s' must use Key=value form", option), ""
+	}
+	allowed, known := knownSyncOptions[key]
+	if !known {
+		if match := closestSyncOption(key); match != "" {
+			return fmt.Sprintf("unknown syncOption '%s'; did you mean '%s'?", key, match), match + "=" + value
+		}
+		return fmt.Sprintf("unknown syncOption '%s'", key), ""
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return "", ""
+		}
+	}
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return fmt.Sprintf("syncOption %s value '%s' must be lowercase '%s'", key, value, candidate), key + "=" + candidate
+		}
+	}
+	return fmt.Sprintf("syncOption %s has invalid value '%s' (expected %s)", key, value, strings.Join(allowed, "|")), ""
+}
+
+// closestSyncOption finds a known option within a small edit distance,
+// ignoring case, to catch typos such as CreateNamepsace.
+func closestSyncOption(key string) string {
+	names := make([]string, 0, len(knownSyncOptions))
+	for name := range knownSyncOptions {
+		names = append(names, name)
+	}
+	return closestName(key, names)
+}
+
+// closestName returns the candidate within edit distance 2 of key
+// (case-insensitive), or "" when none is close enough.
+func closestName(key string, candidates []string) string {
+	names := append([]string(nil), candidates...)
+	sort.Strings(names)
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func ruleAutomatedSyncRetry() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR020",
+		Description:     "Automated sync should configure retry with a valid backoff",
+		Rationale:       "Automated syncs that fail without retry stay failed until the next commit; an invalid backoff is rejected by Argo CD.",
+		Example:         "retry:\n  limit: 5\n  backoff:\n    duration: 5s\n    factor: 2\n    maxDuration: 3m",
+		Remediation:     "Add syncPolicy.retry with a limit and a backoff whose durations parse and whose factor is at least 1.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/auto_sync/",
+		Category:        "operations",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"spec", "syncPolicy"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "spec", "syncPolicy"}
+			}
+			policy := getMap(m.Object, path...)
+			automated, ok := policy["automated"].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			if enabled, set := automated["enabled"].(bool); set && !enabled {
+				return nil
+			}
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			jsonPath := "$." + strings.Join(path, ".") + ".retry"
+			retry, ok := policy["retry"].(map[string]interface{})
+			if !ok {
+				finding := builder.NewFinding("automated sync has no syncPolicy.retry; transient failures will not be retried", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Add retry with exponential backoff",
+						Description: "Retry failed syncs a bounded number of times with increasing delays.",
+						Patch:       "retry:\n  limit: 5\n  backoff:\n    duration: 5s\n    factor: 2\n    maxDuration: 3m",
+						Path:        jsonPath,
+					},
+				}
+				return []types.Finding{finding}
+			}
+			var findings []types.Finding
+			if _, ok := retry["limit"]; !ok {
+				finding := builder.NewFinding("syncPolicy.retry.limit is not set; declare how many times a failed sync is retried", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Set a retry limit",
+						Description: "A bounded limit avoids endless retries of a broken revision.",
+						Patch:       "limit: 5",
+						Path:        jsonPath + ".limit",
+					},
+				}
+				findings = append(findings, finding)
+			}
+			backoff := getMap(retry, "backoff")
+			durations := map[string]time.Duration{}
+			for _, key := range []string{"duration", "maxDuration"} {
+				raw, ok := backoff[key]
+				if !ok {
+					continue
+				}
+				value := strings.TrimSpace(fmt.Sprint(raw))
+				d, err := parseBackoffDuration(value)
+				if err != nil {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("syncPolicy.retry.backoff.%s '%s' is not a valid duration (e.g. 5s, 3m)", key, value), cfg.Severity))
+					continue
+				}
+				durations[key] = d
+			}
+			if d, ok := durations["duration"]; ok {
+				if max, ok := durations["maxDuration"]; ok && max < d {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("syncPolicy.retry.backoff.maxDuration %s is shorter than duration %s", max, d), cfg.Severity))
+				}
+			}
+			if raw, ok := backoff["factor"]; ok {
+				if factor, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(raw)), 64); err != nil || factor < 1 {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("syncPolicy.retry.backoff.factor '%v' must be a number of at least 1", raw), cfg.Severity))
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// parseBackoffDuration mirrors Argo CD, where a bare number is seconds.
+func parseBackoffDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration")
+	}
+	return d, nil
+}
+
+func ruleManagedNamespaceMetadata() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR021",
+		Description:     "managedNamespaceMetadata and CreateNamespace=true should be used together",
+		Rationale:       "managedNamespaceMetadata is only applied to namespaces Argo CD creates, so it has no effect without CreateNamespace=true.",
+		Example:         "syncOptions:\n  - CreateNamespace=true\nmanagedNamespaceMetadata:\n  labels:\n    team: payments",
+		Remediation:     "Add CreateNamespace=true, or drop managedNamespaceMetadata.",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication, types.ResourceKindApplicationSet},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/sync-options/#namespace-metadata",
+		Category:        "operations",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies: func(m *manifest.Manifest) bool {
+			return m.Kind == string(types.ResourceKindApplication) || m.Kind == string(types.ResourceKindApplicationSet)
+		},
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			path := []string{"spec", "syncPolicy"}
+			if m.Kind == string(types.ResourceKindApplicationSet) {
+				path = []string{"spec", "template", "spec", "syncPolicy"}
+			}
+			policy := getMap(m.Object, path...)
+			jsonPath := "$." + strings.Join(path, ".")
+			createNamespace := false
+			for _, item := range getSlice(policy, "syncOptions") {
+				if option, ok := item.(string); ok && strings.TrimSpace(option) == "CreateNamespace=true" {
+					createNamespace = true
+				}
+			}
+			_, hasMetadata := policy["managedNamespaceMetadata"]
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			switch {
+			case hasMetadata && !createNamespace:
+				finding := builder.NewFinding("syncPolicy.managedNamespaceMetadata is ignored without CreateNamespace=true in syncOptions", cfg.Severity)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Enable CreateNamespace",
+						Description: "Argo CD only applies managed namespace metadata to namespaces it creates.",
+						Patch:       "syncOptions:\n  - CreateNamespace=true",
+						Path:        jsonPath + ".syncOptions",
+					},
+				}
+				return []types.Finding{finding}
+			case createNamespace && !hasMetadata:
+				finding := builder.NewFinding("CreateNamespace=true creates an unlabeled namespace; declare syncPolicy.managedNamespaceMetadata", types.SeverityInfo)
+				finding.Suggestions = []types.Suggestion{
+					{
+						Title:       "Label the managed namespace",
+						Description: "Namespace labels and annotations drive policies such as Pod Security admission and network isolation.",
+						Patch:       "managedNamespaceMetadata:\n  labels:\n    pod-security.kubernetes.io/enforce: restricted\n  annotations:\n    argocd.argoproj.io/owner: <team>",
+						Path:        jsonPath + ".managedNamespaceMetadata",
+					},
+				}
+				return []types.Finding{finding}
+			}
+			return nil
+		},
+	}
+}
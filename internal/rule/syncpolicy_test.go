@@ -0,0 +1,174 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestRuleSyncOptionsKnown(t *testing.T) {
+	rl := ruleSyncOptionsKnown()
+	cfg := config.Config{}
+	ctx := &Context{Config: cfg}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     string(types.ResourceKindApplication),
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"syncPolicy": map[string]interface{}{
+					"syncOptions": []interface{}{
+						"CreateNamespace=true",
+						"PrunePropagationPolicy=foreground",
+						"CreateNamepsace=true",
+						"ServerSideApply=True",
+						"PrunePropagationPolicy=cascade",
+						"Retry",
+						"{{ .syncOption }}",
+					},
+				},
+			},
+		},
+	}
+	configured, err := cfg.Resolve(rl.Metadata, app.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	findings := rl.Check(app, ctx, configured)
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got %d: %+v", len(findings), findings)
+	}
+	if !strings.Contains(findings[0].Message, "did you mean 'CreateNamespace'") {
+		t.Fatalf("expected typo suggestion, got %q", findings[0].Message)
+	}
+	if len(findings[0].Suggestions) != 1 || findings[0].Suggestions[0].Patch != "- CreateNamespace=true" {
+		t.Fatalf("expected corrected patch, got %+v", findings[0].Suggestions)
+	}
+	if findings[0].Suggestions[0].Path != "$.spec.syncPolicy.syncOptions[2]" {
+		t.Fatalf("unexpected suggestion path %s", findings[0].Suggestions[0].Path)
+	}
+	if !strings.Contains(findings[1].Message, "must be lowercase 'true'") {
+		t.Fatalf("expected value case finding, got %q", findings[1].Message)
+	}
+	if !strings.Contains(findings[2].Message, "expected foreground|background|orphan") {
+		t.Fatalf("expected invalid value finding, got %q", findings[2].Message)
+	}
+	if !strings.Contains(findings[3].Message, "Key=value") {
+		t.Fatalf("expected malformed option finding, got %q", findings[3].Message)
+	}
+}
+
+func TestRuleSyncOptionsKnownApplicationSetTemplate(t *testing.T) {
+	rl := ruleSyncOptionsKnown()
+	cfg := config.Config{}
+	appset := &manifest.Manifest{
+		FilePath: "appset.yaml",
+		Kind:     string(types.ResourceKindApplicationSet),
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"syncPolicy": map[string]interface{}{
+							"syncOptions": []interface{}{"ApplyOutOfSyncOnly=yes"},
+						},
+					},
+				},
+			},
+		},
+	}
+	configured, err := cfg.Resolve(rl.Metadata, appset.FilePath)
+	if err != nil {
+		t.Fatalf("resolve config: %v", err)
+	}
+	if findings := rl.Check(appset, &Context{Config: cfg}, configured); len(findings) != 1 {
+		t.Fatalf("expected one finding for template syncOptions, got %d", len(findings))
+	}
+}
+
+func TestRuleAutomatedSyncRetry(t *testing.T) {
+	rl := ruleAutomatedSyncRetry()
+	cfg := config.Config{}
+	app := func(policy map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{
+			FilePath: "app.yaml",
+			Kind:     string(types.ResourceKindApplication),
+			Name:     "demo",
+			Object:   map[string]interface{}{"spec": map[string]interface{}{"syncPolicy": policy}},
+		}
+	}
+	check := func(m *manifest.Manifest) []types.Finding {
+		configured, err := cfg.Resolve(rl.Metadata, m.FilePath)
+		if err != nil {
+			t.Fatalf("resolve config: %v", err)
+		}
+		return rl.Check(m, &Context{Config: cfg}, configured)
+	}
+
+	if findings := check(app(map[string]interface{}{"syncOptions": []interface{}{"CreateNamespace=true"}})); len(findings) != 0 {
+		t.Fatalf("expected manual sync to be ignored, got %+v", findings)
+	}
+	findings := check(app(map[string]interface{}{"automated": map[string]interface{}{}}))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "no syncPolicy.retry") {
+		t.Fatalf("expected missing retry finding, got %+v", findings)
+	}
+	findings = check(app(map[string]interface{}{
+		"automated": map[string]interface{}{"prune": true},
+		"retry": map[string]interface{}{
+			"backoff": map[string]interface{}{"duration": "5 seconds", "maxDuration": "10", "factor": 0.5},
+		},
+	}))
+	want := []string{"retry.limit is not set", "backoff.duration '5 seconds'", "factor '0.5'"}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+	findings = check(app(map[string]interface{}{
+		"automated": map[string]interface{}{"prune": true},
+		"retry": map[string]interface{}{
+			"limit":   3,
+			"backoff": map[string]interface{}{"duration": "1m", "maxDuration": "30"},
+		},
+	}))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "shorter than duration") {
+		t.Fatalf("expected maxDuration ordering finding, got %+v", findings)
+	}
+}
+
+func TestRuleManagedNamespaceMetadata(t *testing.T) {
+	rl := ruleManagedNamespaceMetadata()
+	cfg := config.Config{}
+	check := func(policy map[string]interface{}) []types.Finding {
+		m := &manifest.Manifest{
+			FilePath: "app.yaml",
+			Kind:     string(types.ResourceKindApplication),
+			Name:     "demo",
+			Object:   map[string]interface{}{"spec": map[string]interface{}{"syncPolicy": policy}},
+		}
+		configured, err := cfg.Resolve(rl.Metadata, m.FilePath)
+		if err != nil {
+			t.Fatalf("resolve config: %v", err)
+		}
+		return rl.Check(m, &Context{Config: cfg}, configured)
+	}
+	metadata := map[string]interface{}{"labels": map[string]interface{}{"team": "a"}}
+
+	findings := check(map[string]interface{}{"managedNamespaceMetadata": metadata})
+	if len(findings) != 1 || findings[0].Severity != types.SeverityWarn || findings[0].Suggestions[0].Patch != "syncOptions:\n  - CreateNamespace=true" {
+		t.Fatalf("expected CreateNamespace suggestion, got %+v", findings)
+	}
+	findings = check(map[string]interface{}{"syncOptions": []interface{}{"CreateNamespace=true"}})
+	if len(findings) != 1 || findings[0].Severity != types.SeverityInfo {
+		t.Fatalf("expected managedNamespaceMetadata recommendation, got %+v", findings)
+	}
+	if findings := check(map[string]interface{}{"syncOptions": []interface{}{"CreateNamespace=true"}, "managedNamespaceMetadata": metadata}); len(findings) != 0 {
+		t.Fatalf("expected consistent policy to pass, got %+v", findings)
+	}
+}
@@ -0,0 +1,169 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// syncWindowSampleDays is the period simulated when checking whether deny
+// windows leave any time for syncs; five weeks covers every day-of-month.
+const syncWindowSampleDays = 35
+
+var syncWindowSampleStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func ruleSyncWindows() Rule {
+	meta := types.RuleMetadata{
+		ID:              "AR018",
+		Description:     "AppProject sync windows must use valid schedules and durations and leave room for syncs",
+		Rationale:       "An invalid schedule or duration disables the window, and windows that deny every hour block all syncs.",
+		Example:         "syncWindows:\n  - kind: allow\n    schedule: \"0 22 * * *\"\n    duration: 2h\n    applications: [\"*\"]",
+		Remediation:     "Use a five-field cron schedule, a positive duration, and leave at least one allowed period.",
+		DefaultSeverity: types.SeverityError,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindAppProject},
+		HelpURL:         "https://argo-cd.readthedocs.io/en/stable/user-guide/sync_windows/",
+		Category:        "operations",
+		Enabled:         true,
+	}
+	return Rule{
+		Metadata: meta,
+		Applies:  func(m *manifest.Manifest) bool { return m.Kind == string(types.ResourceKindAppProject) },
+		Check: func(m *manifest.Manifest, ctx *Context, cfg types.ConfiguredRule) []types.Finding {
+			builder := types.FindingBuilder{Rule: cfg, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+			allowWildcard, set := cfg.BoolParam("allowWildcardApplications")
+			if !set {
+				allowWildcard = true
+			}
+			var findings []types.Finding
+			var blocking []syncWindowSpan
+			for idx, raw := range getSlice(m.Object, "spec", "syncWindows") {
+				window, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				path := fmt.Sprintf("$.spec.syncWindows[%d]", idx)
+				kind := strings.TrimSpace(getStringMap(window, "kind"))
+				if kind != "allow" && kind != "deny" {
+					findings = append(findings, builder.NewFinding(fmt.Sprintf("syncWindows[%d].kind '%s' must be allow or deny", idx, kind), cfg.Severity))
+				}
+				schedule, scheduleErr := parseCron(getStringMap(window, "schedule"))
+				if scheduleErr != nil {
+					finding := builder.NewFinding(fmt.Sprintf("syncWindows[%d].schedule '%s' is not a valid cron expression: %v", idx, getStringMap(window, "schedule"), scheduleErr), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Use a five-field cron schedule",
+							Description: "Schedules use minute hour day-of-month month day-of-week, e.g. '0 22 * * *' for 22:00 daily.",
+							Patch:       "schedule: '0 22 * * *'",
+							Path:        path + ".schedule",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				duration, durationErr := time.ParseDuration(strings.TrimSpace(getStringMap(window, "duration")))
+				if durationErr != nil || duration <= 0 {
+					finding := builder.NewFinding(fmt.Sprintf("syncWindows[%d].duration '%s' is not a valid positive duration (e.g. 1h, 30m)", idx, getStringMap(window, "duration")), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Use a Go duration string",
+							Description: "Durations combine hours, minutes, and seconds such as 1h30m.",
+							Patch:       "duration: 1h",
+							Path:        path + ".duration",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				appliesToAll := windowMatchesAll(window)
+				if !allowWildcard && listContains(getSlice(window, "applications"), "*") {
+					finding := builder.NewFinding(fmt.Sprintf("syncWindows[%d] applies to every application ('*'); list applications explicitly", idx), cfg.Severity)
+					finding.Suggestions = []types.Suggestion{
+						{
+							Title:       "Scope the window",
+							Description: "Production profiles require windows to name the applications they gate.",
+							Patch:       "applications:\n  - <app-name>",
+							Path:        path + ".applications",
+						},
+					}
+					findings = append(findings, finding)
+				}
+				manual, _ := window["manualSync"].(bool)
+				if kind == "deny" && appliesToAll && !manual && scheduleErr == nil && durationErr == nil && duration > 0 {
+					blocking = append(blocking, syncWindowSpan{schedule: schedule, duration: duration})
+				}
+			}
+			if len(blocking) > 0 && denyWindowsCoverAll(blocking) {
+				findings = append(findings, builder.NewFinding("deny sync windows matching every application cover all times; automated and manual syncs are permanently blocked", cfg.Severity))
+			}
+			return findings
+		},
+	}
+}
+
+type syncWindowSpan struct {
+	schedule *cronSchedule
+	duration time.Duration
+}
+
+func windowMatchesAll(window map[string]interface{}) bool {
+	for _, key := range []string{"applications", "namespaces", "clusters"} {
+		if listContains(getSlice(window, key), "*") {
+			return true
+		}
+	}
+	return false
+}
+
+func listContains(items []interface{}, want string) bool {
+	for _, item := range items {
+		if str, ok := item.(string); ok && strings.TrimSpace(str) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// denyWindowsCoverAll simulates the sample period minute by minute and reports
+// whether the union of the deny windows leaves no gap.
+func denyWindowsCoverAll(windows []syncWindowSpan) bool {
+	total := syncWindowSampleDays * 24 * 60
+	diff := make([]int, total+1)
+	for _, w := range windows {
+		if w.schedule.every > 0 {
+			if w.duration >= w.schedule.every {
+				return true
+			}
+			continue
+		}
+		span := int((w.duration + time.Minute - 1) / time.Minute)
+		lookback := span
+		if lookback > total {
+			lookback = total
+		}
+		for offset := -lookback; offset < total; offset++ {
+			if !w.schedule.matches(syncWindowSampleStart.Add(time.Duration(offset) * time.Minute)) {
+				continue
+			}
+			start, end := offset, offset+span
+			if start < 0 {
+				start = 0
+			}
+			if end > total {
+				end = total
+			}
+			if start < end {
+				diff[start]++
+				diff[end]--
+			}
+		}
+	}
+	active := 0
+	for i := 0; i < total; i++ {
+		active += diff[i]
+		if active <= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+)
+
+func TestParseCron(t *testing.T) {
+	valid := []string{"0 22 * * *", "*/15 9-17 * * mon-fri", "0 0 1,15 * *", "@daily", "@every 2h", "30 2 * JAN-MAR 7"}
+	for _, expr := range valid {
+		if _, err := parseCron(expr); err != nil {
+			t.Fatalf("expected %q to parse: %v", expr, err)
+		}
+	}
+	invalid := []string{"", "0 22 * *", "61 * * * *", "0 5-1 * * *", "* * * * funday", "@fortnightly", "*/0 * * * *"}
+	for _, expr := range invalid {
+		if _, err := parseCron(expr); err == nil {
+			t.Fatalf("expected %q to be rejected", expr)
+		}
+	}
+	schedule, _ := parseCron("30 2 * * sun")
+	if !schedule.matches(time.Date(2024, time.January, 7, 2, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected schedule to fire on Sunday 02:30")
+	}
+	if schedule.matches(time.Date(2024, time.January, 8, 2, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected schedule not to fire on Monday")
+	}
+}
+
+func TestRuleSyncWindows(t *testing.T) {
+	project := projectManifest(map[string]interface{}{
+		"syncWindows": []interface{}{
+			map[string]interface{}{"kind": "allow", "schedule": "0 25 * * *", "duration": "1h", "applications": []interface{}{"web"}},
+			map[string]interface{}{"kind": "allow", "schedule": "0 22 * * *", "duration": "1 hour", "applications": []interface{}{"web"}},
+			map[string]interface{}{"kind": "block", "schedule": "0 22 * * *", "duration": "1h", "applications": []interface{}{"web"}},
+		},
+	})
+	findings := checkRule(t, ruleSyncWindows(), config.Config{}, project)
+	want := []string{"not a valid cron expression", "not a valid positive duration", "must be allow or deny"}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(findings[i].Message, fragment) {
+			t.Fatalf("finding %d: expected %q in %q", i, fragment, findings[i].Message)
+		}
+	}
+}
+
+func TestRuleSyncWindowsBlockingDenyWindows(t *testing.T) {
+	deny := func(schedule, duration string, manual bool) map[string]interface{} {
+		return map[string]interface{}{"kind": "deny", "schedule": schedule, "duration": duration, "namespaces": []interface{}{"*"}, "manualSync": manual}
+	}
+	blocked := projectManifest(map[string]interface{}{
+		"syncWindows": []interface{}{
+			deny("0 0 * * *", "13h", false),
+			deny("0 12 * * *", "12h", false),
+		},
+	})
+	findings := checkRule(t, ruleSyncWindows(), config.Config{}, blocked)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "permanently blocked") {
+		t.Fatalf("expected overlapping deny windows to be reported, got %+v", findings)
+	}
+
+	gap := projectManifest(map[string]interface{}{
+		"syncWindows": []interface{}{
+			deny("0 0 * * *", "12h", false),
+			deny("0 12 * * *", "11h", false),
+			deny("0 23 * * *", "1h", true),
+		},
+	})
+	if findings := checkRule(t, ruleSyncWindows(), config.Config{}, gap); len(findings) != 0 {
+		t.Fatalf("expected windows with a manual-sync gap to pass, got %+v", findings)
+	}
+}
+
+func TestRuleSyncWindowsWildcardInProdProfile(t *testing.T) {
+	project := projectManifest(map[string]interface{}{
+		"syncWindows": []interface{}{
+			map[string]interface{}{"kind": "allow", "schedule": "0 22 * * *", "duration": "1h", "applications": []interface{}{"*"}},
+		},
+	})
+	if findings := checkRule(t, ruleSyncWindows(), config.Config{}, project); len(findings) != 0 {
+		t.Fatalf("expected wildcard applications to pass without a profile, got %+v", findings)
+	}
+	cfg := config.Config{}
+	if err := cfg.ApplyProfiles("prod"); err != nil {
+		t.Fatalf("apply profile: %v", err)
+	}
+	findings := checkRule(t, ruleSyncWindows(), cfg, project)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "applies to every application") {
+		t.Fatalf("expected prod profile to flag wildcard applications, got %+v", findings)
+	}
+}
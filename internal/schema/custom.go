@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// customRuleMeta reports resources that violate a schema loaded with
+// AddSchemaDir or AddCRD.
+var customRuleMeta = types.RuleMetadata{
+	ID:              "SCHEMA_CUSTOM",
+	Description:     "Resources must satisfy the custom resource schemas loaded with --crd-schemas",
+	DefaultSeverity: types.SeverityError,
+	AppliesTo: []types.ResourceKind{
+		types.ResourceKindApplication,
+		types.ResourceKindApplicationSet,
+		types.ResourceKindAppProject,
+	},
+	Category: "schema",
+	Enabled:  true,
+}
+
+// gvkKey identifies a schema by apiVersion and kind, e.g.
+// argoproj.io/v1alpha1/Application.
+func gvkKey(apiVersion, kind string) string {
+	return apiVersion + "/" + kind
+}
+
+// AddSchemaDir loads every schema under dir. YAML or JSON files holding
+// CustomResourceDefinitions contribute the openAPIV3Schema of each served
+// version. JSON schemas are matched by their x-kubernetes-group-version-kind
+// extension or, failing that, by the CRDs-catalog layout
+// <group>/<kind>_<version>.json. A schema for an Argo CD kind replaces the
+// embedded one.
+func (v *Validator) AddSchemaDir(dir string) error {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("read crd schemas: %w", err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if err := v.addSchemaFile(dir, file); err != nil {
+			return fmt.Errorf("crd schema %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (v *Validator) addSchemaFile(dir, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	loaded := false
+	for {
+		var doc map[string]interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if kind, _ := doc["kind"].(string); kind == "CustomResourceDefinition" {
+			if err := v.AddCRD(doc); err != nil {
+				return err
+			}
+			loaded = true
+			continue
+		}
+		keys := extensionKeys(doc)
+		if len(keys) == 0 {
+			key, ok := catalogKey(dir, file)
+			if !ok {
+				return errors.New("not a CustomResourceDefinition, and a JSON schema needs x-kubernetes-group-version-kind or a <group>/<kind>_<version>.json path")
+			}
+			keys = []string{key}
+		}
+		for _, key := range keys {
+			v.setCustom(key, doc)
+		}
+		loaded = true
+	}
+	if !loaded {
+		return errors.New("no schemas found")
+	}
+	return nil
+}
+
+// AddCRD registers the openAPIV3Schema of each version a
+// CustomResourceDefinition serves.
+func (v *Validator) AddCRD(crd map[string]interface{}) error {
+	spec, _ := crd["spec"].(map[string]interface{})
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]interface{})
+	kind, _ := names["kind"].(string)
+	if group == "" || kind == "" {
+		return errors.New("CustomResourceDefinition has no spec.group or spec.names.kind")
+	}
+	versions, _ := spec["versions"].([]interface{})
+	added := 0
+	for _, item := range versions {
+		version, _ := item.(map[string]interface{})
+		name, _ := version["name"].(string)
+		if served, ok := version["served"].(bool); ok && !served {
+			continue
+		}
+		schema, _ := version["schema"].(map[string]interface{})
+		openAPI, _ := schema["openAPIV3Schema"].(map[string]interface{})
+		if name == "" || openAPI == nil {
+			continue
+		}
+		v.setCustom(gvkKey(group+"/"+name, kind), openAPI)
+		added++
+	}
+	if added == 0 {
+		return fmt.Errorf("CustomResourceDefinition %s.%s has no served version with an openAPIV3Schema", strings.ToLower(kind), group)
+	}
+	return nil
+}
+
+func (v *Validator) setCustom(key string, schema map[string]interface{}) {
+	if v.custom == nil {
+		v.custom = map[string]gojsonschema.JSONLoader{}
+	}
+	v.custom[key] = gojsonschema.NewGoLoader(schema)
+}
+
+// extensionKeys reads the x-kubernetes-group-version-kind extension of a
+// Kubernetes OpenAPI schema.
+func extensionKeys(doc map[string]interface{}) []string {
+	entries, _ := doc["x-kubernetes-group-version-kind"].([]interface{})
+	var keys []string
+	for _, entry := range entries {
+		gvk, _ := entry.(map[string]interface{})
+		group, _ := gvk["group"].(string)
+		version, _ := gvk["version"].(string)
+		kind, _ := gvk["kind"].(string)
+		if version == "" || kind == "" {
+			continue
+		}
+		apiVersion := version
+		if group != "" {
+			apiVersion = group + "/" + version
+		}
+		keys = append(keys, gvkKey(apiVersion, kind))
+	}
+	return keys
+}
+
+// catalogKey derives the key of a schema stored as
+// <group>/<kind>_<version>.json, the layout of the datreeio CRDs-catalog.
+// The kind is lower case in that layout and matched case-insensitively.
+func catalogKey(dir, file string) (string, bool) {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) != 2 || strings.ToLower(filepath.Ext(parts[1])) != ".json" {
+		return "", false
+	}
+	base := strings.TrimSuffix(parts[1], filepath.Ext(parts[1]))
+	idx := strings.LastIndex(base, "_")
+	if idx <= 0 || idx == len(base)-1 {
+		return "", false
+	}
+	return gvkKey(parts[0]+"/"+base[idx+1:], strings.ToLower(base[:idx])), true
+}
+
+// customLoader returns the custom schema for an object, if one was loaded.
+func (v *Validator) customLoader(object map[string]interface{}) (gojsonschema.JSONLoader, bool) {
+	if len(v.custom) == 0 {
+		return nil, false
+	}
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	if loader, ok := v.custom[gvkKey(apiVersion, kind)]; ok {
+		return loader, true
+	}
+	loader, ok := v.custom[gvkKey(apiVersion, strings.ToLower(kind))]
+	return loader, ok
+}
+
+// HasCustomSchemas reports whether any custom schema was loaded.
+func (v *Validator) HasCustomSchemas() bool {
+	return len(v.custom) > 0
+}
+
+// ValidateRendered validates the resources m renders to against the custom
+// schemas. Findings point at m and name the rendered resource; resources
+// without a custom schema are skipped.
+func (v *Validator) ValidateRendered(m *manifest.Manifest, resources []map[string]interface{}) ([]types.Finding, error) {
+	var findings []types.Finding
+	for _, obj := range resources {
+		loader, ok := v.customLoader(obj)
+		if !ok {
+			continue
+		}
+		errs, err := validateObject(loader, obj)
+		if err != nil {
+			return nil, err
+		}
+		kind, _ := obj["kind"].(string)
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		builder := types.FindingBuilder{Rule: v.ruleCustom, FilePath: m.FilePath, Line: m.MetadataLine, ResourceName: m.Name, ResourceKind: m.Kind}
+		for _, msg := range errs {
+			findings = append(findings, builder.NewFinding(fmt.Sprintf("rendered %s/%s: %s", kind, name, msg), types.SeverityError))
+		}
+	}
+	return findings, nil
+}
+
+// validateObject returns the schema violations of obj.
+func validateObject(loader gojsonschema.JSONLoader, obj map[string]interface{}) ([]string, error) {
+	result, err := gojsonschema.Validate(loader, gojsonschema.NewGoLoader(obj))
+	if err != nil {
+		return nil, fmt.Errorf("schema validation error: %w", err)
+	}
+	msgs := make([]string, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		msgs = append(msgs, e.String())
+	}
+	return msgs, nil
+}
@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+const applicationCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: applications.argoproj.io
+spec:
+  group: argoproj.io
+  names: {kind: Application, plural: applications}
+  scope: Namespaced
+  versions:
+    - name: v1alpha1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          required: [spec]
+          properties:
+            spec:
+              type: object
+              required: [project]
+              properties:
+                project: {type: string}
+                vendorTier: {type: string, enum: [gold, silver]}
+`
+
+func writeSchemaFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestAddSchemaDirReplacesEmbeddedSchema(t *testing.T) {
+	validator, err := NewValidator("")
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	if err := validator.AddSchemaDir(writeSchemaFiles(t, map[string]string{"crds/application.yaml": applicationCRD})); err != nil {
+		t.Fatalf("add schemas: %v", err)
+	}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     "Application",
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata":   map[string]interface{}{"name": "demo"},
+			"spec":       map[string]interface{}{"project": "default", "vendorTier": "bronze"},
+		},
+	}
+	findings, err := validator.Validate(app)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "SCHEMA_APPLICATION" || !strings.Contains(findings[0].Message, "vendorTier") {
+		t.Fatalf("expected the CRD schema to reject vendorTier, got %+v", findings)
+	}
+}
+
+func TestValidateRenderedUsesCustomSchemas(t *testing.T) {
+	dir := writeSchemaFiles(t, map[string]string{
+		"monitoring.coreos.com/servicemonitor_v1.json": `{"type": "object", "required": ["spec"]}`,
+		"widget.json": `{"type": "object", "required": ["size"], "x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "Widget"}]}`,
+	})
+	validator, err := NewValidator("")
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	if err := validator.AddSchemaDir(dir); err != nil {
+		t.Fatalf("add schemas: %v", err)
+	}
+	owner := &manifest.Manifest{FilePath: "app.yaml", Kind: "Application", Name: "demo"}
+	findings, err := validator.ValidateRendered(owner, []map[string]interface{}{
+		{"apiVersion": "monitoring.coreos.com/v1", "kind": "ServiceMonitor", "metadata": map[string]interface{}{"name": "web"}},
+		{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": map[string]interface{}{"name": "gadget"}, "size": 3},
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "unchecked"}},
+	})
+	if err != nil {
+		t.Fatalf("validate rendered: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "SCHEMA_CUSTOM" || findings[0].ResourceName != "demo" || !strings.HasPrefix(findings[0].Message, "rendered ServiceMonitor/web: ") {
+		t.Fatalf("expected one SCHEMA_CUSTOM finding for the ServiceMonitor, got %+v", findings)
+	}
+}
+
+func TestAddSchemaDirRejectsUnkeyedSchemas(t *testing.T) {
+	validator, err := NewValidator("")
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	err = validator.AddSchemaDir(writeSchemaFiles(t, map[string]string{"loose.json": `{"type": "object"}`}))
+	if err == nil || !strings.Contains(err.Error(), "loose.json") {
+		t.Fatalf("expected an error naming the unkeyed schema, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+	"gopkg.in/yaml.v3"
+)
+
+// UpstreamURL locates an Argo CD CRD manifest by Git ref and CRD file
+// prefix. Tests may replace it.
+var UpstreamURL = "https://raw.githubusercontent.com/argoproj/argo-cd/%s/manifests/crds/%s-crd.yaml"
+
+// upstreamCRDs maps the CRD file prefixes in the Argo CD repository to the
+// embedded schema files they become.
+var upstreamCRDs = []struct {
+	crd  string
+	file string
+}{
+	{crd: "application", file: "application.json"},
+	{crd: "applicationset", file: "applicationset.json"},
+}
+
+var (
+	minorVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)$`)
+	fullVersionPattern  = regexp.MustCompile(`^v?(\d+\.\d+\.\d+(?:-[0-9a-z.]+)?)$`)
+)
+
+// UpstreamRef returns the Git ref of an Argo CD release: the release branch
+// for a minor version such as v2.13, which tracks its latest patch, or the
+// tag for a full version such as v2.13.1.
+func UpstreamRef(version string) (string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(strings.ToLower(version)), "argocd-")
+	if m := minorVersionPattern.FindStringSubmatch(trimmed); m != nil {
+		return fmt.Sprintf("release-%s.%s", m[1], m[2]), nil
+	}
+	if m := fullVersionPattern.FindStringSubmatch(trimmed); m != nil {
+		return "v" + m[1], nil
+	}
+	return "", fmt.Errorf("invalid argocd version %q: want vX.Y or vX.Y.Z", version)
+}
+
+// FetchUpstream downloads the Application and ApplicationSet CRDs of an
+// Argo CD release and converts them with ConvertCRD. The result maps the
+// embedded file names, application.json and applicationset.json, to their
+// content.
+func FetchUpstream(ctx context.Context, version string) (map[string][]byte, error) {
+	ref, err := UpstreamRef(version)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(upstreamCRDs))
+	for _, upstream := range upstreamCRDs {
+		url := fmt.Sprintf(UpstreamURL, ref, upstream.crd)
+		data, err := get(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", url, err)
+		}
+		var crd map[string]interface{}
+		if err := yaml.Unmarshal(data, &crd); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", url, err)
+		}
+		converted, err := ConvertCRD(crd)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s: %w", url, err)
+		}
+		out, err := json.MarshalIndent(converted, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[upstream.file] = append(out, '\n')
+	}
+	return files, nil
+}
+
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fetch.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, fetch.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > fetch.MaxSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", fetch.MaxSize)
+	}
+	return data, nil
+}
+
+// ConvertCRD turns the openAPIV3Schema of the first served version of a
+// CustomResourceDefinition into the embedded schema format: a draft-07
+// document that also pins apiVersion and kind, requires metadata.name, and
+// carries x-kubernetes-group-version-kind so --crd-schemas can load it.
+func ConvertCRD(crd map[string]interface{}) (map[string]interface{}, error) {
+	spec, _ := crd["spec"].(map[string]interface{})
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]interface{})
+	kind, _ := names["kind"].(string)
+	if group == "" || kind == "" {
+		return nil, errors.New("CustomResourceDefinition has no spec.group or spec.names.kind")
+	}
+	versions, _ := spec["versions"].([]interface{})
+	for _, item := range versions {
+		version, _ := item.(map[string]interface{})
+		name, _ := version["name"].(string)
+		if served, ok := version["served"].(bool); ok && !served {
+			continue
+		}
+		schema, _ := version["schema"].(map[string]interface{})
+		openAPI, _ := schema["openAPIV3Schema"].(map[string]interface{})
+		if name == "" || openAPI == nil {
+			continue
+		}
+		return embeddedSchema(group, name, kind, openAPI), nil
+	}
+	return nil, fmt.Errorf("CustomResourceDefinition %s.%s has no served version with an openAPIV3Schema", strings.ToLower(kind), group)
+}
+
+func embeddedSchema(group, version, kind string, openAPI map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(openAPI)+2)
+	for key, value := range openAPI {
+		out[key] = value
+	}
+	out["$schema"] = "http://json-schema.org/draft-07/schema#"
+	out["x-kubernetes-group-version-kind"] = []interface{}{
+		map[string]interface{}{"group": group, "version": version, "kind": kind},
+	}
+	out["type"] = "object"
+
+	properties := map[string]interface{}{}
+	if existing, ok := openAPI["properties"].(map[string]interface{}); ok {
+		for key, value := range existing {
+			properties[key] = value
+		}
+	}
+	properties["apiVersion"] = map[string]interface{}{
+		"type":    "string",
+		"pattern": "^" + regexp.QuoteMeta(group+"/"+version) + "$",
+	}
+	properties["kind"] = map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{kind},
+	}
+	metadata, _ := properties["metadata"].(map[string]interface{})
+	if _, ok := metadata["properties"]; !ok {
+		properties["metadata"] = metadataSchema()
+	}
+	out["properties"] = properties
+
+	required := []interface{}{"apiVersion", "kind", "metadata"}
+	seen := map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+	existing, _ := openAPI["required"].([]interface{})
+	for _, item := range existing {
+		if name, ok := item.(string); ok && !seen[name] {
+			seen[name] = true
+			required = append(required, name)
+		}
+	}
+	out["required"] = required
+	return out
+}
+
+// metadataSchema mirrors the metadata section of the embedded schemas;
+// CRDs leave metadata to the API server and only declare it an object.
+func metadataSchema() map[string]interface{} {
+	dnsLabel := "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	stringMap := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"name"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":      "string",
+				"minLength": 1,
+				"maxLength": 253,
+				"pattern":   dnsLabel,
+			},
+			"namespace":   map[string]interface{}{"type": "string", "pattern": dnsLabel},
+			"labels":      stringMap,
+			"annotations": stringMap,
+			"finalizers": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
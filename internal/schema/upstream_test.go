@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+func TestUpstreamRef(t *testing.T) {
+	cases := map[string]string{
+		"v2.13":      "release-2.13",
+		"3.0":        "release-3.0",
+		"v2.13.1":    "v2.13.1",
+		"v3.0.0-rc1": "v3.0.0-rc1",
+	}
+	for version, want := range cases {
+		got, err := UpstreamRef(version)
+		if err != nil || got != want {
+			t.Fatalf("UpstreamRef(%q) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if _, err := UpstreamRef("latest"); err == nil {
+		t.Fatalf("expected an error for a version without minor")
+	}
+}
+
+func TestFetchUpstreamConvertsCRDs(t *testing.T) {
+	appSetCRD := strings.NewReplacer("Application,", "ApplicationSet,", "applications", "applicationsets").Replace(applicationCRD)
+	var paths []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/release-2.13/application-crd.yaml":
+			_, _ = w.Write([]byte(applicationCRD))
+		case "/release-2.13/applicationset-crd.yaml":
+			_, _ = w.Write([]byte(appSetCRD))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	previousClient, previousURL := fetch.Client, UpstreamURL
+	fetch.Client = server.Client()
+	UpstreamURL = server.URL + "/%s/%s-crd.yaml"
+	defer func() { fetch.Client, UpstreamURL = previousClient, previousURL }()
+
+	files, err := FetchUpstream(context.Background(), "v2.13")
+	if err != nil {
+		t.Fatalf("fetch: %v (requested %v)", err, paths)
+	}
+	if len(files) != 2 || !strings.Contains(string(files["applicationset.json"]), `"kind": "ApplicationSet"`) {
+		t.Fatalf("expected converted application and applicationset schemas, got %v", files)
+	}
+
+	dir := writeSchemaFiles(t, map[string]string{
+		"application.json":    string(files["application.json"]),
+		"applicationset.json": string(files["applicationset.json"]),
+	})
+	validator, err := NewValidator("")
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	if err := validator.AddSchemaDir(dir); err != nil {
+		t.Fatalf("load converted schemas: %v", err)
+	}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     "Application",
+		Name:     "Demo",
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata":   map[string]interface{}{"name": "Demo"},
+			"spec":       map[string]interface{}{"project": "default", "vendorTier": "bronze"},
+		},
+	}
+	findings, err := validator.Validate(app)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.Message)
+	}
+	joined := strings.Join(messages, "\n")
+	if len(findings) != 2 || !strings.Contains(joined, "metadata.name") || !strings.Contains(joined, "vendorTier") {
+		t.Fatalf("expected the converted schema to check metadata.name and the CRD fields, got %v", messages)
+	}
+}
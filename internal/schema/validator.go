@@ -3,7 +3,9 @@ package schema
 import (
 	"embed"
 	"fmt"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
@@ -15,13 +17,23 @@ var (
 	//go:embed data/*/*.json
 	schemaFiles embed.FS
 
-	supportedVersions = map[string]string{
-		"":     "v2.9",
-		"v2.9": "v2.9",
-		"v2.8": "v2.8",
-	}
+	// schemaFS holds one directory of converted CRD schemas per Argo CD
+	// release, data/<version>; tests may replace it.
+	schemaFS fs.FS = schemaFiles
+
+	// defaultVersion is validated against when no version is pinned.
+	defaultVersion = "v2.9"
+
+	// versionAliases names the schemas a release is validated with while
+	// its own directory is not embedded.
+	versionAliases = map[string]string{"v2.8": "v2.9"}
 )
 
+// VersionFromCluster selects the schemas of the Argo CD CRDs installed in
+// a cluster instead of embedded ones. The caller loads them with AddCRD;
+// kinds without a loaded schema are not validated.
+const VersionFromCluster = "from-cluster"
+
 // Validator performs JSON schema validation using embedded CRD specs.
 type Validator struct {
 	version         string
@@ -29,27 +41,36 @@ type Validator struct {
 	appSetLoader    gojsonschema.JSONLoader
 	ruleApplication types.ConfiguredRule
 	ruleAppSet      types.ConfiguredRule
+	ruleCustom      types.ConfiguredRule
+	// custom holds schemas loaded with AddSchemaDir or AddCRD, keyed by
+	// apiVersion/kind.
+	custom map[string]gojsonschema.JSONLoader
 }
 
 // NewValidator constructs a schema validator for the selected Argo CD version.
 func NewValidator(version string) (*Validator, error) {
+	if strings.EqualFold(strings.TrimSpace(version), VersionFromCluster) {
+		return newValidator(VersionFromCluster, nil, nil), nil
+	}
 	resolved, err := resolveVersion(version)
 	if err != nil {
 		return nil, err
 	}
-	appSchema, err := schemaFiles.ReadFile(filepath.Join("data", resolved, "application.json"))
+	appSchema, err := fs.ReadFile(schemaFS, path.Join("data", resolved, "application.json"))
 	if err != nil {
 		return nil, fmt.Errorf("load application schema for %s: %w", resolved, err)
 	}
-	appSetSchema, err := schemaFiles.ReadFile(filepath.Join("data", resolved, "applicationset.json"))
+	appSetSchema, err := fs.ReadFile(schemaFS, path.Join("data", resolved, "applicationset.json"))
 	if err != nil {
 		return nil, fmt.Errorf("load applicationset schema for %s: %w", resolved, err)
 	}
-	appLoader := gojsonschema.NewStringLoader(string(appSchema))
-	appSetLoader := gojsonschema.NewStringLoader(string(appSetSchema))
-	versionSuffix := formatDescriptionSuffix(resolved)
+	return newValidator(resolved, gojsonschema.NewStringLoader(string(appSchema)), gojsonschema.NewStringLoader(string(appSetSchema))), nil
+}
+
+func newValidator(version string, appLoader, appSetLoader gojsonschema.JSONLoader) *Validator {
+	versionSuffix := formatDescriptionSuffix(version)
 	return &Validator{
-		version:      resolved,
+		version:      version,
 		appLoader:    appLoader,
 		appSetLoader: appSetLoader,
 		ruleApplication: types.ConfiguredRule{
@@ -76,14 +97,21 @@ func NewValidator(version string) (*Validator, error) {
 			Severity: types.SeverityError,
 			Enabled:  true,
 		},
-	}, nil
+		ruleCustom: types.ConfiguredRule{
+			Metadata: customRuleMeta,
+			Severity: types.SeverityError,
+			Enabled:  true,
+		},
+	}
 }
 
+// resolveVersion maps a release such as v2.13.1 to its schema directory.
+// Each embedded data/<version> directory serves its own release.
 func resolveVersion(version string) (string, error) {
 	trimmed := strings.TrimSpace(strings.ToLower(version))
 	trimmed = strings.TrimPrefix(trimmed, "argocd-")
 	if trimmed == "" {
-		return supportedVersions[""], nil
+		return defaultVersion, nil
 	}
 	trimmed = strings.TrimPrefix(trimmed, "v")
 	parts := strings.Split(trimmed, ".")
@@ -92,25 +120,69 @@ func resolveVersion(version string) (string, error) {
 	} else if len(parts) == 1 {
 		trimmed = fmt.Sprintf("v%s", parts[0])
 	}
-	if resolved, ok := supportedVersions[trimmed]; ok {
-		return resolved, nil
+	if embedded(trimmed) {
+		return trimmed, nil
+	}
+	if alias, ok := versionAliases[trimmed]; ok && embedded(alias) {
+		return alias, nil
+	}
+	return "", fmt.Errorf("unsupported argocd version %q: embedded schemas cover %s; run `argocd-lint schema fetch %s --out <dir>` and pass --crd-schemas <dir>", version, strings.Join(EmbeddedVersions(), ", "), trimmed)
+}
+
+// embedded reports whether schemas for version are embedded.
+func embedded(version string) bool {
+	_, err := fs.Stat(schemaFS, path.Join("data", version, "application.json"))
+	return err == nil
+}
+
+// EmbeddedVersions lists the releases with embedded schemas, including
+// aliased ones, in version order.
+func EmbeddedVersions() []string {
+	var versions []string
+	entries, _ := fs.ReadDir(schemaFS, "data")
+	for _, entry := range entries {
+		if entry.IsDir() && embedded(entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
 	}
-	return "", fmt.Errorf("unsupported argocd version %q", version)
+	for version, alias := range versionAliases {
+		if !embedded(version) && embedded(alias) {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) })
+	return versions
+}
+
+// versionLess orders vX.Y versions numerically.
+func versionLess(a, b string) bool {
+	var aMajor, aMinor, bMajor, bMinor int
+	fmt.Sscanf(a, "v%d.%d", &aMajor, &aMinor)
+	fmt.Sscanf(b, "v%d.%d", &bMajor, &bMinor)
+	if aMajor != bMajor {
+		return aMajor < bMajor
+	}
+	return aMinor < bMinor
 }
 
 func formatDescriptionSuffix(version string) string {
-	if version == "" {
+	switch version {
+	case "":
 		return ""
+	case VersionFromCluster:
+		return " (as installed in the cluster)"
 	}
 	return fmt.Sprintf(" (%s)", version)
 }
 
 // Metadata returns schema rule metadata entries.
 func (v *Validator) Metadata() []types.RuleMetadata {
-	return []types.RuleMetadata{v.ruleApplication.Metadata, v.ruleAppSet.Metadata}
+	return []types.RuleMetadata{v.ruleApplication.Metadata, v.ruleAppSet.Metadata, v.ruleCustom.Metadata}
 }
 
-// Validate checks the manifest against the matching schema.
+// Validate checks the manifest against the matching schema: a custom schema
+// for its apiVersion and kind when one was loaded, else the embedded
+// Application or ApplicationSet schema.
 func (v *Validator) Validate(m *manifest.Manifest) ([]types.Finding, error) {
 	if m == nil {
 		return nil, fmt.Errorf("manifest is nil")
@@ -125,16 +197,18 @@ func (v *Validator) Validate(m *manifest.Manifest) ([]types.Finding, error) {
 		loader = v.appSetLoader
 		rule = v.ruleAppSet
 	default:
-		return nil, nil
+		rule = v.ruleCustom
 	}
-	docLoader := gojsonschema.NewGoLoader(m.Object)
-	result, err := gojsonschema.Validate(loader, docLoader)
-	if err != nil {
-		return nil, fmt.Errorf("schema validation error: %w", err)
+	if custom, ok := v.customLoader(m.Object); ok {
+		loader = custom
 	}
-	if result.Valid() {
+	if loader == nil {
 		return nil, nil
 	}
+	errs, err := validateObject(loader, m.Object)
+	if err != nil {
+		return nil, err
+	}
 	builder := types.FindingBuilder{
 		Rule:         rule,
 		FilePath:     m.FilePath,
@@ -142,9 +216,9 @@ func (v *Validator) Validate(m *manifest.Manifest) ([]types.Finding, error) {
 		ResourceName: m.Name,
 		ResourceKind: m.Kind,
 	}
-	findings := make([]types.Finding, 0, len(result.Errors()))
-	for _, err := range result.Errors() {
-		findings = append(findings, builder.NewFinding(err.String(), types.SeverityError))
+	findings := make([]types.Finding, 0, len(errs))
+	for _, msg := range errs {
+		findings = append(findings, builder.NewFinding(msg, types.SeverityError))
 	}
 	return findings, nil
 }
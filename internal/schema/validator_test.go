@@ -1,7 +1,12 @@
 package schema
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 )
@@ -138,4 +143,142 @@ func TestSchemaValidatorRejectsUnknownVersion(t *testing.T) {
 	if _, err := NewValidator("v9.9"); err == nil {
 		t.Fatalf("expected error for unsupported version")
 	}
+	if _, err := NewValidator("v3.1"); err == nil || !strings.Contains(err.Error(), "schema fetch v3.1") {
+		t.Fatalf("expected versions without embedded schemas to point at schema fetch, got %v", err)
+	}
+}
+
+// TestEmbeddedSchemasAreDistinct guards against embedding a copy of another
+// release's schemas under a new version; versions that share schemas must
+// be aliases in versionAliases.
+func TestEmbeddedSchemasAreDistinct(t *testing.T) {
+	dirs, err := schemaFiles.ReadDir("data")
+	if err != nil {
+		t.Fatalf("read embedded schemas: %v", err)
+	}
+	seen := map[string]string{}
+	for _, dir := range dirs {
+		var content []byte
+		for _, name := range []string{"application.json", "applicationset.json"} {
+			data, err := schemaFiles.ReadFile("data/" + dir.Name() + "/" + name)
+			if err != nil {
+				t.Fatalf("read %s/%s: %v", dir.Name(), name, err)
+			}
+			content = append(content, data...)
+		}
+		sum := fmt.Sprintf("%x", sha256.Sum256(content))
+		if other, ok := seen[sum]; ok {
+			t.Fatalf("embedded schemas %s and %s are identical", other, dir.Name())
+		}
+		seen[sum] = dir.Name()
+	}
+	for version, dir := range versionAliases {
+		if _, err := schemaFiles.ReadFile("data/" + dir + "/application.json"); err != nil {
+			t.Fatalf("version %q maps to missing schema directory %s", version, dir)
+		}
+	}
+}
+
+func TestEachVersionUsesItsOwnSchemas(t *testing.T) {
+	files := fstest.MapFS{}
+	for _, name := range []string{"application.json", "applicationset.json"} {
+		data, err := schemaFiles.ReadFile("data/v2.9/" + name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		files["data/v2.9/"+name] = &fstest.MapFile{Data: data}
+		files["data/v2.14/"+name] = &fstest.MapFile{Data: data}
+	}
+	// v2.14 added spec.sourceHydrator, which requires drySource and
+	// syncSource.
+	var app map[string]interface{}
+	if err := json.Unmarshal(files["data/v2.14/application.json"].Data, &app); err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+	spec := app["properties"].(map[string]interface{})["spec"].(map[string]interface{})
+	spec["properties"].(map[string]interface{})["sourceHydrator"] = map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"drySource", "syncSource"},
+	}
+	data, err := json.Marshal(app)
+	if err != nil {
+		t.Fatalf("encode schema: %v", err)
+	}
+	files["data/v2.14/application.json"] = &fstest.MapFile{Data: data}
+	previous := schemaFS
+	schemaFS = files
+	defer func() { schemaFS = previous }()
+
+	if got := strings.Join(EmbeddedVersions(), " "); got != "v2.8 v2.9 v2.14" {
+		t.Fatalf("unexpected embedded versions %q", got)
+	}
+	m := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     "Application",
+		Name:     "demo",
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata":   map[string]interface{}{"name": "demo"},
+			"spec": map[string]interface{}{
+				"project":        "workloads",
+				"destination":    map[string]interface{}{"server": "https://kubernetes.default.svc"},
+				"source":         map[string]interface{}{"repoURL": "https://example.com/repo.git"},
+				"sourceHydrator": map[string]interface{}{"drySource": map[string]interface{}{}},
+			},
+		},
+	}
+	for version, want := range map[string]int{"v2.14.3": 1, "v2.9": 0, "v2.8": 0} {
+		validator, err := NewValidator(version)
+		if err != nil {
+			t.Fatalf("new validator for %s: %v", version, err)
+		}
+		findings, err := validator.Validate(m)
+		if err != nil {
+			t.Fatalf("validate %s: %v", version, err)
+		}
+		if len(findings) != want {
+			t.Fatalf("expected %d findings against %s, got %+v", want, version, findings)
+		}
+	}
+	if _, err := NewValidator("v2.13"); err == nil || !strings.Contains(err.Error(), "embedded schemas cover v2.8, v2.9, v2.14") {
+		t.Fatalf("expected a release without schemas to be rejected, got %v", err)
+	}
+}
+
+func TestFromClusterValidatesOnlyLoadedCRDs(t *testing.T) {
+	validator, err := NewValidator("from-cluster")
+	if err != nil {
+		t.Fatalf("new validator: %v", err)
+	}
+	app := &manifest.Manifest{
+		FilePath: "app.yaml",
+		Kind:     "Application",
+		Name:     "demo",
+		Object:   map[string]interface{}{"apiVersion": "argoproj.io/v1alpha1", "kind": "Application"},
+	}
+	if findings, err := validator.Validate(app); err != nil || len(findings) != 0 {
+		t.Fatalf("expected no validation before CRDs are loaded, got %+v (%v)", findings, err)
+	}
+	crd := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"group": "argoproj.io",
+			"names": map[string]interface{}{"kind": "Application"},
+			"versions": []interface{}{map[string]interface{}{
+				"name":   "v1alpha1",
+				"served": true,
+				"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{"type": "object", "required": []interface{}{"spec"}}},
+			}},
+		},
+	}
+	if err := validator.AddCRD(crd); err != nil {
+		t.Fatalf("add crd: %v", err)
+	}
+	findings, err := validator.Validate(app)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "SCHEMA_APPLICATION" {
+		t.Fatalf("expected the cluster schema to require spec, got %+v", findings)
+	}
 }
@@ -0,0 +1,173 @@
+// Package custom adapts the declarative customRules from the config file
+// into rule plugins. CEL rules report a finding when their expression is
+// false; path rules report one finding per selected field that breaks the
+// required, forbidden, pattern, or oneOf constraint.
+package custom
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// FromConfig compiles the custom rules declared in cfg.
+func FromConfig(cfg config.Config) ([]plugin.RulePlugin, error) {
+	plugins := make([]plugin.RulePlugin, 0, len(cfg.CustomRules))
+	for i, def := range cfg.CustomRules {
+		compiled, err := def.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("customRules[%d]: %w", i, err)
+		}
+		severity := types.SeverityWarn
+		if def.Severity != "" {
+			severity, _ = config.ParseSeverity(def.Severity)
+		}
+		category := def.Category
+		if category == "" {
+			category = "custom"
+		}
+		meta := types.RuleMetadata{
+			ID:              def.ID,
+			Description:     def.Description,
+			DefaultSeverity: severity,
+			HelpURL:         def.HelpURL,
+			Category:        category,
+			Tags:            def.Tags,
+			Enabled:         true,
+		}
+		if meta.Description == "" {
+			if def.Path != "" {
+				meta.Description = "Custom rule on " + def.Path
+			} else {
+				meta.Description = "Custom rule: " + def.Expr
+			}
+		}
+		for _, kind := range def.AppliesTo {
+			meta.AppliesTo = append(meta.AppliesTo, types.ResourceKind(kind))
+		}
+		plugins = append(plugins, &customRule{meta: meta, def: def, compiled: compiled})
+	}
+	return plugins, nil
+}
+
+type customRule struct {
+	meta     types.RuleMetadata
+	def      config.CustomRule
+	compiled *config.CompiledRule
+}
+
+func (r *customRule) Metadata() types.RuleMetadata {
+	return r.meta
+}
+
+// violation is one failed check; path and value are empty for CEL rules.
+// literal violations report a broken rule and skip the message template.
+type violation struct {
+	path    string
+	value   interface{}
+	message string
+	literal bool
+}
+
+func (r *customRule) Check(ctx context.Context, m *manifest.Manifest) ([]types.Finding, error) {
+	object := m.Object
+	if object == nil {
+		object = map[string]interface{}{}
+	}
+	vars := map[string]interface{}{
+		"object":    object,
+		"file":      m.FilePath,
+		"kind":      m.Kind,
+		"name":      m.Name,
+		"namespace": m.Namespace,
+	}
+	var violations []violation
+	if r.compiled.Program != nil {
+		violations = r.checkExpr(ctx, vars)
+	} else {
+		violations = r.checkPath(object)
+	}
+	findings := make([]types.Finding, 0, len(violations))
+	for _, v := range violations {
+		msg := v.message
+		if r.compiled.Message != nil && !v.literal {
+			vars["path"], vars["value"], vars["violation"] = v.path, v.value, v.message
+			var b strings.Builder
+			if err := r.compiled.Message.Execute(&b, vars); err != nil {
+				return nil, fmt.Errorf("%s: render message: %w", r.meta.ID, err)
+			}
+			msg = b.String()
+		}
+		findings = append(findings, types.Finding{Message: msg, Line: m.MetadataLine})
+	}
+	return findings, nil
+}
+
+// checkExpr reports a violation when the expression is false. Evaluation
+// errors, such as a missing field without a has() guard, are violations too
+// so a broken expression is never silently skipped.
+func (r *customRule) checkExpr(ctx context.Context, vars map[string]interface{}) []violation {
+	out, _, err := r.compiled.Program.ContextEval(ctx, vars)
+	switch {
+	case err != nil:
+		return []violation{{message: fmt.Sprintf("%s: expression could not be evaluated: %v", r.meta.ID, err), literal: true}}
+	case out.Value() == true:
+		return nil
+	case out.Value() != false:
+		return []violation{{message: fmt.Sprintf("%s: expression returned %v, expected a bool", r.meta.ID, out.Value()), literal: true}}
+	default:
+		return []violation{{message: fmt.Sprintf("%s: expression %s is false", r.meta.ID, r.def.Expr)}}
+	}
+}
+
+func (r *customRule) checkPath(object map[string]interface{}) []violation {
+	matches, missing := r.compiled.Path.Lookup(object)
+	var violations []violation
+	if r.def.Required {
+		for _, path := range missing {
+			violations = append(violations, violation{path: path, message: fmt.Sprintf("%s is required", path)})
+		}
+	}
+	for _, match := range matches {
+		if r.def.Forbidden {
+			violations = append(violations, violation{path: match.Path, value: match.Value, message: fmt.Sprintf("%s must not be set", match.Path)})
+			continue
+		}
+		value := fmt.Sprint(match.Value)
+		if r.compiled.Pattern != nil && !r.compiled.Pattern.MatchString(value) {
+			violations = append(violations, violation{path: match.Path, value: match.Value, message: fmt.Sprintf("%s %q does not match %s", match.Path, value, r.def.Pattern)})
+		}
+		if len(r.def.OneOf) > 0 && !containsString(r.def.OneOf, value) {
+			violations = append(violations, violation{path: match.Path, value: match.Value, message: fmt.Sprintf("%s %q is not one of %s", match.Path, value, strings.Join(r.def.OneOf, ", "))})
+		}
+	}
+	return violations
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *customRule) AppliesTo() plugin.Matcher {
+	if len(r.meta.AppliesTo) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(r.meta.AppliesTo))
+	for _, kind := range r.meta.AppliesTo {
+		allowed[string(kind)] = struct{}{}
+	}
+	return func(m *manifest.Manifest) bool {
+		_, ok := allowed[m.Kind]
+		return ok
+	}
+}
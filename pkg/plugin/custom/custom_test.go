@@ -0,0 +1,109 @@
+package custom
+
+import (
+	"context"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/config"
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func TestFromConfigEvaluatesExpressions(t *testing.T) {
+	cfg, err := config.Parse([]byte(`customRules:
+  - id: CORP001
+    description: Applications must use the corporate Git server
+    severity: error
+    appliesTo: [Application]
+    expr: "object.spec.source.repoURL.startsWith('https://git.corp/')"
+    message: "{{ .name }} pulls from {{ .object.spec.source.repoURL }}"
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rules, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("expected one rule, got %d", len(rules))
+	}
+	rule := rules[0]
+	if meta := rule.Metadata(); meta.ID != "CORP001" || meta.DefaultSeverity != types.SeverityError || meta.Category != "custom" {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if rule.AppliesTo()(&manifest.Manifest{Kind: "AppProject"}) {
+		t.Fatalf("expected the rule to skip AppProjects")
+	}
+	app := func(repo string) *manifest.Manifest {
+		return &manifest.Manifest{Kind: "Application", Name: "payments", MetadataLine: 3, Object: map[string]interface{}{
+			"spec": map[string]interface{}{"source": map[string]interface{}{"repoURL": repo}},
+		}}
+	}
+	if findings, err := rule.Check(context.Background(), app("https://git.corp/payments.git")); err != nil || len(findings) != 0 {
+		t.Fatalf("expected no findings for a corporate repo, got %+v (%v)", findings, err)
+	}
+	findings, err := rule.Check(context.Background(), app("https://github.com/acme/payments.git"))
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Message != "payments pulls from https://github.com/acme/payments.git" || findings[0].Line != 3 {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+	findings, err = rule.Check(context.Background(), &manifest.Manifest{Kind: "Application", Name: "multi", Object: map[string]interface{}{"spec": map[string]interface{}{}}})
+	if err != nil || len(findings) != 1 {
+		t.Fatalf("expected an evaluation error to be reported as a finding, got %+v (%v)", findings, err)
+	}
+}
+
+func TestFromConfigChecksPaths(t *testing.T) {
+	cfg, err := config.Parse([]byte(`customRules:
+  - id: CORP002
+    path: spec.destination.namespace
+    required: true
+    pattern: "^team-"
+  - id: CORP003
+    path: spec.sources[*].repoURL
+    oneOf: [https://git.corp/a.git, https://git.corp/b.git]
+    message: "{{ .name }}: {{ .path }} uses {{ .value }}"
+  - id: CORP004
+    path: metadata.annotations['argocd.argoproj.io/skip-reconcile']
+    forbidden: true
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rules, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	app := &manifest.Manifest{Kind: "Application", Name: "payments", MetadataLine: 3, Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{"argocd.argoproj.io/skip-reconcile": "true"}},
+		"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"server": "https://kubernetes.default.svc"},
+			"sources": []interface{}{
+				map[string]interface{}{"repoURL": "https://git.corp/a.git"},
+				map[string]interface{}{"repoURL": "https://github.com/acme/c.git"},
+			},
+		},
+	}}
+	want := []string{
+		"spec.destination.namespace is required",
+		"payments: spec.sources[1].repoURL uses https://github.com/acme/c.git",
+		"metadata.annotations['argocd.argoproj.io/skip-reconcile'] must not be set",
+	}
+	for i, rule := range rules {
+		findings, err := rule.Check(context.Background(), app)
+		if err != nil {
+			t.Fatalf("%s: check: %v", rule.Metadata().ID, err)
+		}
+		if len(findings) != 1 || findings[0].Message != want[i] || findings[0].Line != 3 {
+			t.Fatalf("%s: unexpected findings %+v", rule.Metadata().ID, findings)
+		}
+	}
+	app.Object["spec"].(map[string]interface{})["destination"] = map[string]interface{}{"namespace": "payments"}
+	findings, err := rules[0].Check(context.Background(), app)
+	if err != nil || len(findings) != 1 || findings[0].Message != `spec.destination.namespace "payments" does not match ^team-` {
+		t.Fatalf("expected a pattern violation, got %+v (%v)", findings, err)
+	}
+}
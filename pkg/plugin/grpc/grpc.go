@@ -0,0 +1,367 @@
+// Package grpc runs heavyweight custom rules as long-lived plugin processes
+// built on hashicorp/go-plugin. A plugin binary calls Serve with its rules;
+// the host starts it once per run through a Loader and streams every manifest
+// to it over a single bidirectional gRPC stream, so rules that call internal
+// inventory APIs pay their setup cost once instead of once per file.
+//
+// Messages are JSON encoded with a registered gRPC codec, so neither side
+// needs generated protobuf code.
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// ProtocolVersion is bumped whenever the wire contract changes; the host
+// refuses plugins built against another version.
+const ProtocolVersion = 1
+
+const (
+	pluginName     = "rules"
+	codecName      = "argocd-lint-json"
+	serviceName    = "argocdlint.plugin.v1.Rules"
+	metadataMethod = "/" + serviceName + "/Metadata"
+	checkMethod    = "/" + serviceName + "/Check"
+)
+
+// Handshake is shared by host and plugin so go-plugin only starts binaries
+// that were built as argocd-lint plugins.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  ProtocolVersion,
+	MagicCookieKey:   "ARGOCD_LINT_PLUGIN",
+	MagicCookieValue: "grpc-rules",
+}
+
+// Resource is the manifest sent to a plugin. Field names match the Rego
+// plugin input.
+type Resource struct {
+	File          string                 `json:"file"`
+	DocumentIndex int                    `json:"document_index"`
+	Kind          string                 `json:"kind"`
+	APIVersion    string                 `json:"api_version"`
+	Name          string                 `json:"name"`
+	Namespace     string                 `json:"namespace"`
+	Line          int                    `json:"line"`
+	Column        int                    `json:"column"`
+	MetadataLine  int                    `json:"metadata_line"`
+	Object        map[string]interface{} `json:"object"`
+}
+
+// Rule is a custom rule served by a plugin binary. Findings may leave rule
+// ID, severity, file, and resource fields empty; the host fills them in.
+type Rule interface {
+	Metadata() types.RuleMetadata
+	Check(ctx context.Context, resource Resource) ([]types.Finding, error)
+}
+
+// Serve runs rules as a plugin process and blocks until the host exits. Call
+// it from the main function of a binary passed to --grpc-plugin.
+func Serve(rules ...Rule) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         goplugin.PluginSet{pluginName: &rulesPlugin{rules: rules}},
+		GRPCServer:      goplugin.DefaultGRPCServer,
+	})
+}
+
+// Loader starts plugin binaries and exposes their rules. Close must be called
+// once the run finishes to stop the plugin processes.
+type Loader struct {
+	paths   []string
+	clients []*goplugin.Client
+}
+
+// NewLoader creates a Loader for the provided plugin binaries.
+func NewLoader(paths ...string) *Loader {
+	return &Loader{paths: paths}
+}
+
+// Load starts every plugin binary and returns one rule plugin per rule it
+// serves.
+func (l *Loader) Load(ctx context.Context) ([]plugin.RulePlugin, error) {
+	var plugins []plugin.RulePlugin
+	for _, path := range l.paths {
+		rc := goplugin.NewClient(&goplugin.ClientConfig{
+			HandshakeConfig:  Handshake,
+			Plugins:          goplugin.PluginSet{pluginName: &rulesPlugin{}},
+			Cmd:              exec.Command(path),
+			AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+			Logger:           hclog.NewNullLogger(),
+		})
+		l.clients = append(l.clients, rc)
+		protocol, err := rc.Client()
+		if err != nil {
+			return nil, fmt.Errorf("start grpc plugin %s: %w", path, err)
+		}
+		raw, err := protocol.Dispense(pluginName)
+		if err != nil {
+			return nil, fmt.Errorf("start grpc plugin %s: %w", path, err)
+		}
+		conn := raw.(*client)
+		metas, err := conn.metadata(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load grpc plugin %s: %w", path, err)
+		}
+		for _, meta := range metas {
+			plugins = append(plugins, &remoteRule{client: conn, meta: meta})
+		}
+	}
+	return plugins, nil
+}
+
+// Close stops every plugin process started by Load.
+func (l *Loader) Close() {
+	for _, rc := range l.clients {
+		rc.Kill()
+	}
+	l.clients = nil
+}
+
+type remoteRule struct {
+	client *client
+	meta   types.RuleMetadata
+}
+
+func (r *remoteRule) Metadata() types.RuleMetadata {
+	return r.meta
+}
+
+func (r *remoteRule) Check(ctx context.Context, m *manifest.Manifest) ([]types.Finding, error) {
+	return r.client.check(ctx, r.meta.ID, Resource{
+		File:          m.FilePath,
+		DocumentIndex: m.DocumentIndex,
+		Kind:          m.Kind,
+		APIVersion:    m.APIVersion,
+		Name:          m.Name,
+		Namespace:     m.Namespace,
+		Line:          m.Line,
+		Column:        m.Column,
+		MetadataLine:  m.MetadataLine,
+		Object:        m.Object,
+	})
+}
+
+func (r *remoteRule) AppliesTo() plugin.Matcher {
+	if len(r.meta.AppliesTo) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(r.meta.AppliesTo))
+	for _, kind := range r.meta.AppliesTo {
+		allowed[string(kind)] = struct{}{}
+	}
+	return func(m *manifest.Manifest) bool {
+		_, ok := allowed[m.Kind]
+		return ok
+	}
+}
+
+// rulesPlugin binds the Rules service into go-plugin on both sides.
+type rulesPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+	rules []Rule
+}
+
+func (p *rulesPlugin) GRPCServer(_ *goplugin.GRPCBroker, s *grpc.Server) error {
+	srv := &server{rules: make(map[string]Rule, len(p.rules))}
+	for _, rule := range p.rules {
+		meta := rule.Metadata()
+		srv.rules[meta.ID] = rule
+		srv.metas = append(srv.metas, meta)
+	}
+	s.RegisterService(&serviceDesc, srv)
+	return nil
+}
+
+func (p *rulesPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return &client{conn: conn}, nil
+}
+
+type metadataRequest struct{}
+
+type metadataResponse struct {
+	Rules []types.RuleMetadata `json:"rules"`
+}
+
+type checkRequest struct {
+	ID       uint64   `json:"id"`
+	Rule     string   `json:"rule"`
+	Resource Resource `json:"resource"`
+}
+
+type checkResponse struct {
+	ID       uint64          `json:"id"`
+	Findings []types.Finding `json:"findings,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// rulesService is the server side of the Rules gRPC service.
+type rulesService interface {
+	metadata(ctx context.Context, req *metadataRequest) (*metadataResponse, error)
+	check(stream grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*rulesService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Metadata",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(metadataRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(rulesService).metadata(ctx, req.(*metadataRequest))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: metadataMethod}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Check",
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			return srv.(rulesService).check(stream)
+		},
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+}
+
+type server struct {
+	rules map[string]Rule
+	metas []types.RuleMetadata
+}
+
+func (s *server) metadata(context.Context, *metadataRequest) (*metadataResponse, error) {
+	return &metadataResponse{Rules: s.metas}, nil
+}
+
+// check answers one checkRequest at a time for as long as the host keeps
+// the stream open.
+func (s *server) check(stream grpc.ServerStream) error {
+	for {
+		var req checkRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		resp := checkResponse{ID: req.ID}
+		if rule, ok := s.rules[req.Rule]; !ok {
+			resp.Error = fmt.Sprintf("unknown rule %q", req.Rule)
+		} else if findings, err := rule.Check(stream.Context(), req.Resource); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Findings = findings
+		}
+		if err := stream.SendMsg(&resp); err != nil {
+			return err
+		}
+	}
+}
+
+// client is the host side of the Rules service. Checks share one stream and
+// are serialised; a cancelled check tears the stream down and the next check
+// opens a new one.
+type client struct {
+	conn   *grpc.ClientConn
+	mu     sync.Mutex
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+	nextID uint64
+}
+
+func (c *client) metadata(ctx context.Context) ([]types.RuleMetadata, error) {
+	var resp metadataResponse
+	if err := c.conn.Invoke(ctx, metadataMethod, &metadataRequest{}, &resp, grpc.CallContentSubtype(codecName)); err != nil {
+		return nil, err
+	}
+	return resp.Rules, nil
+}
+
+func (c *client) check(ctx context.Context, rule string, resource Resource) ([]types.Finding, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.stream == nil {
+		streamCtx, cancel := context.WithCancel(context.Background())
+		stream, err := c.conn.NewStream(streamCtx, &serviceDesc.Streams[0], checkMethod, grpc.CallContentSubtype(codecName))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		c.stream, c.cancel = stream, cancel
+	}
+	c.nextID++
+	req := checkRequest{ID: c.nextID, Rule: rule, Resource: resource}
+	type result struct {
+		resp checkResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func(stream grpc.ClientStream) {
+		var res result
+		if res.err = stream.SendMsg(&req); res.err == nil {
+			res.err = stream.RecvMsg(&res.resp)
+		}
+		done <- res
+	}(c.stream)
+	select {
+	case <-ctx.Done():
+		c.reset()
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			c.reset()
+			return nil, fmt.Errorf("%s: %w", rule, res.err)
+		}
+		if res.resp.ID != req.ID {
+			c.reset()
+			return nil, fmt.Errorf("%s: response %d does not match request %d", rule, res.resp.ID, req.ID)
+		}
+		if res.resp.Error != "" {
+			return nil, fmt.Errorf("%s: %s", rule, res.resp.Error)
+		}
+		return res.resp.Findings, nil
+	}
+}
+
+func (c *client) reset() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.stream, c.cancel = nil, nil
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes the Rules service messages as JSON. It is selected per
+// call through the content subtype, so go-plugin's own protobuf services are
+// unaffected.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return codecName }
@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// servePluginEnv makes the test binary act as a plugin when the Loader
+// starts it.
+const servePluginEnv = "ARGOCD_LINT_TEST_GRPC_PLUGIN"
+
+type prefixRule struct{}
+
+func (prefixRule) Metadata() types.RuleMetadata {
+	return types.RuleMetadata{
+		ID:              "GP001",
+		Description:     "Application names must start with team-",
+		DefaultSeverity: types.SeverityWarn,
+		AppliesTo:       []types.ResourceKind{types.ResourceKindApplication},
+		Enabled:         true,
+	}
+}
+
+func (prefixRule) Check(_ context.Context, resource Resource) ([]types.Finding, error) {
+	if resource.Name == "broken" {
+		return nil, errors.New("inventory unavailable")
+	}
+	if len(resource.Name) >= 5 && resource.Name[:5] == "team-" {
+		return nil, nil
+	}
+	spec, _ := resource.Object["spec"].(map[string]interface{})
+	return []types.Finding{{Message: resource.Name + " in project " + spec["project"].(string) + " lacks the team- prefix"}}, nil
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(servePluginEnv) == "1" {
+		Serve(prefixRule{})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestLoaderStreamsManifestsToPlugin(t *testing.T) {
+	t.Setenv(servePluginEnv, "1")
+	loader := NewLoader(os.Args[0])
+	defer loader.Close()
+	plugins, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Metadata().ID != "GP001" || plugins[0].Metadata().DefaultSeverity != types.SeverityWarn {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	rule := plugins[0]
+	if applies := rule.AppliesTo(); applies == nil || applies(&manifest.Manifest{Kind: "AppProject"}) {
+		t.Fatalf("expected the plugin to apply to Applications only")
+	}
+	for i := 0; i < 3; i++ {
+		findings, err := rule.Check(context.Background(), &manifest.Manifest{
+			Kind:   "Application",
+			Name:   "payments",
+			Object: map[string]interface{}{"spec": map[string]interface{}{"project": "default"}},
+		})
+		if err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+		if len(findings) != 1 || findings[0].Message != "payments in project default lacks the team- prefix" {
+			t.Fatalf("unexpected findings %+v", findings)
+		}
+	}
+	if _, err := rule.Check(context.Background(), &manifest.Manifest{Kind: "Application", Name: "broken"}); err == nil || err.Error() != "GP001: inventory unavailable" {
+		t.Fatalf("expected plugin error to be returned, got %v", err)
+	}
+	if findings, err := rule.Check(context.Background(), &manifest.Manifest{Kind: "Application", Name: "team-payments"}); err != nil || len(findings) != 0 {
+		t.Fatalf("expected the stream to keep working after an error, got %+v (%v)", findings, err)
+	}
+}
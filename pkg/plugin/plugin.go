@@ -36,3 +36,31 @@ func (r *Registry) Register(plugins ...RulePlugin) {
 func (r *Registry) Plugins() []RulePlugin {
 	return append([]RulePlugin(nil), r.plugins...)
 }
+
+type manifestsKey struct{}
+
+// WithManifests returns a context carrying every manifest of the current run,
+// so plugins can implement cross-resource rules.
+func WithManifests(ctx context.Context, manifests []*manifest.Manifest) context.Context {
+	return context.WithValue(ctx, manifestsKey{}, manifests)
+}
+
+// Manifests returns the manifests carried by ctx, or nil outside a run.
+func Manifests(ctx context.Context) []*manifest.Manifest {
+	manifests, _ := ctx.Value(manifestsKey{}).([]*manifest.Manifest)
+	return manifests
+}
+
+type paramsKey struct{}
+
+// WithParams returns a context carrying the configured params of the rule
+// being checked.
+func WithParams(ctx context.Context, params map[string]interface{}) context.Context {
+	return context.WithValue(ctx, paramsKey{}, params)
+}
+
+// Params returns the rule params carried by ctx, or nil when none are set.
+func Params(ctx context.Context) map[string]interface{} {
+	params, _ := ctx.Value(paramsKey{}).(map[string]interface{})
+	return params
+}
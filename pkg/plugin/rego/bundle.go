@@ -0,0 +1,183 @@
+package rego
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-policy-agent/opa/util"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+)
+
+// dataDocument is a data.json or data.yaml file of an OPA bundle. Its content
+// is mounted under data at the path of its directory relative to root, the
+// directory or archive the loader was given, as `opa run --bundle` does.
+type dataDocument struct {
+	root string
+	file string
+}
+
+// reservedData lists the data paths argocd-lint provides itself, which data
+// documents may not replace.
+var reservedData = []string{"manifests", "projects", "params"}
+
+// isDataFile reports whether name is an OPA bundle data document.
+func isDataFile(name string) bool {
+	return name == "data.json" || name == "data.yaml"
+}
+
+// isArchive reports whether name is a bundle archive such as the ones built
+// by `opa build`.
+func isArchive(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar")
+}
+
+func (l *Loader) addDocument(root, file string) {
+	if _, seen := l.seen[file]; !seen {
+		l.seen[file] = struct{}{}
+		l.documents = append(l.documents, dataDocument{root: root, file: file})
+	}
+}
+
+// unpackLocal extracts the loader's local archives into the cache and adds
+// their modules and data documents. With a verifier, each archive needs a
+// signature of its own, which then covers its content.
+func (l *Loader) unpackLocal() error {
+	for _, archive := range l.archives {
+		if l.remote.Verifier != nil {
+			if err := l.remote.Verifier.VerifyFile(archive); err != nil {
+				return err
+			}
+		}
+		data, err := os.ReadFile(archive)
+		if err != nil {
+			return err
+		}
+		cacheDir := l.remote.CacheDir
+		if cacheDir == "" {
+			if cacheDir, err = fetch.DefaultCacheDir(); err != nil {
+				return err
+			}
+		}
+		sum := sha256.Sum256(data)
+		dir, err := unpackArchive(filepath.Join(cacheDir, "archives", hex.EncodeToString(sum[:])), data)
+		if err != nil {
+			return fmt.Errorf("unpack %s: %w", archive, err)
+		}
+		l.addFetched(dir)
+	}
+	l.archives = nil
+	return nil
+}
+
+// loadDocuments reads the loader's data documents and makes them available
+// to its plugins next to data.manifests and data.projects. Documents whose
+// paths overlap are merged; conflicting values are an error.
+func (l *Loader) loadDocuments(ctx context.Context) error {
+	if len(l.documents) == 0 {
+		return nil
+	}
+	merged := map[string]interface{}{}
+	for _, doc := range l.documents {
+		if l.remote.Verifier != nil && !l.fetched[doc.file] {
+			if err := l.remote.Verifier.VerifyFile(doc.file); err != nil {
+				return err
+			}
+		}
+		raw, err := os.ReadFile(doc.file)
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := util.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("parse data document %s: %w", doc.file, err)
+		}
+		var path []string
+		if rel, err := filepath.Rel(doc.root, filepath.Dir(doc.file)); err == nil && rel != "." {
+			path = strings.Split(filepath.ToSlash(rel), "/")
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("data document %s: a document at the bundle root must be an object", doc.file)
+		}
+		if err := mergeData(merged, obj, nil); err != nil {
+			return fmt.Errorf("data document %s: %w", doc.file, err)
+		}
+	}
+	for _, key := range reservedData {
+		if _, ok := merged[key]; ok {
+			return fmt.Errorf("data documents must not define data.%s, which argocd-lint provides", key)
+		}
+	}
+	// Metadata may read data documents, so they are part of the key of
+	// cached modules.
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(encoded)
+	l.dataDigest = hex.EncodeToString(sum[:])
+	return l.data.setDocuments(ctx, merged)
+}
+
+// mergeData copies src into dst, merging nested objects.
+func mergeData(dst, src map[string]interface{}, path []string) error {
+	for key, value := range src {
+		at := append(path[:len(path):len(path)], key)
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		existingObj, ok1 := existing.(map[string]interface{})
+		valueObj, ok2 := value.(map[string]interface{})
+		if !ok1 || !ok2 {
+			return fmt.Errorf("conflicting values for data.%s", strings.Join(at, "."))
+		}
+		if err := mergeData(existingObj, valueObj, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpackArchive extracts the Rego modules and data documents of an archive
+// into dir, unless an earlier run already did. Extraction happens in a
+// temporary directory that is renamed into place, so an interrupted run
+// never leaves a partial bundle behind.
+func unpackArchive(dir string, data []byte) (string, error) {
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".unpack-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	count, err := extractArchive(tmp, data)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", fmt.Errorf("archive contains no Rego modules")
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return dir, nil
+		}
+		return "", err
+	}
+	return dir, nil
+}
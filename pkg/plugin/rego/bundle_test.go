@@ -0,0 +1,117 @@
+package rego
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+)
+
+const bundleTestModule = `package argocd_lint.clusters
+
+metadata := {"id": "DATA001", "description": "destinations must be allowed clusters", "severity": "error"}
+
+deny[f] {
+  server := input.object.spec.destination.server
+  not allowed[server]
+  f := {"message": sprintf("%s deploys to %s, owned by %s", [input.name, server, object.get(data.teams.owners, server, "nobody")])}
+}
+
+allowed[server] {
+  server := data.clusters.allowed[_]
+}
+`
+
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("tar write: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	if err := os.WriteFile(path, archive.Bytes(), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+}
+
+func TestLoaderLoadsBundleArchivesWithData(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	writeTestArchive(t, path, map[string]string{
+		"/.manifest":            `{"revision": "1"}`,
+		"/policy/clusters.rego": bundleTestModule,
+		"/data.json":            `{"clusters": {"allowed": ["https://prod.corp"]}}`,
+		"/teams/data.yaml":      "owners:\n  https://staging.corp: platform\n",
+	})
+	plugins, err := NewLoader(path).WithRemoteOptions(RemoteOptions{CacheDir: t.TempDir()}).Load(ctx)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Metadata().ID != "DATA001" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	app := func(name, server string) *manifest.Manifest {
+		return &manifest.Manifest{Kind: "Application", Name: name, Object: map[string]interface{}{
+			"spec": map[string]interface{}{"destination": map[string]interface{}{"server": server}},
+		}}
+	}
+	manifests := []*manifest.Manifest{app("api", "https://prod.corp"), app("web", "https://staging.corp")}
+	runCtx := plugin.WithManifests(ctx, manifests)
+	if findings, err := plugins[0].Check(runCtx, manifests[0]); err != nil || len(findings) != 0 {
+		t.Fatalf("expected an allowed cluster to pass, got %+v (%v)", findings, err)
+	}
+	findings, err := plugins[0].Check(runCtx, manifests[1])
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Message != "web deploys to https://staging.corp, owned by platform" {
+		t.Fatalf("expected the data documents to survive the manifest sync, got %+v", findings)
+	}
+}
+
+func TestLoaderRejectsConflictingDataDocuments(t *testing.T) {
+	cases := map[string]map[string]string{
+		"conflicting values for data.clusters.allowed": {
+			"data.json":          `{"clusters": {"allowed": ["a"]}}`,
+			"clusters/data.yaml": "allowed: [b]\n",
+		},
+		"must not define data.manifests": {
+			"data.yaml": "manifests: []\n",
+		},
+	}
+	for want, files := range cases {
+		dir := t.TempDir()
+		files["policy.rego"] = bundleTestModule
+		for name, body := range files {
+			file := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+		if _, err := NewLoader(dir).Load(context.Background()); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+}
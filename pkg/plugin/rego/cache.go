@@ -0,0 +1,104 @@
+package rego
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/open-policy-agent/opa/version"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// moduleCacheVersion changes whenever cached entries would be read
+// differently, so entries written by older releases are ignored.
+const moduleCacheVersion = "4"
+
+// cachedModule is what the module cache stores for a module: its rule
+// metadata only, or that it is a library. Compiled queries are not
+// serializable, so a cached module still compiles on its first check.
+type cachedModule struct {
+	Metadata types.RuleMetadata `json:"metadata"`
+	Library  bool               `json:"library,omitempty"`
+}
+
+// WithModuleCache keeps the rule metadata of modules, not their compiled
+// form, under cacheDir/modules, keyed by the SHA-256 of the module source
+// and of the modules loaded with it. Later loads of an unchanged set defer compilation to its first check,
+// so rules that are disabled or match no manifest of a run are never
+// compiled. Loaders with a verifier do not use the cache.
+func (l *Loader) WithModuleCache(cacheDir string) *Loader {
+	l.moduleCache = filepath.Join(cacheDir, "modules")
+	return l
+}
+
+// loadModule loads file through the module cache when the loader has one,
+// returning nil for a library module. Modules are compiled together, so the
+// key covers the sources of all of them. Cache problems are not errors: the
+// module is compiled as if uncached.
+func (l *Loader) loadModule(ctx context.Context, file string) (*regoPlugin, error) {
+	if l.moduleCache == "" || l.remote.Verifier != nil {
+		return l.compileFile(ctx, file)
+	}
+	sum := sha256.Sum256([]byte(moduleCacheVersion + "\x00" + version.Version + "\x00" + l.dataDigest + "\x00" + l.sourcesDigest + "\x00" + l.sources[file]))
+	entry := filepath.Join(l.moduleCache, hex.EncodeToString(sum[:])+".json")
+	if raw, err := os.ReadFile(entry); err == nil {
+		var cached cachedModule
+		if err := json.Unmarshal(raw, &cached); err == nil && cached.Library {
+			return nil, nil
+		} else if err == nil && cached.Metadata.ID != "" {
+			p := &regoPlugin{source: file, meta: cached.Metadata, data: l.data}
+			p.compile = func(ctx context.Context) error {
+				compiled, err := l.compileFile(ctx, file)
+				if err == nil && compiled == nil {
+					err = fmt.Errorf("module is a library")
+				}
+				if err != nil {
+					return fmt.Errorf("load rego plugin %s: %w", file, err)
+				}
+				p.denyQuery, p.appliesQuery = compiled.denyQuery, compiled.appliesQuery
+				return nil
+			}
+			return p, nil
+		}
+	}
+	p, err := l.compileFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		_ = writeCacheEntry(entry, cachedModule{Library: true})
+		return nil, nil
+	}
+	_ = writeCacheEntry(entry, cachedModule{Metadata: p.meta})
+	return p, nil
+}
+
+// writeCacheEntry writes value as JSON to path through a temporary file, so
+// concurrent runs never read a partial entry.
+func writeCacheEntry(path string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
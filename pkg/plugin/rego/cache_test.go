@@ -0,0 +1,66 @@
+package rego
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+)
+
+const cacheTestModule = `package argocd_lint.cached
+
+metadata := {"id": "CACHE001", "description": "cached", "severity": "error", "applies_to": ["Application"]}
+
+deny[f] {
+  input.name == "bad"
+  f := {"message": "bad name"}
+}
+`
+
+func TestModuleCacheSkipsCompilationOnWarmRuns(t *testing.T) {
+	ctx := context.Background()
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	module := filepath.Join(dir, "cached.rego")
+	if err := os.WriteFile(module, []byte(cacheTestModule), 0o644); err != nil {
+		t.Fatalf("write module: %v", err)
+	}
+	load := func() *regoPlugin {
+		t.Helper()
+		plugins, err := NewLoader(dir).WithModuleCache(cacheDir).Load(ctx)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if len(plugins) != 1 {
+			t.Fatalf("expected one plugin, got %d", len(plugins))
+		}
+		return plugins[0].(*regoPlugin)
+	}
+	if cold := load(); cold.compile != nil {
+		t.Fatalf("expected the first load to compile the module")
+	}
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "modules"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one cache entry, got %v (%v)", entries, err)
+	}
+
+	warm := load()
+	if warm.compile == nil {
+		t.Fatalf("expected the second load to use the cache")
+	}
+	if meta := warm.Metadata(); meta.ID != "CACHE001" || meta.DefaultSeverity != "error" || len(meta.AppliesTo) != 1 || !meta.Enabled {
+		t.Fatalf("unexpected cached metadata %+v", meta)
+	}
+	findings, err := warm.Check(ctx, &manifest.Manifest{Kind: "Application", Name: "bad"})
+	if err != nil || len(findings) != 1 || findings[0].Message != "bad name" {
+		t.Fatalf("expected the cached plugin to compile on first check, got %+v (%v)", findings, err)
+	}
+
+	if err := os.WriteFile(module, []byte(cacheTestModule+"\n# changed\n"), 0o644); err != nil {
+		t.Fatalf("modify module: %v", err)
+	}
+	if changed := load(); changed.compile != nil {
+		t.Fatalf("expected a modified module to be compiled again")
+	}
+}
@@ -0,0 +1,517 @@
+package rego
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	opaast "github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"gopkg.in/yaml.v3"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+const (
+	// ConftestRulePrefix prefixes the rule IDs of conftest packages, as in
+	// conftest/main.
+	ConftestRulePrefix = "conftest/"
+	// GatekeeperRulePrefix prefixes the rule IDs of Gatekeeper constraints,
+	// as in gatekeeper/K8sRequiredLabels/must-have-owner.
+	GatekeeperRulePrefix = "gatekeeper/"
+)
+
+// compatQuery evaluates one rule of an existing policy. Its results become
+// findings with the given severity, or the rule's severity when empty.
+type compatQuery struct {
+	query    rego.PreparedEvalQuery
+	severity types.Severity
+}
+
+// compatPlugin adapts conftest and Gatekeeper policies, which report plain
+// messages about the raw resource, to the plugin interface.
+type compatPlugin struct {
+	meta    types.RuleMetadata
+	queries []compatQuery
+	input   func(m *manifest.Manifest) interface{}
+	matches plugin.Matcher
+}
+
+func (p *compatPlugin) Metadata() types.RuleMetadata {
+	return p.meta
+}
+
+func (p *compatPlugin) AppliesTo() plugin.Matcher {
+	return p.matches
+}
+
+func (p *compatPlugin) Check(ctx context.Context, m *manifest.Manifest) ([]types.Finding, error) {
+	input := p.input(m)
+	var findings []types.Finding
+	for _, q := range p.queries {
+		rs, err := q.query.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			return nil, fmt.Errorf("evaluate %s: %w", p.meta.ID, err)
+		}
+		for _, result := range rs {
+			for _, exp := range result.Expressions {
+				for _, message := range compatMessages(exp.Value) {
+					raw := map[string]interface{}{"message": message}
+					if q.severity != "" {
+						raw["severity"] = string(q.severity)
+					}
+					findings = append(findings, mapToFinding(raw, p.meta, m))
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// compatMessages extracts the messages of a deny, warn, or violation rule:
+// conftest returns strings or objects with a msg key, Gatekeeper objects with
+// a msg key, and a boolean rule reports one generic violation when true.
+func compatMessages(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var messages []string
+		for _, item := range v {
+			messages = append(messages, compatMessages(item)...)
+		}
+		return messages
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		if msg, ok := v["msg"].(string); ok && msg != "" {
+			return []string{msg}
+		}
+		return []string{"violation"}
+	case bool:
+		if v {
+			return []string{"violation"}
+		}
+	}
+	return nil
+}
+
+// LoadConftest compiles the loader's modules as conftest policies: every
+// package with deny, violation, or warn rules (including deny_<name> style
+// variants) becomes one rule with the ID conftest/<package>. The policies see
+// the raw manifest as input, as `conftest test` does; deny and violation
+// results use the rule's severity and warn results are warnings. Modules
+// without such rules are libraries imported by the others.
+func (l *Loader) LoadConftest(ctx context.Context) ([]plugin.RulePlugin, error) {
+	if len(l.missing) > 0 {
+		return nil, fmt.Errorf("missing conftest policy paths: %s", strings.Join(l.missing, ", "))
+	}
+	if err := l.pull(ctx); err != nil {
+		return nil, err
+	}
+	if err := l.loadDocuments(ctx); err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string, len(l.files))
+	for _, file := range l.files {
+		if l.remote.Verifier != nil && !l.fetched[file] {
+			if err := l.remote.Verifier.VerifyFile(file); err != nil {
+				return nil, err
+			}
+		}
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read module: %w", err)
+		}
+		sources[file] = string(source)
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	compiler, err := opaast.CompileModules(sources)
+	if err != nil {
+		return nil, fmt.Errorf("compile conftest policies: %w", err)
+	}
+	rules := map[string]map[string]bool{}
+	for _, module := range compiler.Modules {
+		pkg := module.Package.Path.String()
+		for _, rule := range module.Rules {
+			name := string(rule.Head.Name)
+			if _, ok := conftestSeverity(name); !ok {
+				continue
+			}
+			if rules[pkg] == nil {
+				rules[pkg] = map[string]bool{}
+			}
+			rules[pkg][name] = true
+		}
+	}
+	packages := make([]string, 0, len(rules))
+	for pkg := range rules {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	plugins := make([]plugin.RulePlugin, 0, len(packages))
+	for _, pkg := range packages {
+		names := make([]string, 0, len(rules[pkg]))
+		for name := range rules[pkg] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		id := ConftestRulePrefix + strings.TrimPrefix(pkg, "data.")
+		p := &compatPlugin{
+			meta: types.RuleMetadata{
+				ID:              id,
+				Description:     fmt.Sprintf("conftest policy %s", strings.TrimPrefix(pkg, "data.")),
+				DefaultSeverity: types.SeverityError,
+				Category:        "conftest",
+				Enabled:         true,
+			},
+			input: func(m *manifest.Manifest) interface{} { return resourceObject(m) },
+		}
+		for _, name := range names {
+			query, err := rego.New(
+				rego.Compiler(compiler),
+				rego.Store(l.data.store),
+				rego.Query(fmt.Sprintf("%s.%s", pkg, name)),
+			).PrepareForEval(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("prepare %s query: %w", id, err)
+			}
+			severity, _ := conftestSeverity(name)
+			p.queries = append(p.queries, compatQuery{query: query, severity: severity})
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// conftestSeverity returns the finding severity for a conftest rule name:
+// warnings for warn rules, and empty, the rule's severity, for deny and
+// violation rules. It reports false for rules that are not checks.
+func conftestSeverity(name string) (types.Severity, bool) {
+	for _, prefix := range []string{"deny", "violation", "warn"} {
+		if name != prefix && !strings.HasPrefix(name, prefix+"_") {
+			continue
+		}
+		if prefix == "warn" {
+			return types.SeverityWarn, true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+func resourceObject(m *manifest.Manifest) map[string]interface{} {
+	if m.Object == nil {
+		return map[string]interface{}{}
+	}
+	return m.Object
+}
+
+// gatekeeperTemplate is a compiled ConstraintTemplate.
+type gatekeeperTemplate struct {
+	source      string
+	description string
+	compiler    *opaast.Compiler
+	pkg         string
+}
+
+// LoadGatekeeper reads Gatekeeper ConstraintTemplates and constraints from
+// the YAML files at paths, walking directories recursively, and returns one
+// rule per constraint with the ID gatekeeper/<kind>/<name>. Each constraint
+// evaluates the violation rule of its template with input.review.object set
+// to the manifest and input.parameters to the constraint's spec.parameters.
+// spec.match narrows the manifests by kinds, namespaces, excludedNamespaces,
+// name, and labelSelector.matchLabels, and spec.enforcementAction sets the
+// severity: deny is error, warn is warn, and dryrun is info. With a
+// verifier, every YAML file needs a valid signature.
+func LoadGatekeeper(ctx context.Context, verifier *Verifier, paths ...string) ([]plugin.RulePlugin, error) {
+	var files []string
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gatekeeper policy path: %w", err)
+		}
+	}
+	templates := map[string]*gatekeeperTemplate{}
+	var constraints []map[string]interface{}
+	var constraintFiles []string
+	for _, file := range files {
+		if verifier != nil {
+			if err := verifier.VerifyFile(file); err != nil {
+				return nil, err
+			}
+		}
+		docs, err := readYAMLDocuments(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			apiVersion, _ := doc["apiVersion"].(string)
+			switch {
+			case doc["kind"] == "ConstraintTemplate" && strings.HasPrefix(apiVersion, "templates.gatekeeper.sh/"):
+				kind, template, err := compileTemplate(doc, file)
+				if err != nil {
+					return nil, err
+				}
+				if other, ok := templates[kind]; ok {
+					return nil, fmt.Errorf("%s: ConstraintTemplate for %s is already defined in %s", file, kind, other.source)
+				}
+				templates[kind] = template
+			case strings.HasPrefix(apiVersion, "constraints.gatekeeper.sh/"):
+				constraints = append(constraints, doc)
+				constraintFiles = append(constraintFiles, file)
+			}
+		}
+	}
+	plugins := make([]plugin.RulePlugin, 0, len(constraints))
+	for i, constraint := range constraints {
+		kind, _ := constraint["kind"].(string)
+		name := nestedString(constraint, "metadata", "name")
+		template, ok := templates[kind]
+		if !ok {
+			return nil, fmt.Errorf("%s: constraint %s/%s has no ConstraintTemplate", constraintFiles[i], kind, name)
+		}
+		p, err := newConstraintPlugin(ctx, kind, name, constraint, template)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", constraintFiles[i], err)
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+func readYAMLDocuments(file string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var docs []map[string]interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// compileTemplate compiles the Rego of a ConstraintTemplate's first target,
+// from spec.targets[0].rego and libs or from a Rego entry of its code list.
+func compileTemplate(doc map[string]interface{}, file string) (string, *gatekeeperTemplate, error) {
+	kind := nestedString(doc, "spec", "crd", "spec", "names", "kind")
+	if kind == "" {
+		return "", nil, fmt.Errorf("%s: ConstraintTemplate %s has no spec.crd.spec.names.kind", file, nestedString(doc, "metadata", "name"))
+	}
+	targets, _ := nestedValue(doc, "spec", "targets").([]interface{})
+	if len(targets) == 0 {
+		return "", nil, fmt.Errorf("%s: ConstraintTemplate for %s has no targets", file, kind)
+	}
+	target, _ := targets[0].(map[string]interface{})
+	source, _ := target["rego"].(string)
+	libs, _ := target["libs"].([]interface{})
+	if source == "" {
+		code, _ := target["code"].([]interface{})
+		for _, entry := range code {
+			engine, _ := entry.(map[string]interface{})
+			if engine["engine"] != "Rego" {
+				continue
+			}
+			source = nestedString(engine, "source", "rego")
+			libs, _ = nestedValue(engine, "source", "libs").([]interface{})
+		}
+	}
+	if source == "" {
+		return "", nil, fmt.Errorf("%s: ConstraintTemplate for %s has no Rego", file, kind)
+	}
+	modules := map[string]string{kind + ".rego": source}
+	for i, lib := range libs {
+		if text, ok := lib.(string); ok {
+			modules[fmt.Sprintf("%s.lib%d.rego", kind, i)] = text
+		}
+	}
+	module, err := opaast.ParseModule(kind+".rego", source)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: parse ConstraintTemplate %s: %w", file, kind, err)
+	}
+	compiler, err := opaast.CompileModules(modules)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: compile ConstraintTemplate %s: %w", file, kind, err)
+	}
+	description := nestedString(doc, "metadata", "annotations", "description")
+	if description == "" {
+		description = fmt.Sprintf("Gatekeeper constraint template %s", kind)
+	}
+	return kind, &gatekeeperTemplate{source: file, description: description, compiler: compiler, pkg: module.Package.Path.String()}, nil
+}
+
+func newConstraintPlugin(ctx context.Context, kind, name string, constraint map[string]interface{}, template *gatekeeperTemplate) (*compatPlugin, error) {
+	severity := types.SeverityError
+	switch action := nestedString(constraint, "spec", "enforcementAction"); action {
+	case "", "deny":
+	case "warn":
+		severity = types.SeverityWarn
+	case "dryrun":
+		severity = types.SeverityInfo
+	default:
+		return nil, fmt.Errorf("constraint %s/%s: unsupported enforcementAction %q", kind, name, action)
+	}
+	query, err := rego.New(
+		rego.Compiler(template.compiler),
+		rego.Query(template.pkg+".violation"),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("prepare %s violation query: %w", kind, err)
+	}
+	parameters, _ := nestedValue(constraint, "spec", "parameters").(map[string]interface{})
+	if parameters == nil {
+		parameters = map[string]interface{}{}
+	}
+	match, _ := nestedValue(constraint, "spec", "match").(map[string]interface{})
+	return &compatPlugin{
+		meta: types.RuleMetadata{
+			ID:              GatekeeperRulePrefix + kind + "/" + name,
+			Description:     template.description,
+			DefaultSeverity: severity,
+			Category:        "gatekeeper",
+			Enabled:         true,
+		},
+		queries: []compatQuery{{query: query}},
+		input: func(m *manifest.Manifest) interface{} {
+			group, version := splitAPIVersion(m.APIVersion)
+			return map[string]interface{}{
+				"review": map[string]interface{}{
+					"object":    resourceObject(m),
+					"kind":      map[string]interface{}{"group": group, "version": version, "kind": m.Kind},
+					"name":      m.Name,
+					"namespace": m.Namespace,
+					"operation": "CREATE",
+				},
+				"parameters": parameters,
+			}
+		},
+		matches: constraintMatcher(match),
+	}, nil
+}
+
+// constraintMatcher implements the spec.match fields of a constraint that
+// apply to manifests in Git. A nil match applies to every manifest.
+func constraintMatcher(match map[string]interface{}) plugin.Matcher {
+	if len(match) == 0 {
+		return nil
+	}
+	kinds, _ := match["kinds"].([]interface{})
+	namespaces := stringList(match["namespaces"])
+	excluded := stringList(match["excludedNamespaces"])
+	name, _ := match["name"].(string)
+	labels, _ := nestedValue(match, "labelSelector", "matchLabels").(map[string]interface{})
+	return func(m *manifest.Manifest) bool {
+		if len(kinds) > 0 && !matchesKinds(kinds, m) {
+			return false
+		}
+		if len(namespaces) > 0 && !matchesAnyGlob(namespaces, m.Namespace) {
+			return false
+		}
+		if matchesAnyGlob(excluded, m.Namespace) {
+			return false
+		}
+		if name != "" && !matchesAnyGlob([]string{name}, m.Name) {
+			return false
+		}
+		for key, value := range labels {
+			if nestedString(resourceObject(m), "metadata", "labels", key) != fmt.Sprint(value) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func matchesKinds(kinds []interface{}, m *manifest.Manifest) bool {
+	group, _ := splitAPIVersion(m.APIVersion)
+	for _, entry := range kinds {
+		selector, _ := entry.(map[string]interface{})
+		groups := stringList(selector["apiGroups"])
+		names := stringList(selector["kinds"])
+		if (len(groups) == 0 || containsOrWildcard(groups, group)) && (len(names) == 0 || containsOrWildcard(names, m.Kind)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOrWildcard(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == "*" || candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyGlob matches Gatekeeper name patterns: exact, or a prefix when
+// the pattern ends with *.
+func matchesAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); (ok && strings.HasPrefix(value, prefix)) || pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitAPIVersion(apiVersion string) (string, string) {
+	if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+		return group, version
+	}
+	return "", apiVersion
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func nestedValue(obj map[string]interface{}, keys ...string) interface{} {
+	var current interface{} = obj
+	for _, key := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+func nestedString(obj map[string]interface{}, keys ...string) string {
+	s, _ := nestedValue(obj, keys...).(string)
+	return s
+}
@@ -0,0 +1,167 @@
+package rego_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	regoloader "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadConftestEvaluatesDenyAndWarnRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/argo.rego": `package lib.argo
+
+is_application {
+  input.kind == "Application"
+}
+`,
+		"policy/main.rego": `package main
+
+import data.lib.argo
+
+deny[msg] {
+  argo.is_application
+  input.spec.source.targetRevision == "HEAD"
+  msg := sprintf("%s tracks HEAD", [input.metadata.name])
+}
+
+warn_project[{"msg": msg}] {
+  argo.is_application
+  input.spec.project == "default"
+  msg := "uses the default project"
+}
+`,
+		"policy/main_test.rego": `package main
+
+test_ignored_by_the_loader {
+  true
+}
+`,
+	})
+	plugins, err := regoloader.NewLoader(dir).LoadConftest(context.Background())
+	if err != nil {
+		t.Fatalf("load conftest policies: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Metadata().ID != "conftest/main" {
+		t.Fatalf("expected one conftest/main rule, got %d", len(plugins))
+	}
+	m := &manifest.Manifest{FilePath: "apps/api.yaml", Kind: "Application", Name: "api", Object: map[string]interface{}{
+		"kind":     "Application",
+		"metadata": map[string]interface{}{"name": "api"},
+		"spec": map[string]interface{}{
+			"project": "default",
+			"source":  map[string]interface{}{"targetRevision": "HEAD"},
+		},
+	}}
+	findings, err := plugins[0].Check(context.Background(), m)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	got := map[string]types.Severity{}
+	for _, f := range findings {
+		if f.RuleID != "conftest/main" || f.FilePath != "apps/api.yaml" {
+			t.Fatalf("unexpected finding %+v", f)
+		}
+		got[f.Message] = f.Severity
+	}
+	if len(got) != 2 || got["api tracks HEAD"] != types.SeverityError || got["uses the default project"] != types.SeverityWarn {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+}
+
+func TestLoadGatekeeperEvaluatesConstraints(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"template.yaml": `apiVersion: templates.gatekeeper.sh/v1
+kind: ConstraintTemplate
+metadata:
+  name: k8srequiredlabels
+  annotations:
+    description: Requires resources to carry the given labels.
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8srequiredlabels
+
+        violation[{"msg": msg}] {
+          provided := {label | input.review.object.metadata.labels[label]}
+          required := {label | label := input.parameters.labels[_]}
+          missing := required - provided
+          count(missing) > 0
+          msg := sprintf("%s is missing labels %v", [input.review.object.metadata.name, missing])
+        }
+`,
+		"constraints/owner.yaml": `apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: must-have-owner
+spec:
+  enforcementAction: warn
+  match:
+    kinds:
+      - apiGroups: ["argoproj.io"]
+        kinds: ["Application"]
+    excludedNamespaces: ["sandbox-*"]
+  parameters:
+    labels: ["owner"]
+`,
+	})
+	plugins, err := regoloader.LoadGatekeeper(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("load gatekeeper policies: %v", err)
+	}
+	if len(plugins) != 1 {
+		t.Fatalf("expected one constraint, got %d", len(plugins))
+	}
+	plug := plugins[0]
+	meta := plug.Metadata()
+	if meta.ID != "gatekeeper/K8sRequiredLabels/must-have-owner" || meta.DefaultSeverity != types.SeverityWarn || meta.Description != "Requires resources to carry the given labels." {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	app := func(namespace string) *manifest.Manifest {
+		return &manifest.Manifest{APIVersion: "argoproj.io/v1alpha1", Kind: "Application", Name: "api", Namespace: namespace, Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "api", "labels": map[string]interface{}{"team": "payments"}},
+		}}
+	}
+	matcher := plug.AppliesTo()
+	project := &manifest.Manifest{APIVersion: "argoproj.io/v1alpha1", Kind: "AppProject", Namespace: "argocd"}
+	if matcher == nil || !matcher(app("argocd")) || matcher(app("sandbox-42")) || matcher(project) {
+		t.Fatalf("expected the constraint to match Applications outside sandbox namespaces only")
+	}
+	findings, err := plug.Check(context.Background(), app("argocd"))
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, `api is missing labels {"owner"}`) || findings[0].Severity != types.SeverityWarn {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	writeFiles(t, dir, map[string]string{"constraints/orphan.yaml": "apiVersion: constraints.gatekeeper.sh/v1beta1\nkind: K8sUnknown\nmetadata:\n  name: orphan\n"})
+	if _, err := regoloader.LoadGatekeeper(context.Background(), nil, dir); err == nil || !strings.Contains(err.Error(), "has no ConstraintTemplate") {
+		t.Fatalf("expected a constraint without template to be rejected, got %v", err)
+	}
+}
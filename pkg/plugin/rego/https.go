@@ -0,0 +1,65 @@
+package rego
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+)
+
+// IsHTTPSReference reports whether path names a bundle served over HTTPS.
+func IsHTTPSReference(path string) bool {
+	return fetch.IsURL(path)
+}
+
+// FetchBundle downloads a .rego module or a .tar.gz, .tgz, or .tar archive of
+// modules and data documents from an https:// URL and returns the local path to load. A
+// #sha256=<hex> fragment pins the download, and pinned bundles are served
+// from the cache without contacting the server. With opts.Verifier set, the
+// signature is downloaded from the same URL plus the verifier's signature
+// suffix and checked before the bundle is used.
+func FetchBundle(ctx context.Context, ref string, opts RemoteOptions) (string, error) {
+	file, err := fetch.File(ctx, ref, opts.CacheDir)
+	if err != nil {
+		return "", err
+	}
+	if opts.Verifier != nil {
+		base, _, _ := strings.Cut(ref, "#")
+		signature, err := fetch.File(ctx, base+opts.Verifier.SignatureSuffix(), opts.CacheDir)
+		if err != nil {
+			return "", fmt.Errorf("verify %s: %w", ref, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		if err := opts.Verifier.verifyBlob(data, signature); err != nil {
+			return "", fmt.Errorf("verify %s: %w", ref, err)
+		}
+	}
+	switch name := filepath.Base(file); {
+	case strings.HasSuffix(name, ".rego"):
+		return file, nil
+	case isArchive(name):
+		dir, err := unpackBundle(file)
+		if err != nil {
+			return "", fmt.Errorf("unpack %s: %w", ref, err)
+		}
+		return dir, nil
+	default:
+		return "", fmt.Errorf("%s: expected a .rego module or a .tar.gz, .tgz, or .tar archive", ref)
+	}
+}
+
+// unpackBundle extracts the Rego modules and data documents of a downloaded
+// archive next to it, once per cached download.
+func unpackBundle(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return unpackArchive(filepath.Join(filepath.Dir(file), "bundle"), data)
+}
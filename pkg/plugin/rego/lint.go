@@ -0,0 +1,251 @@
+package rego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	opaast "github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+
+	"github.com/argocd-lint/argocd-lint/pkg/types"
+)
+
+// Problem is an issue LintModules found in a plugin module.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+	// Warning marks problems that do not stop the module from loading, such
+	// as strict-mode findings and deny rules that can never fire.
+	Warning bool
+}
+
+// knownKinds lists the resource kinds applies_to may name.
+var knownKinds = map[types.ResourceKind]bool{
+	types.ResourceKindApplication:    true,
+	types.ResourceKindApplicationSet: true,
+	types.ResourceKindAppProject:     true,
+}
+
+// LintModules statically checks the Rego plugin modules under paths without
+// evaluating them against manifests: compile errors, missing or duplicate
+// metadata IDs, IDs that collide with builtin, replaces entries missing from
+// builtin, invalid severities, deny rules that require a kind applies_to
+// excludes, and strict-mode warnings such as unused imports and variables.
+// The modules are compiled together, as Load does, so they may import
+// library modules, which have neither a metadata nor a deny rule. Problems
+// are sorted by file and line.
+func LintModules(ctx context.Context, builtin map[string]bool, paths ...string) ([]Problem, error) {
+	l := NewLoader(paths...)
+	if len(l.missing) > 0 {
+		return nil, fmt.Errorf("missing plugin paths: %s", strings.Join(l.missing, ", "))
+	}
+	if err := l.pull(ctx); err != nil {
+		return nil, err
+	}
+	if err := l.loadDocuments(ctx); err != nil {
+		return nil, err
+	}
+	var problems []Problem
+	modules := make(map[string]*opaast.Module, len(l.files))
+	for _, file := range l.files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		module, err := opaast.ParseModule(file, string(source))
+		if err != nil {
+			problems = append(problems, astProblems(file, err, false, "")...)
+			continue
+		}
+		modules[file] = module
+	}
+	compiler, failed := compileSet(modules)
+	problems = append(problems, failed...)
+	if len(compiler.Modules) > 0 {
+		strict := opaast.NewCompiler().WithStrict(true)
+		strict.Compile(copyModules(modules))
+		if strict.Failed() {
+			problems = append(problems, astProblems("", strict.Errors, true, "strict mode: ")...)
+		}
+	}
+	ids := map[string]string{}
+	for _, file := range l.files {
+		module, ok := compiler.Modules[file]
+		if !ok || isLibrary(module) {
+			continue
+		}
+		found, err := l.lintModule(ctx, file, module, compiler, builtin, ids)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// compileSet compiles modules together. Modules with compile errors are
+// reported and removed from modules, and the rest compiled again, so that
+// one broken module does not hide the problems of the others.
+func compileSet(modules map[string]*opaast.Module) (*opaast.Compiler, []Problem) {
+	var problems []Problem
+	for {
+		compiler := opaast.NewCompiler()
+		compiler.Compile(copyModules(modules))
+		if !compiler.Failed() {
+			return compiler, problems
+		}
+		found := astProblems("", compiler.Errors, false, "")
+		problems = append(problems, found...)
+		dropped := false
+		for _, problem := range found {
+			if _, ok := modules[problem.File]; ok {
+				delete(modules, problem.File)
+				dropped = true
+			}
+		}
+		if !dropped {
+			// Errors without a known file fail the whole set.
+			return opaast.NewCompiler(), problems
+		}
+	}
+}
+
+func copyModules(modules map[string]*opaast.Module) map[string]*opaast.Module {
+	out := make(map[string]*opaast.Module, len(modules))
+	for file, module := range modules {
+		out[file] = module.Copy()
+	}
+	return out
+}
+
+// lintModule checks one compiled module. ids maps the metadata IDs of the
+// modules checked so far to their files.
+func (l *Loader) lintModule(ctx context.Context, file string, module *opaast.Module, compiler *opaast.Compiler, builtin map[string]bool, ids map[string]string) ([]Problem, error) {
+	var problems []Problem
+	add := func(line int, warning bool, format string, args ...interface{}) {
+		problems = append(problems, Problem{File: file, Line: line, Message: fmt.Sprintf(format, args...), Warning: warning})
+	}
+
+	metadataRule := findRule(module, "metadata")
+	if metadataRule == nil {
+		add(module.Package.Location.Row, false, "missing metadata rule")
+		return problems, nil
+	}
+	line := metadataRule.Location.Row
+	query, err := rego.New(
+		rego.Compiler(compiler),
+		rego.Store(l.data.store),
+		rego.Query(module.Package.Path.String()+".metadata"),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("prepare metadata query: %w", err)
+	}
+	meta, err := evaluateMetadata(ctx, query)
+	if err != nil {
+		add(line, false, "%v", err)
+		return problems, nil
+	}
+	if _, ok := types.SeverityOrder[meta.DefaultSeverity]; !ok {
+		add(line, false, "metadata.severity: invalid severity %q (use info, warn, or error)", meta.DefaultSeverity)
+	}
+	switch {
+	case builtin[meta.ID]:
+		add(line, false, "metadata.id: %s collides with a built-in rule", meta.ID)
+	case ids[meta.ID] != "":
+		add(line, false, "metadata.id: duplicate id %s, also used by %s", meta.ID, ids[meta.ID])
+	default:
+		ids[meta.ID] = file
+	}
+	for _, id := range meta.Replaces {
+		if !builtin[id] {
+			add(line, false, "metadata.replaces: %s is not a built-in rule", id)
+		}
+	}
+	for _, kind := range meta.AppliesTo {
+		if !knownKinds[kind] {
+			add(line, true, "metadata.applies_to: unknown kind %q", kind)
+		}
+	}
+
+	if findRule(module, "deny") == nil {
+		add(line, true, "no deny rule, so %s never reports findings", meta.ID)
+	}
+	if len(meta.AppliesTo) == 0 {
+		return problems, nil
+	}
+	allowed := make(map[string]bool, len(meta.AppliesTo))
+	names := make([]string, 0, len(meta.AppliesTo))
+	for _, kind := range meta.AppliesTo {
+		allowed[string(kind)] = true
+		names = append(names, string(kind))
+	}
+	for _, rule := range module.Rules {
+		if string(rule.Head.Name) != "deny" {
+			continue
+		}
+		for _, kind := range requiredKinds(rule.Body) {
+			if !allowed[kind] {
+				add(rule.Location.Row, true, "deny rule can never fire: it requires kind %s, but applies_to is [%s]", kind, strings.Join(names, ", "))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// requiredKinds returns the kinds a rule body pins with input.kind == "X".
+func requiredKinds(body opaast.Body) []string {
+	kindRef := opaast.MustParseRef("input.kind")
+	var kinds []string
+	for _, expr := range body {
+		if expr.Negated || !expr.IsCall() || len(expr.Operands()) != 2 {
+			continue
+		}
+		if op := expr.Operator().String(); op != "equal" && op != "eq" {
+			continue
+		}
+		a, b := expr.Operand(0), expr.Operand(1)
+		if ref, ok := b.Value.(opaast.Ref); ok && ref.Equal(kindRef) {
+			a, b = b, a
+		}
+		ref, ok := a.Value.(opaast.Ref)
+		if !ok || !ref.Equal(kindRef) {
+			continue
+		}
+		if kind, ok := b.Value.(opaast.String); ok {
+			kinds = append(kinds, string(kind))
+		}
+	}
+	return kinds
+}
+
+// astProblems converts OPA parse and compile errors into problems, in the
+// file their location names or else in file.
+func astProblems(file string, err error, warning bool, prefix string) []Problem {
+	var astErrs opaast.Errors
+	if !errors.As(err, &astErrs) {
+		return []Problem{{File: file, Message: prefix + err.Error(), Warning: warning}}
+	}
+	problems := make([]Problem, 0, len(astErrs))
+	for _, e := range astErrs {
+		problem := Problem{File: file, Message: prefix + e.Message, Warning: warning}
+		if e.Location != nil {
+			problem.Line = e.Location.Row
+			if e.Location.File != "" {
+				problem.File = e.Location.File
+			}
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
@@ -0,0 +1,82 @@
+package rego_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	regoloader "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
+)
+
+func TestLintModulesReportsMetadataAndDeadRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"collides.rego": `package argocd_lint.collides
+
+import data.lib.unused
+
+metadata := {"id": "AR001", "severity": "fatal", "applies_to": ["Application"]}
+
+deny[f] {
+  input.kind == "AppProject"
+  f := {"message": "never"}
+}
+
+deny[f] {
+  input.kind == "Application"
+  f := {"message": "sometimes"}
+}
+`,
+		"first.rego":  "package argocd_lint.first\n\nmetadata := {\"id\": \"DUP001\", \"replaces\": [\"AR001\", \"AR404\"]}\n\ndeny[f] {\n  f := {\"message\": input.name}\n}\n",
+		"second.rego": "package argocd_lint.second\n\nmetadata := {\"id\": \"DUP001\"}\n",
+		"nometa.rego": "package argocd_lint.nometa\n\ndeny[f] {\n  f := {\"message\": input.name}\n}\n",
+		"broken.rego": "package argocd_lint.broken\n\ndeny[f] {\n  f := undefined_function(1)\n}\n",
+	})
+	problems, err := regoloader.LintModules(context.Background(), map[string]bool{"AR001": true}, dir)
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		level := "error"
+		if p.Warning {
+			level = "warning"
+		}
+		got = append(got, filepath.Base(p.File)+":"+level+":"+p.Message)
+	}
+	want := []string{
+		"broken.rego:error:undefined function undefined_function",
+		"collides.rego:warning:strict mode: import data.lib.unused unused",
+		"collides.rego:error:metadata.severity: invalid severity \"fatal\"",
+		"collides.rego:error:metadata.id: AR001 collides with a built-in rule",
+		"collides.rego:warning:deny rule can never fire: it requires kind AppProject, but applies_to is [Application]",
+		"first.rego:error:metadata.replaces: AR404 is not a built-in rule",
+		"nometa.rego:error:missing metadata rule",
+		"second.rego:error:metadata.id: duplicate id DUP001",
+		"second.rego:warning:no deny rule",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d problems, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Fatalf("problem %d: expected %q, got %q\nall:\n%s", i, want[i], got[i], strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestLintModulesCompilesSharedLibraries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/argo.rego": "package lib.argo\n\nis_default(project) {\n  project == \"default\"\n}\n",
+		"project.rego":  "package argocd_lint.project\n\nimport data.lib.argo\n\nmetadata := {\"id\": \"RG030\", \"applies_to\": [\"Application\"]}\n\ndeny[f] {\n  argo.is_default(input.object.spec.project)\n  f := {\"message\": \"default project\"}\n}\n",
+	})
+	problems, err := regoloader.LintModules(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("expected the library import to compile, got %+v", problems)
+	}
+}
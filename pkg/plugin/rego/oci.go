@@ -0,0 +1,236 @@
+package rego
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+)
+
+// OCIScheme prefixes plugin paths that name a bundle in an OCI registry, such
+// as oci://registry.corp/policies/argocd:v1.2.0.
+const OCIScheme = "oci://"
+
+// orasUnpackAnnotation marks directory layers pushed by `oras push`.
+const orasUnpackAnnotation = "io.deis.oras.content.unpack"
+
+// RemoteOptions controls how oci:// and https:// bundles are fetched.
+type RemoteOptions struct {
+	// CacheDir holds fetched bundles, with OCI bundles keyed by manifest
+	// digest under oci/, downloads keyed by SHA-256 under https/, and
+	// unpacked local archives keyed by SHA-256 under archives/. Defaults to
+	// argocd-lint under the user cache directory.
+	CacheDir string
+	// PlainHTTP talks to OCI registries over HTTP instead of HTTPS.
+	PlainHTTP bool
+	// Verifier, when set, rejects bundles without a valid signature.
+	Verifier *Verifier
+}
+
+// IsOCIReference reports whether path names an OCI bundle.
+func IsOCIReference(path string) bool {
+	return strings.HasPrefix(path, OCIScheme)
+}
+
+// PullBundle pulls the bundle at ref and returns the local directory holding
+// its Rego modules. Layers may be single .rego files named by their title
+// annotation, or tar archives such as OPA bundles and `oras push` directories.
+// Bundles are cached by manifest digest, so digest references are served from
+// the cache without contacting the registry unless opts.Verifier is set.
+// Registry credentials are read from the Docker config, as written by
+// `oras login` or `docker login`.
+func PullBundle(ctx context.Context, ref string, opts RemoteOptions) (string, error) {
+	parsed, err := registry.ParseReference(strings.TrimPrefix(ref, OCIScheme))
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", ref, err)
+	}
+	if parsed.Reference == "" {
+		return "", fmt.Errorf("%s: reference needs a tag or digest", ref)
+	}
+	cacheDir := opts.CacheDir
+	if cacheDir == "" {
+		if cacheDir, err = fetch.DefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	cacheDir = filepath.Join(cacheDir, "oci")
+	// Verification needs the registry, so only unverified digests may be
+	// served straight from the cache.
+	if digest, err := parsed.Digest(); err == nil && opts.Verifier == nil {
+		dir := filepath.Join(cacheDir, digest.Algorithm().String(), digest.Encoded())
+		if _, err := os.Stat(dir); err == nil {
+			return dir, nil
+		}
+	}
+	repo, err := remote.NewRepository(parsed.Registry + "/" + parsed.Repository)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	repo.PlainHTTP = opts.PlainHTTP
+	client := &auth.Client{Client: retry.DefaultClient, Cache: auth.NewCache()}
+	if store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{}); err == nil {
+		client.Credential = credentials.Credential(store)
+	}
+	repo.Client = client
+	dir, err := pullBundle(ctx, repo, parsed.Reference, cacheDir, opts.Verifier)
+	if err != nil {
+		return "", fmt.Errorf("pull %s: %w", ref, err)
+	}
+	return dir, nil
+}
+
+// pullBundle resolves reference in src, checks its signature when verifier
+// is set, and extracts its layers into a cache directory named after the
+// manifest digest. Extraction happens in a temporary directory that is
+// renamed into place, so an interrupted pull never leaves a partial bundle
+// behind.
+func pullBundle(ctx context.Context, src oras.ReadOnlyTarget, reference, cacheDir string, verifier *Verifier) (string, error) {
+	desc, err := src.Resolve(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+	if verifier != nil {
+		if err := verifier.verifyOCI(ctx, src, desc); err != nil {
+			return "", err
+		}
+	}
+	dir := filepath.Join(cacheDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return "", fmt.Errorf("unsupported manifest media type %s", desc.MediaType)
+	}
+	raw, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return "", err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return "", fmt.Errorf("decode manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".pull-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	modules := 0
+	for _, layer := range manifest.Layers {
+		data, err := content.FetchAll(ctx, src, layer)
+		if err != nil {
+			return "", err
+		}
+		count, err := extractLayer(tmp, layer, data)
+		if err != nil {
+			return "", fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		modules += count
+	}
+	if modules == 0 {
+		return "", fmt.Errorf("bundle %s contains no Rego modules", desc.Digest)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another run may have cached the same digest in the meantime.
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return dir, nil
+		}
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractLayer writes the Rego modules and data documents in layer under dir
+// and returns how many modules it wrote. Other files, such as the .manifest
+// of an OPA bundle, are skipped.
+func extractLayer(dir string, layer ocispec.Descriptor, data []byte) (int, error) {
+	title := layer.Annotations[ocispec.AnnotationTitle]
+	if !strings.Contains(layer.MediaType, "tar") && layer.Annotations[orasUnpackAnnotation] != "true" {
+		switch {
+		case strings.HasSuffix(title, ".rego"):
+			return 1, writeModule(dir, title, bytes.NewReader(data))
+		case isDataFile(path.Base(title)):
+			return 0, writeModule(dir, title, bytes.NewReader(data))
+		}
+		return 0, nil
+	}
+	return extractArchive(dir, data)
+}
+
+// extractArchive writes the Rego modules and data documents in a tar
+// archive, gzipped or not, under dir and returns how many modules it wrote.
+// Entry names may start with a slash, as in bundles built by `opa build`.
+func extractArchive(dir string, data []byte) (int, error) {
+	var reader io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	archive := tar.NewReader(reader)
+	count := 0
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		name := strings.TrimPrefix(header.Name, "/")
+		module := strings.HasSuffix(name, ".rego")
+		if header.Typeflag != tar.TypeReg || (!module && !isDataFile(path.Base(name))) {
+			continue
+		}
+		if err := writeModule(dir, name, archive); err != nil {
+			return count, err
+		}
+		if module {
+			count++
+		}
+	}
+}
+
+func writeModule(dir, name string, r io.Reader) error {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("unsafe path %q", name)
+	}
+	target := filepath.Join(dir, clean)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,175 @@
+package rego
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+
+	"github.com/argocd-lint/argocd-lint/internal/fetch"
+)
+
+const ociTestModule = `package argocd_lint.%s
+
+metadata := {"id": "%s", "description": "test", "severity": "warn"}
+
+deny[f] {
+  false
+  f := {"message": "never"}
+}
+`
+
+func pushTestBundle(t *testing.T, store *memory.Store, files map[string]string) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	var layers []ocispec.Descriptor
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if strings.HasPrefix(name, "tar:") {
+			name = strings.TrimPrefix(name, "tar:")
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatalf("tar header: %v", err)
+			}
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatalf("tar write: %v", err)
+			}
+			continue
+		}
+		desc, err := oras.PushBytes(ctx, store, "application/vnd.argocd-lint.rego", []byte(body))
+		if err != nil {
+			t.Fatalf("push layer: %v", err)
+		}
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		layers = append(layers, desc)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	desc, err := oras.PushBytes(ctx, store, ocispec.MediaTypeImageLayerGzip, archive.Bytes())
+	if err != nil {
+		t.Fatalf("push archive: %v", err)
+	}
+	layers = append(layers, desc)
+	manifest, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.argocd-lint.bundle", oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		t.Fatalf("pack manifest: %v", err)
+	}
+	if err := store.Tag(ctx, manifest, "v1"); err != nil {
+		t.Fatalf("tag: %v", err)
+	}
+	return manifest
+}
+
+func TestPullBundleExtractsAndCachesModules(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	manifest := pushTestBundle(t, store, map[string]string{
+		"naming.rego":            fmt.Sprintf(ociTestModule, "naming", "OCI001"),
+		"README.md":              "skipped",
+		"tar:bundle/owner.rego":  fmt.Sprintf(ociTestModule, "owner", "OCI002"),
+		"tar:bundle/data.json":   "{}",
+		"tar:bundle/.manifest":   "{}",
+		"tar:bundle/nested/x.md": "skipped",
+	})
+	cacheDir := t.TempDir()
+	dir, err := pullBundle(ctx, store, "v1", filepath.Join(cacheDir, "oci"), nil)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if want := filepath.Join(cacheDir, "oci", "sha256", manifest.Digest.Encoded()); dir != want {
+		t.Fatalf("expected bundle in %s, got %s", want, dir)
+	}
+	for _, name := range []string{"naming.rego", filepath.Join("bundle", "owner.rego")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s to be extracted: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected non-Rego layers to be skipped, got %v", err)
+	}
+
+	// Digest references are served from the cache without a registry.
+	ref := OCIScheme + "registry.invalid/policies/argocd@" + manifest.Digest.String()
+	plugins, err := NewLoader(ref).WithRemoteOptions(RemoteOptions{CacheDir: cacheDir}).Load(ctx)
+	if err != nil {
+		t.Fatalf("load cached bundle: %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].Metadata().ID != "OCI002" || plugins[1].Metadata().ID != "OCI001" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+}
+
+func TestPullBundleRejectsUnsafeAndEmptyBundles(t *testing.T) {
+	ctx := context.Background()
+	unsafe := memory.New()
+	pushTestBundle(t, unsafe, map[string]string{"tar:../escape.rego": "package x"})
+	if _, err := pullBundle(ctx, unsafe, "v1", t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+		t.Fatalf("expected an unsafe path error, got %v", err)
+	}
+	empty := memory.New()
+	pushTestBundle(t, empty, map[string]string{"README.md": "no policies"})
+	cacheDir := t.TempDir()
+	if _, err := pullBundle(ctx, empty, "v1", cacheDir, nil); err == nil || !strings.Contains(err.Error(), "no Rego modules") {
+		t.Fatalf("expected an empty bundle error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cacheDir, "sha256")); len(entries) != 0 {
+		t.Fatalf("expected failed pulls to leave no cache entries, got %v", entries)
+	}
+	if _, err := PullBundle(ctx, "oci://registry.corp/policies/argocd", RemoteOptions{CacheDir: cacheDir}); err == nil || !strings.Contains(err.Error(), "tag or digest") {
+		t.Fatalf("expected a missing tag error, got %v", err)
+	}
+}
+
+func TestFetchBundleUnpacksArchives(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	module := fmt.Sprintf(ociTestModule, "https", "WEB001")
+	if err := tw.WriteHeader(&tar.Header{Name: "policies/web.rego", Mode: 0o644, Size: int64(len(module)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("tar header: %v", err)
+	}
+	if _, err := tw.Write([]byte(module)); err != nil {
+		t.Fatalf("tar write: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+	previous := fetch.Client
+	fetch.Client = server.Client()
+	defer func() { fetch.Client = previous }()
+
+	opts := RemoteOptions{CacheDir: t.TempDir()}
+	plugins, err := NewLoader(server.URL + "/argocd/bundle.tar.gz").WithRemoteOptions(opts).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Metadata().ID != "WEB001" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	if _, err := FetchBundle(context.Background(), server.URL+"/argocd/bundle.zip", opts); err == nil || !strings.Contains(err.Error(), "expected a .rego module") {
+		t.Fatalf("expected an unsupported bundle error, got %v", err)
+	}
+}
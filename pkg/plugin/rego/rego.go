@@ -2,6 +2,8 @@ package rego
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,9 +11,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	opaast "github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
 
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
 	"github.com/argocd-lint/argocd-lint/pkg/plugin"
@@ -20,56 +25,149 @@ import (
 
 // Loader discovers and instantiates Rego-backed plugins.
 type Loader struct {
-	files   []string
-	missing []string
+	files     []string
+	documents []dataDocument
+	missing   []string
+	refs      []string
+	archives  []string
+	seen      map[string]struct{}
+	remote    RemoteOptions
+	// fetched holds files from remote bundles and archives, which are
+	// verified as a whole.
+	fetched map[string]bool
+	data    *manifestData
+	// moduleCache, when set, holds the metadata of compiled modules.
+	moduleCache string
+	dataDigest  string
+	// sources holds the module sources read by readModules, and
+	// sourcesDigest their hash, part of the key of cached modules.
+	sources       map[string]string
+	sourcesDigest string
+	// compiler holds the modules compiled together, so that they can
+	// import the rules and functions of shared library modules.
+	compileOnce sync.Once
+	compiler    *opaast.Compiler
+	compileErr  error
 }
 
-// NewLoader creates a Loader for the provided file paths.
+// NewLoader creates a Loader for the provided file paths. Paths starting
+// with oci:// or https:// are remote bundles, fetched when the loader loads,
+// and .tar.gz, .tgz, or .tar paths are bundle archives, such as the ones
+// built by `opa build`, unpacked when the loader loads.
 func NewLoader(paths ...string) *Loader {
-	unique := make(map[string]struct{}, len(paths))
-	var normalized []string
-	var missing []string
+	l := &Loader{seen: make(map[string]struct{}, len(paths)), fetched: map[string]bool{}, data: newManifestData()}
 	for _, p := range paths {
 		if p == "" {
 			continue
 		}
+		if IsOCIReference(p) || IsHTTPSReference(p) {
+			l.refs = append(l.refs, p)
+			continue
+		}
 		abs, err := filepath.Abs(p)
 		if err != nil {
 			continue
 		}
-		info, statErr := os.Stat(abs)
-		if statErr == nil {
-			if info.IsDir() {
-				_ = filepath.WalkDir(abs, func(path string, d os.DirEntry, err error) error {
-					if err != nil {
-						return nil
-					}
-					if d.IsDir() {
-						return nil
-					}
-					if strings.HasSuffix(d.Name(), ".rego") {
-						if _, seen := unique[path]; !seen {
-							unique[path] = struct{}{}
-							normalized = append(normalized, path)
-						}
-					}
-					return nil
-				})
+		if isArchive(abs) {
+			if _, err := os.Stat(abs); err == nil {
+				l.archives = append(l.archives, abs)
 				continue
 			}
-			if strings.HasSuffix(abs, ".rego") {
-				if _, seen := unique[abs]; !seen {
-					unique[abs] = struct{}{}
-					normalized = append(normalized, abs)
-				}
+		}
+		if !l.add(abs) {
+			l.missing = append(l.missing, abs)
+		}
+	}
+	sort.Strings(l.files)
+	sort.Strings(l.missing)
+	return l
+}
+
+// WithRemoteOptions sets how oci:// and https:// bundles are fetched. When
+// opts.Verifier is set, Load refuses local modules and remote bundles without
+// a valid signature.
+func (l *Loader) WithRemoteOptions(opts RemoteOptions) *Loader {
+	l.remote = opts
+	return l
+}
+
+// add records the .rego files and data documents at path, walking
+// directories recursively, and skips *_test.rego files, which hold tests
+// rather than rules. It reports false when path does not exist.
+func (l *Loader) add(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		_ = filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
 			}
-			continue
+			if d.IsDir() {
+				return nil
+			}
+			if isModule(d.Name()) {
+				l.addFile(file)
+			} else if isDataFile(d.Name()) {
+				l.addDocument(path, file)
+			}
+			return nil
+		})
+		return true
+	}
+	if isModule(filepath.Base(path)) {
+		l.addFile(path)
+	} else if isDataFile(filepath.Base(path)) {
+		l.addDocument(filepath.Dir(path), path)
+	}
+	return true
+}
+
+func isModule(name string) bool {
+	return strings.HasSuffix(name, ".rego") && !IsTestFile(name)
+}
+
+func (l *Loader) addFile(path string) {
+	if _, seen := l.seen[path]; !seen {
+		l.seen[path] = struct{}{}
+		l.files = append(l.files, path)
+	}
+}
+
+// pull fetches the loader's remote bundles, unpacks its archives, and adds
+// their modules and data documents.
+func (l *Loader) pull(ctx context.Context) error {
+	if err := l.unpackLocal(); err != nil {
+		return err
+	}
+	for _, ref := range l.refs {
+		fetchBundle := PullBundle
+		if IsHTTPSReference(ref) {
+			fetchBundle = FetchBundle
 		}
-		missing = append(missing, abs)
+		path, err := fetchBundle(ctx, ref, l.remote)
+		if err != nil {
+			return err
+		}
+		l.addFetched(path)
+	}
+	l.refs = nil
+	sort.Strings(l.files)
+	return nil
+}
+
+// addFetched adds the modules and data documents of a fetched or unpacked
+// bundle, which its signature already covers.
+func (l *Loader) addFetched(path string) {
+	files, documents := len(l.files), len(l.documents)
+	l.add(path)
+	for _, file := range l.files[files:] {
+		l.fetched[file] = true
+	}
+	for _, doc := range l.documents[documents:] {
+		l.fetched[doc.file] = true
 	}
-	sort.Strings(normalized)
-	sort.Strings(missing)
-	return &Loader{files: normalized, missing: missing}
 }
 
 // MetadataRecord describes a discovered plugin rule.
@@ -83,13 +181,22 @@ type MetadataRecord struct {
 // awareness.
 func DiscoverMetadata(ctx context.Context, paths ...string) ([]MetadataRecord, []string, error) {
 	loader := NewLoader(paths...)
+	if err := loader.pull(ctx); err != nil {
+		return nil, loader.missing, err
+	}
+	if err := loader.loadDocuments(ctx); err != nil {
+		return nil, loader.missing, err
+	}
+	if err := loader.readModules(); err != nil {
+		return nil, loader.missing, err
+	}
 	records := make([]MetadataRecord, 0, len(loader.files))
 	for _, file := range loader.files {
-		plug, err := loadFile(ctx, file)
+		rp, err := loader.compileFile(ctx, file)
 		if err != nil {
 			return nil, loader.missing, err
 		}
-		if rp, ok := plug.(*regoPlugin); ok {
+		if rp != nil {
 			records = append(records, MetadataRecord{Source: rp.source, Metadata: rp.meta})
 		}
 	}
@@ -102,27 +209,155 @@ func DiscoverMetadata(ctx context.Context, paths ...string) ([]MetadataRecord, [
 	return records, loader.missing, nil
 }
 
-// Load instantiates RulePlugin implementations from the loader's files.
+// Load instantiates RulePlugin implementations from the loader's files. The
+// modules are compiled together; modules with neither a metadata nor a deny
+// rule are libraries that the others may import and yield no plugin.
 func (l *Loader) Load(ctx context.Context) ([]plugin.RulePlugin, error) {
 	if len(l.missing) > 0 {
 		return nil, fmt.Errorf("missing plugin paths: %s", strings.Join(l.missing, ", "))
 	}
+	if err := l.pull(ctx); err != nil {
+		return nil, err
+	}
+	if err := l.loadDocuments(ctx); err != nil {
+		return nil, err
+	}
+	for _, file := range l.files {
+		if l.remote.Verifier != nil && !l.fetched[file] {
+			if err := l.remote.Verifier.VerifyFile(file); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := l.readModules(); err != nil {
+		return nil, err
+	}
 	plugins := make([]plugin.RulePlugin, 0, len(l.files))
 	for _, file := range l.files {
-		p, err := loadFile(ctx, file)
+		p, err := l.loadModule(ctx, file)
 		if err != nil {
 			return nil, fmt.Errorf("load rego plugin %s: %w", file, err)
 		}
-		plugins = append(plugins, p)
+		if p != nil {
+			plugins = append(plugins, p)
+		}
 	}
 	return plugins, nil
 }
 
+// readModules reads the sources of the loader's modules.
+func (l *Loader) readModules() error {
+	l.sources = make(map[string]string, len(l.files))
+	hash := sha256.New()
+	for _, file := range l.files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read module: %w", err)
+		}
+		l.sources[file] = string(source)
+		fmt.Fprintf(hash, "%d\x00%s", len(source), source)
+	}
+	l.sourcesDigest = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// compileModules compiles the sources read by readModules together, once.
+func (l *Loader) compileModules() (*opaast.Compiler, error) {
+	l.compileOnce.Do(func() {
+		l.compiler, l.compileErr = opaast.CompileModules(l.sources)
+		if l.compileErr != nil {
+			l.compileErr = fmt.Errorf("compile modules: %w", l.compileErr)
+		}
+	})
+	return l.compiler, l.compileErr
+}
+
+// compileFile returns the plugin of the module at file, or nil for a
+// library module.
+func (l *Loader) compileFile(ctx context.Context, file string) (*regoPlugin, error) {
+	compiler, err := l.compileModules()
+	if err != nil {
+		return nil, err
+	}
+	return compileModule(ctx, file, compiler, l.data)
+}
+
 type regoPlugin struct {
 	source       string
 	meta         types.RuleMetadata
 	denyQuery    rego.PreparedEvalQuery
 	appliesQuery *rego.PreparedEvalQuery
+	data         *manifestData
+	// compile prepares the queries of plugins whose metadata came from the
+	// module cache, on their first check.
+	compile     func(ctx context.Context) error
+	compileOnce sync.Once
+	compileErr  error
+}
+
+// manifestData backs data.manifests and data.projects with the manifests of
+// the current run, next to the loader's data documents. Plugins from one
+// Loader share it, so the manifest set is converted once per run rather than
+// once per plugin and manifest.
+type manifestData struct {
+	mu        sync.Mutex
+	store     storage.Store
+	documents map[string]interface{}
+	loaded    []*manifest.Manifest
+}
+
+func newManifestData() *manifestData {
+	return &manifestData{store: inmem.New()}
+}
+
+// sync replaces the data document when manifests is not the set it holds.
+// data.manifests lists every manifest in the same shape as input, and
+// data.projects maps AppProject names to their objects.
+func (d *manifestData) sync(ctx context.Context, manifests []*manifest.Manifest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.loaded != nil && sameManifests(d.loaded, manifests) {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(manifests))
+	projects := map[string]interface{}{}
+	for _, m := range manifests {
+		docs = append(docs, manifestToInput(m))
+		if m.Kind == "AppProject" && m.Object != nil {
+			projects[m.Name] = m.Object
+		}
+	}
+	if err := d.write(ctx, docs, projects); err != nil {
+		return err
+	}
+	d.loaded = manifests
+	return nil
+}
+
+// setDocuments stores the data documents of the loader's bundles. Until the
+// first sync, data.manifests and data.projects are empty.
+func (d *manifestData) setDocuments(ctx context.Context, documents map[string]interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.documents = documents
+	d.loaded = nil
+	return d.write(ctx, []interface{}{}, map[string]interface{}{})
+}
+
+func (d *manifestData) write(ctx context.Context, manifests []interface{}, projects map[string]interface{}) error {
+	value := make(map[string]interface{}, len(d.documents)+2)
+	for key, doc := range d.documents {
+		value[key] = doc
+	}
+	value["manifests"], value["projects"] = manifests, projects
+	if err := storage.WriteOne(ctx, d.store, storage.ReplaceOp, storage.Path{}, value); err != nil {
+		return fmt.Errorf("write manifest data: %w", err)
+	}
+	return nil
+}
+
+func sameManifests(a, b []*manifest.Manifest) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
 }
 
 func (p *regoPlugin) Metadata() types.RuleMetadata {
@@ -130,7 +365,22 @@ func (p *regoPlugin) Metadata() types.RuleMetadata {
 }
 
 func (p *regoPlugin) Check(ctx context.Context, m *manifest.Manifest) ([]types.Finding, error) {
-	input := manifestToInput(m)
+	if p.compile != nil {
+		p.compileOnce.Do(func() { p.compileErr = p.compile(ctx) })
+		if p.compileErr != nil {
+			return nil, p.compileErr
+		}
+	}
+	if manifests := plugin.Manifests(ctx); manifests != nil {
+		if err := p.data.sync(ctx, manifests); err != nil {
+			return nil, err
+		}
+	}
+	params := plugin.Params(ctx)
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	input := map[string]interface{}{"resource": manifestToInput(m), "params": params}
 
 	if p.appliesQuery != nil {
 		rs, err := p.appliesQuery.Eval(ctx, rego.EvalInput(input))
@@ -188,26 +438,28 @@ func (p *regoPlugin) AppliesTo() plugin.Matcher {
 	}
 }
 
-func loadFile(ctx context.Context, path string) (plugin.RulePlugin, error) {
-	source, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read module: %w", err)
-	}
-
-	module, err := opaast.ParseModule(path, string(source))
-	if err != nil {
-		return nil, fmt.Errorf("parse module: %w", err)
-	}
+// withResource evaluates deny and applies against the manifest and rule
+// params passed together as input, so each check sees its own data.params
+// while the plugins of a run share one data store.
+const withResource = "with input as input.resource with data.params as input.params"
 
-	compiler, err := opaast.CompileModules(map[string]string{path: string(source)})
-	if err != nil {
-		return nil, fmt.Errorf("compile module: %w", err)
+// compileModule prepares the queries of the module at path from compiler,
+// which holds it compiled, and evaluates its metadata. It returns nil for a
+// library module.
+func compileModule(ctx context.Context, path string, compiler *opaast.Compiler, data *manifestData) (*regoPlugin, error) {
+	module := compiler.Modules[path]
+	if module == nil {
+		return nil, fmt.Errorf("module %s was not compiled", path)
+	}
+	if isLibrary(module) {
+		return nil, nil
 	}
 
 	pkgRef := module.Package.Path.String()
 
 	metadataQuery, err := rego.New(
 		rego.Compiler(compiler),
+		rego.Store(data.store),
 		rego.Query(fmt.Sprintf("%s.metadata", pkgRef)),
 	).PrepareForEval(ctx)
 	if err != nil {
@@ -216,17 +468,19 @@ func loadFile(ctx context.Context, path string) (plugin.RulePlugin, error) {
 
 	denyQuery, err := rego.New(
 		rego.Compiler(compiler),
-		rego.Query(fmt.Sprintf("%s.deny", pkgRef)),
+		rego.Store(data.store),
+		rego.Query(fmt.Sprintf("%s.deny %s", pkgRef, withResource)),
 	).PrepareForEval(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("prepare deny query: %w", err)
 	}
 
 	var appliesQuery *rego.PreparedEvalQuery
-	if hasRule(module, "applies") {
+	if findRule(module, "applies") != nil {
 		prepared, err := rego.New(
 			rego.Compiler(compiler),
-			rego.Query(fmt.Sprintf("%s.applies", pkgRef)),
+			rego.Store(data.store),
+			rego.Query(fmt.Sprintf("%s.applies %s", pkgRef, withResource)),
 		).PrepareForEval(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("prepare applies query: %w", err)
@@ -239,7 +493,7 @@ func loadFile(ctx context.Context, path string) (plugin.RulePlugin, error) {
 		return nil, err
 	}
 
-	return &regoPlugin{source: path, meta: meta, denyQuery: denyQuery, appliesQuery: appliesQuery}, nil
+	return &regoPlugin{source: path, meta: meta, denyQuery: denyQuery, appliesQuery: appliesQuery, data: data}, nil
 }
 
 func manifestToInput(m *manifest.Manifest) map[string]interface{} {
@@ -257,13 +511,19 @@ func manifestToInput(m *manifest.Manifest) map[string]interface{} {
 	}
 }
 
-func hasRule(module *opaast.Module, name string) bool {
+// isLibrary reports whether module has neither a metadata nor a deny rule,
+// so that it only provides rules and functions to other modules.
+func isLibrary(module *opaast.Module) bool {
+	return findRule(module, "metadata") == nil && findRule(module, "deny") == nil
+}
+
+func findRule(module *opaast.Module, name string) *opaast.Rule {
 	for _, rule := range module.Rules {
 		if string(rule.Head.Name) == name {
-			return true
+			return rule
 		}
 	}
-	return false
+	return nil
 }
 
 func evaluateMetadata(ctx context.Context, query rego.PreparedEvalQuery) (types.RuleMetadata, error) {
@@ -290,9 +550,19 @@ func evaluateMetadata(ctx context.Context, query rego.PreparedEvalQuery) (types.
 	if category, ok := obj["category"].(string); ok {
 		meta.Category = category
 	}
+	if tags, ok := obj["tags"].([]interface{}); ok {
+		for _, item := range tags {
+			if s, ok := item.(string); ok {
+				meta.Tags = append(meta.Tags, s)
+			}
+		}
+	}
 	if help, ok := obj["help_url"].(string); ok {
 		meta.HelpURL = help
 	}
+	meta.Rationale, _ = obj["rationale"].(string)
+	meta.Example, _ = obj["example"].(string)
+	meta.Remediation, _ = obj["remediation"].(string)
 	if enabled, ok := obj["enabled"].(bool); ok {
 		meta.Enabled = enabled
 	}
@@ -301,6 +571,16 @@ func evaluateMetadata(ctx context.Context, query rego.PreparedEvalQuery) (types.
 	} else {
 		meta.DefaultSeverity = types.SeverityWarn
 	}
+	switch replaces := obj["replaces"].(type) {
+	case string:
+		meta.Replaces = []string{replaces}
+	case []interface{}:
+		for _, item := range replaces {
+			if s, ok := item.(string); ok {
+				meta.Replaces = append(meta.Replaces, s)
+			}
+		}
+	}
 	if applies, ok := obj["applies_to"].([]interface{}); ok {
 		for _, item := range applies {
 			if s, ok := item.(string); ok {
@@ -385,9 +665,34 @@ func mapToFinding(raw map[string]interface{}, meta types.RuleMetadata, man *mani
 	if help, ok := raw["help_url"].(string); ok && help != "" {
 		finding.HelpURL = help
 	}
+	if suggestions, ok := raw["suggestions"].([]interface{}); ok {
+		finding.Suggestions = toSuggestions(suggestions)
+	}
 	return finding
 }
 
+// toSuggestions converts suggestion objects emitted by deny. Entries without
+// a title are dropped, since reports and SARIF fixes label a fix by its title.
+func toSuggestions(values []interface{}) []types.Suggestion {
+	var suggestions []types.Suggestion
+	for _, value := range values {
+		raw, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		title, _ := raw["title"].(string)
+		if title == "" {
+			continue
+		}
+		suggestion := types.Suggestion{Title: title}
+		suggestion.Description, _ = raw["description"].(string)
+		suggestion.Patch, _ = raw["patch"].(string)
+		suggestion.Path, _ = raw["path"].(string)
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
 func numberToInt(value interface{}) (int, bool) {
 	switch v := value.(type) {
 	case int:
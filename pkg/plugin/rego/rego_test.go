@@ -4,9 +4,11 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/argocd-lint/argocd-lint/internal/manifest"
+	"github.com/argocd-lint/argocd-lint/pkg/plugin"
 	regoloader "github.com/argocd-lint/argocd-lint/pkg/plugin/rego"
 )
 
@@ -33,6 +35,10 @@ deny[f] {
     "line": 17,
     "column": 3,
     "resource_name": input.name,
+    "suggestions": [
+      {"title": "Rename the Application", "description": "Use the agreed name.", "patch": "name: foo", "path": "$.metadata.name"},
+      {"description": "dropped without a title"},
+    ],
   }
 }
 `
@@ -96,6 +102,12 @@ deny[f] {
 	if finding.Message == "" {
 		t.Fatalf("expected message to be populated")
 	}
+	if len(finding.Suggestions) != 1 {
+		t.Fatalf("expected 1 suggestion, got %+v", finding.Suggestions)
+	}
+	if s := finding.Suggestions[0]; s.Title != "Rename the Application" || s.Description != "Use the agreed name." || s.Patch != "name: foo" || s.Path != "$.metadata.name" {
+		t.Fatalf("unexpected suggestion: %+v", s)
+	}
 }
 
 func TestLoaderErrorsForMissingPaths(t *testing.T) {
@@ -210,3 +222,120 @@ deny[f] {
 		t.Fatalf("expected source %s, got %s", modulePath, record.Source)
 	}
 }
+
+func TestPluginsSeeManifestSetAsData(t *testing.T) {
+	dir := t.TempDir()
+	module := `package argocd_lint.project_exists
+
+metadata := {
+  "id": "RG020",
+  "description": "Applications must reference an AppProject defined in this repo",
+  "severity": "error",
+  "applies_to": ["Application"],
+}
+
+deny[f] {
+  project := object.get(input.object, ["spec", "project"], "default")
+  not data.projects[project]
+  f := {"message": sprintf("AppProject %s is not defined (%d manifests)", [project, count(data.manifests)])}
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "project.rego"), []byte(module), 0o644); err != nil {
+		t.Fatalf("write module: %v", err)
+	}
+	plugins, err := regoloader.NewLoader(dir).Load(context.Background())
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("load plugins: %v (%d)", err, len(plugins))
+	}
+	app := func(name, project string) *manifest.Manifest {
+		return &manifest.Manifest{Kind: "Application", Name: name, Object: map[string]interface{}{
+			"spec": map[string]interface{}{"project": project},
+		}}
+	}
+	manifests := []*manifest.Manifest{
+		{Kind: "AppProject", Name: "payments", Object: map[string]interface{}{"spec": map[string]interface{}{}}},
+		app("good", "payments"),
+		app("bad", "ghost"),
+	}
+	ctx := plugin.WithManifests(context.Background(), manifests)
+	if findings, err := plugins[0].Check(ctx, manifests[1]); err != nil || len(findings) != 0 {
+		t.Fatalf("expected no findings for a defined project, got %+v (%v)", findings, err)
+	}
+	findings, err := plugins[0].Check(ctx, manifests[2])
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Message != "AppProject ghost is not defined (3 manifests)" {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	// A new run replaces the data rather than accumulating it.
+	next := []*manifest.Manifest{app("bad", "ghost")}
+	findings, err = plugins[0].Check(plugin.WithManifests(context.Background(), next), next[0])
+	if err != nil || len(findings) != 1 || !strings.Contains(findings[0].Message, "(1 manifests)") {
+		t.Fatalf("expected data from the new run, got %+v (%v)", findings, err)
+	}
+}
+
+func TestLoaderCompilesSharedLibraries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/argo.rego": "package lib.argo\n\nis_default(project) {\n  project == \"default\"\n}\n",
+		"project.rego":  "package argocd_lint.project\n\nimport data.lib.argo\n\nmetadata := {\"id\": \"RG030\", \"applies_to\": [\"Application\"]}\n\ndeny[f] {\n  argo.is_default(input.object.spec.project)\n  f := {\"message\": \"default project\"}\n}\n",
+	})
+	for _, cacheDir := range []string{"", t.TempDir()} {
+		for run := 0; run < 2; run++ {
+			loader := regoloader.NewLoader(dir)
+			if cacheDir != "" {
+				loader = loader.WithModuleCache(cacheDir)
+			}
+			plugins, err := loader.Load(context.Background())
+			if err != nil || len(plugins) != 1 {
+				t.Fatalf("expected the library to yield no plugin, got %d plugins (%v)", len(plugins), err)
+			}
+			app := &manifest.Manifest{Kind: "Application", Name: "demo", Object: map[string]interface{}{
+				"spec": map[string]interface{}{"project": "default"},
+			}}
+			findings, err := plugins[0].Check(context.Background(), app)
+			if err != nil || len(findings) != 1 {
+				t.Fatalf("expected the library function to run, got %+v (%v)", findings, err)
+			}
+		}
+	}
+}
+
+func TestPluginsReadParamsAsData(t *testing.T) {
+	dir := t.TempDir()
+	module := `package argocd_lint.max_replicas
+
+metadata := {
+  "id": "RG021",
+  "description": "Applications must not request more replicas than allowed",
+  "severity": "warn",
+}
+
+deny[f] {
+  limit := object.get(data.params, "maxReplicas", 3)
+  input.object.spec.replicas > limit
+  f := {"message": sprintf("%s requests %d replicas (max %d)", [input.name, input.object.spec.replicas, limit])}
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "replicas.rego"), []byte(module), 0o644); err != nil {
+		t.Fatalf("write module: %v", err)
+	}
+	plugins, err := regoloader.NewLoader(dir).Load(context.Background())
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("load plugins: %v (%d)", err, len(plugins))
+	}
+	m := &manifest.Manifest{Kind: "Application", Name: "api", Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": 4},
+	}}
+	findings, err := plugins[0].Check(context.Background(), m)
+	if err != nil || len(findings) != 1 || findings[0].Message != "api requests 4 replicas (max 3)" {
+		t.Fatalf("expected the default limit without params, got %+v (%v)", findings, err)
+	}
+	ctx := plugin.WithParams(context.Background(), map[string]interface{}{"maxReplicas": 5})
+	if findings, err := plugins[0].Check(ctx, m); err != nil || len(findings) != 0 {
+		t.Fatalf("expected the configured limit to allow 4 replicas, got %+v (%v)", findings, err)
+	}
+}
@@ -0,0 +1,87 @@
+package rego
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/tester"
+)
+
+// TestFileSuffix marks Rego modules that hold unit tests for plugins. The
+// Loader skips them; RunTests evaluates them together with the plugins.
+const TestFileSuffix = "_test.rego"
+
+// IsTestFile reports whether name is a Rego unit test module.
+func IsTestFile(name string) bool {
+	return strings.HasSuffix(name, TestFileSuffix)
+}
+
+// TestResult is the outcome of one test_ rule.
+type TestResult struct {
+	Package  string        `json:"package"`
+	Name     string        `json:"name"`
+	Location string        `json:"location,omitempty"`
+	Fail     bool          `json:"fail,omitempty"`
+	Skip     bool          `json:"skip,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Pass reports whether the test succeeded.
+func (r TestResult) Pass() bool {
+	return !r.Fail && !r.Skip && r.Error == ""
+}
+
+// RunTests evaluates the test_ rules of the .rego modules under paths with
+// opa test semantics: a test passes when its body is true, and todo_test_
+// rules are skipped. Only .rego files and data documents are loaded, so
+// fixtures and signatures next to the modules are ignored. A non-empty run is
+// a regular expression that selects tests by package-qualified name, like opa
+// test --run. As in a lint run, data.params is an empty object and
+// data.manifests and data.projects are empty unless a test mocks them.
+// Results are ordered by file and rule.
+func RunTests(ctx context.Context, run string, paths ...string) ([]TestResult, error) {
+	modules, store, err := tester.Load(paths, func(_ string, info fs.FileInfo, _ int) bool {
+		return !info.IsDir() && !strings.HasSuffix(info.Name(), ".rego") && !isDataFile(info.Name())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load rego tests: %w", err)
+	}
+	// Start from the data a lint run without manifests or params provides;
+	// tests mock it with `with data.params as ...`.
+	for _, key := range reservedData {
+		var empty interface{} = map[string]interface{}{}
+		if key == "manifests" {
+			empty = []interface{}{}
+		}
+		if err := storage.WriteOne(ctx, store, storage.AddOp, storage.Path{key}, empty); err != nil {
+			return nil, fmt.Errorf("seed rego test data: %w", err)
+		}
+	}
+	ch, err := tester.NewRunner().SetStore(store).Filter(run).Run(ctx, modules)
+	if err != nil {
+		return nil, fmt.Errorf("run rego tests: %w", err)
+	}
+	var results []TestResult
+	for r := range ch {
+		result := TestResult{
+			Package:  strings.TrimPrefix(r.Package, "data."),
+			Name:     r.Name,
+			Fail:     r.Fail,
+			Skip:     r.Skip,
+			Duration: r.Duration,
+		}
+		if r.Location != nil {
+			result.Location = fmt.Sprintf("%s:%d", r.Location.File, r.Location.Row)
+		}
+		if r.Error != nil {
+			result.Error = r.Error.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
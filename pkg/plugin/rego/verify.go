@@ -0,0 +1,212 @@
+package rego
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+const (
+	// KeySignatureSuffix names the signature file cosign sign-blob --key
+	// writes with --output-signature, stored next to the signed file.
+	KeySignatureSuffix = ".sig"
+	// KeylessSignatureSuffix names the Sigstore bundle cosign sign-blob
+	// --new-bundle-format writes with --bundle, stored next to the signed file.
+	KeylessSignatureSuffix = ".sigstore.json"
+
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// Verifier checks cosign signatures on plugin modules and bundles before they
+// are loaded. It verifies either against a public key or, keyless, against
+// the Sigstore public-good instance and an expected signer identity.
+type Verifier struct {
+	key      crypto.PublicKey
+	entities *verify.SignedEntityVerifier
+	identity verify.CertificateIdentity
+}
+
+// NewKeyVerifier creates a Verifier for signatures made with the private half
+// of the PEM-encoded public key at path, such as cosign.pub.
+func NewKeyVerifier(path string) (*Verifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key %s is not PEM encoded", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %w", path, err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("public key %s: unsupported key type %T", path, key)
+	}
+	return &Verifier{key: key}, nil
+}
+
+// NewKeylessVerifier creates a Verifier for Sigstore bundles whose Fulcio
+// certificate was issued to identity by the OIDC issuer. The trusted root is
+// fetched from the Sigstore TUF repository.
+func NewKeylessVerifier(identity, issuer string) (*Verifier, error) {
+	material, err := root.FetchTrustedRoot()
+	if err != nil {
+		return nil, fmt.Errorf("fetch sigstore trusted root: %w", err)
+	}
+	return newKeylessVerifier(material, identity, issuer,
+		verify.WithSignedCertificateTimestamps(1),
+		verify.WithTransparencyLog(1),
+		verify.WithObserverTimestamps(1),
+	)
+}
+
+func newKeylessVerifier(material root.TrustedMaterial, identity, issuer string, opts ...verify.VerifierOption) (*Verifier, error) {
+	if identity == "" || issuer == "" {
+		return nil, errors.New("keyless verification needs a certificate identity and OIDC issuer")
+	}
+	certID, err := verify.NewShortCertificateIdentity(issuer, "", identity, "")
+	if err != nil {
+		return nil, err
+	}
+	entities, err := verify.NewSignedEntityVerifier(material, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{entities: entities, identity: certID}, nil
+}
+
+// SignatureSuffix is appended to a module path or bundle URL to locate its
+// signature.
+func (v *Verifier) SignatureSuffix() string {
+	if v.key != nil {
+		return KeySignatureSuffix
+	}
+	return KeylessSignatureSuffix
+}
+
+// VerifyFile checks the signature stored next to path.
+func (v *Verifier) VerifyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := v.verifyBlob(data, path+v.SignatureSuffix()); err != nil {
+		return fmt.Errorf("verify %s: %w", path, err)
+	}
+	return nil
+}
+
+func (v *Verifier) verifyBlob(data []byte, signaturePath string) error {
+	if _, err := os.Stat(signaturePath); err != nil {
+		return fmt.Errorf("missing signature %s", signaturePath)
+	}
+	if v.key == nil {
+		entity, err := bundle.LoadJSONFromPath(signaturePath)
+		if err != nil {
+			return fmt.Errorf("read sigstore bundle: %w", err)
+		}
+		return v.verifyEntity(entity, data)
+	}
+	raw, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return fmt.Errorf("decode signature %s: %w", signaturePath, err)
+	}
+	return verifySignature(v.key, data, signature)
+}
+
+func (v *Verifier) verifyEntity(entity verify.SignedEntity, data []byte) error {
+	policy := verify.NewPolicy(verify.WithArtifact(bytes.NewReader(data)), verify.WithCertificateIdentity(v.identity))
+	_, err := v.entities.Verify(entity, policy)
+	return err
+}
+
+// verifyOCI checks the cosign signature that `cosign sign --key` attaches to
+// the manifest as a sha256-<hex>.sig tag in the same repository.
+func (v *Verifier) verifyOCI(ctx context.Context, src oras.ReadOnlyTarget, manifest ocispec.Descriptor) error {
+	if v.key == nil {
+		return errors.New("keyless verification of oci:// bundles is not supported; verify them with a public key")
+	}
+	tag := strings.Replace(manifest.Digest.String(), ":", "-", 1) + KeySignatureSuffix
+	desc, err := src.Resolve(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("missing cosign signature %s: %w", tag, err)
+	}
+	raw, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return err
+	}
+	var signatures ocispec.Manifest
+	if err := json.Unmarshal(raw, &signatures); err != nil {
+		return fmt.Errorf("decode signature manifest: %w", err)
+	}
+	for _, layer := range signatures.Layers {
+		signature, err := base64.StdEncoding.DecodeString(layer.Annotations[cosignSignatureAnnotation])
+		if err != nil || len(signature) == 0 {
+			continue
+		}
+		payload, err := content.FetchAll(ctx, src, layer)
+		if err != nil {
+			return err
+		}
+		if verifySignature(v.key, payload, signature) != nil {
+			continue
+		}
+		var simple struct {
+			Critical struct {
+				Image struct {
+					DockerManifestDigest string `json:"docker-manifest-digest"`
+				} `json:"image"`
+			} `json:"critical"`
+		}
+		if json.Unmarshal(payload, &simple) == nil && simple.Critical.Image.DockerManifestDigest == manifest.Digest.String() {
+			return nil
+		}
+	}
+	return fmt.Errorf("no valid signature for %s", manifest.Digest)
+}
+
+// verifySignature checks a cosign signature: ECDSA and RSA (PKCS #1 v1.5)
+// sign the SHA-256 digest of data, Ed25519 signs data itself.
+func verifySignature(key crypto.PublicKey, data, signature []byte) error {
+	digest := sha256.Sum256(data)
+	var ok bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, signature)
+	}
+	if !ok {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package rego
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func testSigningKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return key, path
+}
+
+func testSign(t *testing.T, key *ecdsa.PrivateKey, data []byte) string {
+	t.Helper()
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestLoaderVerifiesModuleSignatures(t *testing.T) {
+	key, pubPath := testSigningKey(t)
+	verifier, err := NewKeyVerifier(pubPath)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	dir := t.TempDir()
+	module := filepath.Join(dir, "signed.rego")
+	source := []byte(fmt.Sprintf(ociTestModule, "signed", "SIG001"))
+	if err := os.WriteFile(module, source, 0o644); err != nil {
+		t.Fatalf("write module: %v", err)
+	}
+	load := func() error {
+		_, err := NewLoader(dir).WithRemoteOptions(RemoteOptions{Verifier: verifier}).Load(context.Background())
+		return err
+	}
+	if err := load(); err == nil || !strings.Contains(err.Error(), "missing signature") {
+		t.Fatalf("expected an unsigned module to be refused, got %v", err)
+	}
+	if err := os.WriteFile(module+KeySignatureSuffix, []byte(testSign(t, key, source)+"\n"), 0o644); err != nil {
+		t.Fatalf("write signature: %v", err)
+	}
+	if err := load(); err != nil {
+		t.Fatalf("expected a signed module to load, got %v", err)
+	}
+	if err := os.WriteFile(module, append(source, "# tampered\n"...), 0o644); err != nil {
+		t.Fatalf("modify module: %v", err)
+	}
+	if err := load(); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("expected a modified module to be refused, got %v", err)
+	}
+}
+
+func TestPullBundleVerifiesCosignSignatures(t *testing.T) {
+	ctx := context.Background()
+	key, pubPath := testSigningKey(t)
+	verifier, err := NewKeyVerifier(pubPath)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	store := memory.New()
+	manifest := pushTestBundle(t, store, map[string]string{"naming.rego": fmt.Sprintf(ociTestModule, "naming", "OCI001")})
+	if _, err := pullBundle(ctx, store, "v1", t.TempDir(), verifier); err == nil || !strings.Contains(err.Error(), "missing cosign signature") {
+		t.Fatalf("expected an unsigned bundle to be refused, got %v", err)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"critical": map[string]interface{}{
+		"identity": map[string]string{"docker-reference": "registry.corp/policies/argocd"},
+		"image":    map[string]string{"docker-manifest-digest": manifest.Digest.String()},
+		"type":     "cosign container image signature",
+	}})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	layer, err := oras.PushBytes(ctx, store, "application/vnd.dev.cosign.simplesigning.v1+json", payload)
+	if err != nil {
+		t.Fatalf("push payload: %v", err)
+	}
+	layer.Annotations = map[string]string{cosignSignatureAnnotation: testSign(t, key, payload)}
+	signature, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.dev.cosign.artifact.sig.v1+json", oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}})
+	if err != nil {
+		t.Fatalf("pack signature: %v", err)
+	}
+	if err := store.Tag(ctx, signature, strings.Replace(manifest.Digest.String(), ":", "-", 1)+".sig"); err != nil {
+		t.Fatalf("tag signature: %v", err)
+	}
+	if _, err := pullBundle(ctx, store, "v1", t.TempDir(), verifier); err != nil {
+		t.Fatalf("expected a signed bundle to pull, got %v", err)
+	}
+
+	_, otherPath := testSigningKey(t)
+	other, err := NewKeyVerifier(otherPath)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	if _, err := pullBundle(ctx, store, "v1", t.TempDir(), other); err == nil || !strings.Contains(err.Error(), "no valid signature") {
+		t.Fatalf("expected a signature from another key to be refused, got %v", err)
+	}
+}
+
+func TestKeylessVerifierChecksIdentity(t *testing.T) {
+	sigstore, err := ca.NewVirtualSigstore()
+	if err != nil {
+		t.Fatalf("virtual sigstore: %v", err)
+	}
+	const issuer = "https://token.actions.githubusercontent.com"
+	identity := "https://github.com/corp/policies/.github/workflows/release.yml@refs/heads/main"
+	artifact := []byte(fmt.Sprintf(ociTestModule, "keyless", "KL001"))
+	entity, err := sigstore.Sign(identity, issuer, artifact)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	opts := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1)}
+	verifier, err := newKeylessVerifier(sigstore, identity, issuer, opts...)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	if err := verifier.verifyEntity(entity, artifact); err != nil {
+		t.Fatalf("expected the signature to verify, got %v", err)
+	}
+	if err := verifier.verifyEntity(entity, append(artifact, '\n')); err == nil {
+		t.Fatalf("expected a modified artifact to be refused")
+	}
+	stranger, err := newKeylessVerifier(sigstore, "https://github.com/someone/else", issuer, opts...)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	if err := stranger.verifyEntity(entity, artifact); err == nil {
+		t.Fatalf("expected another signer identity to be refused")
+	}
+	if _, err := newKeylessVerifier(sigstore, identity, "", opts...); err == nil {
+		t.Fatalf("expected a missing issuer to be rejected")
+	}
+}
@@ -1,5 +1,11 @@
 package types
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Severity enumerates lint finding levels.
 type Severity string
 
@@ -38,6 +44,16 @@ type Finding struct {
 	Category     string       `json:"category,omitempty"`
 	HelpURL      string       `json:"helpUrl,omitempty"`
 	Suggestions  []Suggestion `json:"suggestions,omitempty"`
+	// RelatedLocations point at files other than the manifest that caused
+	// the finding, such as the chart template a render failed in.
+	RelatedLocations []Location `json:"relatedLocations,omitempty"`
+}
+
+// Location is a position in a file.
+type Location struct {
+	FilePath string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
 }
 
 // Suggestion proposes an optional remediation for a finding.
@@ -56,7 +72,18 @@ type RuleMetadata struct {
 	AppliesTo       []ResourceKind
 	HelpURL         string
 	Category        string
-	Enabled         bool
+	// Rationale, Example, and Remediation explain the rule in
+	// "argocd-lint rules explain". Example is a compliant YAML snippet.
+	Rationale   string
+	Example     string
+	Remediation string
+	// Tags group rules across categories, e.g. "pci"; config can tune every
+	// rule with a tag at once.
+	Tags    []string
+	Enabled bool
+	// Replaces lists built-in rule IDs that a plugin rule supersedes. The
+	// runner disables those built-ins while the plugin is registered.
+	Replaces []string
 }
 
 // ConfiguredRule holds runtime configuration.
@@ -64,6 +91,84 @@ type ConfiguredRule struct {
 	Metadata RuleMetadata
 	Severity Severity
 	Enabled  bool
+	Params   map[string]interface{}
+}
+
+// StringParam returns a string parameter and whether it was set.
+func (c ConfiguredRule) StringParam(name string) (string, bool) {
+	value, ok := c.Params[name]
+	if !ok || value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// BoolParam returns a boolean parameter and whether it was set. Strings such
+// as "false" are accepted for values coming from CLI or annotations.
+func (c ConfiguredRule) BoolParam(name string) (bool, bool) {
+	value, ok := c.Params[name]
+	if !ok || value == nil {
+		return false, false
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	default:
+		parsed, err := strconv.ParseBool(fmt.Sprint(v))
+		if err != nil {
+			return false, false
+		}
+		return parsed, true
+	}
+}
+
+// FloatParam returns a numeric parameter and whether it was set to a valid
+// number.
+func (c ConfiguredRule) FloatParam(name string) (float64, bool) {
+	value, ok := c.Params[name]
+	if !ok || value == nil {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(value)), 64)
+	if err != nil {
+		return 0, false
+	}
+	return parsed, true
+}
+
+// IntParam returns an integer parameter and whether it was set to a valid
+// integer.
+func (c ConfiguredRule) IntParam(name string) (int, bool) {
+	value, ok := c.Params[name]
+	if !ok || value == nil {
+		return 0, false
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(value)))
+	if err != nil {
+		return 0, false
+	}
+	return parsed, true
+}
+
+// StringSliceParam returns a list parameter and whether it was set. A single
+// scalar is treated as a one-element list.
+func (c ConfiguredRule) StringSliceParam(name string) ([]string, bool) {
+	value, ok := c.Params[name]
+	if !ok || value == nil {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out, true
+	default:
+		return []string{fmt.Sprint(v)}, true
+	}
 }
 
 // FindingBuilder is used inside rule checks to construct findings.